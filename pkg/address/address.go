@@ -0,0 +1,323 @@
+// Package address converts Kaon addresses between the hex form used as Ethereum addresses and the
+// base58 (P2PKH, P2SH) and bech32 (P2WPKH) forms kaond uses, without querying kaond.
+//
+// All of them encode a 20 byte hash, the hex form drops which kind of address it is and which chain
+// it belongs to, so converting from hex needs both. A kind is only converted on chains whose prefix
+// for it is known.
+package address
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/bech32"
+	"github.com/pkg/errors"
+)
+
+// Length in bytes of the hash every address encodes
+const HashLength = 20
+
+var (
+	ErrInvalidHex        = errors.New("invalid hex address")
+	ErrInvalidLength     = errors.New("invalid address length")
+	ErrInvalidChecksum   = errors.New("invalid address checksum")
+	ErrInvalidFormat     = errors.New("invalid address format")
+	ErrWrongChain        = errors.New("address belongs to another chain")
+	ErrUnsupportedChain  = errors.New("unsupported chain")
+	ErrUnsupportedScript = errors.New("unsupported witness program")
+	ErrUnsupportedKind   = errors.New("address kind has no known prefix on this chain")
+)
+
+type Kind int
+
+const (
+	// base58 address of a public key hash
+	PubKeyHash Kind = iota
+	// base58 address of a script hash
+	ScriptHash
+	// bech32 address of a version 0 witness public key hash
+	WitnessPubKeyHash
+)
+
+func (k Kind) String() string {
+	switch k {
+	case PubKeyHash:
+		return "pubkeyhash"
+	case ScriptHash:
+		return "scripthash"
+	case WitnessPubKeyHash:
+		return "witness_v0_keyhash"
+	}
+	return "unknown"
+}
+
+// Params are the address prefixes of a chain
+type Params struct {
+	// chain name, as reported by getblockchaininfo
+	Chain            string
+	PubKeyHashAddrID byte
+	// nil when the P2SH prefix of the chain isn't known, its P2SH addresses are rejected
+	ScriptHashAddrID *byte
+	// human readable part of bech32 addresses, empty when it isn't known and bech32 addresses are rejected
+	Bech32HRP string
+}
+
+func prefix(id byte) *byte {
+	return &id
+}
+
+var (
+	MainNetParams = Params{
+		Chain:            "main",
+		PubKeyHashAddrID: 58,
+		ScriptHashAddrID: prefix(50),
+	}
+	// kaond addresses of testnet and regtest decode to version 84, such as the ones docker/fill_user_account.sh funds
+	TestNetParams = Params{
+		Chain:            "test",
+		PubKeyHashAddrID: 84,
+	}
+	// regtest shares the base58 prefixes of testnet
+	RegTestParams = Params{
+		Chain:            "regtest",
+		PubKeyHashAddrID: 84,
+	}
+)
+
+var chains = []*Params{&MainNetParams, &TestNetParams, &RegTestParams}
+
+// ParamsForChain returns the address prefixes of a chain reported by getblockchaininfo
+func ParamsForChain(chain string) (*Params, error) {
+	for _, params := range chains {
+		if params.Chain == chain {
+			return params, nil
+		}
+	}
+	return nil, errors.Wrapf(ErrUnsupportedChain, "%q", chain)
+}
+
+// Address is a decoded Kaon address
+type Address struct {
+	Kind Kind
+	Hash [HashLength]byte
+}
+
+// Hex returns the hash as an Ethereum address, lowercase and without 0x prefix
+func (a Address) Hex() string {
+	return hex.EncodeToString(a.Hash[:])
+}
+
+// Encode returns the address as kaond shows it on the chain of params
+func (a Address) Encode(params *Params) (string, error) {
+	switch a.Kind {
+	case PubKeyHash:
+		return base58.CheckEncode(a.Hash[:], params.PubKeyHashAddrID), nil
+	case ScriptHash:
+		if params.ScriptHashAddrID == nil {
+			return "", errors.Wrapf(ErrUnsupportedKind, "%s on %s", a.Kind, params.Chain)
+		}
+		return base58.CheckEncode(a.Hash[:], *params.ScriptHashAddrID), nil
+	case WitnessPubKeyHash:
+		if params.Bech32HRP == "" {
+			return "", errors.Wrapf(ErrUnsupportedKind, "%s on %s", a.Kind, params.Chain)
+		}
+		program, err := bech32.ConvertBits(a.Hash[:], 8, 5, true)
+		if err != nil {
+			return "", err
+		}
+		return bech32.Encode(params.Bech32HRP, append([]byte{0}, program...))
+	}
+	return "", errors.Errorf("unknown address kind %d", a.Kind)
+}
+
+// New returns the address of a public key, script or witness public key hash
+func New(kind Kind, hash []byte) (Address, error) {
+	if len(hash) != HashLength {
+		return Address{}, errors.Wrapf(ErrInvalidLength, "%d bytes hash", len(hash))
+	}
+	address := Address{Kind: kind}
+	copy(address.Hash[:], hash)
+	return address, nil
+}
+
+// FromHex parses an Ethereum address, with or without 0x prefix
+func FromHex(hexAddress string, kind Kind) (Address, error) {
+	hexAddress = strings.TrimPrefix(strings.TrimPrefix(hexAddress, "0x"), "0X")
+	hash, err := hex.DecodeString(hexAddress)
+	if err != nil {
+		return Address{}, errors.Wrapf(ErrInvalidHex, "%q", hexAddress)
+	}
+	if len(hash) != HashLength {
+		return Address{}, errors.Wrapf(ErrInvalidLength, "%d bytes in %q", len(hash), hexAddress)
+	}
+	return New(kind, hash)
+}
+
+// Decode parses a base58 or bech32 address of the chain of params
+func Decode(encoded string, params *Params) (Address, error) {
+	var (
+		address Address
+		chains  []*Params
+		err     error
+	)
+	if hasBech32HRP(encoded, params) {
+		address, chains, err = decodeBech32(encoded, params)
+	} else {
+		address, chains, err = decode(encoded)
+	}
+	if err != nil {
+		return Address{}, err
+	}
+	for _, chain := range chains {
+		if chain == params {
+			return address, nil
+		}
+	}
+	return Address{}, errors.Wrapf(ErrWrongChain, "%q isn't a %s address", encoded, params.Chain)
+}
+
+// DecodeAny parses a base58 or bech32 address of any chain
+func DecodeAny(encoded string) (Address, error) {
+	address, _, err := decode(encoded)
+	return address, err
+}
+
+// ToHex converts a base58 or bech32 address of any chain to an Ethereum address without 0x prefix
+func ToHex(encoded string) (string, error) {
+	address, err := DecodeAny(encoded)
+	if err != nil {
+		return "", err
+	}
+	return address.Hex(), nil
+}
+
+// returns the address along with the chains it can belong to
+func decode(encoded string) (Address, []*Params, error) {
+	if params, ok := bech32Chain(encoded); ok {
+		return decodeBech32(encoded, params)
+	}
+
+	hash, version, err := base58.CheckDecode(encoded)
+	if err == base58.ErrChecksum {
+		return Address{}, nil, errors.Wrapf(ErrInvalidChecksum, "%q", encoded)
+	}
+	if err != nil {
+		return Address{}, nil, errors.Wrapf(ErrInvalidFormat, "%q", encoded)
+	}
+	if len(hash) != HashLength {
+		return Address{}, nil, errors.Wrapf(ErrInvalidLength, "%d bytes in %q", len(hash), encoded)
+	}
+
+	address := Address{}
+	copy(address.Hash[:], hash)
+	var matching []*Params
+	for _, params := range chains {
+		if version == params.PubKeyHashAddrID {
+			address.Kind = PubKeyHash
+		} else if params.ScriptHashAddrID != nil && version == *params.ScriptHashAddrID {
+			address.Kind = ScriptHash
+		} else {
+			continue
+		}
+		matching = append(matching, params)
+	}
+	if len(matching) == 0 {
+		return Address{}, nil, errors.Wrapf(ErrUnsupportedChain, "unknown address version %d in %q", version, encoded)
+	}
+	return address, matching, nil
+}
+
+// returns the chain of a bech32 address from its human readable part
+func bech32Chain(encoded string) (*Params, bool) {
+	for _, params := range chains {
+		if hasBech32HRP(encoded, params) {
+			return params, true
+		}
+	}
+	return nil, false
+}
+
+func hasBech32HRP(encoded string, params *Params) bool {
+	return params.Bech32HRP != "" && strings.HasPrefix(strings.ToLower(encoded), params.Bech32HRP+"1")
+}
+
+func decodeBech32(encoded string, params *Params) (Address, []*Params, error) {
+	hrp, data, err := bech32.Decode(encoded)
+	if err != nil {
+		if _, ok := err.(bech32.ErrInvalidChecksum); ok {
+			return Address{}, nil, errors.Wrapf(ErrInvalidChecksum, "%q", encoded)
+		}
+		return Address{}, nil, errors.Wrapf(ErrInvalidFormat, "%q: %s", encoded, err)
+	}
+	if hrp != params.Bech32HRP || len(data) == 0 {
+		return Address{}, nil, errors.Wrapf(ErrInvalidFormat, "%q", encoded)
+	}
+	if data[0] != 0 {
+		return Address{}, nil, errors.Wrapf(ErrUnsupportedScript, "witness version %d in %q", data[0], encoded)
+	}
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return Address{}, nil, errors.Wrapf(ErrInvalidFormat, "%q: %s", encoded, err)
+	}
+	if len(program) != HashLength {
+		// 32 byte witness script hashes don't fit an Ethereum address
+		return Address{}, nil, errors.Wrapf(ErrUnsupportedScript, "%d byte witness program in %q", len(program), encoded)
+	}
+
+	address := Address{Kind: WitnessPubKeyHash}
+	copy(address.Hash[:], program)
+	return address, []*Params{params}, nil
+}
+
+// Codec converts addresses of a single chain
+type Codec struct {
+	params *Params
+}
+
+// ForChain returns the codec of a chain reported by getblockchaininfo
+func ForChain(chain string) (*Codec, error) {
+	params, err := ParamsForChain(chain)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{params: params}, nil
+}
+
+func NewCodec(params *Params) *Codec {
+	return &Codec{params: params}
+}
+
+func (c *Codec) Params() *Params {
+	return c.params
+}
+
+// FromHex returns the P2PKH base58 address of an Ethereum address, like kaond's fromhexaddress
+func (c *Codec) FromHex(hexAddress string) (string, error) {
+	return c.FromHexAs(hexAddress, PubKeyHash)
+}
+
+// FromHexAs returns the address of the given kind of an Ethereum address
+func (c *Codec) FromHexAs(hexAddress string, kind Kind) (string, error) {
+	address, err := FromHex(hexAddress, kind)
+	if err != nil {
+		return "", err
+	}
+	return address.Encode(c.params)
+}
+
+// ToHex returns the Ethereum address, without 0x prefix, of a base58 or bech32 address of the chain,
+// like kaond's gethexaddress which only supports P2PKH addresses
+func (c *Codec) ToHex(encoded string) (string, error) {
+	address, err := Decode(encoded, c.params)
+	if err != nil {
+		return "", err
+	}
+	return address.Hex(), nil
+}
+
+// IsValid checks that the address is a valid base58 or bech32 address of the chain
+func (c *Codec) IsValid(encoded string) bool {
+	_, err := Decode(encoded, c.params)
+	return err == nil
+}
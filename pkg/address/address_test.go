@@ -0,0 +1,164 @@
+package address
+
+import (
+	"strings"
+	"testing"
+
+	btcbech32 "github.com/btcsuite/btcutil/bech32"
+	"github.com/pkg/errors"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		chain   string
+		address string
+		kind    Kind
+		hex     string
+	}{
+		{"main", "QYmyzKNjoox5LkaiUvibZdM252bftQotDx", PubKeyHash, "8585918c3ee7168ee9d79dd9b5883eb65d0e0db0"},
+		// funded by docker/fill_user_account.sh on a regtest kaond
+		{"test", "ar2SzdHghSgeacypPn7zfDe3qfKAEwimus", PubKeyHash, "1ce507204a6fc8fd6aa7e54d1481d30acb0dbead"},
+		{"regtest", "ar2SzdHghSgeacypPn7zfDe3qfKAEwimus", PubKeyHash, "1ce507204a6fc8fd6aa7e54d1481d30acb0dbead"},
+		{"regtest", "auASFMxv45WgjCW6wkpDuHWjxXhzNA9mjP", PubKeyHash, "3f501c368cb9ddb5f27ed72ac0d602724adfa175"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			codec, err := ForChain(tt.chain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hex, err := codec.ToHex(tt.address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.hex != "" && hex != tt.hex {
+				t.Fatalf("expected %s, got %s", tt.hex, hex)
+			}
+			encoded, err := codec.FromHexAs("0x"+hex, tt.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encoded != tt.address {
+				t.Fatalf("expected %s, got %s", tt.address, encoded)
+			}
+		})
+	}
+}
+
+func TestWitnessAddresses(t *testing.T) {
+	// no chain has a known bech32 prefix yet
+	params := MainNetParams
+	params.Bech32HRP = "kc"
+	codec := NewCodec(&params)
+
+	const hash = "8585918c3ee7168ee9d79dd9b5883eb65d0e0db0"
+	encoded, err := codec.FromHexAs(hash, WitnessPubKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "kc1q") {
+		t.Fatalf("expected a version 0 witness address, got %s", encoded)
+	}
+	hex, err := codec.ToHex(strings.ToUpper(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex != hash {
+		t.Fatalf("expected %s, got %s", hash, hex)
+	}
+
+	// rejected where the prefix isn't known
+	if _, err := NewCodec(&MainNetParams).ToHex(encoded); errors.Cause(err) != ErrInvalidFormat {
+		t.Fatalf("expected %v, got %v", ErrInvalidFormat, err)
+	}
+	if _, err := NewCodec(&MainNetParams).FromHexAs(hash, WitnessPubKeyHash); errors.Cause(err) != ErrUnsupportedKind {
+		t.Fatalf("expected %v, got %v", ErrUnsupportedKind, err)
+	}
+}
+
+func TestScriptHashAddresses(t *testing.T) {
+	codec := NewCodec(&MainNetParams)
+	const hash = "8585918c3ee7168ee9d79dd9b5883eb65d0e0db0"
+	encoded, err := codec.FromHexAs(hash, ScriptHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded[0] != 'M' {
+		t.Fatalf("expected a mainnet P2SH address to start with M, got %s", encoded)
+	}
+	address, err := DecodeAny(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.Kind != ScriptHash || address.Hex() != hash {
+		t.Fatalf("unexpected address %s %s", address.Kind, address.Hex())
+	}
+}
+
+func TestInvalidAddresses(t *testing.T) {
+	main := NewCodec(&MainNetParams)
+	withBech32 := MainNetParams
+	withBech32.Bech32HRP = "kc"
+	bech32 := NewCodec(&withBech32)
+	tests := []struct {
+		name    string
+		convert func() error
+		err     error
+	}{
+		{"bad checksum", func() error {
+			_, err := main.ToHex("QYmyzKNjoox5LkaiUvibZdM252bftQotDy")
+			return err
+		}, ErrInvalidChecksum},
+		{"bad bech32 checksum", func() error {
+			_, err := bech32.ToHex("kc1q3422djj7p4mjsgn7m3k3kymd2s36jnrpzcn7xy")
+			return err
+		}, ErrInvalidChecksum},
+		{"testnet address on mainnet", func() error {
+			_, err := main.ToHex("ar2SzdHghSgeacypPn7zfDe3qfKAEwimus")
+			return err
+		}, ErrWrongChain},
+		{"qtum testnet address", func() error {
+			_, err := ToHex("qTTH1Yr2eKCuDLqfxUyBLCAjmomQ8pyrBt")
+			return err
+		}, ErrUnsupportedChain},
+		{"script hash without a known prefix", func() error {
+			_, err := NewCodec(&RegTestParams).FromHexAs("8585918c3ee7168ee9d79dd9b5883eb65d0e0db0", ScriptHash)
+			return err
+		}, ErrUnsupportedKind},
+		{"not base58", func() error {
+			_, err := main.ToHex("0x8585918c3ee7168ee9d79dd9b5883eb65d0e0db0")
+			return err
+		}, ErrInvalidFormat},
+		{"unknown version", func() error {
+			_, err := ToHex("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2")
+			return err
+		}, ErrUnsupportedChain},
+		{"witness script hash", func() error {
+			program, _ := btcbech32.ConvertBits(make([]byte, 32), 8, 5, true)
+			scriptHash, _ := btcbech32.Encode("kc", append([]byte{0}, program...))
+			_, err := bech32.ToHex(scriptHash)
+			return err
+		}, ErrUnsupportedScript},
+		{"bad hex", func() error {
+			_, err := main.FromHex("0x8585918c3ee7168ee9d79dd9b5883eb65d0e0dzz")
+			return err
+		}, ErrInvalidHex},
+		{"short hex", func() error {
+			_, err := main.FromHex("0x8585918c")
+			return err
+		}, ErrInvalidLength},
+		{"unknown chain", func() error {
+			_, err := ForChain("auto")
+			return err
+		}, ErrUnsupportedChain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.convert(); errors.Cause(err) != tt.err {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 )
 
 var ErrDatabaseNotConfigured = errors.New("database not connected")
+var ErrWaitingForChainId = errors.New("waiting for the chain id before indexing blocks")
 
 type BlockHash struct {
 	ctx   context.Context
@@ -94,6 +95,26 @@ func (bh *BlockHash) GetKaonBlockHashContext(ctx context.Context, ethereumBlockH
 	}
 }
 
+// Number of blocks up to latestBlock whose Ethereum block hash isn't in the database yet
+func (bh *BlockHash) MissingBlocks(ctx context.Context, latestBlock int64) (int, error) {
+	bh.mutex.RLock()
+	kaonDB := bh.kaonDB
+	chainId := bh.chainId
+	bh.mutex.RUnlock()
+
+	if kaonDB == nil {
+		return 0, ErrDatabaseNotConfigured
+	}
+	if chainId == 0 {
+		return 0, ErrWaitingForChainId
+	}
+	missing, err := kaonDB.GetMissingBlocks(ctx, chainId, latestBlock)
+	if err != nil {
+		return 0, err
+	}
+	return len(missing), nil
+}
+
 func (bh *BlockHash) Start(databaseConfig *DatabaseConfig, chainIdChan <-chan int) error {
 	numWorkers := runtime.NumCPU() * 2
 	bh.chainIdMutex.Lock()
@@ -0,0 +1,21 @@
+// Package cache provides the key value stores cached kaond responses and filters are kept in.
+//
+// A single gateway keeps them in memory, gateway replicas behind a load balancer can share them through Redis.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// A Store keeps values until their time to live has passed, it may drop them earlier
+type Store interface {
+	// returns nil if there is no value for key
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	// drops every value whose key starts with prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+	// sets key to value only if its value is still old, a nil old matching a missing key, and reports whether it did
+	CompareAndSwap(ctx context.Context, key string, old []byte, value []byte, ttl time.Duration) (bool, error)
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// estimated memory used by an entry on top of its key and value
+const entryOverhead = 128
+
+// Counters of a Memory store
+type MemoryStats struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+	// values dropped to stay within the memory budget
+	Evictions uint64 `json:"evictions"`
+	// values dropped because their time to live passed
+	Expirations uint64 `json:"expirations"`
+}
+
+// Memory is a Store keeping values in a least recently used list bounded by the memory they use
+type Memory struct {
+	mu       sync.Mutex
+	maxBytes int64
+	now      func() time.Time
+	lru      *list.List
+	entries  map[string]*list.Element
+	stats    MemoryStats
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func (e *memoryEntry) size() int64 {
+	return int64(len(e.key) + len(e.value) + entryOverhead)
+}
+
+// NewMemory creates a Memory store using at most maxBytes
+func NewMemory(maxBytes int64) *Memory {
+	return &Memory{
+		maxBytes: maxBytes,
+		now:      time.Now,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Size of an entry counted against the memory budget
+func EntrySize(key string, value []byte) int64 {
+	return (&memoryEntry{key: key, value: value}).size()
+}
+
+// SetClock replaces the clock time to live is measured with, for tests
+func (m *Memory) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.get(key), nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(key, value, ttl)
+	return nil
+}
+
+func (m *Memory) CompareAndSwap(_ context.Context, key string, old []byte, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current := m.get(key)
+	if (current == nil) != (old == nil) || !bytes.Equal(current, old) {
+		return false, nil
+	}
+	m.set(key, value, ttl)
+	return true, nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+	return nil
+}
+
+func (m *Memory) DeletePrefix(_ context.Context, prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, element := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			m.remove(element)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Stats() MemoryStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+func (m *Memory) get(key string) []byte {
+	element, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if m.now().After(entry.expires) {
+		m.remove(element)
+		m.stats.Expirations++
+		return nil
+	}
+	m.lru.MoveToFront(element)
+	return entry.value
+}
+
+func (m *Memory) set(key string, value []byte, ttl time.Duration) {
+	entry := &memoryEntry{
+		key:     key,
+		value:   value,
+		expires: m.now().Add(ttl),
+	}
+	if entry.size() > m.maxBytes {
+		return
+	}
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+	m.entries[key] = m.lru.PushFront(entry)
+	m.stats.Entries++
+	m.stats.Bytes += entry.size()
+
+	for m.stats.Bytes > m.maxBytes {
+		m.remove(m.lru.Back())
+		m.stats.Evictions++
+	}
+}
+
+func (m *Memory) remove(element *list.Element) {
+	entry := m.lru.Remove(element).(*memoryEntry)
+	delete(m.entries, entry.key)
+	m.stats.Entries--
+	m.stats.Bytes -= entry.size()
+}
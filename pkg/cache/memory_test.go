@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	value := []byte(`"0000000000000000000000000000000000000000"`)
+	memory := NewMemory(3 * EntrySize("a", value))
+
+	for _, key := range []string{"a", "b", "c"} {
+		memory.Set(ctx, key, value, time.Minute)
+	}
+	memory.Get(ctx, "a")
+	memory.Set(ctx, "d", value, time.Minute)
+
+	for _, key := range []string{"a", "c", "d"} {
+		if cached, _ := memory.Get(ctx, key); cached == nil {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+	if cached, _ := memory.Get(ctx, "b"); cached != nil {
+		t.Errorf("expected b to be evicted, got %s", cached)
+	}
+	if stats := memory.Stats(); stats.Entries != 3 || stats.Evictions != 1 || stats.Bytes != 3*EntrySize("a", value) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestMemoryExpiresAndDeletes(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	memory := NewMemory(1 << 20)
+	memory.SetClock(func() time.Time { return now })
+
+	memory.Set(ctx, "regtest:block", []byte("1"), time.Minute)
+	memory.Set(ctx, "regtest:tx", []byte("2"), time.Hour)
+	memory.Set(ctx, "test:tx", []byte("3"), time.Hour)
+
+	now = now.Add(2 * time.Minute)
+	if cached, _ := memory.Get(ctx, "regtest:block"); cached != nil {
+		t.Errorf("expected regtest:block to expire, got %s", cached)
+	}
+	if cached, _ := memory.Get(ctx, "regtest:tx"); string(cached) != "2" {
+		t.Errorf("expected regtest:tx to be cached, got %s", cached)
+	}
+
+	memory.DeletePrefix(ctx, "regtest:")
+	if cached, _ := memory.Get(ctx, "regtest:tx"); cached != nil {
+		t.Errorf("expected regtest:tx to be deleted, got %s", cached)
+	}
+	memory.Delete(ctx, "test:tx")
+	if stats := memory.Stats(); stats.Entries != 0 || stats.Expirations != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+// checks the compare and swap semantics shared by the stores
+func testCompareAndSwap(t *testing.T, store Store) {
+	ctx := context.Background()
+	if swapped, err := store.CompareAndSwap(ctx, "regtest:filter:1", nil, []byte("1"), time.Minute); !swapped || err != nil {
+		t.Fatalf("expected a missing key to be set, got %v %v", swapped, err)
+	}
+	if swapped, _ := store.CompareAndSwap(ctx, "regtest:filter:1", nil, []byte("2"), time.Minute); swapped {
+		t.Error("expected an existing key not to match nil")
+	}
+	if swapped, _ := store.CompareAndSwap(ctx, "regtest:filter:1", []byte("1"), []byte("2"), time.Minute); !swapped {
+		t.Error("expected the value to be swapped")
+	}
+	if swapped, _ := store.CompareAndSwap(ctx, "regtest:filter:1", []byte("1"), []byte("3"), time.Minute); swapped {
+		t.Error("expected a stale value not to be swapped")
+	}
+	if cached, _ := store.Get(ctx, "regtest:filter:1"); string(cached) != "2" {
+		t.Errorf("expected the swapped value, got %s", cached)
+	}
+}
+
+func TestMemoryCompareAndSwap(t *testing.T) {
+	testCompareAndSwap(t, NewMemory(1<<20))
+}
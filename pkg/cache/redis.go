@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// number of keys looked at per SCAN when deleting by prefix
+const redisScanCount = 1000
+
+// Sets KEYS[1] to ARGV[3] if its value is ARGV[2], or if it's missing when ARGV[1] is "0", expiring after ARGV[4] milliseconds
+var compareAndSwapScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if ARGV[1] == "0" then
+	if current then
+		return 0
+	end
+elseif current ~= ARGV[2] then
+	return 0
+end
+if tonumber(ARGV[4]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[3], "PX", ARGV[4])
+else
+	redis.call("SET", KEYS[1], ARGV[3])
+end
+return 1
+`)
+
+// Redis is a Store shared by every gateway connected to the same Redis database
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis connects to the Redis database at rawURL, e.g. redis://:password@localhost:6379/0
+func NewRedis(rawURL string) (*Redis, error) {
+	options, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse redis url")
+	}
+	return &Redis{client: redis.NewClient(options)}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return value, err
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, key).Err()
+}
+
+func (r *Redis) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := r.client.Scan(ctx, 0, prefix+"*", redisScanCount).Iterator()
+	for iter.Next(ctx) {
+		if err := r.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+func (r *Redis) CompareAndSwap(ctx context.Context, key string, old []byte, value []byte, ttl time.Duration) (bool, error) {
+	exists := "1"
+	if old == nil {
+		exists = "0"
+	}
+	swapped, err := compareAndSwapScript.Run(ctx, r.client, []string{key}, exists, old, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return swapped == 1, nil
+}
+
+// Checks the connection to Redis
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
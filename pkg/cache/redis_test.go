@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	redis, err := NewRedis("redis://" + server.Addr() + "/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer redis.Close()
+
+	if cached, err := redis.Get(ctx, "regtest:block"); cached != nil || err != nil {
+		t.Fatalf("expected a miss, got %s %v", cached, err)
+	}
+	redis.Set(ctx, "regtest:block", []byte("1"), time.Minute)
+	redis.Set(ctx, "regtest:tx", []byte("2"), time.Hour)
+	redis.Set(ctx, "test:tx", []byte("3"), time.Hour)
+
+	server.FastForward(2 * time.Minute)
+	if cached, _ := redis.Get(ctx, "regtest:block"); cached != nil {
+		t.Errorf("expected regtest:block to expire, got %s", cached)
+	}
+	if cached, _ := redis.Get(ctx, "regtest:tx"); string(cached) != "2" {
+		t.Errorf("expected regtest:tx to be cached, got %s", cached)
+	}
+
+	if err := redis.DeletePrefix(ctx, "regtest:"); err != nil {
+		t.Fatal(err)
+	}
+	if cached, _ := redis.Get(ctx, "regtest:tx"); cached != nil {
+		t.Errorf("expected regtest:tx to be deleted, got %s", cached)
+	}
+	if cached, _ := redis.Get(ctx, "test:tx"); string(cached) != "3" {
+		t.Errorf("expected test:tx to be kept, got %s", cached)
+	}
+}
+
+func TestRedisCompareAndSwap(t *testing.T) {
+	server := miniredis.RunT(t)
+	redis, err := NewRedis("redis://" + server.Addr() + "/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer redis.Close()
+
+	testCompareAndSwap(t, redis)
+}
@@ -0,0 +1,180 @@
+package conversion
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Ethereum uncle hash of a block without uncles, Kaon blocks never have any
+var EmptyUnclesHash = types.EmptyUncleHash.Hex()
+
+// Legacy Ethereum transaction with the fields of an Ethereum transaction as served by eth-rpc-gate, so that
+// the transactions of a block can be checked against its transactionsRoot. Kaon transactions aren't RLP
+// encoded Ethereum transactions, so the hash of the result differs from the transaction's hash.
+func EthereumTransaction(tx *eth.GetTransactionByHashResponse) (*types.Transaction, error) {
+	var (
+		legacyTx = &types.LegacyTx{}
+		err      error
+	)
+	if legacyTx.Nonce, err = decodeOptionalUint64(tx.Nonce); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode nonce")
+	}
+	if legacyTx.GasPrice, err = decodeOptionalBig(tx.GasPrice); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode gasPrice")
+	}
+	gas, err := decodeOptionalBig(tx.Gas)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode gas")
+	}
+	legacyTx.Gas = gas.Uint64()
+	if legacyTx.Value, err = decodeOptionalBig(tx.Value); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode value")
+	}
+	if tx.Input != "" && tx.Input != "0x" {
+		if legacyTx.Data, err = hexutil.Decode(utils.AddHexPrefix(tx.Input)); err != nil {
+			return nil, errors.WithMessage(err, "couldn't decode input")
+		}
+	}
+	if tx.To != "" {
+		to := common.HexToAddress(tx.To)
+		legacyTx.To = &to
+	}
+	if legacyTx.V, err = decodeOptionalBig(tx.V); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode v")
+	}
+	if legacyTx.R, err = decodeOptionalBig(tx.R); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode r")
+	}
+	if legacyTx.S, err = decodeOptionalBig(tx.S); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode s")
+	}
+	return types.NewTx(legacyTx), nil
+}
+
+// Root of the Ethereum transactions trie of a block's transactions, in block order
+func TransactionsRoot(txs []eth.GetTransactionByHashResponse) (string, error) {
+	ethTxs := make(types.Transactions, 0, len(txs))
+	for i := range txs {
+		ethTx, err := EthereumTransaction(&txs[i])
+		if err != nil {
+			return "", errors.WithMessagef(err, "couldn't convert transaction %s", txs[i].Hash)
+		}
+		ethTxs = append(ethTxs, ethTx)
+	}
+	return types.DeriveSha(ethTxs, trie.NewStackTrie(nil)).Hex(), nil
+}
+
+// Consensus fields of an Ethereum receipt for a Kaon receipt, cumulativeGasUsed is counted block-wide
+func EthereumReceipt(receipt *kaon.TransactionReceipt, cumulativeGasUsed uint64) *types.Receipt {
+	ethReceipt := &types.Receipt{
+		Type:              types.LegacyTxType,
+		Status:            types.ReceiptStatusFailed,
+		CumulativeGasUsed: cumulativeGasUsed,
+		Logs:              make([]*types.Log, 0, len(receipt.Log)),
+	}
+	if receipt.Excepted == "None" {
+		ethReceipt.Status = types.ReceiptStatusSuccessful
+	}
+	for _, log := range receipt.Log {
+		topics := make([]common.Hash, 0, len(log.Topics))
+		for _, topic := range log.Topics {
+			topics = append(topics, common.HexToHash(topic))
+		}
+		data, _ := hexutil.Decode(utils.AddHexPrefix(log.Data))
+		ethReceipt.Logs = append(ethReceipt.Logs, &types.Log{
+			Address: common.HexToAddress(log.Address),
+			Topics:  topics,
+			Data:    data,
+		})
+	}
+	ethReceipt.Bloom = types.CreateBloom(types.Receipts{ethReceipt})
+	return ethReceipt
+}
+
+// Receipt of an Ethereum transaction that doesn't run the EVM
+func SuccessfulReceipt(cumulativeGasUsed uint64) *types.Receipt {
+	return EthereumReceipt(&kaon.TransactionReceipt{Excepted: "None"}, cumulativeGasUsed)
+}
+
+// Root of the Ethereum receipts trie of a block's receipts, in block order
+func ReceiptsRoot(receipts types.Receipts) string {
+	return types.DeriveSha(receipts, trie.NewStackTrie(nil)).Hex()
+}
+
+// Ethereum header of a block as served by eth-rpc-gate, its hash is the Keccak256 hash of its RLP encoding
+func EthereumHeader(block *eth.GetBlockByHashResponse) (*types.Header, error) {
+	header := &types.Header{
+		ParentHash:  common.HexToHash(block.ParentHash),
+		UncleHash:   common.HexToHash(block.Sha3Uncles),
+		Coinbase:    common.HexToAddress(block.Miner),
+		Root:        common.HexToHash(block.StateRoot),
+		TxHash:      common.HexToHash(block.TransactionsRoot),
+		ReceiptHash: common.HexToHash(block.ReceiptsRoot),
+		MixDigest:   common.HexToHash(block.MixHash),
+	}
+
+	bloom, err := hexutil.Decode(block.LogsBloom)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode logsBloom")
+	}
+	header.Bloom = types.BytesToBloom(bloom)
+
+	if header.Difficulty, err = decodeOptionalBig(block.Difficulty); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode difficulty")
+	}
+	if header.Number, err = decodeOptionalBig(block.Number); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode number")
+	}
+	if header.GasLimit, err = decodeOptionalUint64(block.GasLimit); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode gasLimit")
+	}
+	if header.GasUsed, err = decodeOptionalUint64(block.GasUsed); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode gasUsed")
+	}
+	if header.Time, err = decodeOptionalUint64(block.Timestamp); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode timestamp")
+	}
+	if header.Extra, err = hexutil.Decode(utils.AddHexPrefix(block.ExtraData)); err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode extraData")
+	}
+
+	nonce, err := hexutil.Decode(utils.AddHexPrefix(block.Nonce))
+	if err != nil || len(nonce) != len(header.Nonce) {
+		return nil, errors.Errorf("couldn't decode nonce %q", block.Nonce)
+	}
+	copy(header.Nonce[:], nonce)
+
+	return header, nil
+}
+
+// Values served by eth-rpc-gate may have leading zeroes, which hexutil rejects
+func decodeOptionalBig(value string) (*big.Int, error) {
+	digits := utils.RemoveHexPrefix(value)
+	if digits == "" {
+		return new(big.Int), nil
+	}
+	n, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, errors.Errorf("invalid hex number %q", value)
+	}
+	return n, nil
+}
+
+func decodeOptionalUint64(value string) (uint64, error) {
+	n, err := decodeOptionalBig(value)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsUint64() {
+		return 0, errors.Errorf("%q overflows uint64", value)
+	}
+	return n.Uint64(), nil
+}
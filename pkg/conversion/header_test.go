@@ -0,0 +1,76 @@
+package conversion
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+)
+
+func TestEthereumHeaderHashMatchesServedBlock(t *testing.T) {
+	txs := []eth.GetTransactionByHashResponse{{
+		Hash:     "0x3208dc44733cbfa11654ad5651305428de473ef1e61a1ec07b0c1a5f4843be91",
+		Nonce:    "0x0",
+		Value:    "0x0",
+		Input:    "0xa9059cbb",
+		To:       "0xaf1ae4e29253ba755c723bca25e883b8deb777b8",
+		Gas:      "0x0000d6d8",
+		GasPrice: "0x28",
+	}}
+	transactionsRoot, err := TransactionsRoot(txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receipts := types.Receipts{EthereumReceipt(&kaon.TransactionReceipt{
+		Excepted: "None",
+		Log: []kaon.Log{{
+			Address: "af1ae4e29253ba755c723bca25e883b8deb777b8",
+			Topics:  []string{"ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+			Data:    "0000000000000000000000000000000000000000000000000000000000000001",
+		}},
+	}, 21000)}
+
+	block := &eth.GetBlockByHashResponse{
+		Number:           "0xf8f",
+		ParentHash:       "0x6d7d56af09383301e1bb32a97d4a5c0661d62302c06a778487d919b7115543be",
+		Nonce:            "0x0000000000000000",
+		Miner:            "0x0000000000000000000000000000000000000000",
+		LogsBloom:        LogsBloom([]kaon.Log{{Address: "af1ae4e29253ba755c723bca25e883b8deb777b8"}}),
+		Timestamp:        "0x5f2ee1a2",
+		ExtraData:        "0x0000000000000000000000000000000000000000000000000000000000000000",
+		StateRoot:        "0x3e49216e58f1ad9e6823b5095dc532f0a6cc44943d36ff4a7b1aa474e172d672",
+		TransactionsRoot: transactionsRoot,
+		ReceiptsRoot:     ReceiptsRoot(receipts),
+		Difficulty:       "0x4",
+		GasLimit:         "0x2625a00",
+		GasUsed:          "0x5208",
+		Sha3Uncles:       EmptyUnclesHash,
+		MixHash:          "0x0000000000000000000000000000000000000000000000000000000000000000",
+	}
+
+	header, err := EthereumHeader(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Hash = header.Hash().Hex()
+
+	// a client decoding the served block must end up with the same hash
+	data, err := json.Marshal(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded types.Header
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Hash().Hex(); got != block.Hash {
+		t.Errorf("served header hashes to %s, want %s", got, block.Hash)
+	}
+
+	if emptyRoot, _ := TransactionsRoot(nil); emptyRoot != types.EmptyRootHash.Hex() {
+		t.Errorf("expected the empty trie root for a block without transactions, got %s", emptyRoot)
+	}
+}
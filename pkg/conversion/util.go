@@ -2,13 +2,18 @@ package conversion
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
 	"strings"
 
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/pkg/errors"
 )
 
 func ExtractETHLogsFromTransactionReceipt(blockData kaon.LogBlockData, logs []kaon.Log) []eth.Log {
@@ -32,6 +37,209 @@ func ExtractETHLogsFromTransactionReceipt(blockData kaon.LogBlockData, logs []ka
 	return result
 }
 
+// Block-wide numbering of transactions and logs. Kaon numbers logs per receipt,
+// while Ethereum clients expect logIndex to be the position of a log in the whole block
+type BlockLogPositions struct {
+	executions         []kaon.Execution
+	transactionIndexes map[receiptKey]uint64
+	transactionHashes  map[receiptKey]string
+	firstExecutions    map[string]receiptKey
+	firstLogIndexes    map[receiptKey]uint64
+	logsBloom          types.Bloom
+}
+
+// A Kaon transaction may have several receipts, one per contract execution
+type receiptKey struct {
+	transactionHash string
+	outputIndex     int64
+}
+
+func newReceiptKey(receipt *kaon.TransactionReceipt) receiptKey {
+	return receiptKey{
+		transactionHash: strings.ToLower(utils.RemoveHexPrefix(receipt.TransactionHash)),
+		outputIndex:     receipt.OutputIndex,
+	}
+}
+
+// executions are the block's Ethereum transactions in block order, receipts must include every receipt with logs in the block
+func NewBlockLogPositions(executions []kaon.Execution, receipts []kaon.TransactionReceipt) *BlockLogPositions {
+	positions := newBlockLogPositions(executions, len(receipts))
+
+	sorted := make([]kaon.TransactionReceipt, len(receipts))
+	copy(sorted, receipts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		left, right := positions.TransactionIndex(&sorted[i]), positions.TransactionIndex(&sorted[j])
+		if left != right {
+			return left < right
+		}
+		return sorted[i].OutputIndex < sorted[j].OutputIndex
+	})
+
+	var logIndex uint64
+	for i := range sorted {
+		receipt := &sorted[i]
+		positions.firstLogIndexes[newReceiptKey(receipt)] = logIndex
+		logIndex += uint64(len(receipt.Log))
+		addLogsToBloom(&positions.logsBloom, receipt.Log)
+	}
+
+	return positions
+}
+
+func newBlockLogPositions(executions []kaon.Execution, receipts int) *BlockLogPositions {
+	positions := &BlockLogPositions{
+		executions:         executions,
+		transactionIndexes: make(map[receiptKey]uint64, len(executions)),
+		transactionHashes:  make(map[receiptKey]string, len(executions)),
+		firstExecutions:    make(map[string]receiptKey, len(executions)),
+		firstLogIndexes:    make(map[receiptKey]uint64, receipts),
+	}
+
+	for i, execution := range executions {
+		key := receiptKey{
+			transactionHash: strings.ToLower(utils.RemoveHexPrefix(execution.TransactionHash)),
+			outputIndex:     execution.OutputIndex,
+		}
+		positions.transactionIndexes[key] = uint64(i)
+		positions.transactionHashes[key] = execution.Hash
+		if _, ok := positions.firstExecutions[key.transactionHash]; !ok {
+			positions.firstExecutions[key.transactionHash] = key
+		}
+	}
+	return positions
+}
+
+// Serialized positions, kept by block hash since they can't change for a block
+type blockLogPositionsJSON struct {
+	Executions      []kaon.Execution    `json:"executions"`
+	FirstLogIndexes []firstLogIndexJSON `json:"firstLogIndexes"`
+	LogsBloom       hexutil.Bytes       `json:"logsBloom"`
+}
+
+type firstLogIndexJSON struct {
+	TransactionHash string `json:"transactionHash"`
+	OutputIndex     int64  `json:"outputIndex"`
+	LogIndex        uint64 `json:"logIndex"`
+}
+
+func (p *BlockLogPositions) MarshalJSON() ([]byte, error) {
+	encoded := blockLogPositionsJSON{
+		Executions:      p.executions,
+		FirstLogIndexes: make([]firstLogIndexJSON, 0, len(p.firstLogIndexes)),
+		LogsBloom:       p.logsBloom.Bytes(),
+	}
+	for key, logIndex := range p.firstLogIndexes {
+		encoded.FirstLogIndexes = append(encoded.FirstLogIndexes, firstLogIndexJSON{
+			TransactionHash: key.transactionHash,
+			OutputIndex:     key.outputIndex,
+			LogIndex:        logIndex,
+		})
+	}
+	// sorted so that the same positions are always encoded the same
+	sort.Slice(encoded.FirstLogIndexes, func(i, j int) bool {
+		return encoded.FirstLogIndexes[i].LogIndex < encoded.FirstLogIndexes[j].LogIndex
+	})
+	return json.Marshal(encoded)
+}
+
+func (p *BlockLogPositions) UnmarshalJSON(data []byte) error {
+	var encoded blockLogPositionsJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	if len(encoded.LogsBloom) != types.BloomByteLength {
+		return errors.Errorf("invalid logs bloom length %d", len(encoded.LogsBloom))
+	}
+
+	*p = *newBlockLogPositions(encoded.Executions, len(encoded.FirstLogIndexes))
+	for _, first := range encoded.FirstLogIndexes {
+		p.firstLogIndexes[receiptKey{transactionHash: first.TransactionHash, outputIndex: first.OutputIndex}] = first.LogIndex
+	}
+	p.logsBloom.SetBytes(encoded.LogsBloom)
+	return nil
+}
+
+// Resolves the execution of a receipt, receipts of outputs that aren't known as executions
+// (e.g. contract outputs that couldn't be parsed) are attributed to the first one of the transaction
+func (p *BlockLogPositions) executionKey(receipt *kaon.TransactionReceipt) (receiptKey, bool) {
+	key := newReceiptKey(receipt)
+	if _, ok := p.transactionIndexes[key]; ok {
+		return key, true
+	}
+	key, ok := p.firstExecutions[key.transactionHash]
+	return key, ok
+}
+
+// Position of the receipt's execution in its block, a nil receiver or an unknown transaction falls back to the index reported by kaond
+func (p *BlockLogPositions) TransactionIndex(receipt *kaon.TransactionReceipt) uint64 {
+	if p != nil {
+		if key, ok := p.executionKey(receipt); ok {
+			return p.transactionIndexes[key]
+		}
+	}
+	return receipt.TransactionIndex
+}
+
+// Ethereum transaction hash of the receipt's execution, a nil receiver or an unknown transaction falls back to the Kaon transaction id
+func (p *BlockLogPositions) TransactionHash(receipt *kaon.TransactionReceipt) string {
+	if p != nil {
+		if key, ok := p.executionKey(receipt); ok {
+			return p.transactionHashes[key]
+		}
+	}
+	return receipt.TransactionHash
+}
+
+// Block-wide index of the log at logIndex inside receipt, a nil receiver or an unknown receipt numbers logs per receipt
+func (p *BlockLogPositions) LogIndex(receipt *kaon.TransactionReceipt, logIndex int) uint64 {
+	if p != nil {
+		if first, ok := p.firstLogIndexes[newReceiptKey(receipt)]; ok {
+			return first + uint64(logIndex)
+		}
+	}
+	return uint64(logIndex)
+}
+
+// Bloom filter over every log in the block, empty for a nil receiver
+func (p *BlockLogPositions) LogsBloom() string {
+	if p == nil {
+		return eth.EmptyLogsBloom
+	}
+	return hexutil.Encode(p.logsBloom.Bytes())
+}
+
+// Ethereum bloom filter over the addresses and topics of logs
+func LogsBloom(logs []kaon.Log) string {
+	var bloom types.Bloom
+	addLogsToBloom(&bloom, logs)
+	return hexutil.Encode(bloom.Bytes())
+}
+
+func addLogsToBloom(bloom *types.Bloom, logs []kaon.Log) {
+	for _, log := range logs {
+		bloom.Add(common.HexToAddress(log.Address).Bytes())
+		for _, topic := range log.Topics {
+			bloom.Add(common.HexToHash(topic).Bytes())
+		}
+	}
+}
+
+// Converts logs of a receipt numbering transactions and logs block-wide, logs are expected
+// to carry their position inside the receipt in Log.Index (see SearchLogsAndFilterExtraTopics)
+func ExtractETHLogsFromTransactionReceiptInBlock(receipt *kaon.TransactionReceipt, logs []kaon.Log, positions *BlockLogPositions) []eth.Log {
+	result := ExtractETHLogsFromTransactionReceipt(receipt, logs)
+	var (
+		transactionHash  = utils.AddHexPrefix(positions.TransactionHash(receipt))
+		transactionIndex = hexutil.EncodeUint64(positions.TransactionIndex(receipt))
+	)
+	for i := range result {
+		result[i].TransactionHash = transactionHash
+		result[i].TransactionIndex = transactionIndex
+		result[i].LogIndex = hexutil.EncodeUint64(positions.LogIndex(receipt, logs[i].Index))
+	}
+	return result
+}
+
 func ConvertLogTopicsToStringArray(topics []interface{}) []string {
 	var requestedTopics []string
 	for _, topic := range topics {
@@ -44,12 +252,24 @@ func ConvertLogTopicsToStringArray(topics []interface{}) []string {
 	return requestedTopics
 }
 
-func SearchLogsAndFilterExtraTopics(ctx context.Context, q *kaon.Kaon, req *kaon.SearchLogsRequest) (kaon.SearchLogsResponse, *eth.JSONRPCError) {
+// Implemented by *kaon.Kaon and by the local log index
+type LogSearcher interface {
+	SearchLogs(ctx context.Context, req *kaon.SearchLogsRequest) (kaon.SearchLogsResponse, error)
+}
+
+func SearchLogsAndFilterExtraTopics(ctx context.Context, q LogSearcher, req *kaon.SearchLogsRequest) (kaon.SearchLogsResponse, *eth.JSONRPCError) {
 	receipts, err := q.SearchLogs(ctx, req)
 	if err != nil {
 		return nil, eth.NewCallbackError(err.Error())
 	}
 
+	// keep track of where each log sits in its receipt, filtering below drops logs
+	for i := range receipts {
+		for index := range receipts[i].Log {
+			receipts[i].Log[index].Index = index
+		}
+	}
+
 	hasTopics := len(req.Topics) != 0
 	hasAddresses := len(req.Addresses) != 0
 
@@ -68,8 +288,7 @@ func SearchLogsAndFilterExtraTopics(ctx context.Context, q *kaon.Kaon, req *kaon
 
 	for _, receipt := range receipts {
 		var logs []kaon.Log
-		for index, log := range receipt.Log {
-			log.Index = index
+		for _, log := range receipt.Log {
 			if hasAddresses && !requestedAddressesMap[strings.ToLower(log.Address)] {
 				continue
 			}
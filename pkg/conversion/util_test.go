@@ -0,0 +1,50 @@
+package conversion
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+)
+
+func TestBlockLogPositionsJSON(t *testing.T) {
+	const (
+		coinbase = "aa00000000000000000000000000000000000000000000000000000000000000"
+		contract = "bb00000000000000000000000000000000000000000000000000000000000000"
+		topic    = "0x0000000000000000000000000000000000000000000000000000000000000001"
+	)
+	executions := []kaon.Execution{
+		{Hash: coinbase, TransactionHash: coinbase, OutputIndex: -1},
+		{Hash: contract, TransactionHash: contract, OutputIndex: 0},
+		{Hash: kaon.ExecutionHash(contract, 2), TransactionHash: contract, OutputIndex: 2},
+	}
+	log := kaon.Log{Address: "0x1000000000000000000000000000000000000001", Topics: []string{topic}}
+	receipts := []kaon.TransactionReceipt{
+		{TransactionHash: contract, OutputIndex: 2, Log: []kaon.Log{log}},
+		{TransactionHash: "0x" + contract, OutputIndex: 0, Log: []kaon.Log{log, log}},
+	}
+	positions := NewBlockLogPositions(executions, receipts)
+
+	data, err := json.Marshal(positions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded BlockLogPositions
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(&decoded, positions) {
+		t.Errorf("expected the positions to survive encoding, got %+v, want %+v", decoded, *positions)
+	}
+	if index := decoded.LogIndex(&receipts[0], 0); index != 2 {
+		t.Errorf("expected the log of the second execution to be the third one of the block, got %d", index)
+	}
+	if index := decoded.TransactionIndex(&receipts[0]); index != 2 {
+		t.Errorf("expected the second execution to be the third transaction of the block, got %d", index)
+	}
+	if decoded.LogsBloom() != positions.LogsBloom() {
+		t.Errorf("expected logsBloom %s, got %s", positions.LogsBloom(), decoded.LogsBloom())
+	}
+}
@@ -17,6 +17,16 @@ var InvalidParamsErrorCode = -32602
 // logic error
 var CallbackErrorCode = -32000
 
+// request exceeds a configured limit (EIP-1474)
+var LimitExceededErrorCode = -32005
+
+// a simulated call reverted or failed in the EVM (eth_simulateV1)
+var ExecutionRevertedErrorCode = 3
+var VMErrorCode = -32015
+
+// eth_sendRawTransactionSync sent the transaction but it wasn't mined before the timeout (EIP-7966)
+var TransactionTimeoutErrorCode = 4
+
 // shutdown error
 // "server is shutting down"
 var ShutdownErrorCode = -32000
@@ -46,6 +56,10 @@ func NewCallbackError(message string) *JSONRPCError {
 	return NewJSONRPCError(CallbackErrorCode, message, nil)
 }
 
+func NewLimitExceededError(message string) *JSONRPCError {
+	return NewJSONRPCError(LimitExceededErrorCode, message, nil)
+}
+
 type JSONRPCError struct {
 	code    int    `json:"code"`
 	message string `json:"message,omitempty"`
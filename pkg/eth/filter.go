@@ -1,11 +1,24 @@
 package eth
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
 	"math/big"
+	"strconv"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/cache"
 )
 
+// how long a filter kept in a shared store lives after it was last saved
+const SharedFilterTTL = 24 * time.Hour
+
 type FilterType int
 
 const (
@@ -20,11 +33,28 @@ type Filter struct {
 	Request      interface{}
 	LastBlockNum *big.Int
 	Data         sync.Map
+
+	// serializes the polls of a filter kept in memory
+	mutex sync.Mutex
+	// state the filter was loaded from or saved with, when it's kept in a shared store
+	stored []byte
+}
+
+// state of a filter kept in a shared store, lastBlockNumber and toBlock are the only data kept
+type filterState struct {
+	ID              uint64          `json:"id"`
+	Type            FilterType      `json:"type"`
+	Request         json.RawMessage `json:"request,omitempty"`
+	LastBlockNumber *uint64         `json:"lastBlockNumber,omitempty"`
+	ToBlock         *uint64         `json:"toBlock,omitempty"`
 }
 
 type FilterSimulator struct {
 	filters     sync.Map
 	maxFilterID *uint64
+
+	store     cache.Store
+	namespace func() string
 }
 
 func NewFilterSimulator() *FilterSimulator {
@@ -34,22 +64,145 @@ func NewFilterSimulator() *FilterSimulator {
 	}
 }
 
+// Keeps the filters in a store shared with other gateways instead of in memory,
+// namespace returns the prefix of their keys and is called for every filter
+func (f *FilterSimulator) SetStore(store cache.Store, namespace func() string) {
+	f.store = store
+	f.namespace = namespace
+}
+
 func (f *FilterSimulator) New(ty FilterType, req ...interface{}) *Filter {
-	id := atomic.AddUint64(f.maxFilterID, 1)
+	var id uint64
+	if f.store != nil {
+		// ids have to be unique across gateways
+		id = randomFilterID()
+	} else {
+		id = atomic.AddUint64(f.maxFilterID, 1)
+	}
 	filter := &Filter{ID: id, Type: ty}
 	if ty == NewFilterTy {
 		filter.Request = req[0]
 	}
 
-	f.filters.Store(id, filter)
+	if f.store == nil {
+		f.filters.Store(id, filter)
+	}
 
 	return filter
 }
 
+// Saves the changes made to a filter, filters kept in memory are always up to date
+func (f *FilterSimulator) Save(filter *Filter) error {
+	if f.store == nil {
+		return nil
+	}
+	value, err := filter.state()
+	if err != nil {
+		return err
+	}
+	if err := f.store.Set(context.Background(), f.key(filter.ID), value, SharedFilterTTL); err != nil {
+		return err
+	}
+	filter.stored = value
+	return nil
+}
+
+// Moves the lastBlockNumber of a polled filter from lastBlockNumber to blockNumber, unless a concurrent
+// poll moved it first, so that the changes in between are returned to a single poll
+func (f *FilterSimulator) Advance(filter *Filter, lastBlockNumber, blockNumber uint64) (bool, error) {
+	if f.store == nil {
+		filter.mutex.Lock()
+		defer filter.mutex.Unlock()
+		if current, ok := filter.Data.Load("lastBlockNumber"); !ok || current.(uint64) != lastBlockNumber {
+			return false, nil
+		}
+		filter.Data.Store("lastBlockNumber", blockNumber)
+		return true, nil
+	}
+
+	filter.Data.Store("lastBlockNumber", blockNumber)
+	value, err := filter.state()
+	if err != nil {
+		return false, err
+	}
+	swapped, err := f.store.CompareAndSwap(context.Background(), f.key(filter.ID), filter.stored, value, SharedFilterTTL)
+	if err != nil || !swapped {
+		filter.Data.Store("lastBlockNumber", lastBlockNumber)
+		return false, err
+	}
+	filter.stored = value
+	return true, nil
+}
+
+// serialized state of a filter kept in a shared store
+func (filter *Filter) state() ([]byte, error) {
+	state := filterState{ID: filter.ID, Type: filter.Type}
+	if filter.Request != nil {
+		request, err := json.Marshal([]interface{}{filter.Request})
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't marshal filter request")
+		}
+		state.Request = request
+	}
+	if lastBlockNumber, ok := filter.Data.Load("lastBlockNumber"); ok {
+		value := lastBlockNumber.(uint64)
+		state.LastBlockNumber = &value
+	}
+	if toBlock, ok := filter.Data.Load("toBlock"); ok {
+		value := toBlock.(uint64)
+		state.ToBlock = &value
+	}
+	return json.Marshal(state)
+}
+
 func (f *FilterSimulator) Uninstall(filterID uint64) {
+	if f.store != nil {
+		f.store.Delete(context.Background(), f.key(filterID))
+		return
+	}
 	f.filters.Delete(filterID)
 }
 
 func (f *FilterSimulator) Filter(filterID uint64) (value interface{}, ok bool) {
-	return f.filters.Load(filterID)
+	if f.store == nil {
+		return f.filters.Load(filterID)
+	}
+
+	stored, err := f.store.Get(context.Background(), f.key(filterID))
+	if err != nil || stored == nil {
+		return nil, false
+	}
+	var state filterState
+	if err := json.Unmarshal(stored, &state); err != nil {
+		return nil, false
+	}
+	filter := &Filter{ID: state.ID, Type: state.Type, stored: stored}
+	if state.Request != nil {
+		var request NewFilterRequest
+		if err := json.Unmarshal(state.Request, &request); err != nil {
+			return nil, false
+		}
+		filter.Request = &request
+	}
+	if state.LastBlockNumber != nil {
+		filter.Data.Store("lastBlockNumber", *state.LastBlockNumber)
+	}
+	if state.ToBlock != nil {
+		filter.Data.Store("toBlock", *state.ToBlock)
+	}
+	return filter, true
+}
+
+func (f *FilterSimulator) key(filterID uint64) string {
+	return f.namespace() + "filter:" + strconv.FormatUint(filterID, 10)
+}
+
+func randomFilterID() uint64 {
+	var id [8]byte
+	for {
+		rand.Read(id[:])
+		if value := binary.BigEndian.Uint64(id[:]); value != 0 {
+			return value
+		}
+	}
 }
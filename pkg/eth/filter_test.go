@@ -0,0 +1,84 @@
+package eth
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/cache"
+)
+
+func TestFiltersAreSharedThroughTheStore(t *testing.T) {
+	store := cache.NewMemory(1 << 20)
+	namespace := func() string { return "eth-rpc-gate:regtest:" }
+	replica1, replica2 := NewFilterSimulator(), NewFilterSimulator()
+	replica1.SetStore(store, namespace)
+	replica2.SetStore(store, namespace)
+
+	var request NewFilterRequest
+	if err := json.Unmarshal([]byte(`[{"fromBlock":"0x1","topics":["0x0f"]}]`), &request); err != nil {
+		t.Fatal(err)
+	}
+	filter := replica1.New(NewFilterTy, &request)
+	filter.Data.Store("lastBlockNumber", uint64(1))
+	filter.Data.Store("toBlock", uint64(10))
+	if err := replica1.Save(filter); err != nil {
+		t.Fatal(err)
+	}
+
+	value, ok := replica2.Filter(filter.ID)
+	if !ok {
+		t.Fatal("expected the filter to be found by the other replica")
+	}
+	shared := value.(*Filter)
+	lastBlockNumber, _ := shared.Data.Load("lastBlockNumber")
+	toBlock, _ := shared.Data.Load("toBlock")
+	sharedRequest := shared.Request.(*NewFilterRequest)
+	if shared.Type != NewFilterTy || lastBlockNumber != uint64(1) || toBlock != uint64(10) ||
+		string(sharedRequest.FromBlock) != `"0x1"` || len(sharedRequest.Topics) != 1 {
+		t.Errorf("unexpected filter %+v %v %v %+v", shared, lastBlockNumber, toBlock, sharedRequest)
+	}
+
+	replica2.Uninstall(filter.ID)
+	if _, ok := replica1.Filter(filter.ID); ok {
+		t.Error("expected the filter to be uninstalled")
+	}
+}
+
+func TestConcurrentPollsAdvanceFilterOnce(t *testing.T) {
+	store := cache.NewMemory(1 << 20)
+	namespace := func() string { return "eth-rpc-gate:regtest:" }
+	replica1, replica2 := NewFilterSimulator(), NewFilterSimulator()
+	replica1.SetStore(store, namespace)
+	replica2.SetStore(store, namespace)
+
+	filter := replica1.New(NewBlockFilterTy)
+	filter.Data.Store("lastBlockNumber", uint64(1))
+	if err := replica1.Save(filter); err != nil {
+		t.Fatal(err)
+	}
+
+	// both replicas read the filter before either of them advanced it
+	value1, _ := replica1.Filter(filter.ID)
+	value2, _ := replica2.Filter(filter.ID)
+	if advanced, err := replica1.Advance(value1.(*Filter), 1, 5); !advanced || err != nil {
+		t.Fatalf("expected the first poll to advance the filter, got %v %v", advanced, err)
+	}
+	if advanced, err := replica2.Advance(value2.(*Filter), 1, 6); advanced || err != nil {
+		t.Fatalf("expected the concurrent poll not to advance the filter, got %v %v", advanced, err)
+	}
+
+	value, _ := replica2.Filter(filter.ID)
+	if lastBlockNumber, _ := value.(*Filter).Data.Load("lastBlockNumber"); lastBlockNumber != uint64(5) {
+		t.Errorf("expected the filter to be at block 5, got %v", lastBlockNumber)
+	}
+
+	inMemory := NewFilterSimulator()
+	memoryFilter := inMemory.New(NewBlockFilterTy)
+	memoryFilter.Data.Store("lastBlockNumber", uint64(1))
+	if advanced, _ := inMemory.Advance(memoryFilter, 1, 5); !advanced {
+		t.Error("expected the in memory filter to advance")
+	}
+	if advanced, _ := inMemory.Advance(memoryFilter, 1, 6); advanced {
+		t.Error("expected a stale poll of the in memory filter not to advance it")
+	}
+}
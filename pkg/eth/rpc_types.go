@@ -1,6 +1,7 @@
 package eth
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/kaonone/eth-rpc-gate/pkg/utils"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
@@ -194,6 +196,82 @@ func (r GeneralSendRawTransactionResponse) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// ========== eth_sendRawTransactionSync ============= //
+
+type SendRawTransactionSyncRequest struct {
+	RawTx string
+	// milliseconds to wait for the receipt, the default if nil
+	Timeout *uint64
+}
+
+// Takes [rawTx, timeout], the timeout as a number or a hex quantity
+func (r *SendRawTransactionSyncRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) == 0 || len(params) > 2 {
+		return errors.New("expected a raw transaction and an optional timeout")
+	}
+	if err := json.Unmarshal(params[0], &r.RawTx); err != nil {
+		return errors.Wrap(err, "invalid raw transaction")
+	}
+	if len(params) == 1 || string(params[1]) == "null" {
+		return nil
+	}
+
+	var timeout uint64
+	if err := json.Unmarshal(params[1], &timeout); err != nil {
+		var quantity string
+		if err := json.Unmarshal(params[1], &quantity); err != nil {
+			return errors.New("invalid timeout, expected milliseconds")
+		}
+		value, ok := new(big.Int).SetString(utils.RemoveHexPrefix(quantity), 16)
+		if !ok || !strings.HasPrefix(quantity, "0x") || !value.IsUint64() {
+			return errors.Errorf("invalid timeout %q, expected milliseconds", quantity)
+		}
+		timeout = value.Uint64()
+	}
+	r.Timeout = &timeout
+	return nil
+}
+
+// ========== kaon_getTransactionStatus ============= //
+
+type (
+	GetTransactionStatusRequest string
+
+	GetTransactionStatusResponse struct {
+		Hash string `json:"hash"`
+		// pending, mined, evicted, dropped or unknown
+		Status string `json:"status"`
+		// submitted through this gateway, only tracked transactions are rebroadcast
+		Tracked       bool   `json:"tracked"`
+		BlockHash     string `json:"blockHash,omitempty"`
+		Confirmations string `json:"confirmations"`
+		Rebroadcasts  string `json:"rebroadcasts"`
+		// unix time the gateway received the transaction
+		SubmittedAt string `json:"submittedAt,omitempty"`
+		// why kaond refused the last rebroadcast
+		Error string `json:"error,omitempty"`
+	}
+)
+
+func (r *GetTransactionStatusRequest) UnmarshalJSON(data []byte) error {
+	var params []string
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) != 1 {
+		return errors.New("expected a transaction hash")
+	}
+	if hash, err := hex.DecodeString(utils.RemoveHexPrefix(params[0])); err != nil || len(hash) != 32 {
+		return errors.Errorf("invalid transaction hash %q", params[0])
+	}
+	*r = GetTransactionStatusRequest(params[0])
+	return nil
+}
+
 // CallResponse
 type CallResponse string
 
@@ -283,17 +361,10 @@ func (t *SignRequest) UnmarshalJSON(data []byte) (err error) {
 	}
 
 	if data, ok := params[1].(string); ok {
-		var msg []byte
-		if !strings.HasPrefix(data, "0x") {
-			msg = []byte(data)
-		} else {
-			msg, err = hex.DecodeString(utils.RemoveHexPrefix(data))
-			if err != nil {
-				return errors.Wrap(err, "invalid data format")
-			}
+		t.Message, err = decodeSignData(data)
+		if err != nil {
+			return err
 		}
-
-		t.Message = msg
 	} else {
 		return errors.New("data should be a hex string")
 	}
@@ -301,6 +372,182 @@ func (t *SignRequest) UnmarshalJSON(data []byte) (err error) {
 	return nil
 }
 
+// Messages to sign are hex encoded, anything else is signed as is
+func decodeSignData(data string) ([]byte, error) {
+	if !strings.HasPrefix(data, "0x") {
+		return []byte(data), nil
+	}
+	msg, err := hex.DecodeString(utils.RemoveHexPrefix(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid data format")
+	}
+	return msg, nil
+}
+
+// ========== personal_sign ============= //
+
+type PersonalSignRequest SignRequest
+
+// Takes [data, account, password], the password of the gateway's accounts is ignored
+func (t *PersonalSignRequest) UnmarshalJSON(data []byte) error {
+	var params []string
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) < 2 || len(params) > 3 {
+		return errors.New("expects 2 or 3 arguments")
+	}
+
+	msg, err := decodeSignData(params[0])
+	if err != nil {
+		return err
+	}
+	t.Message = msg
+	t.Account = params[1]
+	return nil
+}
+
+// ========== personal_ecRecover ============= //
+
+type (
+	ECRecoverRequest struct {
+		Message   []byte
+		Signature []byte
+	}
+	// the hex address of the signer
+	ECRecoverResponse string
+)
+
+func (t *ECRecoverRequest) UnmarshalJSON(data []byte) error {
+	var params []string
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) != 2 {
+		return errors.New("expects 2 arguments")
+	}
+
+	msg, err := decodeSignData(params[0])
+	if err != nil {
+		return err
+	}
+	signature, err := hex.DecodeString(utils.RemoveHexPrefix(params[1]))
+	if err != nil {
+		return errors.Wrap(err, "invalid signature format")
+	}
+	t.Message = msg
+	t.Signature = signature
+	return nil
+}
+
+// ========== eth_signTypedData ============= //
+
+type SignTypedDataRequest struct {
+	Account   string
+	TypedData apitypes.TypedData
+}
+
+// Takes [account, typedData], the typed data can also be a JSON encoded string like MetaMask sends it
+func (t *SignTypedDataRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) != 2 {
+		return errors.New("expects 2 arguments")
+	}
+	if err := json.Unmarshal(params[0], &t.Account); err != nil {
+		return errors.New("account address should be a hex string")
+	}
+
+	typedData := []byte(params[1])
+	var encoded string
+	if err := json.Unmarshal(params[1], &encoded); err == nil {
+		typedData = []byte(encoded)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(typedData), []byte("[")) {
+		return errors.New("legacy typed data, an array of typed values, is signed by eth_signTypedData")
+	}
+	typedData, err := quoteTypedDataChainId(typedData)
+	if err != nil {
+		return errors.Wrap(err, "invalid typed data")
+	}
+	if err := json.Unmarshal(typedData, &t.TypedData); err != nil {
+		return errors.Wrap(err, "invalid typed data")
+	}
+	return nil
+}
+
+// A value of the legacy typed data of eth_signTypedData, which predates EIP-712
+type TypedValue struct {
+	Type  string          `json:"type"`
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+type SignTypedDataLegacyRequest struct {
+	Account   string
+	TypedData []TypedValue
+}
+
+// Takes [typedData, account] like MetaMask or [account, typedData], the typed data can also be a JSON encoded string
+func (t *SignTypedDataLegacyRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) != 2 {
+		return errors.New("expects 2 arguments")
+	}
+
+	account, typedData := params[1], params[0]
+	var encoded string
+	if err := json.Unmarshal(params[0], &encoded); err == nil && common.IsHexAddress(encoded) {
+		account, typedData = params[0], params[1]
+	}
+	if err := json.Unmarshal(account, &t.Account); err != nil {
+		return errors.New("account address should be a hex string")
+	}
+	if err := json.Unmarshal(typedData, &encoded); err == nil {
+		typedData = json.RawMessage(encoded)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(typedData), []byte("{")) {
+		return errors.New("EIP-712 typed data is signed by eth_signTypedData_v4")
+	}
+	if err := json.Unmarshal(typedData, &t.TypedData); err != nil {
+		return errors.Wrap(err, "invalid typed data")
+	}
+	if len(t.TypedData) == 0 {
+		return errors.New("typed data should be a non-empty array")
+	}
+	return nil
+}
+
+// Wallets send the chain id of the domain as a number, which the typed data of go-ethereum only takes as a string
+func quoteTypedDataChainId(typedData []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(typedData, &fields); err != nil {
+		return nil, err
+	}
+	var domain map[string]json.RawMessage
+	if err := json.Unmarshal(fields["domain"], &domain); err != nil || domain == nil {
+		return typedData, nil
+	}
+	var chainId json.Number
+	if err := json.Unmarshal(domain["chainId"], &chainId); err != nil {
+		return typedData, nil
+	}
+	quoted, err := json.Marshal(chainId.String())
+	if err != nil {
+		return nil, err
+	}
+	domain["chainId"] = quoted
+	if fields["domain"], err = json.Marshal(domain); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
 // ========== GetLogs ============= //
 
 type (
@@ -309,7 +556,7 @@ type (
 		ToBlock   json.RawMessage `json:"toBlock"`
 		Address   json.RawMessage `json:"address"` // string or []string
 		Topics    []interface{}   `json:"topics"`
-		Blockhash string          `json:"blockhash"`
+		Blockhash string          `json:"blockHash"`
 	}
 	GetLogsResponse []Log
 )
@@ -471,6 +718,71 @@ func (r *GetTransactionByBlockNumberAndIndex) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// ========== eth_getBlockTransactionCountByHash ========== //
+
+type GetBlockTransactionCountByHashRequest string
+
+func (r *GetBlockTransactionCountByHashRequest) UnmarshalJSON(data []byte) error {
+	var params []interface{}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "couldn't unmarhsal parameters")
+	}
+	if paramsNum := len(params); paramsNum != 1 {
+		return errors.Errorf("invalid parameters number - %d/1", paramsNum)
+	}
+
+	blockHash, ok := params[0].(string)
+	if !ok {
+		return newErrInvalidParameterType(1, params[0], "")
+	}
+	*r = GetBlockTransactionCountByHashRequest(blockHash)
+	return nil
+}
+
+// ========== eth_getBlockTransactionCountByNumber ========== //
+
+type GetBlockTransactionCountByNumberRequest string
+
+func (r *GetBlockTransactionCountByNumberRequest) UnmarshalJSON(data []byte) error {
+	var params []interface{}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "couldn't unmarhsal parameters")
+	}
+	if paramsNum := len(params); paramsNum != 1 {
+		return errors.Errorf("invalid parameters number - %d/1", paramsNum)
+	}
+
+	blockNumber, ok := params[0].(string)
+	if !ok {
+		return newErrInvalidParameterType(1, params[0], "")
+	}
+	*r = GetBlockTransactionCountByNumberRequest(blockNumber)
+	return nil
+}
+
+// ========== eth_getBlockReceipts ========== //
+
+type (
+	GetBlockReceiptsRequest struct {
+		// a block number, tag or hash, or an EIP-1898 block object
+		Block json.RawMessage
+	}
+
+	GetBlockReceiptsResponse []*GetTransactionReceiptResponse
+)
+
+func (r *GetBlockReceiptsRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "couldn't unmarhsal parameters")
+	}
+	if paramsNum := len(params); paramsNum != 1 {
+		return errors.Errorf("invalid parameters number - %d/1", paramsNum)
+	}
+	r.Block = params[0]
+	return nil
+}
+
 // ========== GetTransactionReceipt ============= //
 
 type (
@@ -838,15 +1150,16 @@ type AccountsResponse []string
 // ========== eth_getCode ============= //
 type (
 	GetCodeRequest struct {
-		Address     string
-		BlockNumber string
+		Address string
+		// a block number, tag or EIP-1898 block object
+		Block json.RawMessage
 	}
 	// the code from the given address.
 	GetCodeResponse string
 )
 
 func (r *GetCodeRequest) UnmarshalJSON(data []byte) error {
-	var params []string
+	var params []json.RawMessage
 	err := json.Unmarshal(data, &params)
 	if err != nil {
 		return errors.Wrap(err, "json unmarshalling")
@@ -856,9 +1169,11 @@ func (r *GetCodeRequest) UnmarshalJSON(data []byte) error {
 		return errors.New("params must be set")
 	}
 
-	r.Address = params[0]
+	if err := json.Unmarshal(params[0], &r.Address); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
 	if len(params) > 1 {
-		r.BlockNumber = params[1]
+		r.Block = params[1]
 	}
 
 	return nil
@@ -917,6 +1232,157 @@ func (r *GetFilterChangesRequest) UnmarshalJSON(data []byte) error {
 
 type EstimateGasResponse string
 
+// ========== eth_createAccessList ============= //
+
+type (
+	// Takes [callObject, block]
+	CreateAccessListRequest struct {
+		Call  CallRequest
+		Block json.RawMessage
+	}
+
+	AccessTuple struct {
+		Address     string   `json:"address"`
+		StorageKeys []string `json:"storageKeys"`
+	}
+
+	CreateAccessListResponse struct {
+		AccessList []AccessTuple `json:"accessList"`
+		GasUsed    string        `json:"gasUsed"`
+		// why the call failed, the access list is still returned
+		Error string `json:"error,omitempty"`
+	}
+)
+
+func (r *CreateAccessListRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) == 0 || len(params) > 2 {
+		return errors.Errorf("invalid parameters number - %d/2", len(params))
+	}
+	if err := r.Call.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	if len(params) == 2 {
+		r.Block = params[1]
+	}
+	return nil
+}
+
+// ========== eth_simulateV1 ============= //
+
+type (
+	// Takes [simulationOptions, block]
+	SimulateRequest struct {
+		BlockStateCalls        []SimulateBlockStateCalls `json:"blockStateCalls"`
+		Validation             bool                      `json:"validation"`
+		TraceTransfers         bool                      `json:"traceTransfers"`
+		ReturnFullTransactions bool                      `json:"returnFullTransactions"`
+		Block                  json.RawMessage           `json:"-"`
+	}
+
+	SimulateBlockStateCalls struct {
+		BlockOverrides json.RawMessage `json:"blockOverrides"`
+		StateOverrides json.RawMessage `json:"stateOverrides"`
+		Calls          []SimulateCall  `json:"calls"`
+	}
+
+	// A call object on its own rather than the parameters of eth_call
+	SimulateCall CallRequest
+
+	SimulateResponse []SimulatedBlock
+
+	SimulatedBlock struct {
+		Number  string          `json:"number"`
+		GasUsed string          `json:"gasUsed"`
+		Calls   []SimulatedCall `json:"calls"`
+	}
+
+	SimulatedCall struct {
+		ReturnData string              `json:"returnData"`
+		Logs       []Log               `json:"logs"`
+		GasUsed    string              `json:"gasUsed"`
+		Status     string              `json:"status"`
+		Error      *SimulatedCallError `json:"error,omitempty"`
+	}
+
+	SimulatedCallError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		// revert data
+		Data string `json:"data,omitempty"`
+	}
+)
+
+func (r *SimulateRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "json unmarshalling")
+	}
+	if len(params) == 0 || len(params) > 2 {
+		return errors.Errorf("invalid parameters number - %d/2", len(params))
+	}
+
+	type simulationOptions SimulateRequest
+	var options simulationOptions
+	if err := json.Unmarshal(params[0], &options); err != nil {
+		return errors.Wrap(err, "invalid simulation options")
+	}
+	*r = SimulateRequest(options)
+	if len(params) == 2 {
+		r.Block = params[1]
+	}
+	return nil
+}
+
+// ========== txpool ============= //
+
+type (
+	TxPoolStatusResponse struct {
+		Pending string `json:"pending"`
+		Queued  string `json:"queued"`
+	}
+
+	// Transactions by nonce, the nonces are decimal like geth's
+	TxPoolTransactions map[string]*GetTransactionByHashResponse
+
+	// Transactions by sender and nonce
+	TxPoolContentResponse struct {
+		Pending map[string]TxPoolTransactions `json:"pending"`
+		Queued  map[string]TxPoolTransactions `json:"queued"`
+	}
+
+	TxPoolContentFromRequest string
+
+	TxPoolContentFromResponse struct {
+		Pending TxPoolTransactions `json:"pending"`
+		Queued  TxPoolTransactions `json:"queued"`
+	}
+
+	// Summaries of the transactions by sender and nonce, "to: value wei + gas gas × gasPrice wei"
+	TxPoolInspectResponse struct {
+		Pending map[string]map[string]string `json:"pending"`
+		Queued  map[string]map[string]string `json:"queued"`
+	}
+)
+
+func (r *TxPoolContentFromRequest) UnmarshalJSON(data []byte) error {
+	var params []string
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "couldn't unmarhsal parameters")
+	}
+	if paramsNum := len(params); paramsNum != 1 {
+		return errors.Errorf("invalid parameters number - %d/1", paramsNum)
+	}
+	if !common.IsHexAddress(params[0]) {
+		return errors.Errorf("invalid address %q", params[0])
+	}
+	*r = TxPoolContentFromRequest(utils.AddHexPrefix(strings.ToLower(utils.RemoveHexPrefix(params[0]))))
+	return nil
+}
+
 // ========== eth_gasPrice ============= //
 
 type GasPriceResponse *ETHInt
@@ -1007,6 +1473,8 @@ type (
 		// Represents sha3 hash value based on uncles slice
 		Sha3Uncles string   `json:"sha3Uncles"`
 		Uncles     []string `json:"uncles"`
+		// Only set when serving Ethereum headers, always zero
+		MixHash string `json:"mixHash,omitempty"`
 	}
 )
 
@@ -1268,7 +1736,8 @@ type NewFilterResponse string
 
 type GetBalanceRequest struct {
 	Address string
-	Block   json.RawMessage
+	// a block number, tag or EIP-1898 block object
+	Block json.RawMessage
 }
 
 func (r *GetBalanceRequest) UnmarshalJSON(data []byte) error {
@@ -1283,28 +1752,30 @@ type GetBalanceResponse string
 type (
 	GetTransactionCountRequest struct {
 		Address string
-		Tag     string
+		// a block number, tag or EIP-1898 block object
+		Block json.RawMessage
 	}
 	GetTransactionCountResponse string
 )
 
 func (r *GetTransactionCountRequest) UnmarshalJSON(data []byte) error {
-	tmp := []interface{}{&r.Address, &r.Tag}
+	tmp := []interface{}{&r.Address, &r.Block}
 	return json.Unmarshal(data, &tmp)
 }
 
 // ========== getstorage ============= //
 type (
 	GetStorageRequest struct {
-		Address     string
-		Index       string
-		BlockNumber string
+		Address string
+		Index   string
+		// a block number, tag or EIP-1898 block object
+		Block json.RawMessage
 	}
 	GetStorageResponse string
 )
 
 func (r *GetStorageRequest) UnmarshalJSON(data []byte) error {
-	tmp := []interface{}{&r.Address, &r.Index, &r.BlockNumber}
+	tmp := []interface{}{&r.Address, &r.Index, &r.Block}
 	return json.Unmarshal(data, &tmp)
 }
 
@@ -1554,3 +2025,123 @@ func (r *Web3Sha3Request) UnmarshalJSON(data []byte) error {
 }
 
 type NetPeerCountResponse string
+
+// ========== kaon_getTokenBalances ============= //
+
+type (
+	GetTokenBalancesRequest string
+
+	TokenBalance struct {
+		Token    string `json:"token"`
+		Name     string `json:"name,omitempty"`
+		Symbol   string `json:"symbol,omitempty"`
+		Decimals string `json:"decimals,omitempty"`
+		Balance  string `json:"balance"`
+	}
+
+	GetTokenBalancesResponse []TokenBalance
+)
+
+func (r *GetTokenBalancesRequest) UnmarshalJSON(data []byte) error {
+	var params []string
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "couldn't unmarhsal parameters")
+	}
+	if paramsNum := len(params); paramsNum != 1 {
+		return errors.Errorf("invalid parameters number - %d/1", paramsNum)
+	}
+	if !common.IsHexAddress(params[0]) {
+		return errors.Errorf("invalid address %q", params[0])
+	}
+	*r = GetTokenBalancesRequest(utils.AddHexPrefix(strings.ToLower(utils.RemoveHexPrefix(params[0]))))
+	return nil
+}
+
+// ========== kaon_getTokenTransfers ============= //
+
+type (
+	// The range is optional, the indexed blocks are searched by default
+	GetTokenTransfersRequest struct {
+		Address string
+		Range   TokenTransfersRange
+	}
+
+	TokenTransfersRange struct {
+		FromBlock json.RawMessage `json:"fromBlock"`
+		ToBlock   json.RawMessage `json:"toBlock"`
+		// only the transfers of this token if set
+		Token string `json:"token"`
+	}
+
+	TokenTransfer struct {
+		Token string `json:"token"`
+		From  string `json:"from"`
+		To    string `json:"to"`
+		Value string `json:"value"`
+		// Kaon transaction id
+		TransactionHash string `json:"transactionHash"`
+		BlockHash       string `json:"blockHash"`
+		BlockNumber     string `json:"blockNumber"`
+	}
+
+	GetTokenTransfersResponse []TokenTransfer
+)
+
+func (r *GetTokenTransfersRequest) UnmarshalJSON(data []byte) error {
+	var params []json.RawMessage
+	if err := json.Unmarshal(data, &params); err != nil {
+		return errors.Wrap(err, "couldn't unmarhsal parameters")
+	}
+	if paramsNum := len(params); paramsNum != 1 && paramsNum != 2 {
+		return errors.Errorf("invalid parameters number - %d/2", paramsNum)
+	}
+
+	var address string
+	if err := json.Unmarshal(params[0], &address); err != nil || !common.IsHexAddress(address) {
+		return errors.Errorf("invalid address %s", params[0])
+	}
+	r.Address = utils.AddHexPrefix(strings.ToLower(utils.RemoveHexPrefix(address)))
+
+	if len(params) == 2 {
+		if err := json.Unmarshal(params[1], &r.Range); err != nil {
+			return errors.Wrap(err, "invalid range")
+		}
+		if r.Range.Token != "" && !common.IsHexAddress(r.Range.Token) {
+			return errors.Errorf("invalid token address %q", r.Range.Token)
+		}
+	}
+	return nil
+}
+
+// ========== rpc_discover ============= //
+
+// OpenRPCVersion is the version of the OpenRPC specification the service description follows
+const OpenRPCVersion = "1.2.6"
+
+// JSON schema of a parameter or a result
+type OpenRPCSchema map[string]interface{}
+
+// OpenRPCDocument describes the methods served by the gateway, see https://spec.open-rpc.org
+type OpenRPCDocument struct {
+	OpenRPC string          `json:"openrpc"`
+	Info    OpenRPCInfo     `json:"info"`
+	Methods []OpenRPCMethod `json:"methods"`
+}
+
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenRPCMethod struct {
+	Name    string                     `json:"name"`
+	Summary string                     `json:"summary,omitempty"`
+	Params  []OpenRPCContentDescriptor `json:"params"`
+	Result  *OpenRPCContentDescriptor  `json:"result"`
+}
+
+type OpenRPCContentDescriptor struct {
+	Name     string        `json:"name"`
+	Required bool          `json:"required,omitempty"`
+	Schema   OpenRPCSchema `json:"schema"`
+}
@@ -0,0 +1,226 @@
+package faucet
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrMainnet       = errors.New("the faucet isn't available on mainnet")
+	ErrRateLimited   = errors.New("address has been funded recently")
+	ErrInvalidAmount = errors.New("amount must be positive")
+)
+
+var (
+	DefaultAmount    = decimal.NewFromInt(100)
+	DefaultRateLimit = 24 * time.Hour
+)
+
+// Number of rounds of maturity blocks mined on regtest before giving up on funding
+const maxMaturityRounds = 3
+
+// Faucet sends coins from the address blocks are generated to, which is funded by mining on regtest
+// and has to be funded by the operator on shared testnets
+type Faucet struct {
+	kaon *kaon.Kaon
+
+	amount    decimal.Decimal // in KAON
+	rateLimit time.Duration
+
+	mutex      sync.Mutex
+	lastFunded map[string]time.Time
+}
+
+type Option func(*Faucet) error
+
+func New(kaonClient *kaon.Kaon, opts ...Option) (*Faucet, error) {
+	f := &Faucet{
+		kaon:       kaonClient,
+		amount:     DefaultAmount,
+		rateLimit:  DefaultRateLimit,
+		lastFunded: make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Amount of KAON sent when no amount is requested
+func SetAmount(amount decimal.Decimal) Option {
+	return func(f *Faucet) error {
+		if !amount.IsPositive() {
+			return ErrInvalidAmount
+		}
+		f.amount = amount
+		return nil
+	}
+}
+
+// Minimum time between two rate limited fundings of the same address, 0 disables rate limiting
+func SetRateLimit(rateLimit time.Duration) Option {
+	return func(f *Faucet) error {
+		if rateLimit < 0 {
+			return errors.New("rate limit can't be negative")
+		}
+		f.rateLimit = rateLimit
+		return nil
+	}
+}
+
+func (f *Faucet) Amount() decimal.Decimal {
+	return f.amount
+}
+
+// Sends amount KAON to a hex or base58 address, the default amount when amount is zero. Returns the transaction id.
+func (f *Faucet) Fund(ctx context.Context, address string, amount decimal.Decimal) (string, error) {
+	txID, err := f.send(ctx, address, amount)
+	if err != nil {
+		return "", err
+	}
+	f.kaon.GenerateIfPossible()
+	return txID, nil
+}
+
+// Like Fund with the default amount, refusing addresses funded less than the rate limit ago
+func (f *Faucet) FundRateLimited(ctx context.Context, address string) (string, error) {
+	key, err := f.rateLimitKey(ctx, address)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+
+	f.mutex.Lock()
+	for funded, at := range f.lastFunded {
+		if now.Sub(at) >= f.rateLimit {
+			delete(f.lastFunded, funded)
+		}
+	}
+	if _, ok := f.lastFunded[key]; ok {
+		f.mutex.Unlock()
+		return "", ErrRateLimited
+	}
+	f.lastFunded[key] = now
+	f.mutex.Unlock()
+
+	txID, err := f.Fund(ctx, address, decimal.Zero)
+	if err != nil {
+		// failed attempts don't count
+		f.mutex.Lock()
+		delete(f.lastFunded, key)
+		f.mutex.Unlock()
+		return "", err
+	}
+	return txID, nil
+}
+
+// The hex hash160 of an address, so that its hex and base58 forms are rate limited together
+func (f *Faucet) rateLimitKey(ctx context.Context, address string) (string, error) {
+	if utils.IsEthHexAddress(address) {
+		return strings.ToLower(utils.RemoveHexPrefix(address)), nil
+	}
+	hexAddress, err := f.kaon.GetHexAddress(ctx, address)
+	if err != nil {
+		return "", errors.WithMessage(err, "couldn't convert base58 address")
+	}
+	return strings.ToLower(hexAddress), nil
+}
+
+// Sends the default amount to every account returned by eth_accounts
+func (f *Faucet) FundAccounts(ctx context.Context) error {
+	for _, wif := range f.kaon.Accounts {
+		acc := kaon.Account{WIF: wif}
+		address := acc.ToHexAddress()
+		txID, err := f.send(ctx, address, decimal.Zero)
+		if err != nil {
+			return errors.WithMessagef(err, "couldn't fund account %s", address)
+		}
+		f.kaon.GetDebugLogger().Log("component", "faucet", "msg", "Funded account", "address", address, "txid", txID)
+	}
+	if len(f.kaon.Accounts) != 0 {
+		f.kaon.GenerateIfPossible()
+	}
+	return nil
+}
+
+func (f *Faucet) send(ctx context.Context, address string, amount decimal.Decimal) (string, error) {
+	if f.kaon.Chain() == kaon.ChainMain {
+		return "", ErrMainnet
+	}
+	if amount.IsZero() {
+		amount = f.amount
+	}
+	if !amount.IsPositive() {
+		return "", ErrInvalidAmount
+	}
+
+	base58Address := address
+	if utils.IsEthHexAddress(address) {
+		var err error
+		base58Address, err = f.kaon.FromHexAddressWithContext(ctx, utils.RemoveHexPrefix(address))
+		if err != nil {
+			return "", errors.WithMessage(err, "couldn't convert hex address")
+		}
+	}
+
+	from, err := f.kaon.GenerateToAddress()
+	if err != nil {
+		return "", err
+	}
+	if err := f.ensureSpendable(ctx, from, amount); err != nil {
+		return "", err
+	}
+
+	req := kaon.SendToAddressRequest{
+		Address:       base58Address,
+		Amount:        kaon.TransformAmount(amount),
+		SenderAddress: from,
+	}
+	var txID kaon.SendToAddressResponse
+	if err := f.kaon.RequestWithContext(ctx, kaon.MethodSendToAddress, &req, &txID); err != nil {
+		return "", errors.WithMessage(err, "couldn't send funds")
+	}
+	return string(txID), nil
+}
+
+// Coinbase outputs only become spendable after the mature block height, on regtest blocks are mined to
+// the faucet's address until it can pay amount
+func (f *Faucet) ensureSpendable(ctx context.Context, from string, amount decimal.Decimal) error {
+	if !f.kaon.CanGenerate() {
+		return nil
+	}
+
+	// balances are in units of 10^-18 KAON
+	needed := amount.Shift(18).BigInt()
+	for round := 0; ; round++ {
+		balance, err := f.kaon.GetAddressBalance(ctx, &kaon.GetAddressBalanceRequest{Address: from})
+		if err != nil && err != kaon.ErrInvalidAddress {
+			return errors.WithMessage(err, "couldn't get faucet balance")
+		}
+		if err == nil {
+			spendable := new(big.Int).Sub(&balance.Balance, &balance.Immature)
+			if spendable.Cmp(needed) >= 0 {
+				return nil
+			}
+		}
+		if round == maxMaturityRounds {
+			return errors.New("faucet address has too few mature coins")
+		}
+
+		blocks := f.kaon.GetMatureBlockHeight() + 1
+		f.kaon.GetDebugLogger().Log("component", "faucet", "msg", "Mining blocks to mature faucet coins", "blocks", blocks)
+		if _, err := f.kaon.Generate(ctx, blocks, nil); err != nil {
+			return errors.WithMessage(err, "couldn't mine maturity blocks")
+		}
+	}
+}
@@ -0,0 +1,592 @@
+package fakekaond
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type scriptSigJSON struct {
+	ASM string `json:"asm"`
+	Hex string `json:"hex"`
+}
+
+type scriptPubKeyJSON struct {
+	ASM       string   `json:"asm"`
+	Hex       string   `json:"hex"`
+	ReqSigs   int64    `json:"reqSigs,omitempty"`
+	Type      string   `json:"type"`
+	Address   string   `json:"address,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+type vinJSON struct {
+	Coinbase       string         `json:"coinbase,omitempty"`
+	TxID           string         `json:"txid,omitempty"`
+	Vout           uint32         `json:"vout"`
+	ScriptSig      *scriptSigJSON `json:"scriptSig,omitempty"`
+	PreviousPubkey *scriptSigJSON `json:"previousPubkey,omitempty"`
+	Address        string         `json:"address,omitempty"`
+	Value          *json.Number   `json:"value,omitempty"`
+	Sequence       uint32         `json:"sequence"`
+}
+
+type voutJSON struct {
+	Value        json.Number      `json:"value"`
+	N            int              `json:"n"`
+	ScriptPubKey scriptPubKeyJSON `json:"scriptPubKey"`
+}
+
+type transactionJSON struct {
+	Hex      string     `json:"hex,omitempty"`
+	TxID     string     `json:"txid"`
+	Hash     string     `json:"hash"`
+	Version  int32      `json:"version"`
+	Size     int        `json:"size"`
+	Vsize    int        `json:"vsize"`
+	Weight   int        `json:"weight"`
+	Locktime uint32     `json:"locktime"`
+	Vin      []vinJSON  `json:"vin"`
+	Vout     []voutJSON `json:"vout"`
+
+	Generated     bool   `json:"generated,omitempty"`
+	BlockHash     string `json:"blockhash,omitempty"`
+	Confirmations int64  `json:"confirmations,omitempty"`
+	Time          int64  `json:"time,omitempty"`
+	BlockTime     int64  `json:"blocktime,omitempty"`
+}
+
+type blockHeaderJSON struct {
+	Hash              string  `json:"hash"`
+	Confirmations     int64   `json:"confirmations"`
+	Height            int64   `json:"height"`
+	Version           int32   `json:"version"`
+	VersionHex        string  `json:"versionHex"`
+	Merkleroot        string  `json:"merkleroot"`
+	Time              int64   `json:"time"`
+	Mediantime        int64   `json:"mediantime"`
+	Nonce             uint32  `json:"nonce"`
+	Bits              string  `json:"bits"`
+	Difficulty        float64 `json:"difficulty"`
+	Chainwork         string  `json:"chainwork"`
+	HashStateRoot     string  `json:"hashStateRoot"`
+	HashUTXORoot      string  `json:"hashUTXORoot"`
+	Previousblockhash string  `json:"previousblockhash,omitempty"`
+	Nextblockhash     string  `json:"nextblockhash,omitempty"`
+	Flags             string  `json:"flags"`
+	Proofhash         string  `json:"proofhash"`
+	Modifier          string  `json:"modifier"`
+	GasUsed           uint64  `json:"gasUsed"`
+}
+
+type blockJSON struct {
+	blockHeaderJSON
+	Size         int           `json:"size"`
+	Strippedsize int           `json:"strippedsize"`
+	Weight       int           `json:"weight"`
+	Tx           []interface{} `json:"tx"`
+	Signature    string        `json:"signature"`
+}
+
+func serializeTransaction(msg *wire.MsgTx) []byte {
+	var buf bytes.Buffer
+	msg.Serialize(&buf)
+	return buf.Bytes()
+}
+
+func (n *Node) scriptPubKeyJSON(script []byte) scriptPubKeyJSON {
+	classified := classifyScript(script)
+	result := scriptPubKeyJSON{
+		ASM:  disassemble(classified.ops),
+		Hex:  hex.EncodeToString(script),
+		Type: classified.kind,
+	}
+	if classified.kind == scriptPubKeyHash {
+		address := n.base58Address(classified.address)
+		result.ReqSigs = 1
+		result.Address = address
+		result.Addresses = []string{address}
+	}
+	return result
+}
+
+func (n *Node) transactionJSON(tx *transaction) *transactionJSON {
+	size := tx.msg.SerializeSize()
+	result := &transactionJSON{
+		Hex:      hex.EncodeToString(serializeTransaction(tx.msg)),
+		TxID:     tx.hash.String(),
+		Hash:     tx.hash.String(),
+		Version:  tx.msg.Version,
+		Size:     size,
+		Vsize:    size,
+		Weight:   4 * size,
+		Locktime: tx.msg.LockTime,
+		Vin:      make([]vinJSON, len(tx.msg.TxIn)),
+		Vout:     make([]voutJSON, len(tx.msg.TxOut)),
+		Time:     tx.time,
+	}
+
+	for i, in := range tx.msg.TxIn {
+		if tx.isCoinbase() {
+			result.Vin[i] = vinJSON{Coinbase: hex.EncodeToString(in.SignatureScript), Sequence: in.Sequence}
+			continue
+		}
+		vin := vinJSON{
+			TxID:      in.PreviousOutPoint.Hash.String(),
+			Vout:      in.PreviousOutPoint.Index,
+			ScriptSig: &scriptSigJSON{Hex: hex.EncodeToString(in.SignatureScript)},
+			Sequence:  in.Sequence,
+		}
+		if ops, err := parseScript(in.SignatureScript); err == nil {
+			vin.ScriptSig.ASM = disassemble(ops)
+		}
+		if prev, ok := n.transactions[in.PreviousOutPoint.Hash]; ok && int(in.PreviousOutPoint.Index) < len(prev.msg.TxOut) {
+			prevOut := prev.msg.TxOut[in.PreviousOutPoint.Index]
+			pubkey := n.scriptPubKeyJSON(prevOut.PkScript)
+			value := formatAmount(prevOut.Value)
+			vin.PreviousPubkey = &scriptSigJSON{ASM: pubkey.ASM, Hex: pubkey.Hex}
+			vin.Address = pubkey.Address
+			vin.Value = &value
+		}
+		result.Vin[i] = vin
+	}
+	for i, out := range tx.msg.TxOut {
+		result.Vout[i] = voutJSON{
+			Value:        formatAmount(out.Value),
+			N:            i,
+			ScriptPubKey: n.scriptPubKeyJSON(out.PkScript),
+		}
+	}
+
+	if tx.block != nil {
+		result.Generated = tx.isCoinbase()
+		result.BlockHash = tx.block.hash.String()
+		result.Confirmations = n.confirmations(tx.block)
+		result.Time = tx.block.header.Timestamp.Unix()
+		result.BlockTime = result.Time
+	}
+	return result
+}
+
+func (n *Node) blockHeaderJSON(b *block) blockHeaderJSON {
+	result := blockHeaderJSON{
+		Hash:          b.hash.String(),
+		Confirmations: n.confirmations(b),
+		Height:        b.height,
+		Version:       b.header.Version,
+		VersionHex:    fmt.Sprintf("%08x", b.header.Version),
+		Merkleroot:    b.header.MerkleRoot.String(),
+		Time:          b.header.Timestamp.Unix(),
+		Mediantime:    b.header.Timestamp.Unix(),
+		Nonce:         b.header.Nonce,
+		Bits:          fmt.Sprintf("%08x", b.header.Bits),
+		Difficulty:    4.656542373906925e-10,
+		Chainwork:     fmt.Sprintf("%064x", 2*(b.height+1)),
+		HashStateRoot: hex.EncodeToString(b.stateRoot[:]),
+		HashUTXORoot:  hex.EncodeToString(make([]byte, common.HashLength)),
+		Flags:         "proof-of-work",
+		Proofhash:     b.hash.String(),
+		Modifier:      hex.EncodeToString(make([]byte, common.HashLength)),
+		GasUsed:       b.gasUsed,
+	}
+	if result.Confirmations == 0 {
+		// blocks outside the active chain
+		result.Confirmations = -1
+	}
+	if b.parent != nil {
+		result.Previousblockhash = b.parent.hash.String()
+	}
+	if next := n.blockAt(b.height + 1); next != nil && n.isActive(b) {
+		result.Nextblockhash = next.hash.String()
+	}
+	return result
+}
+
+func (n *Node) serializeBlock(b *block) []byte {
+	msg := wire.NewMsgBlock(&b.header)
+	for _, tx := range b.transactions {
+		msg.AddTransaction(tx.msg)
+	}
+	var buf bytes.Buffer
+	msg.Serialize(&buf)
+	return buf.Bytes()
+}
+
+func (n *Node) lookupBlock(params []json.RawMessage) (*block, error) {
+	var hash string
+	if err := requiredParam(params, 0, &hash); err != nil {
+		return nil, err
+	}
+	parsed, err := parseHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := n.known[parsed]
+	if !ok {
+		return nil, newRPCError(errCodeInvalidAddress, "Block not found")
+	}
+	return b, nil
+}
+
+func getBlockCount(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.tip().height, nil
+}
+
+func getBestBlockHash(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.tip().hash.String(), nil
+}
+
+func getBlockHash(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var height int64
+	if err := requiredParam(params, 0, &height); err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	b := n.blockAt(height)
+	if b == nil {
+		return nil, newRPCError(errCodeInvalidParameter, "Block height out of range")
+	}
+	return b.hash.String(), nil
+}
+
+func getBlockHeader(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	verbose := true
+	if _, err := optionalParam(params, 1, &verbose); err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	b, err := n.lookupBlock(params)
+	if err != nil {
+		return nil, err
+	}
+	if !verbose {
+		var buf bytes.Buffer
+		b.header.Serialize(&buf)
+		return hex.EncodeToString(buf.Bytes()), nil
+	}
+	return n.blockHeaderJSON(b), nil
+}
+
+// The verbosity is a boolean or a number, like kaond's getblock true returns transaction details
+func getBlock(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	verbosity := 2
+	if len(params) > 1 {
+		switch string(params[1]) {
+		case "true":
+			verbosity = 2
+		case "false":
+			verbosity = 0
+		default:
+			if err := requiredParam(params, 1, &verbosity); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	b, err := n.lookupBlock(params)
+	if err != nil {
+		return nil, err
+	}
+	encoded := n.serializeBlock(b)
+	if verbosity == 0 {
+		return hex.EncodeToString(encoded), nil
+	}
+
+	result := blockJSON{
+		blockHeaderJSON: n.blockHeaderJSON(b),
+		Size:            len(encoded),
+		Strippedsize:    len(encoded),
+		Weight:          4 * len(encoded),
+		Tx:              make([]interface{}, len(b.transactions)),
+	}
+	for i, tx := range b.transactions {
+		if verbosity == 1 {
+			result.Tx[i] = tx.hash.String()
+		} else {
+			result.Tx[i] = n.transactionJSON(tx)
+		}
+	}
+	return result, nil
+}
+
+func getBlockchainInfo(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	tip := n.tip()
+	return map[string]interface{}{
+		"chain":                n.chain,
+		"blocks":               tip.height,
+		"headers":              tip.height,
+		"bestblockhash":        tip.hash.String(),
+		"difficulty":           4.656542373906925e-10,
+		"mediantime":           tip.header.Timestamp.Unix(),
+		"verificationprogress": 1,
+		"initialblockdownload": false,
+		"chainwork":            fmt.Sprintf("%064x", 2*(tip.height+1)),
+		"size_on_disk":         0,
+		"pruned":               false,
+		"softforks":            map[string]interface{}{},
+		"warnings":             "",
+	}, nil
+}
+
+func getRawTransaction(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var txid string
+	if err := requiredParam(params, 0, &txid); err != nil {
+		return nil, err
+	}
+	verbose := false
+	if len(params) > 1 && string(params[1]) != "false" && string(params[1]) != "0" {
+		verbose = true
+	}
+	hash, err := parseHash(txid)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	tx, ok := n.transactions[hash]
+	if !ok {
+		return nil, newRPCError(errCodeInvalidAddress, "No such mempool or blockchain transaction. Use gettransaction for wallet transactions.")
+	}
+	if !verbose {
+		return hex.EncodeToString(serializeTransaction(tx.msg)), nil
+	}
+	return n.transactionJSON(tx), nil
+}
+
+// Takes [verbose], entries of the mempool by transaction id when verbose, their ids otherwise
+func getRawMempool(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var verbose bool
+	if _, err := optionalParam(params, 0, &verbose); err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if !verbose {
+		ids := make([]string, len(n.mempool))
+		for i, tx := range n.mempool {
+			ids[i] = tx.hash.String()
+		}
+		return ids, nil
+	}
+	entries := make(map[string]interface{}, len(n.mempool))
+	for _, tx := range n.mempool {
+		size := tx.msg.SerializeSize()
+		entries[tx.hash.String()] = map[string]interface{}{
+			"vsize":  size,
+			"weight": 4 * size,
+			"time":   tx.time,
+			// the height transactions entered the mempool at isn't kept
+			"height": n.tip().height,
+		}
+	}
+	return entries, nil
+}
+
+func decodeRawTransaction(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	msg, err := parseRawTransaction(params)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	result := n.transactionJSON(newTransaction(msg, 0))
+	result.Hex = ""
+	result.Time = 0
+	return result, nil
+}
+
+func parseRawTransaction(params []json.RawMessage) (*wire.MsgTx, error) {
+	var raw string
+	if err := requiredParam(params, 0, &raw); err != nil {
+		return nil, err
+	}
+	decoded, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, newRPCError(errCodeDeserialization, "TX decode failed")
+	}
+	msg := wire.NewMsgTx(wire.TxVersion)
+	if err := msg.Deserialize(bytes.NewReader(decoded)); err != nil {
+		return nil, newRPCError(errCodeDeserialization, "TX decode failed")
+	}
+	return msg, nil
+}
+
+// Accepts a transaction without checking its signatures
+func sendRawTransaction(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	msg, err := parseRawTransaction(params)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	tx := newTransaction(msg, n.nextBlockTime())
+	if known, ok := n.transactions[tx.hash]; ok {
+		if known.block != nil {
+			return nil, newRPCError(errCodeVerifyAlreadyInChain, "Transaction already in block chain")
+		}
+		return nil, newRPCError(errCodeVerifyRejected, "txn-already-in-mempool")
+	}
+	if err := n.acceptToMempool(tx); err != nil {
+		return nil, err
+	}
+	return tx.hash.String(), nil
+}
+
+func getTxOut(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var txid string
+	var index uint32
+	if err := requiredParam(params, 0, &txid); err != nil {
+		return nil, err
+	}
+	if err := requiredParam(params, 1, &index); err != nil {
+		return nil, err
+	}
+	includeMempool := true
+	if _, err := optionalParam(params, 2, &includeMempool); err != nil {
+		return nil, err
+	}
+	hash, err := parseHash(txid)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	outpoint := wire.OutPoint{Hash: hash, Index: index}
+	out, ok := n.utxos[outpoint]
+	if includeMempool {
+		out, ok = n.lookupUTXO(outpoint)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	var confirmations int64
+	if out.height >= 0 {
+		confirmations = n.tip().height - out.height + 1
+	}
+	return map[string]interface{}{
+		"bestblock":     n.tip().hash.String(),
+		"confirmations": confirmations,
+		"value":         formatAmount(out.value),
+		"scriptPubKey":  n.scriptPubKeyJSON(out.script),
+		"coinbase":      out.coinbase,
+		"coinstake":     false,
+	}, nil
+}
+
+func invalidateBlock(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	b, err := n.lookupBlock(params)
+	if err != nil {
+		return nil, err
+	}
+	if b.parent == nil {
+		return nil, newRPCError(errCodeMisc, "Genesis block cannot be invalidated")
+	}
+
+	b.invalid = true
+	if !n.isActive(b) {
+		return nil, nil
+	}
+	for n.tip() != b.parent {
+		if err := n.disconnectTip(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// Reconnects an invalidated block and its descendants when they make a longer chain than the active one
+func reconsiderBlock(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	b, err := n.lookupBlock(params)
+	if err != nil {
+		return nil, err
+	}
+	b.invalid = false
+
+	// the longest known chain through b
+	best := b
+	for _, candidate := range n.known {
+		if candidate.height <= best.height {
+			continue
+		}
+		for ancestor := candidate; ancestor != nil; ancestor = ancestor.parent {
+			if ancestor == b {
+				best = candidate
+				break
+			}
+		}
+	}
+	if best.height <= n.tip().height {
+		return nil, nil
+	}
+
+	var branch []*block
+	for ancestor := best; !n.isActive(ancestor); ancestor = ancestor.parent {
+		branch = append([]*block{ancestor}, branch...)
+	}
+	for n.tip() != branch[0].parent {
+		if err := n.disconnectTip(); err != nil {
+			return nil, err
+		}
+	}
+	for _, next := range branch {
+		next.invalid = false
+		if err := n.reconnect(next); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// Connects a block that was disconnected before, executing its transactions again
+func (n *Node) reconnect(b *block) error {
+	miner := classifyScript(b.transactions[0].msg.TxOut[0].PkScript).address
+	result, err := n.execute(b.height, b.header.Timestamp.Unix(), miner, b.transactions[1:])
+	if err != nil {
+		return err
+	}
+	if err := n.applyExecution(b, result); err != nil {
+		return err
+	}
+	// its transactions might be back in the mempool
+	mined := make(map[chainhash.Hash]bool, len(b.transactions))
+	for _, tx := range b.transactions {
+		mined[tx.hash] = true
+	}
+	var mempool []*transaction
+	for _, tx := range n.mempool {
+		if !mined[tx.hash] {
+			mempool = append(mempool, tx)
+		}
+	}
+	n.mempool = mempool
+	n.connect(b)
+	return nil
+}
@@ -0,0 +1,429 @@
+package fakekaond
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+	"github.com/pkg/errors"
+)
+
+// Output values are serialized in satoshis, 10^-8 KAON, while kaond reports balances in 10^-18 KAON
+const (
+	satoshisPerKAON = 100000000
+	weiPerSatoshi   = 10000000000
+)
+
+// Regtest difficulty bits
+const blockBits = 0x207fffff
+
+type block struct {
+	header wire.BlockHeader
+	hash   chainhash.Hash
+	height int64
+	// coinbase first
+	transactions []*transaction
+
+	gasUsed   uint64
+	stateRoot common.Hash
+	// confirmed unspent outputs once the block is connected
+	utxos map[wire.OutPoint]*utxo
+
+	parent  *block
+	invalid bool
+}
+
+type transaction struct {
+	msg  *wire.MsgTx
+	hash chainhash.Hash
+	// the first seen time for mempool transactions
+	time int64
+
+	// set while the transaction is part of the active chain
+	block    *block
+	index    int
+	receipts []*receipt
+}
+
+func (tx *transaction) isCoinbase() bool {
+	return blockchainIsCoinbase(tx.msg)
+}
+
+func blockchainIsCoinbase(msg *wire.MsgTx) bool {
+	return len(msg.TxIn) == 1 &&
+		msg.TxIn[0].PreviousOutPoint.Index == wire.MaxPrevOutIndex &&
+		msg.TxIn[0].PreviousOutPoint.Hash == chainhash.Hash{}
+}
+
+type utxo struct {
+	address  common.Address
+	value    int64
+	script   []byte
+	height   int64 // -1 for mempool outputs
+	coinbase bool
+}
+
+func newTransaction(msg *wire.MsgTx, time int64) *transaction {
+	return &transaction{
+		msg:  msg,
+		hash: msg.TxHash(),
+		time: time,
+	}
+}
+
+func (n *Node) tip() *block {
+	return n.blocks[len(n.blocks)-1]
+}
+
+func (n *Node) blockAt(height int64) *block {
+	if height < 0 || height >= int64(len(n.blocks)) {
+		return nil
+	}
+	return n.blocks[height]
+}
+
+func (n *Node) isActive(b *block) bool {
+	return n.blockAt(b.height) == b
+}
+
+func (n *Node) confirmations(b *block) int64 {
+	if b == nil || !n.isActive(b) {
+		return 0
+	}
+	return n.tip().height - b.height + 1
+}
+
+func (n *Node) mineGenesis() error {
+	coinbase := coinbaseTransaction(0, []*wire.TxOut{{Value: 0, PkScript: payToPubKeyHashScript(common.Address{})}})
+	b := n.newBlock(nil, []*transaction{newTransaction(coinbase, n.nextBlockTime())})
+	if err := n.applyExecution(b, &executionResult{}); err != nil {
+		return err
+	}
+	n.connect(b)
+	return nil
+}
+
+// Mines the mempool into a new block paying the reward and the fees to address
+func (n *Node) mine(address common.Address) (*block, error) {
+	parent := n.tip()
+	height := parent.height + 1
+	blockTime := n.nextBlockTime()
+
+	transactions := n.mempool
+	result, err := n.execute(height, blockTime, address, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := []*wire.TxOut{{
+		Value:    n.blockReward + result.fees - result.refundTotal(),
+		PkScript: payToPubKeyHashScript(address),
+	}}
+	outputs = append(outputs, result.refunds...)
+	coinbase := newTransaction(coinbaseTransaction(height, outputs), blockTime)
+
+	b := n.newBlock(parent, append([]*transaction{coinbase}, transactions...))
+	if err := n.applyExecution(b, result); err != nil {
+		return nil, err
+	}
+	n.connect(b)
+	return b, nil
+}
+
+func (n *Node) newBlock(parent *block, transactions []*transaction) *block {
+	b := &block{
+		transactions: transactions,
+		parent:       parent,
+	}
+	b.header = wire.BlockHeader{
+		Version:    4,
+		MerkleRoot: merkleRoot(transactions),
+		Timestamp:  time.Unix(transactions[0].time, 0),
+		Bits:       blockBits,
+	}
+	if parent != nil {
+		b.height = parent.height + 1
+		b.header.PrevBlock = parent.hash
+	}
+	b.header.Nonce = uint32(b.height)
+	b.hash = b.header.BlockHash()
+	return b
+}
+
+// Adds a block on top of the tip, its contracts must already have been executed
+func (n *Node) connect(b *block) {
+	for i, tx := range b.transactions {
+		tx.block = b
+		tx.index = i
+		n.transactions[tx.hash] = tx
+		n.spend(n.utxos, tx, b.height)
+	}
+	b.utxos = copyUTXOs(n.utxos)
+	n.known[b.hash] = b
+	n.blocks = append(n.blocks, b)
+
+	n.rebuildMempool(nil)
+	n.notifyChainChanged()
+}
+
+// Removes the tip, returning its transactions to the mempool
+func (n *Node) disconnectTip() error {
+	b := n.tip()
+	if b.parent == nil {
+		return errors.New("the genesis block can't be disconnected")
+	}
+
+	n.blocks = n.blocks[:len(n.blocks)-1]
+	n.utxos = copyUTXOs(b.parent.utxos)
+	var err error
+	n.state, err = state.New(b.parent.stateRoot, n.stateDatabase, nil)
+	if err != nil {
+		return errors.Wrap(err, "couldn't restore EVM state")
+	}
+
+	var returned []*transaction
+	for _, tx := range b.transactions {
+		delete(n.transactions, tx.hash)
+		tx.block = nil
+		tx.index = 0
+		tx.receipts = nil
+		if !tx.isCoinbase() {
+			returned = append(returned, tx)
+		}
+	}
+	n.rebuildMempool(returned)
+	n.notifyChainChanged()
+	return nil
+}
+
+// Re-validates the mempool against the current tip, transactions that became invalid are dropped
+func (n *Node) rebuildMempool(front []*transaction) {
+	pending := append(front, n.mempool...)
+	n.mempool = nil
+	n.mempoolSpent = make(map[wire.OutPoint]bool)
+	n.mempoolOutputs = make(map[wire.OutPoint]*utxo)
+	for _, tx := range pending {
+		if _, ok := n.transactions[tx.hash]; ok && tx.block != nil {
+			// mined in the meantime
+			continue
+		}
+		if err := n.acceptToMempool(tx); err != nil {
+			delete(n.transactions, tx.hash)
+		}
+	}
+}
+
+// Checks a transaction against the confirmed outputs and the mempool and adds it to the mempool
+func (n *Node) acceptToMempool(tx *transaction) error {
+	if tx.isCoinbase() {
+		return newRPCError(errCodeVerifyRejected, "coinbase")
+	}
+	if len(tx.msg.TxIn) == 0 || len(tx.msg.TxOut) == 0 {
+		return newRPCError(errCodeVerifyRejected, "bad-txns-vin-empty")
+	}
+
+	var valueIn, valueOut int64
+	seen := make(map[wire.OutPoint]bool)
+	for _, in := range tx.msg.TxIn {
+		if seen[in.PreviousOutPoint] {
+			return newRPCError(errCodeVerifyRejected, "bad-txns-inputs-duplicate")
+		}
+		seen[in.PreviousOutPoint] = true
+
+		spent, ok := n.lookupUTXO(in.PreviousOutPoint)
+		if !ok {
+			return newRPCError(errCodeVerify, "bad-txns-inputs-missingorspent")
+		}
+		if spent.coinbase && n.tip().height+1-spent.height < n.coinbaseMaturity {
+			return newRPCError(errCodeVerifyRejected, "bad-txns-premature-spend-of-coinbase")
+		}
+		valueIn += spent.value
+	}
+
+	var gasFees int64
+	for _, out := range tx.msg.TxOut {
+		if out.Value < 0 {
+			return newRPCError(errCodeVerifyRejected, "bad-txns-vout-negative")
+		}
+		valueOut += out.Value
+		if script := classifyScript(out.PkScript); script.isContract {
+			gasFees += int64(script.gasLimit * script.gasPrice)
+		}
+	}
+	if valueIn < valueOut {
+		return newRPCError(errCodeVerifyRejected, "bad-txns-in-belowout")
+	}
+	if valueIn-valueOut < gasFees {
+		return newRPCError(errCodeVerifyRejected, "bad-txns-fee-notenough")
+	}
+
+	for _, in := range tx.msg.TxIn {
+		n.mempoolSpent[in.PreviousOutPoint] = true
+		delete(n.mempoolOutputs, in.PreviousOutPoint)
+	}
+	for i, out := range tx.msg.TxOut {
+		script := classifyScript(out.PkScript)
+		if script.kind != scriptPubKeyHash {
+			continue
+		}
+		n.mempoolOutputs[wire.OutPoint{Hash: tx.hash, Index: uint32(i)}] = &utxo{
+			address: script.address,
+			value:   out.Value,
+			script:  out.PkScript,
+			height:  -1,
+		}
+	}
+	tx.block = nil
+	n.transactions[tx.hash] = tx
+	n.mempool = append(n.mempool, tx)
+	return nil
+}
+
+// Unspent output as seen by the mempool
+func (n *Node) lookupUTXO(outpoint wire.OutPoint) (*utxo, bool) {
+	if n.mempoolSpent[outpoint] {
+		return nil, false
+	}
+	if out, ok := n.utxos[outpoint]; ok {
+		return out, true
+	}
+	out, ok := n.mempoolOutputs[outpoint]
+	return out, ok
+}
+
+// Applies a confirmed transaction to an unspent outputs set
+func (n *Node) spend(utxos map[wire.OutPoint]*utxo, tx *transaction, height int64) {
+	if !tx.isCoinbase() {
+		for _, in := range tx.msg.TxIn {
+			delete(utxos, in.PreviousOutPoint)
+		}
+	}
+	for i, out := range tx.msg.TxOut {
+		script := classifyScript(out.PkScript)
+		if script.kind != scriptPubKeyHash || out.Value == 0 {
+			continue
+		}
+		utxos[wire.OutPoint{Hash: tx.hash, Index: uint32(i)}] = &utxo{
+			address:  script.address,
+			value:    out.Value,
+			script:   out.PkScript,
+			height:   height,
+			coinbase: tx.isCoinbase(),
+		}
+	}
+}
+
+func (n *Node) isMature(out *utxo) bool {
+	if !out.coinbase {
+		return true
+	}
+	return out.height >= 0 && n.tip().height+1-out.height >= n.coinbaseMaturity
+}
+
+// Spendable outputs of an address including unconfirmed change, oldest first
+func (n *Node) spendableOutputs(address common.Address) []wire.OutPoint {
+	var outpoints []wire.OutPoint
+	collect := func(outpoint wire.OutPoint, out *utxo) {
+		if out.address == address && !n.mempoolSpent[outpoint] && n.isMature(out) {
+			outpoints = append(outpoints, outpoint)
+		}
+	}
+	for outpoint, out := range n.utxos {
+		collect(outpoint, out)
+	}
+	for outpoint, out := range n.mempoolOutputs {
+		collect(outpoint, out)
+	}
+
+	sort.Slice(outpoints, func(i, j int) bool {
+		a, _ := n.lookupUTXO(outpoints[i])
+		b, _ := n.lookupUTXO(outpoints[j])
+		if a.height != b.height {
+			// unconfirmed outputs last
+			return a.height >= 0 && (b.height < 0 || a.height < b.height)
+		}
+		return compareOutPoints(outpoints[i], outpoints[j]) < 0
+	})
+	return outpoints
+}
+
+func compareOutPoints(a, b wire.OutPoint) int {
+	if c := bytes.Compare(a.Hash[:], b.Hash[:]); c != 0 {
+		return c
+	}
+	switch {
+	case a.Index < b.Index:
+		return -1
+	case a.Index > b.Index:
+		return 1
+	}
+	return 0
+}
+
+func copyUTXOs(utxos map[wire.OutPoint]*utxo) map[wire.OutPoint]*utxo {
+	result := make(map[wire.OutPoint]*utxo, len(utxos))
+	for outpoint, out := range utxos {
+		result[outpoint] = out
+	}
+	return result
+}
+
+func coinbaseTransaction(height int64, outputs []*wire.TxOut) *wire.MsgTx {
+	msg := wire.NewMsgTx(2)
+	// BIP34 height, which also keeps coinbases of different blocks distinct
+	heightScript := scriptBuilder{}.addData(encodeScriptNum(height)).addOp(0)
+	msg.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  heightScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	for _, out := range outputs {
+		msg.AddTxOut(out)
+	}
+	return msg
+}
+
+func merkleRoot(transactions []*transaction) chainhash.Hash {
+	level := make([]chainhash.Hash, len(transactions))
+	for i, tx := range transactions {
+		level[i] = tx.hash
+	}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := make([]chainhash.Hash, len(level)/2)
+		for i := range next {
+			var pair [chainhash.HashSize * 2]byte
+			copy(pair[:], level[2*i][:])
+			copy(pair[chainhash.HashSize:], level[2*i+1][:])
+			next[i] = chainhash.DoubleHashH(pair[:])
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// Salt of the contract created by an output, which makes its address known before the transaction is mined
+func contractSalt(txHash chainhash.Hash, outputIndex uint32) *uint256.Int {
+	var data [chainhash.HashSize + 4]byte
+	copy(data[:], txHash[:])
+	binary.LittleEndian.PutUint32(data[chainhash.HashSize:], outputIndex)
+	return new(uint256.Int).SetBytes(crypto.Keccak256(data[:]))
+}
+
+func contractAddress(sender common.Address, txHash chainhash.Hash, outputIndex uint32, code []byte) common.Address {
+	return crypto.CreateAddress2(sender, contractSalt(txHash, outputIndex).Bytes32(), crypto.Keccak256(code))
+}
+
+func logsBloom(logs []*types.Log) types.Bloom {
+	return types.BytesToBloom(types.LogsBloom(logs))
+}
@@ -0,0 +1,332 @@
+package fakekaond
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type logJSON struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+type receiptJSON struct {
+	BlockHash         string    `json:"blockHash"`
+	BlockNumber       int64     `json:"blockNumber"`
+	TransactionHash   string    `json:"transactionHash"`
+	TransactionIndex  int       `json:"transactionIndex"`
+	OutputIndex       uint32    `json:"outputIndex"`
+	From              string    `json:"from"`
+	To                string    `json:"to"`
+	CumulativeGasUsed uint64    `json:"cumulativeGasUsed"`
+	GasUsed           uint64    `json:"gasUsed"`
+	ContractAddress   string    `json:"contractAddress"`
+	Excepted          string    `json:"excepted"`
+	ExceptedMessage   string    `json:"exceptedMessage"`
+	Bloom             string    `json:"bloom"`
+	StateRoot         string    `json:"stateRoot"`
+	Log               []logJSON `json:"log"`
+}
+
+func hexAddress(address common.Address) string {
+	return hex.EncodeToString(address.Bytes())
+}
+
+func logsJSON(r *receipt) []logJSON {
+	result := make([]logJSON, len(r.logs))
+	for i, log := range r.logs {
+		topics := make([]string, len(log.Topics))
+		for j, topic := range log.Topics {
+			topics[j] = hex.EncodeToString(topic.Bytes())
+		}
+		result[i] = logJSON{
+			Address: hexAddress(log.Address),
+			Topics:  topics,
+			Data:    hex.EncodeToString(log.Data),
+		}
+	}
+	return result
+}
+
+func receiptToJSON(tx *transaction, r *receipt) receiptJSON {
+	bloom := logsBloom(r.logs)
+	return receiptJSON{
+		BlockHash:         tx.block.hash.String(),
+		BlockNumber:       tx.block.height,
+		TransactionHash:   tx.hash.String(),
+		TransactionIndex:  tx.index,
+		OutputIndex:       r.outputIndex,
+		From:              hexAddress(r.from),
+		To:                hexAddress(r.contractAddress),
+		CumulativeGasUsed: r.cumulativeGasUsed,
+		GasUsed:           r.gasUsed,
+		ContractAddress:   hexAddress(r.contractAddress),
+		Excepted:          r.excepted,
+		ExceptedMessage:   r.exceptedMessage,
+		Bloom:             hex.EncodeToString(bloom.Bytes()),
+		StateRoot:         hex.EncodeToString(r.stateRoot.Bytes()),
+		Log:               logsJSON(r),
+	}
+}
+
+// Storage of a contract as kaond reports it, keyed by the hashed slot and then the slot
+func storageJSON(statedb *state.StateDB, address common.Address) map[string]map[string]string {
+	storage := make(map[string]map[string]string)
+	statedb.ForEachStorage(address, func(key, value common.Hash) bool {
+		if value == (common.Hash{}) {
+			return true
+		}
+		storage[hex.EncodeToString(crypto.Keccak256(key.Bytes()))] = map[string]string{
+			hex.EncodeToString(key.Bytes()): hex.EncodeToString(value.Bytes()),
+		}
+		return true
+	})
+	return storage
+}
+
+func (n *Node) parseContractAddress(address string) (common.Address, error) {
+	decoded, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	if err != nil || len(decoded) != common.AddressLength {
+		return common.Address{}, newRPCError(errCodeInvalidAddress, "Incorrect contract address")
+	}
+	return common.BytesToAddress(decoded), nil
+}
+
+func getAccountInfo(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var address string
+	if err := requiredParam(params, 0, &address); err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	contract, err := n.parseContractAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if n.state.GetCodeSize(contract) == 0 {
+		return nil, newRPCError(errCodeInvalidAddress, "Address does not exist")
+	}
+	return map[string]interface{}{
+		"address": hexAddress(contract),
+		"balance": json.Number(n.state.GetBalance(contract).String()),
+		"storage": storageJSON(n.state, contract),
+		"code":    hex.EncodeToString(n.state.GetCode(contract)),
+	}, nil
+}
+
+// Takes [address, blockNumber, index], the storage at the block defaults to the tip's
+func getStorage(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var address string
+	if err := requiredParam(params, 0, &address); err != nil {
+		return nil, err
+	}
+	var height int64 = -1
+	if _, err := optionalParam(params, 1, &height); err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	contract, err := n.parseContractAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := n.stateAt(height)
+	if err != nil {
+		return nil, err
+	}
+	return storageJSON(statedb, contract), nil
+}
+
+// The state after the block at height, the current state for a negative height
+func (n *Node) stateAt(height int64) (*state.StateDB, error) {
+	if height < 0 {
+		return n.state, nil
+	}
+	b := n.blockAt(height)
+	if b == nil {
+		return nil, newRPCError(errCodeInvalidParams, "Incorrect block number")
+	}
+	return state.New(b.stateRoot, n.stateDatabase, nil)
+}
+
+// Takes [address, data, senderAddress, gasLimit, amount]
+func callContract(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var address, data, senderAddress string
+	if err := requiredParam(params, 0, &address); err != nil {
+		return nil, err
+	}
+	if err := requiredParam(params, 1, &data); err != nil {
+		return nil, err
+	}
+	if _, err := optionalParam(params, 2, &senderAddress); err != nil {
+		return nil, err
+	}
+	gasLimit := uint64(blockGasLimit)
+	if _, err := optionalParam(params, 3, &gasLimit); err != nil {
+		return nil, err
+	}
+	input, err := hex.DecodeString(strings.TrimPrefix(data, "0x"))
+	if err != nil {
+		return nil, newRPCError(errCodeInvalidParameter, "Invalid data (data not hex)")
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	contract, err := n.parseContractAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if n.state.GetCodeSize(contract) == 0 {
+		return nil, newRPCError(errCodeInvalidParameter, "contract address does not exist")
+	}
+	var sender common.Address
+	if senderAddress != "" {
+		if sender, err = n.parseAddress(senderAddress); err != nil {
+			return nil, err
+		}
+	}
+
+	r := n.staticCall(sender, contract, input, gasLimit)
+	bloom := logsBloom(r.logs)
+	return map[string]interface{}{
+		"address": hexAddress(contract),
+		"executionResult": map[string]interface{}{
+			"gasUsed":         r.gasUsed,
+			"excepted":        r.excepted,
+			"exceptedMessage": r.exceptedMessage,
+			"newAddress":      hexAddress(contract),
+			"output":          hex.EncodeToString(r.output),
+			"codeDeposit":     0,
+			"gasRefunded":     r.gasRefunded,
+			"depositSize":     0,
+			"gasForDeposit":   0,
+		},
+		"transactionReceipt": map[string]interface{}{
+			"stateRoot": hex.EncodeToString(r.stateRoot.Bytes()),
+			"gasUsed":   r.gasUsed,
+			"bloom":     hex.EncodeToString(bloom.Bytes()),
+			"log":       logsJSON(r),
+		},
+	}, nil
+}
+
+// Takes [bytecode, gasLimit, gasPrice, senderAddress, broadcast, changeToSender]
+func createContract(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var bytecode string
+	if err := requiredParam(params, 0, &bytecode); err != nil {
+		return nil, err
+	}
+	code, err := hex.DecodeString(strings.TrimPrefix(bytecode, "0x"))
+	if err != nil || len(code) == 0 {
+		return nil, newRPCError(errCodeInvalidParameter, "Invalid data (data not hex)")
+	}
+	gasLimit, gasPrice, err := gasParams(params, 1, defaultCreateGasLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	fee := int64(gasLimit*gasPrice) + walletFee
+	sender, err := n.senderParam(params, 3, fee)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := n.send(sender, []*wire.TxOut{{Value: 0, PkScript: createScript(gasLimit, gasPrice, code)}}, fee)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"txid":    tx.hash.String(),
+		"sender":  n.base58Address(sender),
+		"hash160": hexAddress(sender),
+		"address": hexAddress(contractAddress(sender, tx.hash, 0, code)),
+	}, nil
+}
+
+// Takes [contractAddress, data, amount, gasLimit, gasPrice, senderAddress, broadcast, changeToSender]
+func sendToContract(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var address, data string
+	if err := requiredParam(params, 0, &address); err != nil {
+		return nil, err
+	}
+	if err := requiredParam(params, 1, &data); err != nil {
+		return nil, err
+	}
+	input, err := hex.DecodeString(strings.TrimPrefix(data, "0x"))
+	if err != nil {
+		return nil, newRPCError(errCodeInvalidParameter, "Invalid data (data not hex)")
+	}
+	var amount int64
+	if len(params) > 2 {
+		if amount, err = parseAmount(params[2]); err != nil {
+			return nil, err
+		}
+	}
+	gasLimit, gasPrice, err := gasParams(params, 3, defaultCallGasLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	contract, err := n.parseContractAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if n.state.GetCodeSize(contract) == 0 {
+		return nil, newRPCError(errCodeInvalidParameter, "contract address does not exist")
+	}
+	fee := int64(gasLimit*gasPrice) + walletFee
+	sender, err := n.senderParam(params, 5, amount+fee)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := n.send(sender, []*wire.TxOut{{Value: amount, PkScript: callScript(gasLimit, gasPrice, input, contract)}}, fee)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"txid":    tx.hash.String(),
+		"sender":  n.base58Address(sender),
+		"hash160": hexAddress(sender),
+	}, nil
+}
+
+// Receipts of every contract output of a mined transaction, empty otherwise
+func getTransactionReceipt(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var txid string
+	if err := requiredParam(params, 0, &txid); err != nil {
+		return nil, err
+	}
+	hash, err := parseHash(txid)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	result := []receiptJSON{}
+	if tx, ok := n.transactions[hash]; ok && tx.block != nil {
+		for _, r := range tx.receipts {
+			result = append(result, receiptToJSON(tx, r))
+		}
+	}
+	return result, nil
+}
+
+func gasPrice(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	// kaond reports the minimum gas price in 10^-18 KAON
+	return json.Number(new(big.Int).Mul(big.NewInt(defaultGasPrice), big.NewInt(weiPerSatoshi)).String()), nil
+}
@@ -0,0 +1,276 @@
+package fakekaond
+
+import (
+	"math/big"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pkg/errors"
+)
+
+const blockGasLimit = 40000000
+
+// Result of a single contract output
+type receipt struct {
+	outputIndex uint32
+	from        common.Address
+	// callee or created contract
+	contractAddress common.Address
+	isCreate        bool
+
+	gasUsed           uint64
+	cumulativeGasUsed uint64
+	gasRefunded       uint64
+	output            []byte
+	excepted          string
+	exceptedMessage   string
+	logs              []*types.Log
+	stateRoot         common.Hash
+}
+
+type executionResult struct {
+	// what inputs pay over outputs, before refunds
+	fees int64
+	// coinbase outputs returning unused gas and the value of failed calls to senders
+	refunds  []*wire.TxOut
+	receipts [][]*receipt
+	gasUsed  uint64
+}
+
+func (r *executionResult) refundTotal() int64 {
+	var total int64
+	for _, out := range r.refunds {
+		total += out.Value
+	}
+	return total
+}
+
+// Runs the contract outputs of transactions mined at height on top of the tip's state
+func (n *Node) execute(height, time int64, miner common.Address, transactions []*transaction) (*executionResult, error) {
+	result := &executionResult{receipts: make([][]*receipt, len(transactions))}
+	view := copyUTXOs(n.utxos)
+
+	for i, tx := range transactions {
+		var valueIn, valueOut int64
+		var firstInput common.Address
+		for j, in := range tx.msg.TxIn {
+			spent, ok := view[in.PreviousOutPoint]
+			if !ok {
+				return nil, errors.Errorf("transaction %s spends a missing output", tx.hash)
+			}
+			if j == 0 {
+				firstInput = spent.address
+			}
+			valueIn += spent.value
+		}
+		for _, out := range tx.msg.TxOut {
+			valueOut += out.Value
+		}
+		result.fees += valueIn - valueOut
+
+		for j, out := range tx.msg.TxOut {
+			script := classifyScript(out.PkScript)
+			if !script.isContract {
+				continue
+			}
+			sender := firstInput
+			if script.sender != nil {
+				sender = *script.sender
+			}
+
+			r := n.executeOutput(height, time, miner, tx.hash, uint32(j), sender, script, out.Value)
+			result.gasUsed += r.gasUsed
+			r.cumulativeGasUsed = result.gasUsed
+			result.receipts[i] = append(result.receipts[i], r)
+
+			refund := int64(script.gasLimit-r.gasUsed) * int64(script.gasPrice)
+			if r.excepted != "None" {
+				refund += out.Value
+			}
+			if refund > 0 {
+				result.refunds = append(result.refunds, &wire.TxOut{Value: refund, PkScript: payToPubKeyHashScript(sender)})
+			}
+		}
+		n.spend(view, tx, height)
+	}
+	return result, nil
+}
+
+func (n *Node) executeOutput(height, time int64, miner common.Address, txHash chainhash.Hash, outputIndex uint32, sender common.Address, script *outputScript, value int64) *receipt {
+	r := &receipt{
+		outputIndex: outputIndex,
+		from:        sender,
+		isCreate:    script.isCreate,
+		excepted:    "None",
+	}
+	if script.isCreate {
+		r.contractAddress = contractAddress(sender, txHash, outputIndex, script.data)
+	} else {
+		r.contractAddress = script.address
+	}
+
+	salt := contractSalt(txHash, outputIndex)
+	key := common.Hash(salt.Bytes32())
+	n.state.Prepare(key, int(outputIndex))
+
+	intrinsic, err := core.IntrinsicGas(script.data, nil, script.isCreate, true, true)
+	if err != nil || intrinsic > script.gasLimit {
+		r.gasUsed = script.gasLimit
+		r.excepted = "OutOfGasIntrinsic"
+		r.stateRoot = n.state.IntermediateRoot(true)
+		return r
+	}
+
+	amount := new(big.Int).Mul(big.NewInt(value), big.NewInt(weiPerSatoshi))
+	n.state.AddBalance(sender, amount)
+
+	evm := vm.NewEVM(n.blockContext(height, time, miner), vm.TxContext{
+		Origin:   sender,
+		GasPrice: new(big.Int).Mul(new(big.Int).SetUint64(script.gasPrice), big.NewInt(weiPerSatoshi)),
+	}, n.state, n.chainConfig, vm.Config{})
+	n.state.PrepareAccessList(sender, &r.contractAddress, vm.ActivePrecompiles(n.chainConfig.Rules(big.NewInt(height), false)), nil)
+
+	gas := script.gasLimit - intrinsic
+	var leftOver uint64
+	if script.isCreate {
+		r.output, _, leftOver, err = evm.Create2(vm.AccountRef(sender), script.data, gas, amount, salt)
+	} else {
+		r.output, leftOver, err = evm.Call(vm.AccountRef(sender), script.address, script.data, gas, amount)
+	}
+
+	r.gasUsed = script.gasLimit - leftOver
+	if err == nil {
+		refund := n.state.GetRefund()
+		if limit := r.gasUsed / params.RefundQuotientEIP3529; refund > limit {
+			refund = limit
+		}
+		r.gasUsed -= refund
+		r.gasRefunded = refund
+		r.logs = n.state.GetLogs(key, common.Hash{})
+	} else {
+		r.excepted = exceptedName(err)
+		if err == vm.ErrExecutionReverted {
+			r.exceptedMessage = common.Bytes2Hex(r.output)
+		}
+		// the value goes back to the sender through the coinbase
+		n.state.SubBalance(sender, amount)
+	}
+	r.stateRoot = n.state.IntermediateRoot(true)
+	return r
+}
+
+// Stores the execution of a block's transactions, whose coinbase isn't executed
+func (n *Node) applyExecution(b *block, result *executionResult) error {
+	for i, receipts := range result.receipts {
+		b.transactions[i+1].receipts = receipts
+	}
+	b.gasUsed = result.gasUsed
+
+	root, err := n.state.Commit(true)
+	if err != nil {
+		return errors.Wrap(err, "couldn't commit EVM state")
+	}
+	b.stateRoot = root
+	n.state, err = state.New(root, n.stateDatabase, nil)
+	return errors.Wrap(err, "couldn't reopen EVM state")
+}
+
+func (n *Node) blockContext(height, time int64, miner common.Address) vm.BlockContext {
+	return vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash: func(number uint64) common.Hash {
+			if b := n.blockAt(int64(number)); b != nil {
+				return common.BytesToHash(reverse(b.hash[:]))
+			}
+			return common.Hash{}
+		},
+		Coinbase:    miner,
+		GasLimit:    blockGasLimit,
+		BlockNumber: big.NewInt(height),
+		Time:        big.NewInt(time),
+		Difficulty:  big.NewInt(1),
+		BaseFee:     big.NewInt(0),
+	}
+}
+
+// Runs a call against a copy of the tip's state for callcontract
+func (n *Node) staticCall(sender common.Address, contract common.Address, data []byte, gasLimit uint64) *receipt {
+	statedb := n.state.Copy()
+	tip := n.tip()
+	evm := vm.NewEVM(n.blockContext(tip.height, tip.header.Timestamp.Unix(), common.Address{}), vm.TxContext{
+		Origin:   sender,
+		GasPrice: big.NewInt(0),
+	}, statedb, n.chainConfig, vm.Config{NoBaseFee: true})
+
+	r := &receipt{from: sender, contractAddress: contract, excepted: "None"}
+	intrinsic, err := core.IntrinsicGas(data, nil, false, true, true)
+	if err != nil || intrinsic > gasLimit {
+		r.gasUsed = gasLimit
+		r.excepted = "OutOfGasIntrinsic"
+		return r
+	}
+
+	key := crypto.Keccak256Hash(contract[:], data)
+	statedb.Prepare(key, 0)
+	statedb.PrepareAccessList(sender, &contract, vm.ActivePrecompiles(n.chainConfig.Rules(big.NewInt(tip.height), false)), nil)
+	output, leftOver, err := evm.Call(vm.AccountRef(sender), contract, data, gasLimit-intrinsic, new(big.Int))
+	r.output = output
+	r.gasUsed = gasLimit - leftOver
+	if err == nil {
+		refund := statedb.GetRefund()
+		if limit := r.gasUsed / params.RefundQuotientEIP3529; refund > limit {
+			refund = limit
+		}
+		r.gasUsed -= refund
+		r.gasRefunded = refund
+		r.logs = statedb.GetLogs(key, common.Hash{})
+	} else {
+		r.excepted = exceptedName(err)
+		if err == vm.ErrExecutionReverted {
+			r.exceptedMessage = common.Bytes2Hex(output)
+		}
+	}
+	r.stateRoot = statedb.IntermediateRoot(true)
+	return r
+}
+
+// Names kaond uses for EVM exceptions
+func exceptedName(err error) string {
+	switch err {
+	case vm.ErrExecutionReverted:
+		return "Revert"
+	case vm.ErrOutOfGas, vm.ErrCodeStoreOutOfGas, vm.ErrGasUintOverflow:
+		return "OutOfGas"
+	case vm.ErrInvalidJump:
+		return "BadJumpDestination"
+	case vm.ErrDepth:
+		return "OutOfStack"
+	case vm.ErrInsufficientBalance:
+		return "NotEnoughCash"
+	}
+	switch err.(type) {
+	case *vm.ErrStackUnderflow:
+		return "StackUnderflow"
+	case *vm.ErrStackOverflow:
+		return "OutOfStack"
+	case *vm.ErrInvalidOpCode:
+		return "BadInstruction"
+	}
+	return "Unknown"
+}
+
+func reverse(b []byte) []byte {
+	result := make([]byte, len(b))
+	for i := range b {
+		result[len(b)-1-i] = b[i]
+	}
+	return result
+}
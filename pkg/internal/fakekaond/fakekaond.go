@@ -0,0 +1,348 @@
+// Package fakekaond is an in-process, stateful fake of kaond's JSON-RPC interface with an embedded EVM.
+// It plugs into a kaon.Client through kaon.SetDoer, or serves HTTP as an http.Handler, so that whole
+// flows can be tested without a running node.
+package fakekaond
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/kaonone/eth-rpc-gate/pkg/address"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/pkg/errors"
+)
+
+// Node is a stateful in-process stand-in for kaond's JSON-RPC interface, for end-to-end tests that shouldn't
+// need docker. Blocks are only mined by generatetoaddress, contract outputs run on go-ethereum's EVM when
+// their transaction is mined and every address is treated as part of the wallet, no signatures are checked.
+//
+// It plugs into a client either as its doer, with kaon.SetDoer(node), or as an http.Handler behind a listener.
+type Node struct {
+	mutex sync.Mutex
+
+	chain            string
+	pubKeyHashAddrID byte
+	blockReward      int64 // in satoshis
+	coinbaseMaturity int64
+	chainConfig      *params.ChainConfig
+
+	stateDatabase state.Database
+	// world state at the tip of the active chain
+	state *state.StateDB
+
+	blocks []*block // active chain, indexed by height
+	known  map[chainhash.Hash]*block
+	// transactions of the active chain and the mempool
+	transactions map[chainhash.Hash]*transaction
+	mempool      []*transaction
+	// confirmed unspent outputs at the tip of the active chain
+	utxos map[wire.OutPoint]*utxo
+	// view of the mempool on top of utxos
+	mempoolSpent   map[wire.OutPoint]bool
+	mempoolOutputs map[wire.OutPoint]*utxo
+
+	lastBlockTime int64
+	// closed and replaced whenever the active chain changes
+	chainChanged chan struct{}
+}
+
+type Option func(*Node) error
+
+func New(opts ...Option) (*Node, error) {
+	n := &Node{
+		chain:            kaon.ChainRegTest,
+		blockReward:      DefaultBlockReward * satoshisPerKAON,
+		coinbaseMaturity: DefaultCoinbaseMaturity,
+		chainConfig:      params.AllEthashProtocolChanges,
+		stateDatabase:    state.NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &trie.Config{Preimages: true}),
+		known:            make(map[chainhash.Hash]*block),
+		transactions:     make(map[chainhash.Hash]*transaction),
+		utxos:            make(map[wire.OutPoint]*utxo),
+		mempoolSpent:     make(map[wire.OutPoint]bool),
+		mempoolOutputs:   make(map[wire.OutPoint]*utxo),
+		chainChanged:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		if err := opt(n); err != nil {
+			return nil, err
+		}
+	}
+
+	addressParams, err := address.ParamsForChain(n.chain)
+	if err != nil {
+		return nil, err
+	}
+	n.pubKeyHashAddrID = addressParams.PubKeyHashAddrID
+
+	n.state, err = state.New(common.Hash{}, n.stateDatabase, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create EVM state")
+	}
+	if err := n.mineGenesis(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+const (
+	DefaultBlockReward      = 20000 // in KAON
+	DefaultCoinbaseMaturity = 10
+)
+
+// Chain reported by getblockchaininfo, which also selects the base58 address prefix
+func SetChain(chain string) Option {
+	return func(n *Node) error {
+		switch chain {
+		case kaon.ChainMain, kaon.ChainTest, kaon.ChainRegTest:
+			n.chain = chain
+			return nil
+		}
+		return errors.Errorf("unsupported chain %q", chain)
+	}
+}
+
+// Reward of each mined block in KAON
+func SetBlockReward(reward int64) Option {
+	return func(n *Node) error {
+		if reward < 0 {
+			return errors.New("block reward can't be negative")
+		}
+		n.blockReward = reward * satoshisPerKAON
+		return nil
+	}
+}
+
+// Number of confirmations before coinbase outputs can be spent, matching eth-rpc-gate's mature block height
+func SetCoinbaseMaturity(confirmations int64) Option {
+	return func(n *Node) error {
+		if confirmations < 0 {
+			return errors.New("coinbase maturity can't be negative")
+		}
+		n.coinbaseMaturity = confirmations
+		return nil
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	ID      json.RawMessage `json:"id"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	Result interface{}        `json:"result"`
+	Error  *kaon.JSONRPCError `json:"error"`
+	ID     json.RawMessage    `json:"id"`
+}
+
+type handler func(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error)
+
+// Error returned to the client as a JSON-RPC error, with kaond's error codes
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.message, e.code)
+}
+
+func newRPCError(code int, format string, args ...interface{}) *rpcError {
+	return &rpcError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// kaond's error codes
+const (
+	errCodeMethodNotFound       = -32601
+	errCodeInvalidParams        = -32602
+	errCodeMisc                 = -1
+	errCodeType                 = -3
+	errCodeInvalidAddress       = -5
+	errCodeInsufficientFunds    = -6
+	errCodeInvalidParameter     = -8
+	errCodeDeserialization      = -22
+	errCodeVerify               = -25
+	errCodeVerifyRejected       = -26
+	errCodeVerifyAlreadyInChain = -27
+)
+
+// Do serves a kaond JSON-RPC request, making the node usable as the doer of a kaon.Client
+func (n *Node) Do(req *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	status, response := n.serve(req.Context(), body)
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(response)),
+		Request:    req,
+	}, nil
+}
+
+func (n *Node) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	status, response := n.serve(req.Context(), body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(response)
+}
+
+func (n *Node) serve(ctx context.Context, body []byte) (int, []byte) {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) != 0 && trimmed[0] == '[' {
+		return n.serveBatch(ctx, trimmed)
+	}
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		response, _ := json.Marshal(rpcResponse{Error: &kaon.JSONRPCError{Code: -32700, Message: "Parse error"}, ID: json.RawMessage("null")})
+		return http.StatusInternalServerError, response
+	}
+
+	response := n.respond(ctx, &req)
+	status := http.StatusOK
+	if response.Error != nil {
+		// like bitcoind, errors come with an HTTP error status
+		status = http.StatusInternalServerError
+		if response.Error.Code == errCodeMethodNotFound {
+			status = http.StatusNotFound
+		}
+	}
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		encoded, _ = json.Marshal(rpcResponse{Error: &kaon.JSONRPCError{Code: -32603, Message: err.Error()}, ID: req.ID})
+		status = http.StatusInternalServerError
+	}
+	return status, encoded
+}
+
+// Like bitcoind, a batch is answered with an OK status and an array of responses in the order of the requests
+func (n *Node) serveBatch(ctx context.Context, body []byte) (int, []byte) {
+	var reqs []rpcRequest
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		response, _ := json.Marshal(rpcResponse{Error: &kaon.JSONRPCError{Code: -32700, Message: "Parse error"}, ID: json.RawMessage("null")})
+		return http.StatusInternalServerError, response
+	}
+	responses := make([]rpcResponse, len(reqs))
+	for i := range reqs {
+		responses[i] = n.respond(ctx, &reqs[i])
+	}
+	encoded, err := json.Marshal(responses)
+	if err != nil {
+		encoded, _ = json.Marshal(rpcResponse{Error: &kaon.JSONRPCError{Code: -32603, Message: err.Error()}, ID: json.RawMessage("null")})
+		return http.StatusInternalServerError, encoded
+	}
+	return http.StatusOK, encoded
+}
+
+func (n *Node) respond(ctx context.Context, req *rpcRequest) rpcResponse {
+	result, err := n.call(ctx, req.Method, req.Params)
+	if err == nil {
+		return rpcResponse{Result: result, ID: req.ID}
+	}
+	response := rpcResponse{ID: req.ID, Error: &kaon.JSONRPCError{Code: errCodeMisc, Message: err.Error()}}
+	if rpcErr, ok := err.(*rpcError); ok {
+		response.Error = &kaon.JSONRPCError{Code: rpcErr.code, Message: rpcErr.message}
+	}
+	return response
+}
+
+func (n *Node) call(ctx context.Context, method string, rawParams json.RawMessage) (interface{}, error) {
+	h, ok := handlers[method]
+	if !ok {
+		return nil, newRPCError(errCodeMethodNotFound, "Method not found")
+	}
+
+	var params []json.RawMessage
+	if len(rawParams) != 0 && string(rawParams) != "null" {
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return nil, newRPCError(errCodeInvalidParams, "params must be an array")
+		}
+	}
+	// trailing nulls are omitted parameters
+	for len(params) != 0 && string(params[len(params)-1]) == "null" {
+		params = params[:len(params)-1]
+	}
+	return h(ctx, n, params)
+}
+
+// Mines blocks paying the reward to a hex or base58 address, returns the block hashes
+func (n *Node) Generate(blocks int, address string) ([]string, error) {
+	result, err := generateToAddress(context.Background(), n, []json.RawMessage{
+		json.RawMessage(fmt.Sprint(blocks)),
+		mustMarshal(address),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]string), nil
+}
+
+// Height of the active chain's tip
+func (n *Node) BlockCount() int64 {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.tip().height
+}
+
+// Drops a transaction from the mempool like kaond evicting it, along with the transactions spending its
+// outputs. Returns false if the transaction isn't in the mempool.
+func (n *Node) Evict(txid string) bool {
+	hash, err := chainhash.NewHashFromStr(txid)
+	if err != nil {
+		return false
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for i, tx := range n.mempool {
+		if tx.hash != *hash {
+			continue
+		}
+		n.mempool = append(n.mempool[:i:i], n.mempool[i+1:]...)
+		delete(n.transactions, tx.hash)
+		n.rebuildMempool(nil)
+		return true
+	}
+	return false
+}
+
+func (n *Node) notifyChainChanged() {
+	close(n.chainChanged)
+	n.chainChanged = make(chan struct{})
+}
+
+func (n *Node) nextBlockTime() int64 {
+	now := time.Now().Unix()
+	if now <= n.lastBlockTime {
+		now = n.lastBlockTime + 1
+	}
+	n.lastBlockTime = now
+	return now
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
@@ -0,0 +1,88 @@
+package fakekaond
+
+import (
+	"context"
+	"encoding/json"
+)
+
+var handlers = map[string]handler{
+	"getblockcount":        getBlockCount,
+	"getbestblockhash":     getBestBlockHash,
+	"getblockhash":         getBlockHash,
+	"getblockheader":       getBlockHeader,
+	"getblock":             getBlock,
+	"getblockchaininfo":    getBlockchainInfo,
+	"getrawtransaction":    getRawTransaction,
+	"decoderawtransaction": decodeRawTransaction,
+	"getrawmempool":        getRawMempool,
+	"sendrawtransaction":   sendRawTransaction,
+	"gettxout":             getTxOut,
+	"invalidateblock":      invalidateBlock,
+	"reconsiderblock":      reconsiderBlock,
+
+	"gethexaddress":     getHexAddress,
+	"fromhexaddress":    fromHexAddress,
+	"getaddressbalance": getAddressBalance,
+	"getaddressutxos":   getAddressUTXOs,
+	"getaddressdeltas":  getAddressDeltas,
+	"listunspent":       listUnspent,
+	"getaddressnonce":   getAddressNonce,
+	"sendtoaddress":     sendToAddress,
+	"generatetoaddress": generateToAddress,
+	"gettransaction":    getTransaction,
+	"createwallet":      createWallet,
+	"loadwallet":        createWallet,
+
+	"getaccountinfo":        getAccountInfo,
+	"getstorage":            getStorage,
+	"callcontract":          callContract,
+	"createcontract":        createContract,
+	"sendtocontract":        sendToContract,
+	"gettransactionreceipt": getTransactionReceipt,
+	"searchlogs":            searchLogs,
+	"waitforlogs":           waitForLogs,
+	"gasprice":              gasPrice,
+
+	"getnetworkinfo": getNetworkInfo,
+	"getpeerinfo":    getPeerInfo,
+	"getstakinginfo": getStakingInfo,
+}
+
+func getNetworkInfo(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"version":         1000000,
+		"subversion":      "/fakekaond:1.0.0/",
+		"protocolversion": 70016,
+		"localservices":   "0000000000000000",
+		"localrelay":      true,
+		"timeoffset":      0,
+		"networkactive":   true,
+		"connections":     0,
+		"networks":        []interface{}{},
+		"relayfee":        formatAmount(walletFee / 10),
+		"incrementalfee":  formatAmount(walletFee / 10),
+		"localaddresses":  []interface{}{},
+		"warnings":        "",
+	}, nil
+}
+
+func getPeerInfo(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	return []interface{}{}, nil
+}
+
+func getStakingInfo(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return map[string]interface{}{
+		"enabled":         false,
+		"staking":         false,
+		"errors":          "",
+		"currentblocktx":  len(n.tip().transactions) - 1,
+		"pooledtx":        len(n.mempool),
+		"difficulty":      4.656542373906925e-10,
+		"search-interval": 0,
+		"weight":          0,
+		"netstakeweight":  0,
+		"expectedtime":    0,
+	}, nil
+}
@@ -0,0 +1,237 @@
+package fakekaond
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Address and positional topic filter of searchlogs and waitforlogs, null topics match anything
+type logFilter struct {
+	Addresses []string  `json:"addresses"`
+	Topics    []*string `json:"topics"`
+}
+
+func normalizeHex(s string) string {
+	return strings.ToLower(strings.TrimPrefix(s, "0x"))
+}
+
+func (f *logFilter) matches(log *types.Log) bool {
+	if len(f.Addresses) != 0 {
+		address := hexAddress(log.Address)
+		found := false
+		for _, a := range f.Addresses {
+			if normalizeHex(a) == address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for i, topic := range f.Topics {
+		if topic == nil {
+			continue
+		}
+		if i >= len(log.Topics) || normalizeHex(*topic) != normalizeHex(log.Topics[i].Hex()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *logFilter) matchesAny(r *receipt) bool {
+	for _, log := range r.logs {
+		if f.matches(log) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses a filter given as {"addresses": [...]} and {"topics": [...]} objects, or a single one of both
+func parseLogFilter(params []json.RawMessage, addressesIndex, topicsIndex int) (*logFilter, error) {
+	filter := &logFilter{}
+	if _, err := optionalParam(params, addressesIndex, filter); err != nil {
+		return nil, err
+	}
+	if topicsIndex != addressesIndex {
+		var topics logFilter
+		if _, err := optionalParam(params, topicsIndex, &topics); err != nil {
+			return nil, err
+		}
+		filter.Topics = topics.Topics
+	}
+	return filter, nil
+}
+
+// Parses a block number, "latest" or a negative number stands for the tip
+func (n *Node) blockNumberParam(params []json.RawMessage, i int, defaultHeight int64) (int64, error) {
+	if i >= len(params) || string(params[i]) == "null" {
+		return defaultHeight, nil
+	}
+	if string(params[i]) == `"latest"` {
+		return n.tip().height, nil
+	}
+	var height int64
+	if err := requiredParam(params, i, &height); err != nil {
+		return 0, err
+	}
+	if height < 0 {
+		return n.tip().height, nil
+	}
+	return height, nil
+}
+
+// Receipts of transactions with a log matching the filter, with all their logs
+func searchLogs(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	filter, err := parseLogFilter(params, 2, 3)
+	if err != nil {
+		return nil, err
+	}
+	var minConf int64
+	if _, err := optionalParam(params, 4, &minConf); err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	from, err := n.blockNumberParam(params, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	to, err := n.blockNumberParam(params, 1, n.tip().height)
+	if err != nil {
+		return nil, err
+	}
+	if last := n.tip().height - minConf + 1; minConf > 0 && to > last {
+		to = last
+	}
+
+	result := []receiptJSON{}
+	for height := from; height <= to; height++ {
+		b := n.blockAt(height)
+		if b == nil {
+			break
+		}
+		for _, tx := range b.transactions {
+			for _, r := range tx.receipts {
+				if len(r.logs) != 0 && filter.matchesAny(r) {
+					result = append(result, receiptToJSON(tx, r))
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+type waitForLogsEntry struct {
+	BlockHash         string   `json:"blockHash"`
+	BlockNumber       int64    `json:"blockNumber"`
+	TransactionHash   string   `json:"transactionHash"`
+	TransactionIndex  int      `json:"transactionIndex"`
+	OutputIndex       uint32   `json:"outputIndex"`
+	From              string   `json:"from"`
+	To                string   `json:"to"`
+	CumulativeGasUsed uint64   `json:"cumulativeGasUsed"`
+	GasUsed           uint64   `json:"gasUsed"`
+	ContractAddress   string   `json:"contractAddress"`
+	Excepted          string   `json:"excepted"`
+	ExceptedMessage   string   `json:"exceptedMessage"`
+	Topics            []string `json:"topics"`
+	Data              string   `json:"data"`
+}
+
+// Takes [fromBlock, toBlock, filter, minconf] and blocks until a matching log is mined, toBlock is reached
+// or the request is cancelled
+func waitForLogs(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	filter, err := parseLogFilter(params, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+	minConf := int64(6)
+	if _, err := optionalParam(params, 3, &minConf); err != nil {
+		return nil, err
+	}
+	if minConf < 1 {
+		minConf = 1
+	}
+
+	n.mutex.Lock()
+	from, err := n.blockNumberParam(params, 0, n.tip().height+1)
+	if err != nil {
+		n.mutex.Unlock()
+		return nil, err
+	}
+	to, err := n.blockNumberParam(params, 1, -1)
+	n.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if len(params) < 2 || string(params[1]) == "null" {
+		// wait indefinitely
+		to = -1
+	}
+
+	for {
+		n.mutex.Lock()
+		last := n.tip().height - minConf + 1
+		if to >= 0 && last > to {
+			last = to
+		}
+		entries := []waitForLogsEntry{}
+		for height := from; height <= last; height++ {
+			b := n.blockAt(height)
+			for _, tx := range b.transactions {
+				for _, r := range tx.receipts {
+					entries = append(entries, n.matchingEntries(tx, r, filter)...)
+				}
+			}
+		}
+		changed := n.chainChanged
+		n.mutex.Unlock()
+
+		if len(entries) != 0 || (to >= 0 && last >= to) {
+			return map[string]interface{}{
+				"entries":   entries,
+				"count":     len(entries),
+				"nextBlock": last + 1,
+			}, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (n *Node) matchingEntries(tx *transaction, r *receipt, filter *logFilter) []waitForLogsEntry {
+	var entries []waitForLogsEntry
+	receipt := receiptToJSON(tx, r)
+	for i, log := range r.logs {
+		if !filter.matches(log) {
+			continue
+		}
+		entries = append(entries, waitForLogsEntry{
+			BlockHash:         receipt.BlockHash,
+			BlockNumber:       receipt.BlockNumber,
+			TransactionHash:   receipt.TransactionHash,
+			TransactionIndex:  receipt.TransactionIndex,
+			OutputIndex:       receipt.OutputIndex,
+			From:              receipt.From,
+			To:                receipt.To,
+			CumulativeGasUsed: receipt.CumulativeGasUsed,
+			GasUsed:           receipt.GasUsed,
+			ContractAddress:   receipt.Log[i].Address,
+			Excepted:          receipt.Excepted,
+			ExceptedMessage:   receipt.ExceptedMessage,
+			Topics:            receipt.Log[i].Topics,
+			Data:              receipt.Log[i].Data,
+		})
+	}
+	return entries
+}
@@ -0,0 +1,90 @@
+package fakekaond
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+)
+
+// Decodes the required parameter at index i
+func requiredParam(params []json.RawMessage, i int, v interface{}) error {
+	if i >= len(params) {
+		return newRPCError(errCodeInvalidParams, "missing parameter %d", i+1)
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return newRPCError(errCodeType, "parameter %d: %s", i+1, err)
+	}
+	return nil
+}
+
+// Decodes the parameter at index i if it was given, reports whether it was
+func optionalParam(params []json.RawMessage, i int, v interface{}) (bool, error) {
+	if i >= len(params) || string(params[i]) == "null" {
+		return false, nil
+	}
+	return true, requiredParam(params, i, v)
+}
+
+// Accepts hex addresses, with or without a 0x prefix, and base58 addresses of the node's chain
+func (n *Node) parseAddress(address string) (common.Address, error) {
+	stripped := strings.TrimPrefix(address, "0x")
+	if len(stripped) == 2*common.AddressLength {
+		if decoded, err := hex.DecodeString(stripped); err == nil {
+			return common.BytesToAddress(decoded), nil
+		}
+	}
+	return n.parseBase58Address(address)
+}
+
+func (n *Node) parseBase58Address(address string) (common.Address, error) {
+	decoded, version, err := base58.CheckDecode(address)
+	if err != nil || version != n.pubKeyHashAddrID || len(decoded) != common.AddressLength {
+		return common.Address{}, newRPCError(errCodeInvalidAddress, "Invalid address")
+	}
+	return common.BytesToAddress(decoded), nil
+}
+
+func (n *Node) base58Address(address common.Address) string {
+	return base58.CheckEncode(address.Bytes(), n.pubKeyHashAddrID)
+}
+
+func parseHash(s string) (chainhash.Hash, error) {
+	if len(s) != 2*chainhash.HashSize {
+		return chainhash.Hash{}, newRPCError(errCodeInvalidParameter, "must be of length %d (not %d, for '%s')", 2*chainhash.HashSize, len(s), s)
+	}
+	hash, err := chainhash.NewHashFromStr(s)
+	if err != nil {
+		return chainhash.Hash{}, newRPCError(errCodeInvalidParameter, "must be hexadecimal string (not '%s')", s)
+	}
+	return *hash, nil
+}
+
+// Parses a KAON amount given as a number or a string into satoshis
+func parseAmount(raw json.RawMessage) (int64, error) {
+	var amount decimal.Decimal
+	if err := json.Unmarshal(raw, &amount); err != nil {
+		return 0, newRPCError(errCodeType, "Amount is not a number or string")
+	}
+	satoshis := amount.Shift(8)
+	if amount.IsNegative() || !satoshis.Equal(satoshis.Truncate(0)) {
+		return 0, newRPCError(errCodeType, "Invalid amount")
+	}
+	return satoshis.IntPart(), nil
+}
+
+// Renders satoshis as a KAON amount the way kaond does, a JSON number
+func formatAmount(satoshis int64) json.Number {
+	return json.Number(decimal.New(satoshis, -8).StringFixed(8))
+}
+
+// Balances and address deltas are reported as integers in 10^-18 KAON
+func formatBalance(satoshis int64) json.Number {
+	balance := new(big.Int).Mul(big.NewInt(satoshis), big.NewInt(weiPerSatoshi))
+	return json.Number(balance.String())
+}
@@ -0,0 +1,335 @@
+package fakekaond
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// Kaon specific opcodes
+const (
+	opCreate = 0xc1
+	opCall   = 0xc2
+	opSpend  = 0xc3
+	opSender = 0xc4
+)
+
+// EVM version pushed by contract outputs
+const evmVersion = 4
+
+var opcodeNames = map[byte]string{
+	txscript.OP_DUP:         "OP_DUP",
+	txscript.OP_HASH160:     "OP_HASH160",
+	txscript.OP_EQUAL:       "OP_EQUAL",
+	txscript.OP_EQUALVERIFY: "OP_EQUALVERIFY",
+	txscript.OP_CHECKSIG:    "OP_CHECKSIG",
+	txscript.OP_RETURN:      "OP_RETURN",
+	opCreate:                "OP_CREATE",
+	opCall:                  "OP_CALL",
+	opSpend:                 "OP_SPEND",
+	opSender:                "OP_SENDER",
+}
+
+// A single opcode of a script, data is set for pushes
+type scriptOp struct {
+	opcode byte
+	data   []byte
+}
+
+func (op scriptOp) isPush() bool {
+	return op.opcode <= txscript.OP_PUSHDATA4
+}
+
+// Numeric value of small integer opcodes and pushes of script numbers
+func (op scriptOp) number() (int64, bool) {
+	switch {
+	case op.opcode >= txscript.OP_1 && op.opcode <= txscript.OP_16:
+		return int64(op.opcode-txscript.OP_1) + 1, true
+	case op.opcode == txscript.OP_1NEGATE:
+		return -1, true
+	case op.isPush() && len(op.data) <= 8:
+		return decodeScriptNum(op.data), true
+	}
+	return 0, false
+}
+
+func parseScript(script []byte) ([]scriptOp, error) {
+	var ops []scriptOp
+	for i := 0; i < len(script); {
+		opcode := script[i]
+		i++
+
+		var size int
+		switch {
+		case opcode >= txscript.OP_DATA_1 && opcode <= txscript.OP_DATA_75:
+			size = int(opcode)
+		case opcode == txscript.OP_PUSHDATA1:
+			if i+1 > len(script) {
+				return nil, errors.New("truncated OP_PUSHDATA1")
+			}
+			size = int(script[i])
+			i++
+		case opcode == txscript.OP_PUSHDATA2:
+			if i+2 > len(script) {
+				return nil, errors.New("truncated OP_PUSHDATA2")
+			}
+			size = int(binary.LittleEndian.Uint16(script[i:]))
+			i += 2
+		case opcode == txscript.OP_PUSHDATA4:
+			if i+4 > len(script) {
+				return nil, errors.New("truncated OP_PUSHDATA4")
+			}
+			size = int(binary.LittleEndian.Uint32(script[i:]))
+			i += 4
+		}
+		if i+size > len(script) {
+			return nil, errors.Errorf("push of %d bytes exceeds the script", size)
+		}
+
+		op := scriptOp{opcode: opcode}
+		if opcode <= txscript.OP_PUSHDATA4 {
+			op.data = script[i : i+size]
+		}
+		ops = append(ops, op)
+		i += size
+	}
+	return ops, nil
+}
+
+// Renders a script the way kaond does, pushes of up to 4 bytes are shown as numbers
+func disassemble(ops []scriptOp) string {
+	parts := make([]string, len(ops))
+	for i, op := range ops {
+		switch {
+		case op.isPush() && len(op.data) <= 4:
+			parts[i] = strconv.FormatInt(decodeScriptNum(op.data), 10)
+		case op.isPush():
+			parts[i] = hex.EncodeToString(op.data)
+		default:
+			if n, ok := op.number(); ok {
+				parts[i] = strconv.FormatInt(n, 10)
+			} else if name, ok := opcodeNames[op.opcode]; ok {
+				parts[i] = name
+			} else {
+				parts[i] = "OP_UNKNOWN"
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Minimal little endian encoding with a sign bit, as used for numbers in scripts
+func encodeScriptNum(n int64) []byte {
+	if n == 0 {
+		return nil
+	}
+
+	negative := n < 0
+	abs := uint64(n)
+	if negative {
+		abs = uint64(-n)
+	}
+
+	var result []byte
+	for abs > 0 {
+		result = append(result, byte(abs&0xff))
+		abs >>= 8
+	}
+	if result[len(result)-1]&0x80 != 0 {
+		extra := byte(0x00)
+		if negative {
+			extra = 0x80
+		}
+		result = append(result, extra)
+	} else if negative {
+		result[len(result)-1] |= 0x80
+	}
+	return result
+}
+
+func decodeScriptNum(data []byte) int64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	var result int64
+	for i, b := range data {
+		result |= int64(b) << uint8(8*i)
+	}
+	if data[len(data)-1]&0x80 != 0 {
+		result &= ^(int64(0x80) << uint8(8*(len(data)-1)))
+		return -result
+	}
+	return result
+}
+
+// Builds scripts without txscript.ScriptBuilder's size limit, contract bytecode easily exceeds it
+type scriptBuilder []byte
+
+func (b scriptBuilder) addOp(opcode byte) scriptBuilder {
+	return append(b, opcode)
+}
+
+func (b scriptBuilder) addInt(n int64) scriptBuilder {
+	if n == 0 {
+		return append(b, txscript.OP_0)
+	}
+	if n == -1 || (n >= 1 && n <= 16) {
+		return append(b, byte(txscript.OP_1-1+n))
+	}
+	return b.addData(encodeScriptNum(n))
+}
+
+func (b scriptBuilder) addData(data []byte) scriptBuilder {
+	size := len(data)
+	switch {
+	case size == 0:
+		return append(b, txscript.OP_0)
+	case size <= txscript.OP_DATA_75:
+		b = append(b, byte(size))
+	case size <= 0xff:
+		b = append(b, txscript.OP_PUSHDATA1, byte(size))
+	case size <= 0xffff:
+		b = append(b, txscript.OP_PUSHDATA2, byte(size), byte(size>>8))
+	default:
+		b = append(b, txscript.OP_PUSHDATA4, byte(size), byte(size>>8), byte(size>>16), byte(size>>24))
+	}
+	return append(b, data...)
+}
+
+func payToPubKeyHashScript(address common.Address) []byte {
+	return scriptBuilder{}.
+		addOp(txscript.OP_DUP).
+		addOp(txscript.OP_HASH160).
+		addData(address.Bytes()).
+		addOp(txscript.OP_EQUALVERIFY).
+		addOp(txscript.OP_CHECKSIG)
+}
+
+func createScript(gasLimit, gasPrice uint64, code []byte) []byte {
+	return scriptBuilder{}.
+		addInt(evmVersion).
+		addInt(int64(gasLimit)).
+		addInt(int64(gasPrice)).
+		addData(code).
+		addOp(opCreate)
+}
+
+func callScript(gasLimit, gasPrice uint64, data []byte, contract common.Address) []byte {
+	return scriptBuilder{}.
+		addInt(evmVersion).
+		addInt(int64(gasLimit)).
+		addInt(int64(gasPrice)).
+		addData(data).
+		addData(contract.Bytes()).
+		addOp(opCall)
+}
+
+// Output script types as reported by kaond
+const (
+	scriptPubKeyHash   = "pubkeyhash"
+	scriptCreate       = "create"
+	scriptCall         = "call"
+	scriptCreateSender = "create_sender"
+	scriptCallSender   = "call_sender"
+	scriptNullData     = "nulldata"
+	scriptNonstandard  = "nonstandard"
+)
+
+// What the node understands of an output script
+type outputScript struct {
+	kind string
+	ops  []scriptOp
+
+	// receiver of pay to pubkey hash outputs, callee of OP_CALL outputs
+	address common.Address
+
+	// only set for contract outputs
+	sender     *common.Address
+	gasLimit   uint64
+	gasPrice   uint64
+	data       []byte
+	isCreate   bool
+	isContract bool
+}
+
+func classifyScript(script []byte) *outputScript {
+	ops, err := parseScript(script)
+	if err != nil || len(ops) == 0 {
+		return &outputScript{kind: scriptNonstandard}
+	}
+	out := &outputScript{kind: scriptNonstandard, ops: ops}
+
+	if len(ops) == 5 &&
+		ops[0].opcode == txscript.OP_DUP &&
+		ops[1].opcode == txscript.OP_HASH160 &&
+		len(ops[2].data) == common.AddressLength &&
+		ops[3].opcode == txscript.OP_EQUALVERIFY &&
+		ops[4].opcode == txscript.OP_CHECKSIG {
+		out.kind = scriptPubKeyHash
+		out.address = common.BytesToAddress(ops[2].data)
+		return out
+	}
+	if ops[0].opcode == txscript.OP_RETURN {
+		out.kind = scriptNullData
+		return out
+	}
+
+	contractOps := ops
+	// 1 <sender> <signature> OP_SENDER precedes the contract part
+	if len(ops) > 4 && ops[3].opcode == opSender {
+		if len(ops[1].data) != common.AddressLength {
+			return out
+		}
+		sender := common.BytesToAddress(ops[1].data)
+		out.sender = &sender
+		contractOps = ops[4:]
+	}
+
+	final := contractOps[len(contractOps)-1].opcode
+	switch {
+	case final == opCreate && len(contractOps) == 5:
+		out.isCreate = true
+	case final == opCall && len(contractOps) == 6:
+		if len(contractOps[4].data) != common.AddressLength {
+			return out
+		}
+		out.address = common.BytesToAddress(contractOps[4].data)
+	default:
+		return out
+	}
+
+	version, ok := contractOps[0].number()
+	if !ok || version != evmVersion {
+		return out
+	}
+	gasLimit, ok := contractOps[1].number()
+	if !ok || gasLimit <= 0 {
+		return out
+	}
+	gasPrice, ok := contractOps[2].number()
+	if !ok || gasPrice < 0 {
+		return out
+	}
+	out.gasLimit = uint64(gasLimit)
+	out.gasPrice = uint64(gasPrice)
+	out.data = contractOps[3].data
+	out.isContract = true
+
+	switch {
+	case out.isCreate && out.sender != nil:
+		out.kind = scriptCreateSender
+	case out.isCreate:
+		out.kind = scriptCreate
+	case out.sender != nil:
+		out.kind = scriptCallSender
+	default:
+		out.kind = scriptCall
+	}
+	return out
+}
@@ -0,0 +1,528 @@
+package fakekaond
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Relay fee added to every wallet transaction, 0.001 KAON
+const walletFee = 100000
+
+const (
+	defaultCreateGasLimit = 2500000
+	defaultCallGasLimit   = 250000
+	defaultGasPrice       = 40 // satoshis, 0.0000004 KAON
+	maxGasLimit           = 40000000
+)
+
+// Builds a transaction spending sender's outputs, the change goes back to sender, and adds it to the mempool
+func (n *Node) send(sender common.Address, outputs []*wire.TxOut, fee int64) (*transaction, error) {
+	need := fee
+	for _, out := range outputs {
+		need += out.Value
+	}
+
+	msg := wire.NewMsgTx(2)
+	var funded int64
+	for _, outpoint := range n.spendableOutputs(sender) {
+		if funded >= need {
+			break
+		}
+		out, _ := n.lookupUTXO(outpoint)
+		funded += out.value
+		msg.AddTxIn(&wire.TxIn{PreviousOutPoint: outpoint, Sequence: wire.MaxTxInSequenceNum - 1})
+	}
+	if funded < need {
+		return nil, newRPCError(errCodeInsufficientFunds, "Insufficient funds")
+	}
+
+	for _, out := range outputs {
+		msg.AddTxOut(out)
+	}
+	if change := funded - need; change > 0 {
+		msg.AddTxOut(&wire.TxOut{Value: change, PkScript: payToPubKeyHashScript(sender)})
+	}
+
+	tx := newTransaction(msg, n.nextBlockTime())
+	if err := n.acceptToMempool(tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Picks the address with the oldest spendable output that can pay need on its own, like a wallet would
+func (n *Node) defaultSender(need int64) (common.Address, error) {
+	balances := make(map[common.Address]int64)
+	var owners []common.Address
+	for outpoint, out := range n.utxos {
+		if n.mempoolSpent[outpoint] || !n.isMature(out) {
+			continue
+		}
+		if _, ok := balances[out.address]; !ok {
+			owners = append(owners, out.address)
+		}
+		balances[out.address] += out.value
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return n.oldestOutput(owners[i]) < n.oldestOutput(owners[j])
+	})
+	for _, owner := range owners {
+		if balances[owner] >= need {
+			return owner, nil
+		}
+	}
+	return common.Address{}, newRPCError(errCodeInsufficientFunds, "Insufficient funds")
+}
+
+func (n *Node) oldestOutput(address common.Address) int64 {
+	oldest := n.tip().height + 1
+	for _, out := range n.utxos {
+		if out.address == address && out.height < oldest {
+			oldest = out.height
+		}
+	}
+	return oldest
+}
+
+// Sender given as an optional base58 or hex parameter, otherwise the wallet's choice
+func (n *Node) senderParam(params []json.RawMessage, i int, need int64) (common.Address, error) {
+	var sender string
+	if ok, err := optionalParam(params, i, &sender); err != nil {
+		return common.Address{}, err
+	} else if !ok || sender == "" {
+		return n.defaultSender(need)
+	}
+	return n.parseAddress(sender)
+}
+
+func gasParams(params []json.RawMessage, i int, defaultGasLimit uint64) (gasLimit, gasPrice uint64, err error) {
+	gasLimit = defaultGasLimit
+	if _, err := optionalParam(params, i, &gasLimit); err != nil {
+		return 0, 0, err
+	}
+	if gasLimit > maxGasLimit {
+		return 0, 0, newRPCError(errCodeType, "Invalid value for gasLimit (maximum is: %d)", maxGasLimit)
+	}
+
+	gasPrice = defaultGasPrice
+	if i+1 < len(params) {
+		price, err := parseAmount(params[i+1])
+		if err != nil {
+			return 0, 0, err
+		}
+		if price < defaultGasPrice {
+			return 0, 0, newRPCError(errCodeType, "Invalid value for gasPrice (minimum is: 0.0000004)")
+		}
+		gasPrice = uint64(price)
+	}
+	return gasLimit, gasPrice, nil
+}
+
+func getHexAddress(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var address string
+	if err := requiredParam(params, 0, &address); err != nil {
+		return nil, err
+	}
+	parsed, err := n.parseBase58Address(address)
+	if err != nil {
+		return nil, err
+	}
+	return hex.EncodeToString(parsed.Bytes()), nil
+}
+
+func fromHexAddress(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var address string
+	if err := requiredParam(params, 0, &address); err != nil {
+		return nil, err
+	}
+	decoded, err := hex.DecodeString(address)
+	if err != nil || len(decoded) != common.AddressLength {
+		return nil, newRPCError(errCodeInvalidAddress, "Invalid pubkeyhash address")
+	}
+	return n.base58Address(common.BytesToAddress(decoded)), nil
+}
+
+// Takes a single address or {"addresses": [...]}
+func (n *Node) addressesParam(params []json.RawMessage) ([]common.Address, error) {
+	var single string
+	var request struct {
+		Addresses []string `json:"addresses"`
+	}
+	if err := requiredParam(params, 0, &single); err == nil {
+		request.Addresses = []string{single}
+	} else if err := requiredParam(params, 0, &request); err != nil {
+		return nil, err
+	}
+
+	addresses := make([]common.Address, len(request.Addresses))
+	for i, address := range request.Addresses {
+		parsed, err := n.parseBase58Address(address)
+		if err != nil {
+			return nil, err
+		}
+		addresses[i] = parsed
+	}
+	return addresses, nil
+}
+
+func contains(addresses []common.Address, address common.Address) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+// Balance of confirmed outputs, like kaond's address index
+func getAddressBalance(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	addresses, err := n.addressesParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var balance, received, immature int64
+	for _, out := range n.utxos {
+		if !contains(addresses, out.address) {
+			continue
+		}
+		balance += out.value
+		if !n.isMature(out) {
+			immature += out.value
+		}
+	}
+	for _, b := range n.blocks {
+		for _, tx := range b.transactions {
+			for _, out := range tx.msg.TxOut {
+				if script := classifyScript(out.PkScript); script.kind == scriptPubKeyHash && contains(addresses, script.address) {
+					received += out.Value
+				}
+			}
+		}
+	}
+	return map[string]interface{}{
+		"balance":  formatBalance(balance),
+		"received": formatBalance(received),
+		"immature": formatBalance(immature),
+	}, nil
+}
+
+func getAddressUTXOs(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	addresses, err := n.addressesParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]interface{}{}
+	for _, outpoint := range n.sortedUTXOs() {
+		out := n.utxos[outpoint]
+		if !contains(addresses, out.address) {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"address":     n.base58Address(out.address),
+			"txid":        outpoint.Hash.String(),
+			"outputIndex": outpoint.Index,
+			"script":      hex.EncodeToString(out.script),
+			"satoshis":    formatAmount(out.value),
+			"height":      out.height,
+			"isStake":     false,
+		})
+	}
+	return result, nil
+}
+
+// Takes {"addresses": [...], "start": n, "end": n}, the balance changes of the addresses by the transactions
+// of the active chain, in block order
+func getAddressDeltas(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var heights struct {
+		Start int64 `json:"start"`
+		End   int64 `json:"end"`
+	}
+	if len(params) > 0 {
+		// a single address has no heights
+		json.Unmarshal(params[0], &heights)
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	addresses, err := n.addressesParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := int64(0), n.tip().height
+	if heights.Start != 0 || heights.End != 0 {
+		if heights.Start <= 0 || heights.End <= 0 {
+			return nil, newRPCError(errCodeInvalidAddress, "Start and end are expected to be greater than zero")
+		}
+		if heights.End < heights.Start {
+			return nil, newRPCError(errCodeInvalidAddress, "End value is expected to be greater than start")
+		}
+		start = heights.Start
+		if heights.End < end {
+			end = heights.End
+		}
+	}
+
+	// pay to public key hash outputs seen so far, to find the owner of spent outputs
+	outputs := make(map[wire.OutPoint]*utxo)
+	result := []map[string]interface{}{}
+	addDelta := func(b *block, blockIndex int, tx *transaction, index int, address common.Address, value int64) {
+		if b.height < start || !contains(addresses, address) {
+			return
+		}
+		result = append(result, map[string]interface{}{
+			"satoshis":   formatBalance(value),
+			"txid":       tx.hash.String(),
+			"index":      index,
+			"blockindex": blockIndex,
+			"height":     b.height,
+			"address":    n.base58Address(address),
+		})
+	}
+	for _, b := range n.blocks[:end+1] {
+		for blockIndex, tx := range b.transactions {
+			if !tx.isCoinbase() {
+				for i, in := range tx.msg.TxIn {
+					if spent, ok := outputs[in.PreviousOutPoint]; ok {
+						addDelta(b, blockIndex, tx, i, spent.address, -spent.value)
+					}
+				}
+			}
+			for i, out := range tx.msg.TxOut {
+				script := classifyScript(out.PkScript)
+				if script.kind != scriptPubKeyHash {
+					continue
+				}
+				outputs[wire.OutPoint{Hash: tx.hash, Index: uint32(i)}] = &utxo{address: script.address, value: out.Value}
+				addDelta(b, blockIndex, tx, i, script.address, out.Value)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Confirmed outputs by height
+func (n *Node) sortedUTXOs() []wire.OutPoint {
+	outpoints := make([]wire.OutPoint, 0, len(n.utxos))
+	for outpoint := range n.utxos {
+		outpoints = append(outpoints, outpoint)
+	}
+	sort.Slice(outpoints, func(i, j int) bool {
+		a, b := n.utxos[outpoints[i]], n.utxos[outpoints[j]]
+		if a.height != b.height {
+			return a.height < b.height
+		}
+		return compareOutPoints(outpoints[i], outpoints[j]) < 0
+	})
+	return outpoints
+}
+
+func listUnspent(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	minConf, maxConf := int64(1), int64(9999999)
+	if _, err := optionalParam(params, 0, &minConf); err != nil {
+		return nil, err
+	}
+	if _, err := optionalParam(params, 1, &maxConf); err != nil {
+		return nil, err
+	}
+	var filter []string
+	if _, err := optionalParam(params, 2, &filter); err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	var addresses []common.Address
+	for _, address := range filter {
+		parsed, err := n.parseBase58Address(address)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, parsed)
+	}
+
+	result := []map[string]interface{}{}
+	for _, outpoint := range n.sortedUTXOs() {
+		out := n.utxos[outpoint]
+		confirmations := n.tip().height - out.height + 1
+		if n.mempoolSpent[outpoint] || confirmations < minConf || confirmations > maxConf {
+			continue
+		}
+		if len(addresses) != 0 && !contains(addresses, out.address) {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"txid":          outpoint.Hash.String(),
+			"vout":          outpoint.Index,
+			"address":       n.base58Address(out.address),
+			"label":         "",
+			"scriptPubKey":  hex.EncodeToString(out.script),
+			"amount":        formatAmount(out.value),
+			"confirmations": confirmations,
+			"spendable":     n.isMature(out),
+			"solvable":      true,
+			"safe":          true,
+		})
+	}
+	return result, nil
+}
+
+// Number of contracts the address created, the EVM nonce
+// Takes [address, blockNumber], the nonce at the block defaults to the tip's
+func getAddressNonce(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var address string
+	if err := requiredParam(params, 0, &address); err != nil {
+		return nil, err
+	}
+	var height int64 = -1
+	if _, err := optionalParam(params, 1, &height); err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	parsed, err := n.parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := n.stateAt(height)
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetNonce(parsed), nil
+}
+
+// Takes [address, amount, comment, comment_to, subtractfeefromamount, replaceable, conf_target, estimate_mode, avoid_reuse, senderaddress, changeToSender]
+func sendToAddress(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var address string
+	if err := requiredParam(params, 0, &address); err != nil {
+		return nil, err
+	}
+	if len(params) < 2 {
+		return nil, newRPCError(errCodeInvalidParams, "missing amount")
+	}
+	amount, err := parseAmount(params[1])
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		return nil, newRPCError(errCodeType, "Invalid amount for send")
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	receiver, err := n.parseBase58Address(address)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := n.senderParam(params, 9, amount+walletFee)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := n.send(sender, []*wire.TxOut{{Value: amount, PkScript: payToPubKeyHashScript(receiver)}}, walletFee)
+	if err != nil {
+		return nil, err
+	}
+	return tx.hash.String(), nil
+}
+
+// Mines blocks right away, the reward goes to the given base58 or hex address
+func generateToAddress(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var blocks int
+	var address string
+	if err := requiredParam(params, 0, &blocks); err != nil {
+		return nil, err
+	}
+	if err := requiredParam(params, 1, &address); err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	miner, err := n.parseAddress(address)
+	if err != nil {
+		return nil, newRPCError(errCodeInvalidAddress, "Error: Invalid address")
+	}
+
+	hashes := []string{}
+	for i := 0; i < blocks; i++ {
+		b, err := n.mine(miner)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, b.hash.String())
+	}
+	return hashes, nil
+}
+
+// Wallet transactions are every transaction of the node, there's a single wallet
+func getTransaction(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var txid string
+	if err := requiredParam(params, 0, &txid); err != nil {
+		return nil, err
+	}
+	hash, err := parseHash(txid)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	tx, ok := n.transactions[hash]
+	if !ok {
+		return nil, newRPCError(errCodeInvalidAddress, "Invalid or non-wallet transaction id")
+	}
+
+	details := n.transactionJSON(tx)
+	var fee int64
+	if !tx.isCoinbase() {
+		for _, in := range tx.msg.TxIn {
+			if prev, ok := n.transactions[in.PreviousOutPoint.Hash]; ok {
+				fee += prev.msg.TxOut[in.PreviousOutPoint.Index].Value
+			}
+		}
+		for _, out := range tx.msg.TxOut {
+			fee -= out.Value
+		}
+	}
+	result := map[string]interface{}{
+		"amount":             formatAmount(0),
+		"fee":                formatAmount(-fee),
+		"confirmations":      n.confirmations(tx.block),
+		"txid":               details.TxID,
+		"walletconflicts":    []string{},
+		"time":               tx.time,
+		"timereceived":       tx.time,
+		"bip125-replaceable": "no",
+		"details":            []interface{}{},
+		"hex":                details.Hex,
+	}
+	if tx.isCoinbase() {
+		result["generated"] = true
+	}
+	if tx.block != nil {
+		result["blockhash"] = details.BlockHash
+		result["blockindex"] = tx.index
+		result["blocktime"] = details.BlockTime
+	}
+	return result, nil
+}
+
+func createWallet(ctx context.Context, n *Node, params []json.RawMessage) (interface{}, error) {
+	var name string
+	if _, err := optionalParam(params, 0, &name); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"name": name, "warning": ""}, nil
+}
@@ -56,10 +56,17 @@ func (d *doerMappedMock) updateId(id int) {
 func (d *doerMappedMock) Do(request *http.Request) (*http.Response, error) {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
-	requestJSON, err := parseRequestFromBody(request)
+	requestBody, err := ioutil.ReadAll(request.Body)
 	if err != nil {
 		return nil, err
 	}
+	if trimmed := bytes.TrimSpace(requestBody); len(trimmed) != 0 && trimmed[0] == '[' {
+		return d.doBatch(trimmed)
+	}
+	requestJSON := &eth.JSONRPCRequest{}
+	if err := json.Unmarshal(requestBody, requestJSON); err != nil {
+		return nil, err
+	}
 
 	if d.Responses[requestJSON.Method] == nil {
 		log.Printf("No mocked response for %s\n", requestJSON.Method)
@@ -72,6 +79,60 @@ func (d *doerMappedMock) Do(request *http.Request) (*http.Response, error) {
 	}, nil
 }
 
+// type for mocking requests to client with responses recorded by --record, requests that
+// were not recorded are answered with the responses added to the mapped mock
+type doerReplayMock struct {
+	*doerMappedMock
+	replayer *kaon.Replayer
+}
+
+func NewDoerReplayMock(fixture string) (*doerReplayMock, error) {
+	replayer, err := kaon.NewReplayerFromFile(fixture)
+	if err != nil {
+		return nil, err
+	}
+	mappedMock := NewDoerMappedMock()
+	replayer.Fallback = mappedMock
+	return &doerReplayMock{
+		doerMappedMock: mappedMock,
+		replayer:       replayer,
+	}, nil
+}
+
+func (d *doerReplayMock) Do(request *http.Request) (*http.Response, error) {
+	return d.replayer.Do(request)
+}
+
+// answers each request of a batch with the next response of its method, requests without response are left out
+func (d *doerMappedMock) doBatch(requestBody []byte) (*http.Response, error) {
+	var requests []eth.JSONRPCRequest
+	if err := json.Unmarshal(requestBody, &requests); err != nil {
+		return nil, err
+	}
+	responses := make([]*eth.JSONRPCResult, 0, len(requests))
+	for _, requestJSON := range requests {
+		responseRaw := d.popResponse(requestJSON.Method)
+		if responseRaw == nil {
+			log.Printf("No mocked response for %s\n", requestJSON.Method)
+			continue
+		}
+		response := &eth.JSONRPCResult{}
+		if err := json.Unmarshal(responseRaw, response); err != nil {
+			return nil, err
+		}
+		response.ID = requestJSON.ID
+		responses = append(responses, response)
+	}
+	responseBody, err := json.Marshal(responses)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader(responseBody)),
+	}, nil
+}
+
 func PrepareEthRPCRequest(id int, params []json.RawMessage) (*eth.JSONRPCRequest, error) {
 	requestID, err := json.Marshal(1)
 	if err != nil {
@@ -214,21 +275,6 @@ func (d *doerMappedMock) AddErrorWithRequestID(requestID int, requestType string
 	return nil
 }
 
-func parseRequestFromBody(request *http.Request) (*eth.JSONRPCRequest, error) {
-	requestJSON := eth.JSONRPCRequest{}
-	requestBody, err := ioutil.ReadAll(request.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(requestBody, &requestJSON)
-	if err != nil {
-		return nil, err
-	}
-
-	return &requestJSON, err
-}
-
 func CreateMockedClient(doerInstance Doer) (kaonClient *kaon.Kaon, err error) {
 	return CreateMockedClientForNetwork(doerInstance, "test")
 }
@@ -509,7 +555,7 @@ func SetupGetBlockByHashResponsesWithVouts(t *testing.T, vouts []*kaon.DecodedRa
 			Abandoned: false}},
 		Hex: "020000000159c0514feea50f915854d9ec45bc6458bb14419c78b17e7be3f7fd5f563475b5010000006a473044022072d64a1f4ea2d54b7b05050fc853ab192c91cc5ca17e23007867f92f2ab59d9202202b8c9ab9348c8edbb3b98b1788382c8f37642ec9bd6a4429817ab79927319200012103520b1500a400483f19b93c4cb277a2f29693ea9d6739daaf6ae6e971d29e3140feffffff02000000000000000063010403400d0301644440c10f190000000000000000000000006b22910b1e302cf74803ffd1691c2ecb858d3712000000000000000000000000000000000000000000000000000000000000000a14be528c8378ff082e4ba43cb1baa363dbf3f577bfc260e66272970100001976a9146b22910b1e302cf74803ffd1691c2ecb858d371288acb00f0000",
 	}
-	err = mockedClientDoer.AddResponse(kaon.MethodGetTransaction, getTransactionResponse)
+	err = mockedClientDoer.AddResponse(kaon.MethodGetTransaction, &getTransactionResponse)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -3,10 +3,22 @@ package kaon
 import (
 	"encoding/hex"
 
-	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/address"
 )
 
+// Schemes the accounts of the gateway sign messages with
+const (
+	// keccak256 of the message prefixed with "\x19Ethereum Signed Message:\n" and its length, as personal_sign
+	// does on Ethereum, signatures are r || s || v
+	SignSchemeEthereum = "ethereum"
+	// double SHA256 of the message prefixed with "\x15Kaon Signed Message:\n" and its length, as kaond's
+	// signmessage does, signatures are compact signatures starting with their recovery byte
+	SignSchemeKaon = "kaon"
+)
+
+var SignSchemes = []string{SignSchemeEthereum, SignSchemeKaon}
+
 type Accounts []*btcutil.WIF
 
 func (as Accounts) FindByHexAddress(addr string) *btcutil.WIF {
@@ -32,27 +44,15 @@ func (a *Account) ToHexAddress() string {
 	return hex.EncodeToString(keyid)
 }
 
-var kaonMainNetParams = chaincfg.MainNetParams
-var kaonTestNetParams = chaincfg.MainNetParams
-
-func init() {
-	kaonMainNetParams.PubKeyHashAddrID = 58
-	kaonMainNetParams.ScriptHashAddrID = 50
-
-	kaonTestNetParams.PubKeyHashAddrID = 120
-	kaonTestNetParams.ScriptHashAddrID = 110
-}
-
 func (a *Account) ToBase58Address(isMain bool) (string, error) {
-	params := &kaonMainNetParams
+	params := &address.MainNetParams
 	if !isMain {
-		params = &kaonTestNetParams
+		params = &address.TestNetParams
 	}
 
-	addr, err := btcutil.NewAddressPubKey(a.SerializePubKey(), params)
+	addr, err := address.New(address.PubKeyHash, btcutil.Hash160(a.SerializePubKey()))
 	if err != nil {
 		return "", err
 	}
-
-	return addr.AddressPubKeyHash().String(), nil
+	return addr.Encode(params)
 }
@@ -0,0 +1,49 @@
+package kaon
+
+import (
+	"context"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/address"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+)
+
+// Converts addresses of the chain kaond is on without querying kaond, nil if the chain has no known address prefixes
+func (c *Kaon) AddressCodec() *address.Codec {
+	codec, err := address.ForChain(c.Chain())
+	if err != nil {
+		return nil
+	}
+	return codec
+}
+
+// Converts an Ethereum address to a base58 P2PKH address, like kaond's fromhexaddress
+func (c *Kaon) FromHexAddress(addr string) (string, error) {
+	return c.FromHexAddressWithContext(nil, addr)
+}
+
+func (c *Kaon) FromHexAddressWithContext(ctx context.Context, addr string) (string, error) {
+	codec := c.AddressCodec()
+	if codec == nil {
+		return c.Method.FromHexAddressWithContext(ctx, utils.RemoveHexPrefix(addr))
+	}
+	base58Address, err := codec.FromHex(addr)
+	if err != nil && c.IsDebugEnabled() {
+		c.GetDebugLogger().Log("function", "FromHexAddress", "Address", addr, "error", err)
+	}
+	return base58Address, err
+}
+
+// Converts a base58 or bech32 address to an Ethereum address without 0x prefix
+func (c *Kaon) Base58AddressToHex(addr string) (string, error) {
+	return c.GetHexAddress(nil, addr)
+}
+
+// Converts a base58 or bech32 address to an Ethereum address without 0x prefix, unlike kaond's
+// gethexaddress P2SH and P2WPKH addresses are supported on chains whose prefixes for them are known
+func (c *Kaon) GetHexAddress(ctx context.Context, addr string) (string, error) {
+	codec := c.AddressCodec()
+	if codec == nil {
+		return c.Method.GetHexAddress(ctx, addr)
+	}
+	return codec.ToHex(addr)
+}
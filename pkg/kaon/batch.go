@@ -0,0 +1,261 @@
+package kaon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// number of requests sent to kaond in a single JSON-RPC array
+	DefaultBatchSize = 50
+	// number of batches of a single call sent to kaond at the same time
+	DefaultBatchConcurrency = 4
+)
+
+var ErrMissingBatchResponse = errors.New("kaond did not answer this request of the batch")
+
+// A request sent as part of a batch, Result is filled in place unless Error is set
+type BatchElem struct {
+	Method string
+	Params interface{}
+	Result interface{}
+	Error  error
+}
+
+func (c *Client) batchSize() int {
+	if size := c.GetFlagInt(FLAG_BATCH_SIZE); size != nil {
+		return *size
+	}
+	return DefaultBatchSize
+}
+
+func (c *Client) batchConcurrency() int {
+	if concurrency := c.GetFlagInt(FLAG_BATCH_CONCURRENCY); concurrency != nil && *concurrency > 0 {
+		return *concurrency
+	}
+	return DefaultBatchConcurrency
+}
+
+// Sends the requests to kaond in JSON-RPC arrays of at most the batch size, a few at a time.
+// A request failing only sets the Error of its element, the returned error is set if the context is cancelled.
+// Requests of a batch kaond could not answer at all are retried one by one. Like single requests, they
+// bypass the cache with a context from WithoutCache.
+func (c *Client) BatchRequestWithContext(ctx context.Context, elems []*BatchElem) error {
+	if ctx == nil {
+		ctx = c.GetContext()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	pending := make([]*BatchElem, 0, len(elems))
+	for _, elem := range elems {
+		if !c.fromCache(ctx, elem) {
+			pending = append(pending, elem)
+		}
+	}
+
+	size := c.batchSize()
+	if size <= 1 {
+		// batching disabled
+		for _, elem := range pending {
+			elem.Error = c.RequestWithContext(ctx, elem.Method, elem.Params, elem.Result)
+		}
+		return ctx.Err()
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, c.batchConcurrency())
+	for start := 0; start < len(pending); start += size {
+		end := start + size
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			for _, elem := range pending[start:] {
+				elem.Error = ctx.Err()
+			}
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if err := c.doBatch(ctx, batch); err != nil {
+				c.GetDebugLogger().Log("msg", "Batch request failed, sending its requests one by one", "requests", len(batch), "error", err)
+				for _, elem := range batch {
+					elem.Error = c.RequestWithContext(ctx, elem.Method, elem.Params, elem.Result)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// Serves an element from the client cache, returns false if it has to be requested
+func (c *Client) fromCache(ctx context.Context, elem *BatchElem) bool {
+	if !c.cache.isCachable(elem.Method) || bypassesCache(ctx) {
+		return false
+	}
+	cachedResult, err := c.cache.getResponse(elem.Method, elem.Params)
+	if cachedResult == nil || err != nil {
+		return false
+	}
+	if err := json.Unmarshal(cachedResult, elem.Result); err != nil {
+		return false
+	}
+	return true
+}
+
+// Sends a single JSON-RPC array, the returned error is set if kaond did not answer with an array
+func (c *Client) doBatch(ctx context.Context, elems []*BatchElem) error {
+	reqs := make([]*JSONRPCRequest, len(elems))
+	byID := make(map[string]*BatchElem, len(elems))
+	for i, elem := range elems {
+		req, err := c.NewRPCRequest(elem.Method, elem.Params)
+		if err != nil {
+			return errors.WithMessage(err, "couldn't make new rpc request")
+		}
+		reqs[i] = req
+		byID[string(req.ID)] = elem
+	}
+
+	reqBody, err := json.Marshal(reqs)
+	if err != nil {
+		return err
+	}
+	if c.IsDebugEnabled() && !c.GetFlagBool(FLAG_HIDE_KAOND_LOGS) && c.logWriter != nil {
+		fmt.Fprintf(c.logWriter, "=> Kaon RPC batch request of %d requests\n", len(reqs))
+	}
+
+	respBody, err := c.do(ctx, bytes.NewReader(reqBody))
+	if err != nil {
+		defer c.failure()
+		return errors.Wrap(err, "Client#do")
+	}
+	var results []*JSONRPCResult
+	if err := json.Unmarshal(respBody, &results); err != nil {
+		defer c.failure()
+		if string(respBody) == ErrKaonWorkQueueDepth.Error() {
+			return ErrKaonWorkQueueDepth
+		}
+		return errors.Wrap(err, "couldn't parse batch response")
+	}
+	defer c.success()
+
+	for _, elem := range elems {
+		elem.Error = ErrMissingBatchResponse
+	}
+	for _, res := range results {
+		if res == nil {
+			continue
+		}
+		elem, ok := byID[string(res.ID)]
+		if !ok {
+			continue
+		}
+		if res.Error != nil {
+			elem.Error = res.Error.TryGetKnownError()
+			continue
+		}
+		if err := json.Unmarshal(res.RawResult, elem.Result); err != nil {
+			elem.Error = errors.Wrap(err, "couldn't unmarshal response result field")
+			continue
+		}
+		elem.Error = nil
+		if c.cache.isCachable(elem.Method) && !bypassesCache(ctx) {
+			c.cache.storeResponse(elem.Method, elem.Params, res.RawResult)
+		}
+	}
+	return nil
+}
+
+// Batched GetRawTransaction, transactions kaond failed to serve are missing from the result
+func (m *Method) GetRawTransactions(ctx context.Context, txIDs []string, hexEncoded bool) map[string]*GetRawTransactionResponse {
+	elems := make([]*BatchElem, len(txIDs))
+	for i, txID := range txIDs {
+		elems[i] = &BatchElem{
+			Method: MethodGetRawTransaction,
+			Params: &GetRawTransactionRequest{TxID: txID, Verbose: !hexEncoded},
+			Result: new(GetRawTransactionResponse),
+		}
+	}
+	m.BatchRequestWithContext(ctx, elems)
+
+	txs := make(map[string]*GetRawTransactionResponse, len(txIDs))
+	for i, elem := range elems {
+		if elem.Error != nil {
+			if m.IsDebugEnabled() {
+				m.GetDebugLogger().Log("function", "GetRawTransactions", "Transaction ID", txIDs[i], "error", elem.Error)
+			}
+			continue
+		}
+		txs[txIDs[i]] = elem.Result.(*GetRawTransactionResponse)
+	}
+	return txs
+}
+
+// Batched GetBlockHash, fails with the error of the first block kaond failed to serve
+func (m *Method) GetBlockHashes(ctx context.Context, heights []*big.Int) ([]GetBlockHashResponse, error) {
+	hashes := make([]GetBlockHashResponse, len(heights))
+	elems := make([]*BatchElem, len(heights))
+	for i, height := range heights {
+		elems[i] = &BatchElem{
+			Method: MethodGetBlockHash,
+			Params: &GetBlockHashRequest{Int: height},
+			Result: &hashes[i],
+		}
+	}
+	if err := m.BatchRequestWithContext(ctx, elems); err != nil {
+		return nil, err
+	}
+	for i, elem := range elems {
+		if elem.Error != nil {
+			if m.IsDebugEnabled() {
+				m.GetDebugLogger().Log("function", "GetBlockHashes", "Block", heights[i].String(), "error", elem.Error)
+			}
+			return nil, elem.Error
+		}
+	}
+	return hashes, nil
+}
+
+// Batched GetTransactionReceipts, fails with the error of the first transaction kaond failed to serve
+func (m *Method) GetTransactionsReceipts(ctx context.Context, txHashes []string) (map[string]GetTransactionReceiptsResponse, error) {
+	receipts := make([]GetTransactionReceiptsResponse, len(txHashes))
+	elems := make([]*BatchElem, len(txHashes))
+	for i, txHash := range txHashes {
+		elems[i] = &BatchElem{
+			Method: MethodGetTransactionReceipt,
+			Params: GetTransactionReceiptRequest(txHash),
+			Result: &receipts[i],
+		}
+	}
+	if err := m.BatchRequestWithContext(ctx, elems); err != nil {
+		return nil, err
+	}
+	result := make(map[string]GetTransactionReceiptsResponse, len(txHashes))
+	for i, elem := range elems {
+		if elem.Error != nil {
+			if m.IsDebugEnabled() {
+				m.GetDebugLogger().Log("function", "GetTransactionsReceipts", "Transaction Hash", txHashes[i], "error", elem.Error)
+			}
+			return nil, elem.Error
+		}
+		result[txHashes[i]] = receipts[i]
+	}
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package kaon
+
+import (
+	"context"
+	"sync"
+)
+
+// Block-wide transaction and log positions are derived from a verbose getblock and a searchlogs over the
+// block, which is too much to repeat for every log served. They can't change for a block hash, so once
+// derived they're kept in the response cache by block hash, and in the stores to outlive it.
+
+// Keeps serialized block log positions beyond the response cache, e.g. in the log index
+type BlockLogPositionsStore interface {
+	// ok is false if the positions of the block weren't derived yet
+	GetBlockLogPositions(blockHash string) (positions []byte, ok bool, err error)
+	PutBlockLogPositions(blockHash string, positions []byte) error
+}
+
+type blockLogPositionsStores struct {
+	mutex  sync.RWMutex
+	stores []BlockLogPositionsStore
+}
+
+func (s *blockLogPositionsStores) add(store BlockLogPositionsStore) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stores = append(s.stores, store)
+}
+
+func (s *blockLogPositionsStores) get() []BlockLogPositionsStore {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.stores
+}
+
+// Persists derived block log positions in store on top of the response cache
+func (c *Kaon) AddBlockLogPositionsStore(store BlockLogPositionsStore) {
+	c.blockLogPositions.add(store)
+}
+
+// Serialized log positions derived before for a block, ok is false if they have to be derived
+func (c *Kaon) BlockLogPositions(ctx context.Context, blockHash string) (positions []byte, ok bool) {
+	blockHash = normalizeHash(blockHash)
+	if positions := c.cache.getBlockLogPositions(ctx, blockHash); positions != nil {
+		return positions, true
+	}
+	for _, store := range c.blockLogPositions.get() {
+		positions, ok, err := store.GetBlockLogPositions(blockHash)
+		if err != nil {
+			c.GetErrorLogger().Log("msg", "Couldn't look up block log positions", "blockHash", blockHash, "err", err)
+			continue
+		}
+		if ok {
+			c.cache.storeBlockLogPositions(ctx, blockHash, positions)
+			return positions, true
+		}
+	}
+	return nil, false
+}
+
+// Remembers the serialized log positions derived for a block for BlockLogPositions
+func (c *Kaon) StoreBlockLogPositions(ctx context.Context, blockHash string, positions []byte) {
+	blockHash = normalizeHash(blockHash)
+	c.cache.storeBlockLogPositions(ctx, blockHash, positions)
+	for _, store := range c.blockLogPositions.get() {
+		if err := store.PutBlockLogPositions(blockHash, positions); err != nil {
+			c.GetErrorLogger().Log("msg", "Couldn't persist block log positions", "blockHash", blockHash, "err", err)
+		}
+	}
+}
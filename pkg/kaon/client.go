@@ -21,6 +21,8 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/kaonone/eth-rpc-gate/pkg/analytics"
 	"github.com/kaonone/eth-rpc-gate/pkg/blockhash"
+	"github.com/kaonone/eth-rpc-gate/pkg/cache"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -29,6 +31,18 @@ var FLAG_IGNORE_UNKNOWN_TX = "IGNORE_UNKNOWN_TX"
 var FLAG_DISABLE_SNIPPING_LOGS = "DISABLE_SNIPPING_LOGS"
 var FLAG_HIDE_KAOND_LOGS = "HIDE_KAOND_LOGS"
 var FLAG_MATURE_BLOCK_HEIGHT_OVERRIDE = "FLAG_MATURE_BLOCK_HEIGHT_OVERRIDE"
+var FLAG_GETLOGS_MAX_BLOCK_RANGE = "GETLOGS_MAX_BLOCK_RANGE"
+var FLAG_GETLOGS_MAX_RESULTS = "GETLOGS_MAX_RESULTS"
+var FLAG_GETLOGS_CHUNK_SIZE = "GETLOGS_CHUNK_SIZE"
+var FLAG_ETH_HEADERS = "ETH_HEADERS"
+var FLAG_ETH_HEADERS_START_HEIGHT = "ETH_HEADERS_START_HEIGHT"
+var FLAG_BATCH_SIZE = "BATCH_SIZE"
+var FLAG_BATCH_CONCURRENCY = "BATCH_CONCURRENCY"
+var FLAG_SAFE_DEPTH = "SAFE_DEPTH"
+var FLAG_FINALIZED_DEPTH = "FINALIZED_DEPTH"
+var FLAG_SIGN_SCHEME = "SIGN_SCHEME"
+var FLAG_TX_REBROADCAST_LIMIT = "TX_REBROADCAST_LIMIT"
+var FLAG_TX_POLL_INTERVAL = "TX_POLL_INTERVAL"
 
 var maximumRequestTime = int((6 * time.Second).Milliseconds())
 var maximumBackoff = (2 * time.Second).Milliseconds()
@@ -154,30 +168,55 @@ func (c *Client) RequestWithContext(ctx context.Context, method string, params i
 		ctx = c.GetContext()
 	}
 
-	// check if method is cacheable first
-	if c.cache.isCachable(method) {
-		c.cache.setContext(ctx)
-		// check if we have a cached result
-		cachedResult, err := c.cache.getResponse(method, params)
-		if cachedResult != nil && err == nil {
-			// we have a cached result, return it
-			err := json.Unmarshal(cachedResult, result)
-			if err != nil {
-				c.GetDebugLogger().Log("method", method, "params", params, "result", result, "error", err)
-				return errors.Wrap(err, "couldn't unmarshal response result field")
-			}
-			if c.IsDebugEnabled() && !c.GetFlagBool(FLAG_HIDE_KAOND_LOGS) {
-				c.printRPCRequest(method, params)
-				c.printCachedRPCResponse(cachedResult)
-			}
-			return nil
+	if !c.cache.isCachable(method) || bypassesCache(ctx) {
+		rawResult, err := c.request(ctx, method, params)
+		if err != nil {
+			return err
 		}
+		return c.unmarshalResult(method, params, rawResult, result)
 	}
-	// we don't have a cached result, so we need to make a request
-	req, err := c.NewRPCRequest(method, params)
+
+	key, err := cacheKey(method, params)
 	if err != nil {
 		return errors.WithMessage(err, "couldn't make new rpc request")
 	}
+	// check if we have a cached result
+	if cachedResult := c.cache.get(ctx, method, key); cachedResult != nil {
+		if c.IsDebugEnabled() && !c.GetFlagBool(FLAG_HIDE_KAOND_LOGS) {
+			c.printRPCRequest(method, params)
+			c.printCachedRPCResponse(cachedResult)
+		}
+		return c.unmarshalResult(method, params, cachedResult, result)
+	}
+	// identical requests already in flight share the response of the first one
+	rawResult, err := c.cache.do(key, func() ([]byte, error) {
+		rawResult, err := c.request(ctx, method, params)
+		if err == nil {
+			c.cache.store(ctx, method, key, rawResult)
+		}
+		return rawResult, err
+	})
+	if err != nil {
+		return err
+	}
+	return c.unmarshalResult(method, params, rawResult, result)
+}
+
+func (c *Client) unmarshalResult(method string, params interface{}, rawResult []byte, result interface{}) error {
+	err := json.Unmarshal(rawResult, result)
+	if err != nil {
+		c.GetDebugLogger().Log("method", method, "params", params, "result", result, "error", err)
+		return errors.Wrap(err, "couldn't unmarshal response result field")
+	}
+	return nil
+}
+
+// Sends the request to kaond, retrying while kaond is busy
+func (c *Client) request(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	req, err := c.NewRPCRequest(method, params)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't make new rpc request")
+	}
 
 	handledErrors := make(map[error]bool)
 
@@ -213,31 +252,23 @@ func (c *Client) RequestWithContext(ctx context.Context, method string, params i
 				select {
 				case <-time.After(backoffTime):
 				case <-done:
-					return errors.WithMessage(ctx.Err(), "context cancelled")
+					return nil, errors.WithMessage(ctx.Err(), "context cancelled")
 				}
 				c.GetLogger().Log("msg", "Retrying Kaon command")
 			} else {
 				if i != 0 {
 					c.GetLogger().Log("msg", fmt.Sprintf("Giving up on Kaon RPC call after %d tries since its busy", i+1))
 				}
-				return err
+				return nil, err
 			}
 		} else {
 			break
 		}
 	}
 
-	err = json.Unmarshal(resp.RawResult, result)
-	if err != nil {
-		c.GetDebugLogger().Log("method", method, "params", params, "result", result, "error", err)
-		return errors.Wrap(err, "couldn't unmarshal response result field")
-	}
-
-	if c.cache.isCachable(method) {
-		c.cache.storeResponse(method, params, resp.RawResult)
-	}
+	c.cache.observe(method, resp.RawResult)
 
-	return nil
+	return resp.RawResult, nil
 }
 
 func (c *Client) Do(ctx context.Context, req *JSONRPCRequest) (*SuccessJSONRPCResult, error) {
@@ -324,11 +355,12 @@ func (c *Client) NewRPCRequest(method string, params interface{}) (*JSONRPCReque
 
 	c.idMutex.Lock()
 	c.id = c.id.Add(c.id, c.idStep)
+	id := c.id.String()
 	c.idMutex.Unlock()
 
 	return &JSONRPCRequest{
 		JSONRPC: RPCVersion,
-		ID:      json.RawMessage(`"` + c.id.String() + `"`),
+		ID:      json.RawMessage(`"` + id + `"`),
 		Method:  method,
 		Params:  paramsJSON,
 	}, nil
@@ -497,6 +529,132 @@ func SetMatureBlockHeight(height *int) func(*Client) error {
 	}
 }
 
+func SetGetLogsMaxBlockRange(blocks int) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_GETLOGS_MAX_BLOCK_RANGE, blocks)
+		return nil
+	}
+}
+
+func SetGetLogsMaxResults(results int) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_GETLOGS_MAX_RESULTS, results)
+		return nil
+	}
+}
+
+func SetGetLogsChunkSize(blocks int) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_GETLOGS_CHUNK_SIZE, blocks)
+		return nil
+	}
+}
+
+// Sets how many blocks below the tip the safe block tag is, a negative depth keeps the chain's default
+func SetSafeDepth(blocks int) func(*Client) error {
+	return func(c *Client) error {
+		if blocks >= 0 {
+			c.SetFlag(FLAG_SAFE_DEPTH, blocks)
+		}
+		return nil
+	}
+}
+
+// Sets how many blocks below the tip the finalized block tag is, a negative depth keeps the chain's default
+func SetFinalizedDepth(blocks int) func(*Client) error {
+	return func(c *Client) error {
+		if blocks >= 0 {
+			c.SetFlag(FLAG_FINALIZED_DEPTH, blocks)
+		}
+		return nil
+	}
+}
+
+// Sets how the accounts of the gateway sign messages, one of SignSchemes
+func SetSignScheme(scheme string) func(*Client) error {
+	return func(c *Client) error {
+		if !utils.InStrSlice(SignSchemes, scheme) {
+			return errors.Errorf("invalid sign scheme '%s', expected one of %s", scheme, strings.Join(SignSchemes, ", "))
+		}
+		c.SetFlag(FLAG_SIGN_SCHEME, scheme)
+		return nil
+	}
+}
+
+// Sets how many times a transaction evicted from kaond's mempool is sent again, 0 disables rebroadcasting
+func SetTxRebroadcastLimit(times int) func(*Client) error {
+	return func(c *Client) error {
+		if times < 0 {
+			return errors.Errorf("invalid rebroadcast limit %d", times)
+		}
+		c.SetFlag(FLAG_TX_REBROADCAST_LIMIT, times)
+		return nil
+	}
+}
+
+// Sets how often transactions submitted through the gateway are looked up
+func SetTxPollInterval(interval time.Duration) func(*Client) error {
+	return func(c *Client) error {
+		if interval <= 0 {
+			return errors.Errorf("invalid transaction poll interval %s", interval)
+		}
+		c.SetFlag(FLAG_TX_POLL_INTERVAL, interval)
+		return nil
+	}
+}
+
+func SetEthHeaders(enabled bool) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_ETH_HEADERS, enabled)
+		return nil
+	}
+}
+
+// Sets the first block served with an Ethereum header, blocks below keep their Kaon hash
+func SetEthHeadersStartHeight(height int) func(*Client) error {
+	return func(c *Client) error {
+		if height < 0 {
+			return errors.Errorf("invalid Ethereum headers start height %d", height)
+		}
+		c.SetFlag(FLAG_ETH_HEADERS_START_HEIGHT, height)
+		return nil
+	}
+}
+
+func SetBatchSize(requests int) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_BATCH_SIZE, requests)
+		return nil
+	}
+}
+
+func SetBatchConcurrency(batches int) func(*Client) error {
+	return func(c *Client) error {
+		c.SetFlag(FLAG_BATCH_CONCURRENCY, batches)
+		return nil
+	}
+}
+
+// Sets the memory used by cached kaond responses in MiB, 0 disables caching
+func SetCacheSize(megabytes int) func(*Client) error {
+	return func(c *Client) error {
+		if megabytes <= 0 {
+			c.cache.setMemory(nil)
+			return nil
+		}
+		c.cache.setMemory(cache.NewMemory(int64(megabytes) << 20))
+		return nil
+	}
+}
+
+// Keeps cached kaond responses in a store shared with other gateways instead of in memory
+func SetCacheStore(store cache.Store) func(*Client) error {
+	return func(c *Client) error {
+		c.cache.setShared(store)
+		return nil
+	}
+}
+
 func SetContext(ctx context.Context) func(*Client) error {
 	return func(c *Client) error {
 		c.ctx = ctx
@@ -560,6 +718,23 @@ func SetAnalytics(analytics *analytics.Analytics) func(*Client) error {
 	}
 }
 
+func (c *Client) CacheStats() CacheStats {
+	return c.cache.getStats()
+}
+
+// Reports whether responses of method requested with ctx are cached, it isn't when the cache is disabled
+// with --cache-size 0 and no shared store or ctx comes from WithoutCache
+func (c *Client) CachesResponses(ctx context.Context, method string) bool {
+	return c.cache.isCachable(method) && !bypassesCache(ctx)
+}
+
+// Returns the store shared with other gateways, nil if the cache is kept in memory
+func (c *Client) SharedCacheStore() cache.Store {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	return c.cache.shared
+}
+
 func (c *Client) GetContext() context.Context {
 	return c.ctx
 }
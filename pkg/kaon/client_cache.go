@@ -5,16 +5,25 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/cache"
 )
 
-// sets the timeout for flushing out the cashed memory
+// sets how long a response depending on the chain tip is cached if no new block is seen in between
 const CACHABLE_METHOD_CACHE_TIMEOUT = time.Second * 30
 
+// sets how long a response that can no longer change is cached, as long as it isn't evicted
+const CACHABLE_METHOD_FINALIZED_CACHE_TIMEOUT = time.Hour
+
+// maximum memory used by cached responses in MiB
+const DefaultCacheSize = 64
+
 const (
 	KaonMethodGetblock                    = "getblock"
 	KaonMethodGetblockhash                = "getblockhash"
@@ -29,11 +38,11 @@ const (
 	UltMethodFromHexAddress               = "fromhexaddress"
 )
 
+// getblockchaininfo changes with every block and is not worth caching
 var cachable_methods = []string{
 	KaonMethodGetblock,
 	KaonMethodGetblockhash,
 	KaonMethodGetblockheader,
-	KaonMethodGetblockchaininfo,
 	KaonMethodGethexaddress,
 	KaonMethodGetrawtransaction,
 	KaonMethodGettransaction,
@@ -43,28 +52,136 @@ var cachable_methods = []string{
 	UltMethodFromHexAddress,
 }
 
-// stores the rpc response for 'method' and 'params' in the cache
-// 'methods' is a map where keys are method names and values are maps of rpc responses
+// not a kaond method, the log positions derived for a block are kept in the cache under its hash
+const cacheBlockLogPositions = "blocklogpositions"
+
+// methods whose response only depends on their params
+var immutable_methods = []string{
+	cacheBlockLogPositions,
+	KaonMethodGethexaddress,
+	KaonMethodDecoderawtransaction,
+	KaonMethodGetTransactionHashByEthHash,
+	UltMethodFromHexAddress,
+}
+
+// prefix of the keys kept in the cache store, followed by the chain
+const CacheKeyPrefix = "eth-rpc-gate:"
+
+// follows the chain in the keys of cached responses, keeping them apart from the filters and other state
+// sharing the store, which aren't dropped when the cache is flushed
+const responseKeyPrefix = "resp:"
+
+// Hit and miss counters of the kaond response cache
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	// requests answered by an identical request that was already in flight
+	Shared uint64 `json:"shared"`
+	// failed reads and writes of a shared store
+	Errors uint64 `json:"errors"`
+	// usage of the in memory store, zero when a shared store is used
+	cache.MemoryStats
+}
+
+// decides which rpc responses are cached and for how long, the responses are kept in a cache.Store.
+// Responses that can still change with the chain tip are stored under the height of the tip
+// and are no longer found once a new block is seen, responses of confirmed blocks and
+// transactions are kept until they expire or are evicted
 type clientCache struct {
-	mu        sync.RWMutex
-	ctx       context.Context
+	mu        sync.Mutex
 	logger    log.Logger
 	logWriter io.Writer
 	debug     bool
-	methods   map[string]responses
+
+	// in memory store used unless a shared store is set, caching is disabled if both are nil
+	memory *cache.Memory
+	shared cache.Store
+	// chain the cached responses belong to, responses aren't shared before it is known
+	namespace string
+
+	// confirmations after which blocks and transactions can no longer be reorganized, the finalized depth
+	// of the chain, the deepest one until it is known
+	finalityDepth int64
+
+	// requests to kaond in flight, keyed by method and params
+	calls map[string]*cacheCall
+
+	// last block height seen
+	tip   int64
+	stats CacheStats
 }
 
-// 'responses' is a map where keys are rpc param bytes, and values are response bytes (for the given method)
-type responses map[string][]byte
+type cacheCall struct {
+	wg       sync.WaitGroup
+	response []byte
+	err      error
+}
 
 func newClientCache() *clientCache {
 	return &clientCache{
-		methods: make(map[string]responses),
+		memory:        cache.NewMemory(DefaultCacheSize << 20),
+		finalityDepth: ChainFinality[ChainMain].Finalized,
+		calls:         make(map[string]*cacheCall),
+		tip:           -1,
 	}
 }
 
+// identifies a request by its method and params
+func cacheKey(method string, params interface{}) (string, error) {
+	parambytes, err := json.Marshal(params)
+	if err != nil {
+		return "", errors.New("failed to marshal params")
+	}
+	return method + " " + string(parambytes), nil
+}
+
+func (cache *clientCache) getStore() cache.Store {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.shared != nil {
+		if cache.namespace == "" {
+			return nil
+		}
+		return cache.shared
+	}
+	if cache.memory == nil {
+		return nil
+	}
+	return cache.memory
+}
+
+// prefix of the keys of finalized responses, tip dependent responses are stored under the current tip
+func (cache *clientCache) prefix(finalized bool) string {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	prefix := CacheKeyPrefix + cache.namespace + ":" + responseKeyPrefix
+	if finalized {
+		return prefix
+	}
+	return prefix + "tip-" + strconv.FormatInt(cache.tip, 10) + ":"
+}
+
+type withoutCacheKey struct{}
+
+// Returns a context whose kaond requests bypass the cache, for state changing without the tip changing,
+// like the mempool
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutCacheKey{}, true)
+}
+
+func bypassesCache(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	bypass, _ := ctx.Value(withoutCacheKey{}).(bool)
+	return bypass
+}
+
 // checks if the method should be cached
 func (cache *clientCache) isCachable(method string) bool {
+	if cache.getStore() == nil {
+		return false
+	}
 	for _, m := range cachable_methods {
 		if m == method {
 			return true
@@ -73,70 +190,225 @@ func (cache *clientCache) isCachable(method string) bool {
 	return false
 }
 
+func isImmutable(method string) bool {
+	for _, m := range immutable_methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
 // stores the rpc response for 'method' and 'params' in the cache
 func (cache *clientCache) storeResponse(method string, params interface{}, response []byte) error {
-	parambytes, err := json.Marshal(params)
+	key, err := cacheKey(method, params)
 	if err != nil {
-		return errors.New("failed to marshal params")
+		return err
 	}
+	cache.store(context.Background(), method, key, response)
+	return nil
+}
+
+// returns the cached rpc response for 'method' and 'params', nil if it isn't cached
+func (cache *clientCache) getResponse(method string, params interface{}) ([]byte, error) {
+	key, err := cacheKey(method, params)
+	if err != nil {
+		return nil, err
+	}
+	return cache.get(context.Background(), method, key), nil
+}
+
+func (cache *clientCache) getBlockLogPositions(ctx context.Context, blockHash string) []byte {
+	key, err := cacheKey(cacheBlockLogPositions, []string{blockHash})
+	if err != nil {
+		return nil
+	}
+	return cache.get(ctx, cacheBlockLogPositions, key)
+}
+
+func (cache *clientCache) storeBlockLogPositions(ctx context.Context, blockHash string, positions []byte) {
+	key, err := cacheKey(cacheBlockLogPositions, []string{blockHash})
+	if err != nil {
+		return
+	}
+	cache.store(ctx, cacheBlockLogPositions, key, positions)
+}
+
+func (cache *clientCache) get(ctx context.Context, method string, key string) []byte {
+	store := cache.getStore()
+	if store == nil {
+		return nil
+	}
+	response, err := store.Get(ctx, cache.prefix(true)+key)
+	if response == nil && err == nil && !isImmutable(method) {
+		response, err = store.Get(ctx, cache.prefix(false)+key)
+	}
+
 	cache.mu.Lock()
 	defer cache.mu.Unlock()
-	responses, ok := cache.methods[method]
-	if !ok {
-		responses = make(map[string][]byte)
-		cache.methods[method] = responses
+	if err != nil {
+		cache.stats.Errors++
+		cache.getDebugLogger().Log("msg", "failed to read cached response", "method", method, "error", err)
+		response = nil
 	}
-	if _, ok := responses[string(parambytes)]; !ok {
-		responses[string(parambytes)] = response
-		cache.setFlushResponseTimer(method, parambytes)
+	if response == nil {
+		cache.stats.Misses++
+	} else {
+		cache.stats.Hits++
 	}
-	return nil
+	return response
 }
 
-// returns the cached rpc response for 'method' and 'params'
-func (cache *clientCache) getResponse(method string, params interface{}) ([]byte, error) {
-	parambytes, err := json.Marshal(params)
-	if err != nil {
-		return nil, errors.New("failed to marshal param")
+func (cache *clientCache) store(ctx context.Context, method string, key string, response []byte) {
+	store := cache.getStore()
+	if store == nil {
+		return
 	}
-	cache.mu.RLock()
-	defer cache.mu.RUnlock()
-	if resp, ok := cache.methods[method]; ok {
-		if r, ok := resp[string(parambytes)]; ok {
-			return r, nil
+	finalized := cache.isFinalized(method, key, response)
+	ttl := CACHABLE_METHOD_CACHE_TIMEOUT
+	if finalized {
+		ttl = CACHABLE_METHOD_FINALIZED_CACHE_TIMEOUT
+	}
+	if err := store.Set(ctx, cache.prefix(finalized)+key, response, ttl); err != nil {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		cache.stats.Errors++
+		cache.getDebugLogger().Log("msg", "failed to cache response", "method", method, "error", err)
+	}
+}
+
+// checks if the response can no longer be changed by a reorganization
+func (cache *clientCache) isFinalized(method string, key string, response []byte) bool {
+	if isImmutable(method) {
+		return true
+	}
+
+	switch method {
+	case KaonMethodGetblockhash:
+		var params []int64
+		if err := json.Unmarshal([]byte(key[len(method)+1:]), &params); err != nil || len(params) != 1 {
+			return false
 		}
+		cache.mu.Lock()
+		tip, finalityDepth := cache.tip, cache.finalityDepth
+		cache.mu.Unlock()
+		return tip >= 0 && params[0]+finalityDepth <= tip
+	case KaonMethodGetrawtransaction:
+		var rawTx string
+		if json.Unmarshal(response, &rawTx) == nil {
+			// the serialized transaction is identified by its id
+			return true
+		}
+		fallthrough
+	case KaonMethodGetblock, KaonMethodGetblockheader, KaonMethodGettransaction:
+		var confirmed struct {
+			Confirmations int64 `json:"confirmations"`
+		}
+		if err := json.Unmarshal(response, &confirmed); err != nil {
+			return false
+		}
+		cache.mu.Lock()
+		finalityDepth := cache.finalityDepth
+		cache.mu.Unlock()
+		return confirmed.Confirmations > finalityDepth
 	}
-	return nil, nil
+	return false
 }
 
-// set a timer to flush the cached rpc response for 'method' and 'parambytes'
-func (cache *clientCache) setFlushResponseTimer(method string, parambytes []byte) {
-	go func() {
-		// TODO check if this works as expected
-		var done <-chan struct{}
-		if cache.ctx != nil {
-			done = cache.ctx.Done()
-		} else {
-			done = context.Background().Done()
+// follows the chain tip from block count responses, tip dependent responses stored before a new tip are no longer found
+func (cache *clientCache) observe(method string, response []byte) {
+	var tip int64
+	switch method {
+	case MethodGetBlockCount:
+		if err := json.Unmarshal(response, &tip); err != nil {
+			return
 		}
-		select {
-		case <-time.After(CACHABLE_METHOD_CACHE_TIMEOUT):
-			cache.getDebugLogger().Log("msg", "flushing cache", "reason", "cache timeout", "method", method)
-		case <-done:
-			cache.getDebugLogger().Log("msg", "flushing cache", "reason", "context canceled", "method", method)
+	case MethodGetBlockChainInfo:
+		var info struct {
+			Blocks int64 `json:"blocks"`
 		}
+		if err := json.Unmarshal(response, &info); err != nil {
+			return
+		}
+		tip = info.Blocks
+	default:
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.tip = tip
+}
+
+// runs 'request' only once for concurrent identical requests, the followers get the same response
+func (cache *clientCache) do(key string, request func() ([]byte, error)) ([]byte, error) {
+	cache.mu.Lock()
+	if call, ok := cache.calls[key]; ok {
+		cache.stats.Shared++
+		cache.mu.Unlock()
+		call.wg.Wait()
+		return call.response, call.err
+	}
+	call := &cacheCall{}
+	call.wg.Add(1)
+	cache.calls[key] = call
+	cache.mu.Unlock()
+
+	call.response, call.err = request()
+	call.wg.Done()
+
+	cache.mu.Lock()
+	delete(cache.calls, key)
+	cache.mu.Unlock()
+	return call.response, call.err
+}
+
+// drops every cached rpc response, e.g. after the active chain has been changed
+func (cache *clientCache) flush() {
+	store := cache.getStore()
+	if store == nil {
+		return
+	}
+	cache.mu.Lock()
+	cache.getDebugLogger().Log("msg", "flushing cache", "reason", "active chain changed")
+	cache.mu.Unlock()
+	if err := store.DeletePrefix(context.Background(), cache.prefix(true)); err != nil {
 		cache.mu.Lock()
 		defer cache.mu.Unlock()
-		delete(cache.methods[method], string(parambytes))
-	}()
+		cache.stats.Errors++
+		cache.getDebugLogger().Log("msg", "failed to flush cache", "error", err)
+	}
 }
 
-func (cache *clientCache) setContext(ctx context.Context) {
+func (cache *clientCache) getStats() CacheStats {
 	cache.mu.Lock()
-	defer cache.mu.Unlock()
-	if cache.ctx == nil {
-		cache.ctx = ctx
+	stats := cache.stats
+	memory := cache.memory
+	shared := cache.shared
+	cache.mu.Unlock()
+	if memory != nil && shared == nil {
+		stats.MemoryStats = memory.Stats()
 	}
+	return stats
+}
+
+func (cache *clientCache) setMemory(memory *cache.Memory) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.memory = memory
+}
+
+func (cache *clientCache) setShared(shared cache.Store) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.shared = shared
+}
+
+func (cache *clientCache) setNamespace(chain string, finalityDepth int64) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.namespace = chain
+	cache.finalityDepth = finalityDepth
 }
 
 func (cache *clientCache) configLogger(logWriter io.Writer, debug bool) {
@@ -149,7 +421,7 @@ func (cache *clientCache) configLogger(logWriter io.Writer, debug bool) {
 }
 
 func (cache *clientCache) getDebugLogger() log.Logger {
-	if !cache.isDebugEnabled() {
+	if !cache.isDebugEnabled() || cache.logWriter == nil {
 		return log.NewNopLogger()
 	}
 	if cache.logger == nil {
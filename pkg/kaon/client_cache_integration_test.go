@@ -63,34 +63,16 @@ func TestCacheWithClient(t *testing.T) {
 		assertResponseBody(t, result, test_expectedResult)
 
 		outputLog := logBuffer.String()
-		if !strings.Contains(outputLog, "kaon (CACHED) RPC response") {
-			t.Errorf("\nexpected: %s\n\n, got: %s", "kaon (CACHED) RPC response", outputLog)
+		if !strings.Contains(outputLog, "Kaon (CACHED) RPC response") {
+			t.Errorf("\nexpected: %s\n\n, got: %s", "Kaon (CACHED) RPC response", outputLog)
 		}
-
-	})
-	t.Run("Cache for getblock is flushed after timeout", func(t *testing.T) {
-		logBuffer.Reset()
-		// wait for the cache to be flushed
-		time.Sleep(CACHABLE_METHOD_CACHE_TIMEOUT + time.Millisecond*100)
-		cached_response, err := client.cache.getResponse(test_method, test_params)
-		if err != nil {
-			t.Fatal("No error expected: ", err)
-		}
-		if !bytes.Equal(cached_response, nil) {
-			t.Errorf("\nexpected: nil\n\n, got: %s", string(cached_response))
-		}
-		outputLog := logBuffer.String()
-		if !strings.Contains(outputLog, `msg="flushing cache" reason="cache timeout"`) {
-			t.Errorf("expected log message not found: %s", outputLog)
+		if stats := client.CacheStats(); stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+			t.Errorf("unexpected cache stats %+v", stats)
 		}
 	})
-	t.Run("After cache is flushed, it should be updated with a new getblock response", func(t *testing.T) {
-		err = client.Request(test_method, test_params, &result)
-		if err != nil {
-			t.Fatal(err)
-		}
-		assertResponseBody(t, result, test_expectedResult)
-
+	t.Run("Cache for confirmed getblock is kept after the tip dependent timeout", func(t *testing.T) {
+		now := time.Now()
+		client.cache.memory.SetClock(func() time.Time { return now.Add(CACHABLE_METHOD_CACHE_TIMEOUT + time.Second) })
 		cached_response, err := client.cache.getResponse(test_method, test_params)
 		if err != nil {
 			t.Fatal("No error expected: ", err)
@@ -99,10 +81,10 @@ func TestCacheWithClient(t *testing.T) {
 			t.Errorf("\nexpected: %s\n\n, got: %s", string(test_expectedResult), string(cached_response))
 		}
 	})
-	t.Run("Cache for getblock is flushed again after timeout", func(t *testing.T) {
-		logBuffer.Reset()
-		// wait for the cache to be flushed
-		time.Sleep(CACHABLE_METHOD_CACHE_TIMEOUT + time.Millisecond*100)
+	t.Run("Cache for confirmed getblock expires after the finalized timeout", func(t *testing.T) {
+		now := time.Now()
+		client.cache.memory.SetClock(func() time.Time { return now.Add(CACHABLE_METHOD_FINALIZED_CACHE_TIMEOUT + time.Second) })
+		defer client.cache.memory.SetClock(time.Now)
 		cached_response, err := client.cache.getResponse(test_method, test_params)
 		if err != nil {
 			t.Fatal("No error expected: ", err)
@@ -110,28 +92,11 @@ func TestCacheWithClient(t *testing.T) {
 		if !bytes.Equal(cached_response, nil) {
 			t.Errorf("\nexpected: nil\n\n, got: %s", string(cached_response))
 		}
-		outputLog := logBuffer.String()
-		if !strings.Contains(outputLog, `msg="flushing cache" reason="cache timeout"`) {
-			t.Errorf("expected log message not found: %s", outputLog)
+		if stats := client.CacheStats(); stats.Expirations != 1 || stats.Entries != 0 {
+			t.Errorf("unexpected cache stats %+v", stats)
 		}
 	})
-
-	// create http client with context.WithCancel to test cache flushing on cancelation
-	ctx, canceFunc := context.WithCancel(context.Background())
-	client, err = NewClient(
-		true,
-		URL,
-		SetDebug(true),
-		SetLogWriter(logWriter),
-		SetLogger(logger),
-		SetContext(ctx),
-	)
-	client.URL = kaonMockServer.URL
-	if err != nil {
-		t.Fatal(err)
-	}
-	t.Run("Cache should be flushed when ctx is canceled", func(t *testing.T) {
-		logBuffer.Reset()
+	t.Run("After cache expired, it should be updated with a new getblock response", func(t *testing.T) {
 		err = client.Request(test_method, test_params, &result)
 		if err != nil {
 			t.Fatal(err)
@@ -145,72 +110,43 @@ func TestCacheWithClient(t *testing.T) {
 		if !bytes.Equal(cached_response, test_expectedResult) {
 			t.Errorf("\nexpected: %s\n\n, got: %s", string(test_expectedResult), string(cached_response))
 		}
-
-		canceFunc()
-		// wait for the cache to be flushed after canceling the context
-		time.Sleep(time.Millisecond * 100)
-		cached_response, err = client.cache.getResponse(test_method, test_params)
+	})
+	t.Run("Cache is flushed when the active chain changes", func(t *testing.T) {
+		logBuffer.Reset()
+		client.cache.flush()
+		cached_response, err := client.cache.getResponse(test_method, test_params)
 		if err != nil {
 			t.Fatal("No error expected: ", err)
 		}
 		if !bytes.Equal(cached_response, nil) {
-			t.Errorf("\nexpected: %v\n, got: %s", nil, string(cached_response))
+			t.Errorf("\nexpected: nil\n\n, got: %s", string(cached_response))
 		}
-		// wait for the 'flushing' go routine to write to the log
-		time.Sleep(time.Millisecond * 100)
 		outputLog := logBuffer.String()
-		if !strings.Contains(outputLog, `msg="flushing cache" reason="context canceled"`) {
+		if !strings.Contains(outputLog, `msg="flushing cache" reason="active chain changed"`) {
 			t.Errorf("expected log message not found: %s", outputLog)
 		}
 	})
 
-	// create http client with context.WithTimeout to test cache flushing on cancelation
-	ctx, _ = context.WithTimeout(context.Background(), time.Second*2)
+	// a cache size of 0 disables caching
 	client, err = NewClient(
 		true,
 		URL,
-		SetDebug(true),
-		SetLogWriter(logWriter),
-		SetLogger(logger),
-		SetContext(ctx),
+		SetCacheSize(0),
 	)
-	client.URL = kaonMockServer.URL
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Run("Cache should be flushed when ctx times out", func(t *testing.T) {
-		logBuffer.Reset()
+	client.URL = kaonMockServer.URL
+	t.Run("Responses are not cached when the cache is disabled", func(t *testing.T) {
 		err = client.Request(test_method, test_params, &result)
 		if err != nil {
 			t.Fatal(err)
 		}
 		assertResponseBody(t, result, test_expectedResult)
-
-		cached_response, err := client.cache.getResponse(test_method, test_params)
-		if err != nil {
-			t.Fatal("No error expected: ", err)
-		}
-		if !bytes.Equal(cached_response, test_expectedResult) {
-			t.Errorf("\nexpected: %s\n\n, got: %s\n", string(test_expectedResult), string(cached_response))
-		}
-
-		time.Sleep(time.Second * 3)
-
-		cached_response, err = client.cache.getResponse(test_method, test_params)
-		if err != nil {
-			t.Fatal("No error expected: ", err)
-		}
-		if !bytes.Equal(cached_response, nil) {
-			t.Errorf("\nexpected: %v\n, got: %s", nil, string(cached_response))
-		}
-		// wait for the 'flushing' go routine to write to the log
-		time.Sleep(time.Millisecond * 200)
-		outputLog := logBuffer.String()
-		if !strings.Contains(outputLog, `msg="flushing cache" reason="context canceled"`) {
-			t.Errorf("expected log message not found: %s", outputLog)
+		if stats := client.CacheStats(); stats.Entries != 0 || stats.Misses != 0 {
+			t.Errorf("unexpected cache stats %+v", stats)
 		}
 	})
-
 }
 
 func NewKaonMockServer(body []byte) *httptest.Server {
@@ -0,0 +1,179 @@
+package kaon
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// kaond puts the transactions of invalidated blocks back into its mempool and has no way to drop them from it,
+// the next block would mine them again
+var ErrRevertOverTransactions = errors.New("can't revert blocks with transactions, kaond would mine them again")
+
+// Regtest state behind the Hardhat/Ganache compatible developer methods
+type devMode struct {
+	mutex        sync.Mutex
+	automine     bool
+	stopInterval chan struct{}
+
+	snapshots      []snapshot // in taking order
+	nextSnapshotID uint64
+}
+
+// Chain tip at the time a snapshot was taken
+type snapshot struct {
+	id     uint64
+	height int64
+	hash   string
+}
+
+func newDevMode() *devMode {
+	return &devMode{
+		automine:       true,
+		nextSnapshotID: 1,
+	}
+}
+
+// Whether a block is generated after each transaction sent through eth-rpc-gate on regtest
+func (c *Kaon) Automine() bool {
+	c.dev.mutex.Lock()
+	defer c.dev.mutex.Unlock()
+	return c.dev.automine
+}
+
+func (c *Kaon) SetAutomine(enabled bool) {
+	c.dev.mutex.Lock()
+	defer c.dev.mutex.Unlock()
+	c.dev.automine = enabled
+}
+
+// Generates a block every interval until interval mining is changed again, an interval of 0 disables it
+func (c *Kaon) SetIntervalMining(interval time.Duration) {
+	c.dev.mutex.Lock()
+	defer c.dev.mutex.Unlock()
+
+	if c.dev.stopInterval != nil {
+		close(c.dev.stopInterval)
+		c.dev.stopInterval = nil
+	}
+	if interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	c.dev.stopInterval = stop
+	go c.mineEvery(interval, stop)
+}
+
+func (c *Kaon) mineEvery(interval time.Duration, stop <-chan struct{}) {
+	var done <-chan struct{}
+	if c.ctx != nil {
+		done = c.ctx.Done()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := c.Generate(c.ctx, 1, nil); err != nil {
+				c.GetErrorLogger().Log("msg", "Error generating new block on interval", "err", err)
+			}
+		case <-stop:
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// Remembers the current chain tip, returns the id to revert to it with RevertToSnapshot
+func (c *Kaon) Snapshot(ctx context.Context) (uint64, error) {
+	blockCount, err := c.GetBlockCount(ctx)
+	if err != nil {
+		return 0, errors.WithMessage(err, "couldn't get block count")
+	}
+	hash, err := c.GetBlockHash(ctx, blockCount.Int)
+	if err != nil {
+		return 0, errors.WithMessage(err, "couldn't get block hash")
+	}
+
+	c.dev.mutex.Lock()
+	defer c.dev.mutex.Unlock()
+	id := c.dev.nextSnapshotID
+	c.dev.nextSnapshotID++
+	c.dev.snapshots = append(c.dev.snapshots, snapshot{
+		id:     id,
+		height: blockCount.Int64(),
+		hash:   string(hash),
+	})
+	return id, nil
+}
+
+// Invalidates the blocks mined after a snapshot was taken. Like with Hardhat, the snapshot and the ones taken
+// after it can't be reverted to again. Returns false for unknown snapshots and ErrRevertOverTransactions,
+// keeping the snapshot, if a block mined after it has transactions.
+func (c *Kaon) RevertToSnapshot(ctx context.Context, id uint64) (bool, error) {
+	c.dev.mutex.Lock()
+	defer c.dev.mutex.Unlock()
+
+	i := 0
+	for i < len(c.dev.snapshots) && c.dev.snapshots[i].id != id {
+		i++
+	}
+	if i == len(c.dev.snapshots) {
+		return false, nil
+	}
+	target := c.dev.snapshots[i]
+
+	// the snapshot's tip may have been invalidated directly on kaond, it has to be on the active chain again
+	if err := c.ReconsiderBlock(ctx, target.hash); err != nil {
+		return false, errors.WithMessage(err, "couldn't reconsider snapshot block")
+	}
+
+	blockCount, err := c.GetBlockCount(ctx)
+	if err != nil {
+		return false, errors.WithMessage(err, "couldn't get block count")
+	}
+	if blockCount.Int64() <= target.height {
+		c.dev.snapshots = c.dev.snapshots[:i]
+		return true, nil
+	}
+
+	// invalidating the first block after the snapshot drops every block built on it
+	var first string
+	for height := target.height + 1; height <= blockCount.Int64(); height++ {
+		hash, err := c.GetBlockHash(ctx, big.NewInt(height))
+		if err != nil {
+			return false, errors.WithMessage(err, "couldn't get block hash")
+		}
+		block, err := c.GetBlock(ctx, string(hash), false)
+		if err != nil {
+			return false, errors.WithMessagef(err, "couldn't get block %d", height)
+		}
+		if transactions := len(block.Txs) - blockRewardTransactions(block); transactions > 0 {
+			return false, errors.Wrapf(ErrRevertOverTransactions, "block %d has %d", height, transactions)
+		}
+		if first == "" {
+			first = string(hash)
+		}
+	}
+
+	c.dev.snapshots = c.dev.snapshots[:i]
+	if err := c.InvalidateBlock(ctx, first); err != nil {
+		return false, errors.WithMessage(err, "couldn't invalidate block")
+	}
+	return true, nil
+}
+
+// the coinbase, followed by the coinstake in proof of stake blocks
+func blockRewardTransactions(block *GetBlockResponse) int {
+	if strings.Contains(block.Flags, "proof-of-stake") {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,213 @@
+package kaon
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/cache"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+)
+
+// With --eth-headers, blocks are served with the Keccak256 hash of an Ethereum header whose parentHash is the
+// Ethereum hash of the parent block. Computing a header needs every transaction and receipt of the block and
+// the header of its parent, so computed headers are remembered by Kaon block hash, along with the Kaon block
+// each Ethereum hash stands for.
+
+const ethereumHeaderRegistrySize = 10000
+
+// how long a header kept in a store shared by gateway replicas lives after it was last computed
+const SharedEthereumHeaderTTL = 90 * 24 * time.Hour
+
+// Fields of an Ethereum header that differ from the Kaon header of a block
+type EthereumHeader struct {
+	Hash             string `json:"hash"`
+	ParentHash       string `json:"parentHash"`
+	TransactionsRoot string `json:"transactionsRoot"`
+	ReceiptsRoot     string `json:"receiptsRoot"`
+}
+
+// Keeps computed headers beyond the bounded registry, so that they're still known after a restart,
+// an eviction or on another gateway
+type EthereumHeaderStore interface {
+	// ok is false if the header of the block wasn't computed yet
+	GetEthereumHeader(kaonBlockHash string) (header EthereumHeader, ok bool, err error)
+	// ok is false if no header with that hash was computed yet
+	GetKaonBlockHash(ethereumBlockHash string) (kaonBlockHash string, ok bool, err error)
+	PutEthereumHeader(kaonBlockHash string, header EthereumHeader) error
+}
+
+// Bounded, the oldest headers are forgotten first and are then looked up in the stores
+type ethereumHeaderRegistry struct {
+	mutex   sync.RWMutex
+	headers map[string]EthereumHeader
+	blocks  map[string]string
+	order   []string
+	next    int
+	stores  []EthereumHeaderStore
+}
+
+func newEthereumHeaderRegistry(size int) *ethereumHeaderRegistry {
+	return &ethereumHeaderRegistry{
+		headers: make(map[string]EthereumHeader, size),
+		blocks:  make(map[string]string, size),
+		order:   make([]string, size),
+	}
+}
+
+// reports whether the header wasn't known yet
+func (r *ethereumHeaderRegistry) add(kaonBlockHash string, header EthereumHeader) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.headers[kaonBlockHash]; ok {
+		return false
+	}
+
+	if evicted := r.order[r.next]; evicted != "" {
+		delete(r.blocks, normalizeHash(r.headers[evicted].Hash))
+		delete(r.headers, evicted)
+	}
+	r.order[r.next] = kaonBlockHash
+	r.next = (r.next + 1) % len(r.order)
+	r.headers[kaonBlockHash] = header
+	r.blocks[normalizeHash(header.Hash)] = kaonBlockHash
+	return true
+}
+
+func (r *ethereumHeaderRegistry) get(kaonBlockHash string) (EthereumHeader, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	header, ok := r.headers[kaonBlockHash]
+	return header, ok
+}
+
+func (r *ethereumHeaderRegistry) getKaonBlockHash(ethereumBlockHash string) (string, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	kaonBlockHash, ok := r.blocks[ethereumBlockHash]
+	return kaonBlockHash, ok
+}
+
+func (r *ethereumHeaderRegistry) addStore(store EthereumHeaderStore) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stores = append(r.stores, store)
+}
+
+func (r *ethereumHeaderRegistry) getStores() []EthereumHeaderStore {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.stores
+}
+
+func normalizeHash(hash string) string {
+	return strings.ToLower(utils.RemoveHexPrefix(hash))
+}
+
+// EthereumHeaderStore backed by the cache store shared by gateway replicas
+type sharedEthereumHeaderStore struct {
+	kaon  *Kaon
+	store cache.Store
+}
+
+func (s *sharedEthereumHeaderStore) headerKey(kaonBlockHash string) string {
+	return CacheKeyPrefix + s.kaon.Chain() + ":ethheader:" + kaonBlockHash
+}
+
+func (s *sharedEthereumHeaderStore) blockKey(ethereumBlockHash string) string {
+	return CacheKeyPrefix + s.kaon.Chain() + ":ethblock:" + ethereumBlockHash
+}
+
+func (s *sharedEthereumHeaderStore) GetEthereumHeader(kaonBlockHash string) (EthereumHeader, bool, error) {
+	var header EthereumHeader
+	value, err := s.store.Get(context.Background(), s.headerKey(kaonBlockHash))
+	if err != nil || value == nil {
+		return header, false, err
+	}
+	if err := json.Unmarshal(value, &header); err != nil {
+		return header, false, err
+	}
+	return header, true, nil
+}
+
+func (s *sharedEthereumHeaderStore) GetKaonBlockHash(ethereumBlockHash string) (string, bool, error) {
+	value, err := s.store.Get(context.Background(), s.blockKey(ethereumBlockHash))
+	if err != nil || value == nil {
+		return "", false, err
+	}
+	return string(value), true, nil
+}
+
+func (s *sharedEthereumHeaderStore) PutEthereumHeader(kaonBlockHash string, header EthereumHeader) error {
+	value, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Set(context.Background(), s.headerKey(kaonBlockHash), value, SharedEthereumHeaderTTL); err != nil {
+		return err
+	}
+	return s.store.Set(context.Background(), s.blockKey(normalizeHash(header.Hash)), []byte(kaonBlockHash), SharedEthereumHeaderTTL)
+}
+
+// Persists computed headers in store on top of the shared cache store, if any
+func (c *Kaon) AddEthereumHeaderStore(store EthereumHeaderStore) {
+	c.ethereumHeaders.addStore(store)
+}
+
+// Remembers the Ethereum header computed for a block for EthereumHeader and ResolveEthereumBlockHash
+func (c *Kaon) RegisterEthereumHeader(kaonBlockHash string, header EthereumHeader) {
+	kaonBlockHash = normalizeHash(kaonBlockHash)
+	if !c.ethereumHeaders.add(kaonBlockHash, header) {
+		return
+	}
+	for _, store := range c.ethereumHeaders.getStores() {
+		if err := store.PutEthereumHeader(kaonBlockHash, header); err != nil {
+			c.GetErrorLogger().Log("msg", "Couldn't persist Ethereum block header", "blockHash", kaonBlockHash, "err", err)
+		}
+	}
+}
+
+// Ethereum header computed before for a block, ok is false if it has to be computed
+func (c *Kaon) EthereumHeader(kaonBlockHash string) (header EthereumHeader, ok bool) {
+	kaonBlockHash = normalizeHash(kaonBlockHash)
+	if header, ok = c.ethereumHeaders.get(kaonBlockHash); ok {
+		return header, true
+	}
+	for _, store := range c.ethereumHeaders.getStores() {
+		header, ok, err := store.GetEthereumHeader(kaonBlockHash)
+		if err != nil {
+			c.GetErrorLogger().Log("msg", "Couldn't look up Ethereum block header", "blockHash", kaonBlockHash, "err", err)
+			continue
+		}
+		if ok {
+			c.ethereumHeaders.add(kaonBlockHash, header)
+			return header, true
+		}
+	}
+	return EthereumHeader{}, false
+}
+
+// Resolves the Ethereum hash of a block served before to its Kaon block hash, without 0x prefix.
+// ok is false for any other hash.
+func (c *Kaon) ResolveEthereumBlockHash(ethereumBlockHash string) (kaonBlockHash string, ok bool) {
+	ethereumBlockHash = normalizeHash(ethereumBlockHash)
+	if kaonBlockHash, ok = c.ethereumHeaders.getKaonBlockHash(ethereumBlockHash); ok {
+		return kaonBlockHash, true
+	}
+	for _, store := range c.ethereumHeaders.getStores() {
+		kaonBlockHash, ok, err := store.GetKaonBlockHash(ethereumBlockHash)
+		if err != nil {
+			c.GetErrorLogger().Log("msg", "Couldn't look up Ethereum block hash", "hash", ethereumBlockHash, "err", err)
+			continue
+		}
+		if ok {
+			return kaonBlockHash, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,49 @@
+package kaon
+
+import "testing"
+
+type mapEthereumHeaderStore struct {
+	headers map[string]EthereumHeader
+	blocks  map[string]string
+}
+
+func (s *mapEthereumHeaderStore) GetEthereumHeader(kaonBlockHash string) (EthereumHeader, bool, error) {
+	header, ok := s.headers[kaonBlockHash]
+	return header, ok, nil
+}
+
+func (s *mapEthereumHeaderStore) GetKaonBlockHash(ethereumBlockHash string) (string, bool, error) {
+	kaonBlockHash, ok := s.blocks[ethereumBlockHash]
+	return kaonBlockHash, ok, nil
+}
+
+func (s *mapEthereumHeaderStore) PutEthereumHeader(kaonBlockHash string, header EthereumHeader) error {
+	s.headers[kaonBlockHash] = header
+	s.blocks[normalizeHash(header.Hash)] = kaonBlockHash
+	return nil
+}
+
+func TestEthereumHeaders(t *testing.T) {
+	store := &mapEthereumHeaderStore{headers: map[string]EthereumHeader{}, blocks: map[string]string{}}
+	gateway := &Kaon{ethereumHeaders: newEthereumHeaderRegistry(1)}
+	gateway.AddEthereumHeaderStore(store)
+
+	first := EthereumHeader{Hash: "0xAA01", ParentHash: "0x00"}
+	second := EthereumHeader{Hash: "0xaa02", ParentHash: first.Hash}
+	gateway.RegisterEthereumHeader("0xbb01", first)
+	gateway.RegisterEthereumHeader("bb02", second)
+
+	// the first header was evicted from the registry
+	if header, ok := gateway.EthereumHeader("BB01"); !ok || header != first {
+		t.Errorf("expected the header to be found in the store, got %+v (%v)", header, ok)
+	}
+	if kaonBlockHash, ok := gateway.ResolveEthereumBlockHash("aa01"); !ok || kaonBlockHash != "bb01" {
+		t.Errorf("expected the Ethereum hash to resolve to its Kaon block, got %s (%v)", kaonBlockHash, ok)
+	}
+	if kaonBlockHash, ok := gateway.ResolveEthereumBlockHash("0xAA02"); !ok || kaonBlockHash != "bb02" {
+		t.Errorf("expected the Ethereum hash to resolve to its Kaon block, got %s (%v)", kaonBlockHash, ok)
+	}
+	if _, ok := gateway.ResolveEthereumBlockHash("bb02"); ok {
+		t.Error("Kaon block hashes shouldn't resolve as Ethereum block hashes")
+	}
+}
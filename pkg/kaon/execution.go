@@ -0,0 +1,311 @@
+package kaon
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/kaonone/eth-rpc-gate/pkg/cache"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// A Kaon transaction can carry several OP_CALL/OP_CREATE outputs, each of them is a separate EVM execution.
+// Ethereum tooling expects a single execution per transaction, so every execution is exposed as its own
+// Ethereum transaction: the first one keeps the Kaon transaction id, so transactions with a single
+// execution are unaffected, and the following ones get a hash derived from the transaction id and output index.
+
+const executionRegistrySize = 10000
+
+// how long a derived hash kept in a store shared by gateway replicas lives after it was last handed out
+const SharedExecutionTTL = 90 * 24 * time.Hour
+
+// An Ethereum transaction as derived from a Kaon transaction
+type Execution struct {
+	// Ethereum transaction hash, without 0x prefix
+	Hash string
+	// Kaon transaction id
+	TransactionHash string
+	// Output running the EVM execution, -1 for transactions without contract outputs
+	OutputIndex int64
+}
+
+// Ethereum transaction hash of the EVM execution in output outputIndex of a transaction that isn't its first execution
+func ExecutionHash(txID string, outputIndex int64) string {
+	txIDBytes, err := hex.DecodeString(utils.RemoveHexPrefix(txID))
+	if err != nil {
+		txIDBytes = []byte(txID)
+	}
+
+	data := make([]byte, len(txIDBytes)+8)
+	copy(data, txIDBytes)
+	binary.BigEndian.PutUint64(data[len(txIDBytes):], uint64(outputIndex))
+
+	return hex.EncodeToString(crypto.Keccak256(data))
+}
+
+// Ethereum transactions represented by a Kaon transaction, contracts must be ordered by output index
+func Executions(txID string, contracts []ContractInfo) []Execution {
+	txID = strings.ToLower(utils.RemoveHexPrefix(txID))
+	if len(contracts) == 0 {
+		return []Execution{{Hash: txID, TransactionHash: txID, OutputIndex: -1}}
+	}
+
+	executions := make([]Execution, len(contracts))
+	for i, contract := range contracts {
+		hash := txID
+		if i != 0 {
+			hash = ExecutionHash(txID, contract.OutputIndex)
+		}
+		executions[i] = Execution{
+			Hash:            hash,
+			TransactionHash: txID,
+			OutputIndex:     contract.OutputIndex,
+		}
+	}
+	return executions
+}
+
+// Parses a single output script, returns nil for outputs that don't run the EVM
+func parseContractOutput(asm string, outputIndex int64) (*ContractInfo, error) {
+	var (
+		script  = strings.Split(asm, " ")
+		finalOp = script[len(script)-1]
+	)
+
+	var (
+		invokeInfo *ContractInvokeInfo
+		err        error
+	)
+	switch finalOp {
+	case "OP_CALL":
+		// OP_CALL with OP_SENDER has the script type "nonstandard"
+		invokeInfo, err = ParseCallSenderASM(script)
+		if err != nil {
+			// Check for OP_CALL without OP_SENDER
+			invokeInfo, err = ParseCallASM(script)
+			if err != nil {
+				return nil, errors.WithMessage(err, "couldn't parse call sender ASM")
+			}
+		}
+	case "OP_CREATE":
+		invokeInfo, err = ParseCreateSenderASM(script)
+		if err != nil {
+			// Check for OP_CREATE without OP_SENDER
+			invokeInfo, err = ParseCreateASM(script)
+			if err != nil {
+				return nil, errors.WithMessage(err, "couldn't parse create sender ASM")
+			}
+		}
+	default:
+		return nil, nil
+	}
+
+	if invokeInfo == nil {
+		return nil, errors.Errorf("couldn't parse contract output %d", outputIndex)
+	}
+
+	return &ContractInfo{
+		From:        invokeInfo.From,
+		To:          invokeInfo.To,
+		GasLimit:    invokeInfo.GasLimit,
+		GasPrice:    invokeInfo.GasPrice,
+		UserInput:   invokeInfo.CallData,
+		OutputIndex: outputIndex,
+		IsCreate:    finalOp == "OP_CREATE",
+	}, nil
+}
+
+// Returns every EVM execution of the transaction in output order, unlike ExtractContractInfo which stops at the first one
+func (resp *DecodedRawTransactionResponse) ExtractContractInfos() ([]ContractInfo, error) {
+	var infos []ContractInfo
+	for _, vout := range resp.Vouts {
+		info, err := parseContractOutput(vout.ScriptPubKey.ASM, vout.N)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			infos = append(infos, *info)
+		}
+	}
+	return infos, nil
+}
+
+// Returns every EVM execution of the transaction in output order, unlike ExtractContractInfo which stops at the first one
+func (resp *BlockTransactionDetails) ExtractContractInfos() ([]ContractInfo, error) {
+	var infos []ContractInfo
+	for _, vout := range resp.Vouts {
+		info, err := parseContractOutput(vout.ScriptPubKey.ASM, vout.N)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			infos = append(infos, *info)
+		}
+	}
+	return infos, nil
+}
+
+// Returns every EVM execution of the transaction in output order, unlike ExtractContractInfo which stops at the first one
+func (resp *GetRawTransactionResponse) ExtractContractInfos() ([]ContractInfo, error) {
+	var infos []ContractInfo
+	for i, vout := range resp.Vouts {
+		// outputs are listed in order, their index is their n
+		info, err := parseContractOutput(vout.Details.ASM, int64(i))
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			infos = append(infos, *info)
+		}
+	}
+	return infos, nil
+}
+
+// Remembers which execution a derived hash stands for, since the derivation can't be reversed.
+// Bounded, the oldest hashes are forgotten first and are then looked up in the stores.
+type executionRegistry struct {
+	mutex      sync.RWMutex
+	executions map[string]Execution
+	order      []string
+	next       int
+	stores     []ExecutionStore
+}
+
+func newExecutionRegistry(size int) *executionRegistry {
+	return &executionRegistry{
+		executions: make(map[string]Execution, size),
+		order:      make([]string, size),
+	}
+}
+
+// Keeps derived hashes beyond the bounded registry, so that they still resolve after a restart,
+// an eviction or on another gateway
+type ExecutionStore interface {
+	// ok is false if hash isn't known
+	GetExecution(hash string) (execution Execution, ok bool, err error)
+	PutExecutions(executions []Execution) error
+}
+
+// reports whether the execution wasn't known yet
+func (r *executionRegistry) add(execution Execution) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.executions[execution.Hash]; ok {
+		return false
+	}
+
+	if evicted := r.order[r.next]; evicted != "" {
+		delete(r.executions, evicted)
+	}
+	r.order[r.next] = execution.Hash
+	r.next = (r.next + 1) % len(r.order)
+	r.executions[execution.Hash] = execution
+	return true
+}
+
+func (r *executionRegistry) get(hash string) (Execution, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	execution, ok := r.executions[hash]
+	return execution, ok
+}
+
+func (r *executionRegistry) addStore(store ExecutionStore) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stores = append(r.stores, store)
+}
+
+func (r *executionRegistry) getStores() []ExecutionStore {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.stores
+}
+
+// ExecutionStore backed by the cache store shared by gateway replicas
+type sharedExecutionStore struct {
+	kaon  *Kaon
+	store cache.Store
+}
+
+func (s *sharedExecutionStore) key(hash string) string {
+	return CacheKeyPrefix + s.kaon.Chain() + ":execution:" + hash
+}
+
+func (s *sharedExecutionStore) GetExecution(hash string) (Execution, bool, error) {
+	var execution Execution
+	value, err := s.store.Get(context.Background(), s.key(hash))
+	if err != nil || value == nil {
+		return execution, false, err
+	}
+	if err := json.Unmarshal(value, &execution); err != nil {
+		return execution, false, err
+	}
+	return execution, true, nil
+}
+
+func (s *sharedExecutionStore) PutExecutions(executions []Execution) error {
+	for _, execution := range executions {
+		value, err := json.Marshal(execution)
+		if err != nil {
+			return err
+		}
+		if err := s.store.Set(context.Background(), s.key(execution.Hash), value, SharedExecutionTTL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Persists derived hashes in store on top of the shared cache store, if any
+func (c *Kaon) AddExecutionStore(store ExecutionStore) {
+	c.executions.addStore(store)
+}
+
+// Expands a transaction into its executions and remembers the derived hashes for ResolveExecutionHash
+func (c *Kaon) RegisterExecutions(txID string, contracts []ContractInfo) []Execution {
+	executions := Executions(txID, contracts)
+	var added []Execution
+	for _, execution := range executions {
+		if execution.Hash != execution.TransactionHash && c.executions.add(execution) {
+			added = append(added, execution)
+		}
+	}
+	if len(added) != 0 {
+		for _, store := range c.executions.getStores() {
+			if err := store.PutExecutions(added); err != nil {
+				c.GetErrorLogger().Log("msg", "Couldn't persist derived transaction hashes", "txid", txID, "err", err)
+			}
+		}
+	}
+	return executions
+}
+
+// Resolves a derived Ethereum transaction hash seen before in a block, transaction or receipt.
+// ok is false for any other hash, which is then a Kaon transaction id.
+func (c *Kaon) ResolveExecutionHash(hash string) (execution Execution, ok bool) {
+	hash = strings.ToLower(utils.RemoveHexPrefix(hash))
+	if execution, ok = c.executions.get(hash); ok {
+		return execution, true
+	}
+	for _, store := range c.executions.getStores() {
+		execution, ok, err := store.GetExecution(hash)
+		if err != nil {
+			c.GetErrorLogger().Log("msg", "Couldn't look up derived transaction hash", "hash", hash, "err", err)
+			continue
+		}
+		if ok {
+			c.executions.add(execution)
+			return execution, true
+		}
+	}
+	return Execution{}, false
+}
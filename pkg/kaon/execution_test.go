@@ -0,0 +1,129 @@
+package kaon
+
+import (
+	"testing"
+)
+
+func TestExtractContractInfosAndExecutions(t *testing.T) {
+	testData := DecodedRawTransactionResponse{
+		Vouts: []*DecodedRawTransactionOutV{
+			{
+				N: 0,
+				ScriptPubKey: DecodedRawTransactionScriptPubKey{
+					ASM: "OP_DUP OP_HASH160 81e872329e767a0487de7e970992b13b644f1f4f OP_EQUALVERIFY OP_CHECKSIG",
+				},
+			},
+			{
+				N: 1,
+				ScriptPubKey: DecodedRawTransactionScriptPubKey{
+					ASM: "4 55000 40 a9059cbb000000000000000000000000710e94d7f8a5d7a1e5be52bd783370d6e3008a2a0000000000000000000000000000000000000000000000000000000005f5e100 af1ae4e29253ba755c723bca25e883b8deb777b8 OP_CALL",
+				},
+			},
+			{
+				N: 3,
+				ScriptPubKey: DecodedRawTransactionScriptPubKey{
+					ASM: "4 60000 40 70a08231 6b22910b1e302cf74803ffd1691c2ecb858d3712 OP_CALL",
+				},
+			},
+		},
+	}
+
+	contracts, err := testData.ExtractContractInfos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contracts) != 2 {
+		t.Fatalf("expected 2 contract outputs, got %d", len(contracts))
+	}
+	if contracts[0].OutputIndex != 1 || contracts[1].OutputIndex != 3 {
+		t.Fatalf("unexpected output indexes: %d, %d", contracts[0].OutputIndex, contracts[1].OutputIndex)
+	}
+	if contracts[1].To != "6b22910b1e302cf74803ffd1691c2ecb858d3712" || contracts[1].UserInput != "70a08231" || contracts[1].IsCreate {
+		t.Fatalf("unexpected second contract output: %+v", contracts[1])
+	}
+
+	txID := "0x3208DC44733CBFA11654AD5651305428DE473EF1E61A1EC07B0C1A5F4843BE91"
+	executions := Executions(txID, contracts)
+	if len(executions) != 2 {
+		t.Fatalf("expected 2 executions, got %d", len(executions))
+	}
+
+	// the first execution keeps the Kaon transaction id
+	expectedTxID := "3208dc44733cbfa11654ad5651305428de473ef1e61a1ec07b0c1a5f4843be91"
+	if executions[0].Hash != expectedTxID || executions[0].TransactionHash != expectedTxID {
+		t.Errorf("unexpected first execution: %+v", executions[0])
+	}
+
+	// following ones get a deterministic hash derived from the transaction id and output index
+	if executions[1].Hash != ExecutionHash(expectedTxID, 3) || executions[1].Hash == expectedTxID || len(executions[1].Hash) != 64 {
+		t.Errorf("unexpected second execution hash: %s", executions[1].Hash)
+	}
+	if ExecutionHash(expectedTxID, 3) == ExecutionHash(expectedTxID, 4) {
+		t.Error("execution hashes of different outputs collide")
+	}
+
+	if executions := Executions(txID, nil); len(executions) != 1 || executions[0].Hash != expectedTxID || executions[0].OutputIndex != -1 {
+		t.Errorf("unexpected executions of a transaction without contract outputs: %+v", executions)
+	}
+}
+
+func TestResolveExecutionHash(t *testing.T) {
+	kaon := &Kaon{executions: newExecutionRegistry(2)}
+
+	contracts := []ContractInfo{{OutputIndex: 0}, {OutputIndex: 1}, {OutputIndex: 2}}
+	executions := kaon.RegisterExecutions("3208dc44733cbfa11654ad5651305428de473ef1e61a1ec07b0c1a5f4843be91", contracts)
+
+	if _, ok := kaon.ResolveExecutionHash(executions[0].Hash); ok {
+		t.Error("transaction ids shouldn't be registered as derived hashes")
+	}
+
+	execution, ok := kaon.ResolveExecutionHash("0x" + executions[2].Hash)
+	if !ok || execution != executions[2] {
+		t.Errorf("couldn't resolve execution hash, got %+v (%v)", execution, ok)
+	}
+
+	// the registry is bounded, the oldest hashes are forgotten first
+	kaon.RegisterExecutions("8fcd819194cce6a8454b2bec334d3448df4f097e9cdc36707bfd569900268950", contracts[:2])
+	if _, ok := kaon.ResolveExecutionHash(executions[1].Hash); ok {
+		t.Error("expected the oldest execution hash to be evicted")
+	}
+	if _, ok := kaon.ResolveExecutionHash(executions[2].Hash); !ok {
+		t.Error("expected the newest execution hashes to be kept")
+	}
+}
+
+type mapExecutionStore map[string]Execution
+
+func (s mapExecutionStore) GetExecution(hash string) (Execution, bool, error) {
+	execution, ok := s[hash]
+	return execution, ok, nil
+}
+
+func (s mapExecutionStore) PutExecutions(executions []Execution) error {
+	for _, execution := range executions {
+		s[execution.Hash] = execution
+	}
+	return nil
+}
+
+func TestResolveExecutionHashFromStore(t *testing.T) {
+	store := mapExecutionStore{}
+	gateway := &Kaon{executions: newExecutionRegistry(2)}
+	gateway.AddExecutionStore(store)
+
+	contracts := []ContractInfo{{OutputIndex: 0}, {OutputIndex: 1}, {OutputIndex: 2}}
+	executions := gateway.RegisterExecutions("3208dc44733cbfa11654ad5651305428de473ef1e61a1ec07b0c1a5f4843be91", contracts)
+	if len(store) != 2 {
+		t.Fatalf("expected the derived hashes to be persisted, got %+v", store)
+	}
+
+	// e.g. after a restart, or on another replica
+	restarted := &Kaon{executions: newExecutionRegistry(2)}
+	restarted.AddExecutionStore(store)
+	if execution, ok := restarted.ResolveExecutionHash(executions[1].Hash); !ok || execution != executions[1] {
+		t.Errorf("couldn't resolve execution hash from the store, got %+v (%v)", execution, ok)
+	}
+	if _, ok := restarted.ResolveExecutionHash(executions[0].Hash); ok {
+		t.Error("transaction ids shouldn't resolve as derived hashes")
+	}
+}
@@ -27,6 +27,7 @@ const (
 	MethodCallContract                = "callcontract"
 	MethodGasPrice                    = "gasprice"
 	MethodDecodeRawTransaction        = "decoderawtransaction"
+	MethodGetRawMempool               = "getrawmempool"
 	MethodGetTransactionOut           = "gettxout"
 	MethodGetBlockCount               = "getblockcount"
 	MethodGetBlockChainInfo           = "getblockchaininfo"
@@ -47,8 +48,11 @@ const (
 	MethodGetAddressBalance           = "getaddressbalance"
 	MethodGetAddressNonce             = "getaddressnonce"
 	MethodGetAddressUTXOs             = "getaddressutxos"
+	MethodGetAddressDeltas            = "getaddressdeltas"
 	MethodCreateWallet                = "createwallet"
 	MethodLoadWallet                  = "loadwallet"
+	MethodInvalidateBlock             = "invalidateblock"
+	MethodReconsiderBlock             = "reconsiderblock"
 )
 
 type JSONRPCRequest struct {
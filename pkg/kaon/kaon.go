@@ -2,8 +2,6 @@ package kaon
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"math"
 	"strings"
@@ -23,6 +21,13 @@ type Kaon struct {
 	chain            string
 
 	errorState *errorState
+	executions *executionRegistry
+	// Ethereum headers of blocks served with --eth-headers
+	ethereumHeaders *ethereumHeaderRegistry
+	// block log positions stores besides the response cache
+	blockLogPositions *blockLogPositionsStores
+	dev               *devMode
+	tracker           *txTracker
 }
 
 const (
@@ -41,10 +46,15 @@ func New(c *Client, chain string) (*Kaon, error) {
 	}
 
 	kaon := &Kaon{
-		Client:     c,
-		Method:     &Method{Client: c},
-		chain:      chain,
-		errorState: newErrorState(),
+		Client:            c,
+		Method:            &Method{Client: c},
+		chain:             chain,
+		errorState:        newErrorState(),
+		executions:        newExecutionRegistry(executionRegistrySize),
+		ethereumHeaders:   newEthereumHeaderRegistry(ethereumHeaderRegistrySize),
+		blockLogPositions: &blockLogPositionsStores{},
+		dev:               newDevMode(),
+		tracker:           newTxTracker(),
 	}
 
 	c.SetErrorHandler(func(ctx context.Context, err error) error {
@@ -54,6 +64,14 @@ func New(c *Client, chain string) (*Kaon, error) {
 		return nil
 	})
 
+	if store := c.SharedCacheStore(); store != nil {
+		kaon.executions.addStore(&sharedExecutionStore{kaon: kaon, store: store})
+		kaon.ethereumHeaders.addStore(&sharedEthereumHeaderStore{kaon: kaon, store: store})
+	}
+
+	if chain != ChainAuto && chain != ChainUnknown {
+		c.cache.setNamespace(chain, kaon.Finality().Finalized)
+	}
 	kaon.detectChain()
 
 	return kaon, nil
@@ -88,6 +106,7 @@ func (c *Kaon) detectingChain() {
 				close(queryingComplete)
 			}
 			c.chainMutex.Unlock()
+			c.cache.setNamespace(chain, c.Finality().Finalized)
 			c.GetDebugLogger().Log("msg", "Detected chain type", "chain", chain)
 			return
 		}
@@ -160,43 +179,122 @@ func (c *Kaon) GetMatureBlockHeight() int {
 	return 10
 }
 
-func (c *Kaon) CanGenerate() bool {
-	return c.Chain() == ChainRegTest
+// Default limits applied to eth_getLogs when they are not configured explicitly,
+// a value of 0 disables the corresponding limit
+const (
+	DefaultGetLogsMaxBlockRange = 5000
+	DefaultGetLogsMaxResults    = 10000
+	DefaultGetLogsChunkSize     = 1000
+)
+
+// Maximum number of blocks a single eth_getLogs request may span
+func (c *Kaon) GetLogsMaxBlockRange() int64 {
+	maxBlockRange := c.GetFlagInt(FLAG_GETLOGS_MAX_BLOCK_RANGE)
+	if maxBlockRange != nil {
+		return int64(*maxBlockRange)
+	}
+
+	return DefaultGetLogsMaxBlockRange
 }
 
-func (c *Kaon) GenerateIfPossible() {
-	if !c.CanGenerate() {
-		return
+// Maximum number of logs a single eth_getLogs request may return
+func (c *Kaon) GetLogsMaxResults() int {
+	maxResults := c.GetFlagInt(FLAG_GETLOGS_MAX_RESULTS)
+	if maxResults != nil {
+		return *maxResults
 	}
 
-	if _, generateErr := c.Generate(c.ctx, 1, nil); generateErr != nil {
-		c.GetErrorLogger().Log("Error generating new block", generateErr)
+	return DefaultGetLogsMaxResults
+}
+
+// Number of blocks requested from kaond per searchlogs call
+func (c *Kaon) GetLogsChunkSize() int64 {
+	chunkSize := c.GetFlagInt(FLAG_GETLOGS_CHUNK_SIZE)
+	if chunkSize != nil {
+		return int64(*chunkSize)
 	}
+
+	return DefaultGetLogsChunkSize
 }
 
-// Presents hexed address prefix of a specific chain without
-// `0x` prefix, this is a ready to use hexed string
-type HexAddressPrefix string
+// Confirmation depths of the safe and finalized block tags of a chain
+type Finality struct {
+	Safe      int64
+	Finalized int64
+}
 
-const (
-	PrefixMainChainAddress    HexAddressPrefix = "UniM" // 0x55 0x6e 0x69 0x4d
-	PrefixTestChainAddress    HexAddressPrefix = "UniT" // 0x55 0x6e 0x69 0x54
-	PrefixRegTestChainAddress HexAddressPrefix = "UniP" // 0x55 0x6e 0x69 0x70
-)
+// kaond, like Qtum, refuses to reorganize blocks deeper than its maximum reorganization depth, so blocks
+// that deep are final. A handful of confirmations makes a reorganization unlikely enough to be safe.
+var ChainFinality = map[string]Finality{
+	ChainMain:    {Safe: 10, Finalized: 500},
+	ChainTest:    {Safe: 10, Finalized: 500},
+	ChainRegTest: {Safe: 1, Finalized: 10},
+}
 
-// Returns decoded hexed string prefix, as ready to use slice of bytes
-func (prefix HexAddressPrefix) AsBytes() ([]byte, error) {
-	bytes, err := hex.DecodeString(string(prefix))
-	if err != nil {
-		return nil, errors.Wrap(err, "couldn't decode hexed string")
+// Confirmation depths of the safe and finalized block tags, the flags override the chain's
+func (c *Kaon) Finality() Finality {
+	finality, ok := ChainFinality[c.Chain()]
+	if !ok {
+		finality = ChainFinality[ChainMain]
+	}
+	if safe := c.GetFlagInt(FLAG_SAFE_DEPTH); safe != nil {
+		finality.Safe = int64(*safe)
 	}
-	return bytes, nil
+	if finalized := c.GetFlagInt(FLAG_FINALIZED_DEPTH); finalized != nil {
+		finality.Finalized = int64(*finalized)
+	}
+	return finality
+}
+
+// Height of the safe block when tip is the latest one
+func (c *Kaon) SafeBlockHeight(tip int64) int64 {
+	return belowTip(tip, c.Finality().Safe)
 }
 
-// Returns first 4 bytes of a double sha256 hash of the provided `prefixedAddrBytes`,
-// which must be already prefixed with a specific chain prefix
-func CalcAddressChecksum(prefixedAddr []byte) []byte {
-	hash := sha256.Sum256(prefixedAddr)
-	hash = sha256.Sum256(hash[:])
-	return hash[:4]
+// Height of the finalized block when tip is the latest one
+func (c *Kaon) FinalizedBlockHeight(tip int64) int64 {
+	return belowTip(tip, c.Finality().Finalized)
+}
+
+func belowTip(tip, depth int64) int64 {
+	if tip < depth {
+		return 0
+	}
+	return tip - depth
+}
+
+// How the accounts of the gateway sign messages, kaond's signmessage scheme unless configured otherwise
+func (c *Kaon) SignScheme() string {
+	if scheme := c.GetFlagString(FLAG_SIGN_SCHEME); scheme != nil {
+		return *scheme
+	}
+	return SignSchemeKaon
+}
+
+// Whether blocks are served with Ethereum transactions and receipts roots and a hash computed from their header
+func (c *Kaon) EthHeadersEnabled() bool {
+	return c.GetFlagBool(FLAG_ETH_HEADERS)
+}
+
+// First block served with an Ethereum header, the blocks below keep their Kaon hash so that the first
+// Ethereum header doesn't depend on the whole chain
+func (c *Kaon) EthHeadersStartHeight() uint64 {
+	if height := c.GetFlagInt(FLAG_ETH_HEADERS_START_HEIGHT); height != nil {
+		return uint64(*height)
+	}
+	return 0
+}
+
+func (c *Kaon) CanGenerate() bool {
+	return c.Chain() == ChainRegTest
+}
+
+func (c *Kaon) GenerateIfPossible() {
+	if !c.CanGenerate() || !c.Automine() {
+		return
+	}
+
+	if _, generateErr := c.Generate(c.ctx, 1, nil); generateErr != nil {
+		c.GetErrorLogger().Log("Error generating new block", generateErr)
+	}
 }
@@ -166,6 +166,21 @@ func (m *Method) GetTransactionReceipt(ctx context.Context, txHash string) (*Get
 	return resp, nil
 }
 
+func (m *Method) GetTransactionReceipts(ctx context.Context, txHash string) (GetTransactionReceiptsResponse, error) {
+	var resp GetTransactionReceiptsResponse
+	err := m.RequestWithContext(ctx, MethodGetTransactionReceipt, GetTransactionReceiptRequest(txHash), &resp)
+	if err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "GetTransactionReceipts", "Transaction Hash", txHash, "error", err)
+		}
+		return nil, err
+	}
+	if m.IsDebugEnabled() {
+		m.GetDebugLogger().Log("function", "GetTransactionReceipts", "Transaction Hash", txHash, "result", marshalToString(resp))
+	}
+	return resp, nil
+}
+
 func (m *Method) DecodeRawTransaction(ctx context.Context, hex string) (*DecodedRawTransactionResponse, error) {
 	var resp *DecodedRawTransactionResponse
 	err := m.RequestWithContext(ctx, MethodDecodeRawTransaction, DecodeRawTransactionRequest(hex), &resp)
@@ -181,6 +196,22 @@ func (m *Method) DecodeRawTransaction(ctx context.Context, hex string) (*Decoded
 	return resp, nil
 }
 
+// Entries of the mempool by transaction id
+func (m *Method) GetRawMempool(ctx context.Context) (GetRawMempoolResponse, error) {
+	var resp GetRawMempoolResponse
+	err := m.RequestWithContext(ctx, MethodGetRawMempool, &GetRawMempoolRequest{Verbose: true}, &resp)
+	if err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "GetRawMempool", "error", err)
+		}
+		return nil, err
+	}
+	if m.IsDebugEnabled() {
+		m.GetDebugLogger().Log("function", "GetRawMempool", "size", len(resp))
+	}
+	return resp, nil
+}
+
 func (m *Method) GetTransactionOut(ctx context.Context, hash string, voutNumber int, mempoolIncluded bool) (*GetTransactionOutResponse, error) {
 	var (
 		req = GetTransactionOutRequest{
@@ -308,27 +339,9 @@ func (m *Method) GetBlock(ctx context.Context, hash string, fullTransaction bool
 }
 
 func (m *Method) Generate(ctx context.Context, blockNum int, maxTries *int) (resp GenerateResponse, err error) {
-	generateToAccount := m.GetFlagString(FLAG_GENERATE_ADDRESS_TO)
-	var qAddress string
-
-	if len(m.Accounts) == 0 && generateToAccount == nil {
-		return nil, errors.New("you must specify Kaon accounts")
-	} else {
-		if generateToAccount == nil {
-			acc := Account{m.Accounts[0]}
-
-			qAddress, err = acc.ToBase58Address(m.isMain)
-			if err != nil {
-				if m.IsDebugEnabled() {
-					m.GetDebugLogger().Log("function", "Generate", "msg", "Error getting address for account", "error", err)
-				}
-				return nil, err
-			}
-			m.GetDebugLogger().Log("function", "Generate", "msg", "generating to account 0", "account", qAddress)
-		} else {
-			qAddress = *generateToAccount
-			m.GetDebugLogger().Log("function", "Generate", "msg", "generating to specified account", "account", qAddress)
-		}
+	qAddress, err := m.GenerateToAddress()
+	if err != nil {
+		return nil, err
 	}
 
 	req := GenerateRequest{
@@ -351,6 +364,57 @@ func (m *Method) Generate(ctx context.Context, blockNum int, maxTries *int) (res
 	return
 }
 
+// Address blocks are generated to on regtest, either the configured one or the first account's
+func (m *Method) GenerateToAddress() (string, error) {
+	generateToAccount := m.GetFlagString(FLAG_GENERATE_ADDRESS_TO)
+	if generateToAccount != nil {
+		m.GetDebugLogger().Log("function", "GenerateToAddress", "msg", "generating to specified account", "account", *generateToAccount)
+		return *generateToAccount, nil
+	}
+	if len(m.Accounts) == 0 {
+		return "", errors.New("you must specify Kaon accounts")
+	}
+
+	acc := Account{m.Accounts[0]}
+	qAddress, err := acc.ToBase58Address(m.isMain)
+	if err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "GenerateToAddress", "msg", "Error getting address for account", "error", err)
+		}
+		return "", err
+	}
+	m.GetDebugLogger().Log("function", "GenerateToAddress", "msg", "generating to account 0", "account", qAddress)
+	return qAddress, nil
+}
+
+func (m *Method) InvalidateBlock(ctx context.Context, hash string) error {
+	var result interface{}
+	err := m.RequestWithContext(ctx, MethodInvalidateBlock, []interface{}{hash}, &result)
+	if err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "InvalidateBlock", "hash", hash, "error", err)
+		}
+		return err
+	}
+	// cached blocks and transactions may no longer be on the active chain
+	m.cache.flush()
+	return nil
+}
+
+func (m *Method) ReconsiderBlock(ctx context.Context, hash string) error {
+	var result interface{}
+	err := m.RequestWithContext(ctx, MethodReconsiderBlock, []interface{}{hash}, &result)
+	if err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "ReconsiderBlock", "hash", hash, "error", err)
+		}
+		return err
+	}
+	// cached blocks and transactions may no longer be on the active chain
+	m.cache.flush()
+	return nil
+}
+
 /**
  * Note that Kaon searchlogs api returns all logs in a transaction receipt if any log matches a topic
  * While Ethereum behaves differently and will only return logs where topics match
@@ -421,6 +485,19 @@ func (m *Method) ListUnspent(ctx context.Context, req *ListUnspentRequest) (resp
 	return
 }
 
+func (m *Method) GetAddressDeltas(ctx context.Context, req *GetAddressDeltasRequest) (resp GetAddressDeltasResponse, err error) {
+	if err := m.RequestWithContext(ctx, MethodGetAddressDeltas, req, &resp); err != nil {
+		if m.IsDebugEnabled() {
+			m.GetDebugLogger().Log("function", "GetAddressDeltas", "error", err)
+		}
+		return nil, err
+	}
+	if m.IsDebugEnabled() {
+		m.GetDebugLogger().Log("function", "GetAddressDeltas", "request", marshalToString(req), "msg", "Successfully got address deltas")
+	}
+	return
+}
+
 func (m *Method) GetStorage(ctx context.Context, req *GetStorageRequest) (resp *GetStorageResponse, err error) {
 	if err := m.RequestWithContext(ctx, MethodGetStorage, req, &resp); err != nil {
 		if m.IsDebugEnabled() {
@@ -0,0 +1,279 @@
+package kaon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A Kaon JSON-RPC request/response pair, one per line in record files
+type Record struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *JSONRPCError   `json:"error,omitempty"`
+}
+
+func (r *Record) key() string {
+	return recordKey(r.Method, r.Params)
+}
+
+func recordKey(method string, params json.RawMessage) string {
+	compacted := &bytes.Buffer{}
+	if len(params) == 0 || json.Compact(compacted, params) != nil {
+		return method
+	}
+	if compacted.String() == "null" || compacted.String() == "[]" {
+		return method
+	}
+	return method + " " + compacted.String()
+}
+
+// Recorder is a doer that writes every request/response pair going through it
+type Recorder struct {
+	doer   doer
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func NewRecorder(d doer, w io.Writer) *Recorder {
+	return &Recorder{
+		doer:   d,
+		writer: w,
+	}
+}
+
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	// only JSON-RPC exchanges are recorded, kaond also answers with plain text when it is busy
+	var requests []*JSONRPCRequest
+	var results []*JSONRPCResult
+	if isBatch(reqBody) {
+		if json.Unmarshal(reqBody, &requests) != nil || json.Unmarshal(respBody, &results) != nil {
+			return resp, nil
+		}
+	} else {
+		var request JSONRPCRequest
+		var result JSONRPCResult
+		if json.Unmarshal(reqBody, &request) != nil || json.Unmarshal(respBody, &result) != nil {
+			return resp, nil
+		}
+		requests, results = []*JSONRPCRequest{&request}, []*JSONRPCResult{&result}
+	}
+
+	resultsByID := make(map[string]*JSONRPCResult, len(results))
+	for _, result := range results {
+		if result != nil {
+			resultsByID[string(result.ID)] = result
+		}
+	}
+	for _, request := range requests {
+		result, ok := resultsByID[string(request.ID)]
+		if !ok {
+			continue
+		}
+		if err := r.write(&Record{
+			Method: request.Method,
+			Params: request.Params,
+			Result: result.RawResult,
+			Error:  result.Error,
+		}); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+func isBatch(body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) != 0 && trimmed[0] == '['
+}
+
+func (r *Recorder) write(record *Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "couldn't marshal record")
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, err = r.writer.Write(append(line, '\n'))
+	return errors.Wrap(err, "couldn't write record")
+}
+
+// Replayer is a doer that answers requests from records instead of a node.
+// Records with the same method and params are served in order, the last one is repeated once all have been used.
+type Replayer struct {
+	mutex   sync.Mutex
+	records map[string][]*Record
+
+	// answers requests that were not recorded, they fail if nil
+	Fallback doer
+}
+
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	replayer := &Replayer{
+		records: make(map[string][]*Record),
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, errors.Wrapf(err, "couldn't parse record on line %d", line)
+		}
+		replayer.Add(&record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "couldn't read records")
+	}
+	return replayer, nil
+}
+
+func NewReplayerFromFile(path string) (*Replayer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return NewReplayer(file)
+}
+
+func (r *Replayer) Add(record *Record) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	key := record.key()
+	r.records[key] = append(r.records[key], record)
+}
+
+// Returns the next record for the request, or nil if none was recorded
+func (r *Replayer) Next(method string, params json.RawMessage) *Record {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	key := recordKey(method, params)
+	records := r.records[key]
+	if len(records) == 0 {
+		return nil
+	}
+	if len(records) > 1 {
+		r.records[key] = records[1:]
+	}
+	return records[0]
+}
+
+func (r *Replayer) has(method string, params json.RawMessage) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.records[recordKey(method, params)]) != 0
+}
+
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return nil, errors.New("empty request body")
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []*JSONRPCRequest
+	if isBatch(body) {
+		if err := json.Unmarshal(body, &requests); err != nil {
+			return nil, errors.Wrap(err, "couldn't parse request")
+		}
+	} else {
+		var request JSONRPCRequest
+		if err := json.Unmarshal(body, &request); err != nil {
+			return nil, errors.Wrap(err, "couldn't parse request")
+		}
+		requests = []*JSONRPCRequest{&request}
+	}
+
+	for _, request := range requests {
+		if !r.has(request.Method, request.Params) {
+			if r.Fallback != nil {
+				req.Body = ioutil.NopCloser(bytes.NewReader(body))
+				return r.Fallback.Do(req)
+			}
+			return nil, errors.Errorf("no recorded response for %s %s", request.Method, string(request.Params))
+		}
+	}
+
+	results := make([]*JSONRPCResult, len(requests))
+	for i, request := range requests {
+		record := r.Next(request.Method, request.Params)
+		results[i] = &JSONRPCResult{
+			JSONRPC:   RPCVersion,
+			RawResult: record.Result,
+			Error:     record.Error,
+			ID:        request.ID,
+		}
+	}
+
+	var respBody []byte
+	if isBatch(body) {
+		respBody, err = json.Marshal(results)
+	} else {
+		respBody, err = json.Marshal(results[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(respBody)),
+		Request:    req,
+	}, nil
+}
+
+// Writes every request/response pair to w, must come after SetDoer when both are given
+func SetRecorder(w io.Writer) func(*Client) error {
+	return func(c *Client) error {
+		c.doer = NewRecorder(c.doer, w)
+		return nil
+	}
+}
+
+// Serves requests from the records of the given file instead of querying kaond
+func SetReplayFile(path string) func(*Client) error {
+	return func(c *Client) error {
+		replayer, err := NewReplayerFromFile(path)
+		if err != nil {
+			return errors.Wrap(err, "couldn't load replay file")
+		}
+		c.doer = replayer
+		return nil
+	}
+}
@@ -3,9 +3,11 @@ package kaon
 import (
 	"encoding/json"
 	"math/big"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/kaonone/eth-rpc-gate/pkg/address"
 	"github.com/kaonone/eth-rpc-gate/pkg/utils"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
@@ -433,6 +435,19 @@ type (
 	}
 )
 
+// Logs emitted by the call
+func (r *CallContractResponse) Logs() ([]Log, error) {
+	data, err := json.Marshal(r.TransactionReceipt.Log)
+	if err != nil {
+		return nil, err
+	}
+	var logs []Log
+	if err := json.Unmarshal(data, &logs); err != nil {
+		return nil, errors.Wrap(err, "couldn't unmarshal logs of the call")
+	}
+	return logs, nil
+}
+
 func (r *CallContractRequest) MarshalJSON() ([]byte, error) {
 	params := []interface{}{
 		utils.RemoveHexPrefix(r.To),
@@ -590,6 +605,10 @@ type ContractInfo struct {
 	GasLimit  string
 	GasPrice  string
 	UserInput string
+
+	// Only set by ExtractContractInfos
+	OutputIndex int64
+	IsCreate    bool
 }
 
 // TODO: complete
@@ -677,10 +696,7 @@ func (resp *DecodedRawTransactionResponse) ExtractContractInfo() (_ ContractInfo
 				continue
 			}
 
-			// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-			// will cause ConvertKaonAddress to fall
-			// but we not support them for now
-			fromAddressIn, err := utils.ConvertKaonAddress(*sender)
+			fromAddressIn, err := address.ToHex(*sender)
 
 			if err != nil {
 				continue
@@ -688,10 +704,7 @@ func (resp *DecodedRawTransactionResponse) ExtractContractInfo() (_ ContractInfo
 			fromAddress = fromAddressIn
 		} else {
 
-			// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-			// will cause ConvertKaonAddress to fall
-			// but we not support them for now
-			fromAddressIn, err := utils.ConvertKaonAddress(vin.Address)
+			fromAddressIn, err := address.ToHex(vin.Address)
 
 			if err != nil {
 				continue
@@ -713,12 +726,9 @@ func (resp *DecodedRawTransactionResponse) ExtractContractInfo() (_ ContractInfo
 			vout     = resp.Vouts[vin.Vout]
 			reciever = ""
 		)
-		for _, address := range vout.ScriptPubKey.Addresses {
-			if address != "" {
-				// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-				// will cause ConvertKaonAddress to fall
-				// but we not support them for now
-				hex, err := utils.ConvertKaonAddress(address)
+		for _, kaonAddress := range vout.ScriptPubKey.Addresses {
+			if kaonAddress != "" {
+				hex, err := address.ToHex(kaonAddress)
 				if err == nil {
 					reciever = hex
 					break
@@ -739,10 +749,7 @@ func (resp *DecodedRawTransactionResponse) ExtractContractInfo() (_ ContractInfo
 				continue
 			}
 
-			// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-			// will cause ConvertKaonAddress to fall
-			// but we not support them for now
-			recieverIn, err := utils.ConvertKaonAddress(*recieverParsed)
+			recieverIn, err := address.ToHex(*recieverParsed)
 
 			if err != nil {
 				continue
@@ -768,6 +775,41 @@ func (resp *DecodedRawTransactionResponse) IsContractCreation() bool {
 	return false
 }
 
+// ========== GetRawMempool ============= //
+
+type (
+	// Verbose returns the mempool entries by transaction id rather than the ids alone
+	GetRawMempoolRequest struct {
+		Verbose bool
+	}
+
+	/*
+		{
+		  "d0fe0caa1b798c36da37e9118a06a7d151632d670b82d1c7dc3985577a71880f": {
+		    "vsize": 552,
+		    "weight": 2208,
+		    "time": 1700000000,
+		    "height": 608,
+		    ...
+		  }
+		}
+	*/
+	GetRawMempoolResponse map[string]MempoolEntry
+
+	MempoolEntry struct {
+		Vsize  int64 `json:"vsize"`
+		Weight int64 `json:"weight"`
+		// when the transaction entered the mempool
+		Time int64 `json:"time"`
+		// block height when the transaction entered the mempool
+		Height int64 `json:"height"`
+	}
+)
+
+func (r *GetRawMempoolRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{r.Verbose})
+}
+
 // ========== GetTransactionOut ============= //
 type (
 	// GetTransactionOutRequest struct {
@@ -899,6 +941,24 @@ func (resp *GetTransactionReceiptResponse) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Receipts of every EVM execution in a transaction, ordered by output index
+type GetTransactionReceiptsResponse []TransactionReceipt
+
+func (resp *GetTransactionReceiptsResponse) UnmarshalJSON(data []byte) error {
+	var receipts []TransactionReceipt
+	if err := json.Unmarshal(data, &receipts); err != nil {
+		return err
+	}
+	if len(receipts) == 0 {
+		return EmptyResponseErr
+	}
+	sort.SliceStable(receipts, func(i, j int) bool {
+		return receipts[i].OutputIndex < receipts[j].OutputIndex
+	})
+	*resp = receipts
+	return nil
+}
+
 // ========== GetBlockCount ============= //
 
 type (
@@ -1660,10 +1720,18 @@ type (
 		Hex       string   `json:"hex"`
 		ReqSigs   int64    `json:"reqSigs"`
 		Type      string   `json:"type"`
+		Address   string   `json:"address"`
 		Addresses []string `json:"addresses"`
 	}
 )
 
+func (k *BlockTransactionScriptPubKey) GetAddresses() []string {
+	if len(k.Address) != 0 {
+		return []string{k.Address}
+	}
+	return k.Addresses[:]
+}
+
 func (r *GetBlockRequest) MarshalJSON() ([]byte, error) {
 
 	return json.Marshal([]interface{}{
@@ -1814,12 +1882,9 @@ func (resp *BlockTransactionDetails) ExtractContractInfo() (_ ContractInfo, isCo
 			var (
 				reciever = ""
 			)
-			for _, address := range vout.ScriptPubKey.Addresses {
-				if address != "" {
-					// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-					// will cause ConvertKaonAddress to fall
-					// but we not support them for now
-					hex, err := utils.ConvertKaonAddress(address)
+			for _, kaonAddress := range vout.ScriptPubKey.Addresses {
+				if kaonAddress != "" {
+					hex, err := address.ToHex(kaonAddress)
 					if err == nil {
 						reciever = hex
 						break
@@ -1859,10 +1924,7 @@ func (resp *BlockTransactionDetails) ExtractContractInfo() (_ ContractInfo, isCo
 				continue
 			}
 
-			// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-			// will cause ConvertKaonAddress to fall
-			// but we not support them for now
-			fromAddressIn, err := utils.ConvertKaonAddress(*sender)
+			fromAddressIn, err := address.ToHex(*sender)
 
 			if err != nil {
 				continue
@@ -1870,10 +1932,7 @@ func (resp *BlockTransactionDetails) ExtractContractInfo() (_ ContractInfo, isCo
 			fromAddress = fromAddressIn
 		} else {
 
-			// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-			// will cause ConvertKaonAddress to fall
-			// but we not support them for now
-			fromAddressIn, err := utils.ConvertKaonAddress(vin.Address)
+			fromAddressIn, err := address.ToHex(vin.Address)
 
 			if err != nil {
 				continue
@@ -1895,12 +1954,9 @@ func (resp *BlockTransactionDetails) ExtractContractInfo() (_ ContractInfo, isCo
 			vout     = resp.Vouts[i]
 			reciever = ""
 		)
-		for _, address := range vout.ScriptPubKey.Addresses {
-			if address != "" {
-				// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-				// will cause ConvertKaonAddress to fall
-				// but we not support them for now
-				hex, err := utils.ConvertKaonAddress(address)
+		for _, kaonAddress := range vout.ScriptPubKey.Addresses {
+			if kaonAddress != "" {
+				hex, err := address.ToHex(kaonAddress)
 				if err == nil {
 					reciever = hex
 					break
@@ -1921,10 +1977,7 @@ func (resp *BlockTransactionDetails) ExtractContractInfo() (_ ContractInfo, isCo
 				continue
 			}
 
-			// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-			// will cause ConvertKaonAddress to fall
-			// but we not support them for now
-			recieverIn, err := utils.ConvertKaonAddress(*recieverParsed)
+			recieverIn, err := address.ToHex(*recieverParsed)
 
 			if err != nil {
 				continue
@@ -2088,6 +2141,67 @@ func (r *GetAddressUTXOsRequest) MarshalJSON() ([]byte, error) {
 	return json.Marshal(params)
 }
 
+// ========== GetAddressDeltas ============= //
+
+type (
+	/*
+		Arguments:
+		1. Input params              (json object, required) Json object
+			{
+			"addresses": [        (json array, required) The Kaon addresses
+				"address",          (string) The Kaon address
+				...
+			],
+			"start": n,           (numeric, optional) The start block height
+			"end": n,             (numeric, optional) The end block height
+			"chainInfo": bool,    (boolean, optional) Include chain info in results, only applies if start and end specified
+			}
+
+		Result:
+		[
+			{
+			"satoshis" : n,       (numeric) The difference of satoshis
+			"txid" : "hex",       (string) The related txid
+			"index" : n,          (numeric) The related input or output index
+			"blockindex" : n,     (numeric) The related block index
+			"height" : n,         (numeric) The block height
+			"address" : "str",    (string) The base58check encoded address
+			}
+			,...
+		]
+	*/
+
+	GetAddressDeltasRequest struct {
+		Addresses []string
+		// both or neither of Start and End, kaond expects heights greater than zero
+		Start int64
+		End   int64
+	}
+
+	AddressDelta struct {
+		Address    string `json:"address"`
+		TXID       string `json:"txid"`
+		Index      uint   `json:"index"`
+		BlockIndex uint   `json:"blockindex"`
+		Height     int64  `json:"height"`
+		// an integer in the unit of getaddressbalance, negative for spent outputs
+		Satoshis big.Int `json:"satoshis"`
+	}
+
+	GetAddressDeltasResponse []AddressDelta
+)
+
+func (r *GetAddressDeltasRequest) MarshalJSON() ([]byte, error) {
+	params := map[string]interface{}{
+		"addresses": r.Addresses,
+	}
+	if r.Start > 0 && r.End > 0 {
+		params["start"] = r.Start
+		params["end"] = r.End
+	}
+	return json.Marshal([]interface{}{params})
+}
+
 // ========== ListUnspent ============= //
 type (
 
@@ -2257,6 +2371,12 @@ func (request *GetTransactionCountRequest) MarshalJSON() ([]byte, error) {
 	return json.Marshal(params)
 }
 
+func (resp *GetTransactionCountResponse) UnmarshalJSON(data []byte) error {
+	// the embedded big.Int is nil until it is allocated
+	resp.Int = new(big.Int)
+	return resp.Int.UnmarshalJSON(data)
+}
+
 // ======== getaddressbalance ========= //
 type (
 
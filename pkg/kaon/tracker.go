@@ -0,0 +1,255 @@
+package kaon
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Transactions submitted through the gateway are tracked until they are mined deep enough to be safe. kaond
+// forgets the transactions it evicts from its mempool (immature inputs, fee too low, full mempool), so a
+// tracked transaction missing from both the mempool and the chain is sent again, a bounded number of times.
+// Tracked transactions are only kept in memory, a restarted gateway or another replica doesn't know about them.
+
+const (
+	DefaultTxRebroadcastLimit = 3
+	DefaultTxPollInterval     = 15 * time.Second
+
+	trackerSize = 10000
+)
+
+type TransactionStatus string
+
+const (
+	// in kaond's mempool
+	TransactionPending TransactionStatus = "pending"
+	// in a block of the active chain
+	TransactionMined TransactionStatus = "mined"
+	// missing from kaond's mempool and chain, it's sent again at the next poll
+	TransactionEvicted TransactionStatus = "evicted"
+	// evicted again after every rebroadcast, no longer tracked
+	TransactionDropped TransactionStatus = "dropped"
+	// not submitted through the gateway and unknown to kaond
+	TransactionUnknown TransactionStatus = "unknown"
+)
+
+// A transaction submitted through the gateway
+type TrackedTransaction struct {
+	// Kaon transaction id, without 0x prefix
+	ID     string
+	Status TransactionStatus
+	// serialized transaction sent again when evicted, empty until known for wallet transactions
+	Raw         string
+	SubmittedAt time.Time
+
+	BlockHash     string
+	Confirmations int64
+
+	Rebroadcasts int
+	// why kaond refused the last rebroadcast
+	LastError string
+
+	// stopped polling, the transaction is safe or dropped
+	settled bool
+}
+
+type txTracker struct {
+	mutex        sync.Mutex
+	transactions map[string]*TrackedTransaction
+	// ids in submission order, the oldest ones are forgotten first
+	order   []string
+	polling bool
+}
+
+func newTxTracker() *txTracker {
+	return &txTracker{
+		transactions: make(map[string]*TrackedTransaction),
+	}
+}
+
+// How many times an evicted transaction is sent again before being reported as dropped
+func (c *Kaon) TxRebroadcastLimit() int {
+	if limit := c.GetFlagInt(FLAG_TX_REBROADCAST_LIMIT); limit != nil {
+		return *limit
+	}
+	return DefaultTxRebroadcastLimit
+}
+
+// How often tracked transactions are looked up
+func (c *Kaon) TxPollInterval() time.Duration {
+	if interval, ok := c.GetFlag(FLAG_TX_POLL_INTERVAL).(time.Duration); ok && interval > 0 {
+		return interval
+	}
+	return DefaultTxPollInterval
+}
+
+// Tracks a transaction submitted through the gateway, raw is the serialized transaction if known. Polling
+// starts with the first tracked transaction and stops once they are all settled.
+func (c *Kaon) TrackTransaction(txID string, raw string) {
+	txID = strings.ToLower(utils.RemoveHexPrefix(txID))
+	raw = utils.RemoveHexPrefix(raw)
+
+	t := c.tracker
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if tx, ok := t.transactions[txID]; ok {
+		if tx.Raw == "" {
+			tx.Raw = raw
+		}
+		return
+	}
+	t.transactions[txID] = &TrackedTransaction{
+		ID:          txID,
+		Status:      TransactionPending,
+		Raw:         raw,
+		SubmittedAt: time.Now(),
+	}
+	t.order = append(t.order, txID)
+	if len(t.order) > trackerSize {
+		delete(t.transactions, t.order[0])
+		t.order = t.order[1:]
+	}
+
+	if !t.polling {
+		t.polling = true
+		go c.pollTransactions()
+	}
+}
+
+// Status of a transaction submitted through the gateway, false if it isn't tracked
+func (c *Kaon) TrackedTransaction(txID string) (TrackedTransaction, bool) {
+	txID = strings.ToLower(utils.RemoveHexPrefix(txID))
+
+	c.tracker.mutex.Lock()
+	defer c.tracker.mutex.Unlock()
+	tx, ok := c.tracker.transactions[txID]
+	if !ok {
+		return TrackedTransaction{}, false
+	}
+	return *tx, true
+}
+
+func (c *Kaon) pollTransactions() {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ticker := time.NewTicker(c.TxPollInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if c.PollTransactions(ctx) == 0 {
+				c.tracker.mutex.Lock()
+				// a transaction may have been tracked since the poll
+				if c.unsettledTransactions() == 0 {
+					c.tracker.polling = false
+					c.tracker.mutex.Unlock()
+					return
+				}
+				c.tracker.mutex.Unlock()
+			}
+		case <-ctx.Done():
+			c.tracker.mutex.Lock()
+			c.tracker.polling = false
+			c.tracker.mutex.Unlock()
+			return
+		}
+	}
+}
+
+// must be called with the tracker mutex held
+func (c *Kaon) unsettledTransactions() int {
+	unsettled := 0
+	for _, tx := range c.tracker.transactions {
+		if !tx.settled {
+			unsettled++
+		}
+	}
+	return unsettled
+}
+
+// Looks up every unsettled tracked transaction once and rebroadcasts the evicted ones, returns how many
+// transactions are still unsettled
+func (c *Kaon) PollTransactions(ctx context.Context) int {
+	c.tracker.mutex.Lock()
+	var unsettled []TrackedTransaction
+	for _, txID := range c.tracker.order {
+		if tx := c.tracker.transactions[txID]; !tx.settled {
+			unsettled = append(unsettled, *tx)
+		}
+	}
+	c.tracker.mutex.Unlock()
+
+	// kaond is queried without holding the mutex, the transactions are updated afterwards
+	polled := make([]TrackedTransaction, 0, len(unsettled))
+	for _, tx := range unsettled {
+		if err := c.pollTransaction(ctx, &tx); err != nil {
+			c.GetDebugLogger().Log("function", "PollTransactions", "msg", "Couldn't look up tracked transaction", "txid", tx.ID, "err", err)
+			continue
+		}
+		polled = append(polled, tx)
+	}
+
+	c.tracker.mutex.Lock()
+	defer c.tracker.mutex.Unlock()
+	for _, tx := range polled {
+		if tracked, ok := c.tracker.transactions[tx.ID]; ok {
+			*tracked = tx
+		}
+	}
+	return c.unsettledTransactions()
+}
+
+func (c *Kaon) pollTransaction(ctx context.Context, tx *TrackedTransaction) error {
+	// a cached response would hide evictions until the next block
+	rawTx, err := c.GetRawTransaction(WithoutCache(ctx), tx.ID, false)
+	if err == nil {
+		if tx.Raw == "" {
+			tx.Raw = rawTx.Hex
+		}
+		tx.LastError = ""
+		if rawTx.BlockHash == "" {
+			tx.Status = TransactionPending
+			tx.BlockHash = ""
+			tx.Confirmations = 0
+			return nil
+		}
+		tx.Status = TransactionMined
+		tx.BlockHash = rawTx.BlockHash
+		tx.Confirmations = rawTx.Confirmations
+		// deep enough not to be reorganized back into the mempool
+		tx.settled = rawTx.Confirmations > c.Finality().Safe
+		return nil
+	}
+	if errors.Cause(err) != ErrInvalidAddress {
+		return err
+	}
+
+	// unknown to kaond, -5 "No such mempool or blockchain transaction"
+	tx.BlockHash = ""
+	tx.Confirmations = 0
+	if tx.Raw == "" || tx.Rebroadcasts >= c.TxRebroadcastLimit() {
+		tx.Status = TransactionDropped
+		tx.settled = true
+		return nil
+	}
+
+	tx.Rebroadcasts++
+	req := SendRawTransactionRequest([1]string{tx.Raw})
+	if _, err := c.SendRawTransaction(ctx, &req); err != nil && errors.Cause(err) != ErrVerifyAlreadyInChain {
+		c.GetDebugLogger().Log("function", "PollTransactions", "msg", "Couldn't rebroadcast evicted transaction", "txid", tx.ID, "err", err)
+		tx.Status = TransactionEvicted
+		tx.LastError = err.Error()
+		return nil
+	}
+	tx.Status = TransactionPending
+	tx.LastError = ""
+	return nil
+}
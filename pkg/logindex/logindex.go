@@ -0,0 +1,708 @@
+package logindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/kaonone/eth-rpc-gate/pkg/conversion"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/pkg/errors"
+)
+
+var ErrNotIndexed = errors.New("block range not indexed")
+var ErrReceiptNotIndexed = errors.New("transaction receipt not indexed")
+
+var (
+	DefaultPollInterval   = 10 * time.Second
+	DefaultBatchSize      = uint64(1000)
+	DefaultMaxReorgDepth  = uint64(500)
+	defaultLevelDBCache   = 16
+	defaultLevelDBHandles = 16
+)
+
+// Database layout, all numbers are big endian so that keys sort by block height
+var (
+	headKey  = []byte("LastBlock")  // headKey -> highest indexed block
+	startKey = []byte("FirstBlock") // startKey -> lowest indexed block
+
+	blockHashPrefix = []byte("b") // blockHashPrefix + num -> block hash
+	receiptPrefix   = []byte("r") // receiptPrefix + num + tx index + output index -> receipt json
+	txLookupPrefix  = []byte("x") // txLookupPrefix + tx hash -> num + tx index
+	addressPrefix   = []byte("a") // addressPrefix + address + num + tx index + output index -> nil
+	topicPrefix     = []byte("t") // topicPrefix + topic + num + tx index + output index -> nil
+	executionPrefix = []byte("e") // executionPrefix + derived tx hash -> execution json, never rolled back
+	headerPrefix    = []byte("h") // headerPrefix + Kaon block hash -> Ethereum header json, never rolled back
+	ethBlockPrefix  = []byte("k") // ethBlockPrefix + Ethereum block hash -> Kaon block hash, never rolled back
+	positionsPrefix = []byte("p") // positionsPrefix + block hash -> block log positions json, never rolled back
+)
+
+// Index keeps the receipts returned by kaond's searchlogs in an embedded key-value store,
+// indexed by contract address and topic, so that log queries over already seen blocks
+// don't have to hit kaond. It follows the chain tip and rolls back blocks that got reorganised.
+type Index struct {
+	ctx   context.Context
+	kaon  *kaon.Kaon
+	db    ethdb.KeyValueStore
+	mutex sync.RWMutex
+
+	startHeight   uint64
+	pollInterval  time.Duration
+	batchSize     uint64
+	maxReorgDepth uint64
+}
+
+type Option func(*Index) error
+
+// Only blocks from this height onwards are indexed, ignored when reopening an existing index
+func SetStartHeight(height uint64) Option {
+	return func(idx *Index) error {
+		idx.startHeight = height
+		return nil
+	}
+}
+
+func SetPollInterval(interval time.Duration) Option {
+	return func(idx *Index) error {
+		if interval <= 0 {
+			return errors.New("poll interval must be positive")
+		}
+		idx.pollInterval = interval
+		return nil
+	}
+}
+
+// Number of blocks requested from kaond per searchlogs call while catching up
+func SetBatchSize(blocks uint64) Option {
+	return func(idx *Index) error {
+		if blocks == 0 {
+			return errors.New("batch size must be positive")
+		}
+		idx.batchSize = blocks
+		return nil
+	}
+}
+
+// Block hashes are only kept for this many blocks below the tip, deeper blocks are treated as final
+func SetMaxReorgDepth(blocks uint64) Option {
+	return func(idx *Index) error {
+		idx.maxReorgDepth = blocks
+		return nil
+	}
+}
+
+// Opens (or creates) a leveldb backed index at path
+func Open(ctx context.Context, kaonClient *kaon.Kaon, path string, opts ...Option) (*Index, error) {
+	db, err := leveldb.New(path, defaultLevelDBCache, defaultLevelDBHandles, "", false)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open log index database")
+	}
+
+	idx, err := New(ctx, kaonClient, db, opts...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+func New(ctx context.Context, kaonClient *kaon.Kaon, db ethdb.KeyValueStore, opts ...Option) (*Index, error) {
+	if ctx == nil {
+		panic("ctx cannot be nil")
+	}
+	if kaonClient == nil {
+		panic("kaon cannot be nil")
+	}
+
+	idx := &Index{
+		ctx:           ctx,
+		kaon:          kaonClient,
+		db:            db,
+		pollInterval:  DefaultPollInterval,
+		batchSize:     DefaultBatchSize,
+		maxReorgDepth: DefaultMaxReorgDepth,
+	}
+
+	for _, opt := range opts {
+		if err := opt(idx); err != nil {
+			return nil, err
+		}
+	}
+
+	// an existing index keeps the range it was built with
+	start, ok, err := readNumber(db, startKey)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		idx.startHeight = start
+	} else if err := db.Put(startKey, encodeNumber(idx.startHeight)); err != nil {
+		return nil, errors.Wrap(err, "couldn't initialize log index")
+	}
+
+	return idx, nil
+}
+
+// Follows the chain tip in the background until the context is cancelled, then closes the database
+func (idx *Index) Start() {
+	go idx.run()
+}
+
+func (idx *Index) run() {
+	defer idx.Close()
+
+	ticker := time.NewTicker(idx.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := idx.Sync(idx.ctx); err != nil && idx.ctx.Err() == nil {
+			idx.kaon.GetErrorLogger().Log("msg", "Failed to sync log index", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-idx.ctx.Done():
+			return
+		}
+	}
+}
+
+func (idx *Index) Close() error {
+	return idx.db.Close()
+}
+
+// Returns the highest indexed block, ok is false if nothing has been indexed yet
+func (idx *Index) Head() (head uint64, ok bool) {
+	if idx == nil {
+		return 0, false
+	}
+	head, ok, _ = readNumber(idx.db, headKey)
+	return
+}
+
+// Reports whether every block in [from, to] has been indexed
+func (idx *Index) Covers(from, to uint64) bool {
+	if idx == nil || from > to || from < idx.startHeight {
+		return false
+	}
+	head, ok := idx.Head()
+	return ok && to <= head
+}
+
+// Rolls back blocks that are no longer part of the main chain and indexes everything up to the current tip.
+// Safe to call again after a failure or a restart, indexing resumes after the last stored block.
+func (idx *Index) Sync(ctx context.Context) error {
+	blockCount, err := idx.kaon.GetBlockCount(ctx)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get block count")
+	}
+	tip := blockCount.Uint64()
+
+	if err := idx.rollbackReorganised(ctx, tip); err != nil {
+		return err
+	}
+
+	next := idx.startHeight
+	if head, ok := idx.Head(); ok {
+		next = head + 1
+	}
+
+	for next <= tip {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		to := next + idx.batchSize - 1
+		if to > tip {
+			to = tip
+		}
+
+		if err := idx.ingest(ctx, next, to, tip); err != nil {
+			return err
+		}
+
+		next = to + 1
+	}
+
+	return nil
+}
+
+func (idx *Index) rollbackReorganised(ctx context.Context, tip uint64) error {
+	for {
+		head, ok := idx.Head()
+		if !ok {
+			return nil
+		}
+
+		if head <= tip {
+			stored, err := idx.db.Get(blockHashKey(head))
+			if err != nil {
+				// no hash kept for this block, it is deeper than any reorg we track
+				return nil
+			}
+
+			actual, err := idx.kaon.GetBlockHash(ctx, new(big.Int).SetUint64(head))
+			if err != nil {
+				return errors.Wrapf(err, "couldn't get block hash for block %d", head)
+			}
+
+			if strings.EqualFold(string(stored), string(actual)) {
+				return nil
+			}
+		}
+
+		idx.kaon.GetDebugLogger().Log("msg", "Rolling back reorganised block from log index", "block", head)
+		if err := idx.rollback(head); err != nil {
+			return err
+		}
+	}
+}
+
+func (idx *Index) ingest(ctx context.Context, from, to, tip uint64) error {
+	// hashes are kept for the last indexed block and anything that can still be reorganised
+	hashes := make(map[uint64]string)
+	for number := from; number <= to; number++ {
+		if number != to && number+idx.maxReorgDepth < tip {
+			continue
+		}
+
+		hash, err := idx.kaon.GetBlockHash(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get block hash for block %d", number)
+		}
+		hashes[number] = string(hash)
+	}
+
+	receipts, err := idx.kaon.SearchLogs(ctx, &kaon.SearchLogsRequest{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "couldn't search logs in blocks [%d, %d]", from, to)
+	}
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	batch := idx.db.NewBatch()
+	for i := range receipts {
+		receipt := &receipts[i]
+		if receipt.BlockNumber < from || receipt.BlockNumber > to {
+			continue
+		}
+		if hash, ok := hashes[receipt.BlockNumber]; ok && !strings.EqualFold(hash, receipt.BlockHash) {
+			// the chain moved between the two calls, try again on the next sync
+			return errors.Errorf("block %d was reorganised while indexing", receipt.BlockNumber)
+		}
+
+		if err := putReceipt(batch, receipt); err != nil {
+			return err
+		}
+	}
+
+	for number, hash := range hashes {
+		if err := batch.Put(blockHashKey(number), []byte(hash)); err != nil {
+			return err
+		}
+	}
+
+	if err := batch.Put(headKey, encodeNumber(to)); err != nil {
+		return err
+	}
+
+	return batch.Write()
+}
+
+// Removes everything indexed for a single block, which must be the current head
+func (idx *Index) rollback(number uint64) error {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	batch := idx.db.NewBatch()
+
+	prefix := append(append([]byte{}, receiptPrefix...), encodeNumber(number)...)
+	it := idx.db.NewIterator(prefix, nil)
+	for it.Next() {
+		var receipt kaon.TransactionReceipt
+		if err := json.Unmarshal(it.Value(), &receipt); err != nil {
+			it.Release()
+			return errors.Wrap(err, "couldn't decode indexed receipt")
+		}
+
+		position := copyBytes(it.Key()[len(receiptPrefix):])
+		for _, log := range receipt.Log {
+			batch.Delete(addressKey(log.Address, position))
+			for _, topic := range log.Topics {
+				batch.Delete(topicKey(topic, position))
+			}
+		}
+		batch.Delete(txLookupKey(receipt.TransactionHash))
+		batch.Delete(copyBytes(it.Key()))
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	batch.Delete(blockHashKey(number))
+	if number > idx.startHeight {
+		batch.Put(headKey, encodeNumber(number-1))
+	} else {
+		batch.Delete(headKey)
+	}
+
+	return batch.Write()
+}
+
+// Same semantics as kaond's searchlogs: returns every receipt in the range with at least one log
+// matching the requested addresses and topics. Fails with ErrNotIndexed if the range hasn't been indexed yet.
+func (idx *Index) SearchLogs(ctx context.Context, req *kaon.SearchLogsRequest) (kaon.SearchLogsResponse, error) {
+	if req.FromBlock == nil || req.ToBlock == nil || !req.FromBlock.IsUint64() || !req.ToBlock.IsUint64() {
+		return nil, ErrNotIndexed
+	}
+	from, to := req.FromBlock.Uint64(), req.ToBlock.Uint64()
+
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	if !idx.Covers(from, to) {
+		return nil, ErrNotIndexed
+	}
+
+	keys, err := idx.receiptKeys(req, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make(map[common.Address]bool, len(req.Addresses))
+	for _, address := range req.Addresses {
+		addresses[common.HexToAddress(address)] = true
+	}
+
+	receipts := make(kaon.SearchLogsResponse, 0, len(keys))
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		data, err := idx.db.Get(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't read indexed receipt")
+		}
+
+		var receipt kaon.TransactionReceipt
+		if err := json.Unmarshal(data, &receipt); err != nil {
+			return nil, errors.Wrap(err, "couldn't decode indexed receipt")
+		}
+
+		for _, log := range receipt.Log {
+			if len(addresses) != 0 && !addresses[common.HexToAddress(log.Address)] {
+				continue
+			}
+			if conversion.DoFiltersMatch(req.Topics, log.Topics) {
+				receipts = append(receipts, receipt)
+				break
+			}
+		}
+	}
+
+	return receipts, nil
+}
+
+// Returns the indexed receipts of a transaction with logs, ordered by output index. Only executions
+// that emitted logs are indexed, so a transaction may have more receipts than returned here.
+func (idx *Index) GetTransactionReceipts(txHash string) (kaon.GetTransactionReceiptsResponse, error) {
+	if idx == nil {
+		return nil, ErrReceiptNotIndexed
+	}
+
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	position, err := idx.db.Get(txLookupKey(txHash))
+	if err != nil {
+		return nil, ErrReceiptNotIndexed
+	}
+
+	prefix := append(append([]byte{}, receiptPrefix...), position...)
+	it := idx.db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var receipts kaon.GetTransactionReceiptsResponse
+	for it.Next() {
+		var receipt kaon.TransactionReceipt
+		if err := json.Unmarshal(it.Value(), &receipt); err != nil {
+			return nil, errors.Wrap(err, "couldn't decode indexed receipt")
+		}
+		receipts = append(receipts, receipt)
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	if len(receipts) == 0 {
+		return nil, ErrReceiptNotIndexed
+	}
+
+	return receipts, nil
+}
+
+var _ kaon.ExecutionStore = (*Index)(nil)
+
+// Looks up a derived transaction hash of a contract execution, see kaon.Execution
+func (idx *Index) GetExecution(hash string) (kaon.Execution, bool, error) {
+	var execution kaon.Execution
+	key := executionKey(hash)
+	has, err := idx.db.Has(key)
+	if err != nil || !has {
+		return execution, false, err
+	}
+	data, err := idx.db.Get(key)
+	if err != nil {
+		return execution, false, err
+	}
+	if err := json.Unmarshal(data, &execution); err != nil {
+		return execution, false, errors.Wrap(err, "couldn't decode execution")
+	}
+	return execution, true, nil
+}
+
+// Persists derived transaction hashes, they only depend on the transaction so reorganisations don't affect them
+func (idx *Index) PutExecutions(executions []kaon.Execution) error {
+	batch := idx.db.NewBatch()
+	for _, execution := range executions {
+		data, err := json.Marshal(execution)
+		if err != nil {
+			return errors.Wrap(err, "couldn't encode execution")
+		}
+		if err := batch.Put(executionKey(execution.Hash), data); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+var _ kaon.EthereumHeaderStore = (*Index)(nil)
+
+// Looks up the Ethereum header computed for a block served with --eth-headers
+func (idx *Index) GetEthereumHeader(kaonBlockHash string) (kaon.EthereumHeader, bool, error) {
+	var header kaon.EthereumHeader
+	key := hashKey(headerPrefix, kaonBlockHash)
+	has, err := idx.db.Has(key)
+	if err != nil || !has {
+		return header, false, err
+	}
+	data, err := idx.db.Get(key)
+	if err != nil {
+		return header, false, err
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return header, false, errors.Wrap(err, "couldn't decode Ethereum header")
+	}
+	return header, true, nil
+}
+
+// Looks up the Kaon block an Ethereum block hash served with --eth-headers stands for
+func (idx *Index) GetKaonBlockHash(ethereumBlockHash string) (string, bool, error) {
+	key := hashKey(ethBlockPrefix, ethereumBlockHash)
+	has, err := idx.db.Has(key)
+	if err != nil || !has {
+		return "", false, err
+	}
+	data, err := idx.db.Get(key)
+	if err != nil {
+		return "", false, err
+	}
+	return hex.EncodeToString(data), true, nil
+}
+
+// Persists the Ethereum header of a block, it only depends on the block and its ancestors so reorganisations don't affect it
+func (idx *Index) PutEthereumHeader(kaonBlockHash string, header kaon.EthereumHeader) error {
+	data, err := json.Marshal(header)
+	if err != nil {
+		return errors.Wrap(err, "couldn't encode Ethereum header")
+	}
+	batch := idx.db.NewBatch()
+	if err := batch.Put(hashKey(headerPrefix, kaonBlockHash), data); err != nil {
+		return err
+	}
+	if err := batch.Put(hashKey(ethBlockPrefix, header.Hash), common.HexToHash(kaonBlockHash).Bytes()); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+var _ kaon.BlockLogPositionsStore = (*Index)(nil)
+
+// Looks up the serialized log positions derived for a block, see conversion.BlockLogPositions
+func (idx *Index) GetBlockLogPositions(blockHash string) ([]byte, bool, error) {
+	key := hashKey(positionsPrefix, blockHash)
+	has, err := idx.db.Has(key)
+	if err != nil || !has {
+		return nil, false, err
+	}
+	data, err := idx.db.Get(key)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Persists the log positions of a block, they only depend on the block so reorganisations don't affect them
+func (idx *Index) PutBlockLogPositions(blockHash string, positions []byte) error {
+	return idx.db.Put(hashKey(positionsPrefix, blockHash), positions)
+}
+
+// Collects the keys of candidate receipts in block order, using the address index when addresses
+// are requested, then the topic index, and a plain range scan otherwise
+func (idx *Index) receiptKeys(req *kaon.SearchLogsRequest, from, to uint64) ([][]byte, error) {
+	var prefixes [][]byte
+	if len(req.Addresses) != 0 {
+		for _, address := range req.Addresses {
+			prefixes = append(prefixes, addressKey(address, nil))
+		}
+	} else {
+		for _, filter := range req.Topics {
+			if len(filter) == 0 {
+				continue
+			}
+			for _, topic := range filter {
+				prefixes = append(prefixes, topicKey(topic, nil))
+			}
+			break
+		}
+	}
+
+	if len(prefixes) == 0 {
+		prefixes = append(prefixes, receiptPrefix)
+	}
+
+	var keys [][]byte
+	for _, prefix := range prefixes {
+		it := idx.db.NewIterator(prefix, encodeNumber(from))
+		for it.Next() {
+			position := it.Key()[len(prefix):]
+			if binary.BigEndian.Uint64(position[:8]) > to {
+				break
+			}
+			keys = append(keys, append(append([]byte{}, receiptPrefix...), position...))
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	unique := keys[:0]
+	for i, key := range keys {
+		if i == 0 || !bytes.Equal(key, keys[i-1]) {
+			unique = append(unique, key)
+		}
+	}
+
+	return unique, nil
+}
+
+func putReceipt(batch ethdb.Batch, receipt *kaon.TransactionReceipt) error {
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return errors.Wrap(err, "couldn't encode receipt")
+	}
+
+	position := receiptPosition(receipt)
+	if err := batch.Put(append(append([]byte{}, receiptPrefix...), position...), data); err != nil {
+		return err
+	}
+	if err := batch.Put(txLookupKey(receipt.TransactionHash), position[:16]); err != nil {
+		return err
+	}
+
+	for _, log := range receipt.Log {
+		if err := batch.Put(addressKey(log.Address, position), nil); err != nil {
+			return err
+		}
+		for _, topic := range log.Topics {
+			if err := batch.Put(topicKey(topic, position), nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func receiptPosition(receipt *kaon.TransactionReceipt) []byte {
+	position := make([]byte, 24)
+	binary.BigEndian.PutUint64(position[0:], receipt.BlockNumber)
+	binary.BigEndian.PutUint64(position[8:], receipt.TransactionIndex)
+	binary.BigEndian.PutUint64(position[16:], uint64(receipt.OutputIndex))
+	return position
+}
+
+func blockHashKey(number uint64) []byte {
+	return append(append([]byte{}, blockHashPrefix...), encodeNumber(number)...)
+}
+
+func txLookupKey(txHash string) []byte {
+	return append(append([]byte{}, txLookupPrefix...), common.HexToHash(txHash).Bytes()...)
+}
+
+func executionKey(hash string) []byte {
+	return hashKey(executionPrefix, hash)
+}
+
+func hashKey(prefix []byte, hash string) []byte {
+	return append(append([]byte{}, prefix...), common.HexToHash(hash).Bytes()...)
+}
+
+func addressKey(address string, position []byte) []byte {
+	key := append(append([]byte{}, addressPrefix...), common.HexToAddress(address).Bytes()...)
+	return append(key, position...)
+}
+
+func topicKey(topic string, position []byte) []byte {
+	key := append(append([]byte{}, topicPrefix...), common.HexToHash(topic).Bytes()...)
+	return append(key, position...)
+}
+
+func encodeNumber(number uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	return enc
+}
+
+func readNumber(db ethdb.KeyValueReader, key []byte) (uint64, bool, error) {
+	has, err := db.Has(key)
+	if err != nil || !has {
+		return 0, false, err
+	}
+
+	data, err := db.Get(key)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(data) != 8 {
+		return 0, false, errors.Errorf("corrupted log index entry %q", key)
+	}
+
+	return binary.BigEndian.Uint64(data), true, nil
+}
+
+func copyBytes(b []byte) []byte {
+	return append([]byte{}, b...)
+}
@@ -0,0 +1,246 @@
+package logindex
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+)
+
+var (
+	testAddressA = "db46f738bf32cdafb9a4a70eb8b44c76646bcaf0"
+	testAddressB = "6b22910b1e302cf74803ffd1691c2ecb858d3712"
+	testTopicA   = "0f6798a560793a54c3bcfe86a93cde1e73087d944c0ea20544137d4121396885"
+	testTopicB   = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+)
+
+func testReceipt(blockHash string, blockNumber uint64, txHash string, address string, topic string) kaon.TransactionReceipt {
+	receipt := internal.KaonTransactionReceipt([]kaon.Log{{
+		Address: address,
+		Topics:  []string{topic},
+		Data:    "0000000000000000000000000000000000000000000000000000000000000001",
+	}})
+	receipt.BlockHash = blockHash
+	receipt.BlockNumber = blockNumber
+	receipt.TransactionHash = txHash
+	return receipt
+}
+
+func newSyncedIndex(t *testing.T, db ethdb.KeyValueStore, blockCount int64, blockHash string, receipts kaon.SearchLogsResponse) *Index {
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = clientDoerMock.AddResponse(kaon.MethodGetBlockCount, big.NewInt(blockCount)); err != nil {
+		t.Fatal(err)
+	}
+	if err = clientDoerMock.AddResponse(kaon.MethodGetBlockHash, blockHash); err != nil {
+		t.Fatal(err)
+	}
+	if err = clientDoerMock.AddResponse(kaon.MethodSearchLogs, receipts); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := New(context.Background(), kaonClient, db, SetStartHeight(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = idx.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return idx
+}
+
+func TestSyncAndSearchLogs(t *testing.T) {
+	blockHash := "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	idx := newSyncedIndex(t, memorydb.New(), 3, blockHash, kaon.SearchLogsResponse{
+		testReceipt(blockHash, 1, "11", testAddressA, testTopicA),
+		testReceipt(blockHash, 2, "22", testAddressB, testTopicB),
+		testReceipt(blockHash, 3, "33", testAddressA, testTopicB),
+	})
+
+	if head, ok := idx.Head(); !ok || head != 3 {
+		t.Fatalf("expected head 3, got %d (%v)", head, ok)
+	}
+	if !idx.Covers(1, 3) {
+		t.Fatal("expected blocks [1, 3] to be indexed")
+	}
+	if idx.Covers(0, 3) || idx.Covers(1, 4) {
+		t.Fatal("blocks outside of the indexed range reported as covered")
+	}
+
+	byAddress, err := idx.SearchLogs(context.Background(), &kaon.SearchLogsRequest{
+		FromBlock: big.NewInt(1),
+		ToBlock:   big.NewInt(3),
+		Addresses: []string{testAddressA},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byAddress) != 2 || byAddress[0].BlockNumber != 1 || byAddress[1].BlockNumber != 3 {
+		t.Fatalf("unexpected receipts searching by address: %+v", byAddress)
+	}
+
+	byTopic, err := idx.SearchLogs(context.Background(), &kaon.SearchLogsRequest{
+		FromBlock: big.NewInt(1),
+		ToBlock:   big.NewInt(2),
+		Topics:    []kaon.SearchLogsTopic{{testTopicB}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byTopic) != 1 || byTopic[0].TransactionHash != "22" {
+		t.Fatalf("unexpected receipts searching by topic: %+v", byTopic)
+	}
+
+	if _, err = idx.SearchLogs(context.Background(), &kaon.SearchLogsRequest{
+		FromBlock: big.NewInt(2),
+		ToBlock:   big.NewInt(10),
+	}); err != ErrNotIndexed {
+		t.Fatalf("expected ErrNotIndexed, got %v", err)
+	}
+
+	receipts, err := idx.GetTransactionReceipts("0x33")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 1 || receipts[0].BlockNumber != 3 || len(receipts[0].Log) != 1 {
+		t.Fatalf("unexpected receipts: %+v", receipts)
+	}
+}
+
+func TestSyncResumesAfterRestart(t *testing.T) {
+	blockHash := "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	db := memorydb.New()
+
+	newSyncedIndex(t, db, 2, blockHash, kaon.SearchLogsResponse{
+		testReceipt(blockHash, 1, "11", testAddressA, testTopicA),
+	})
+
+	// kaond only returns logs for the blocks that haven't been indexed yet
+	idx := newSyncedIndex(t, db, 4, blockHash, kaon.SearchLogsResponse{
+		testReceipt(blockHash, 4, "44", testAddressA, testTopicA),
+	})
+
+	if !idx.Covers(1, 4) {
+		t.Fatal("expected blocks [1, 4] to be indexed")
+	}
+
+	receipts, err := idx.SearchLogs(context.Background(), &kaon.SearchLogsRequest{
+		FromBlock: big.NewInt(1),
+		ToBlock:   big.NewInt(4),
+		Addresses: []string{testAddressA},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 2 || receipts[0].TransactionHash != "11" || receipts[1].TransactionHash != "44" {
+		t.Fatalf("unexpected receipts: %+v", receipts)
+	}
+}
+
+func TestSyncRollsBackReorganisedBlocks(t *testing.T) {
+	oldHash := "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	newHash := "8fcd819194cce6a8454b2bec334d3448df4f097e9cdc36707bfd569900268950"
+	db := memorydb.New()
+
+	newSyncedIndex(t, db, 2, oldHash, kaon.SearchLogsResponse{
+		testReceipt(oldHash, 2, "22", testAddressA, testTopicA),
+	})
+
+	idx := newSyncedIndex(t, db, 2, newHash, kaon.SearchLogsResponse{
+		testReceipt(newHash, 2, "23", testAddressB, testTopicA),
+	})
+
+	if _, err := idx.GetTransactionReceipts("22"); err != ErrReceiptNotIndexed {
+		t.Fatalf("expected reorganised receipt to be removed, got %v", err)
+	}
+
+	receipts, err := idx.SearchLogs(context.Background(), &kaon.SearchLogsRequest{
+		FromBlock: big.NewInt(1),
+		ToBlock:   big.NewInt(2),
+		Topics:    []kaon.SearchLogsTopic{{testTopicA}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 1 || receipts[0].TransactionHash != "23" || receipts[0].BlockHash != newHash {
+		t.Fatalf("unexpected receipts: %+v", receipts)
+	}
+}
+
+func TestExecutionsArePersisted(t *testing.T) {
+	db := memorydb.New()
+	idx := newSyncedIndex(t, db, 0, "", kaon.SearchLogsResponse{})
+
+	executions := kaon.Executions("3208dc44733cbfa11654ad5651305428de473ef1e61a1ec07b0c1a5f4843be91", []kaon.ContractInfo{{OutputIndex: 1}, {OutputIndex: 3}})
+	if err := idx.PutExecutions(executions[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := newSyncedIndex(t, db, 0, "", kaon.SearchLogsResponse{})
+	execution, ok, err := reopened.GetExecution(executions[1].Hash)
+	if err != nil || !ok || execution != executions[1] {
+		t.Errorf("expected the execution to be found after reopening the index, got %+v %v %v", execution, ok, err)
+	}
+	if _, ok, _ := reopened.GetExecution(executions[0].Hash); ok {
+		t.Error("expected the transaction id not to be found")
+	}
+}
+
+func TestBlockLogPositionsArePersisted(t *testing.T) {
+	db := memorydb.New()
+	idx := newSyncedIndex(t, db, 0, "", kaon.SearchLogsResponse{})
+
+	blockHash := "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	positions := []byte(`{"executions":[],"firstLogIndexes":[],"logsBloom":"0x00"}`)
+	if err := idx.PutBlockLogPositions(blockHash, positions); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := newSyncedIndex(t, db, 0, "", kaon.SearchLogsResponse{})
+	got, ok, err := reopened.GetBlockLogPositions("0x" + blockHash)
+	if err != nil || !ok || string(got) != string(positions) {
+		t.Errorf("expected the positions to be found after reopening the index, got %s %v %v", got, ok, err)
+	}
+	if _, ok, _ := reopened.GetBlockLogPositions("6f9b1e0b83b5a2d2f1f4a3f9c3ad4f0a3e0c7f6a2b1c0d9e8f7a6b5c4d3e2f1a"); ok {
+		t.Error("expected positions of another block not to be found")
+	}
+}
+
+func TestEthereumHeadersArePersisted(t *testing.T) {
+	db := memorydb.New()
+	idx := newSyncedIndex(t, db, 0, "", kaon.SearchLogsResponse{})
+
+	kaonBlockHash := "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	header := kaon.EthereumHeader{
+		Hash:             "0x6f9b1e0b83b5a2d2f1f4a3f9c3ad4f0a3e0c7f6a2b1c0d9e8f7a6b5c4d3e2f1a",
+		ParentHash:       "0x0000000000000000000000000000000000000000000000000000000000000000",
+		TransactionsRoot: "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+		ReceiptsRoot:     "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+	}
+	if err := idx.PutEthereumHeader(kaonBlockHash, header); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := newSyncedIndex(t, db, 0, "", kaon.SearchLogsResponse{})
+	got, ok, err := reopened.GetEthereumHeader(kaonBlockHash)
+	if err != nil || !ok || got != header {
+		t.Errorf("expected the header to be found after reopening the index, got %+v %v %v", got, ok, err)
+	}
+	resolved, ok, err := reopened.GetKaonBlockHash(header.Hash)
+	if err != nil || !ok || resolved != kaonBlockHash {
+		t.Errorf("expected the Ethereum hash to resolve to %s, got %s %v %v", kaonBlockHash, resolved, ok, err)
+	}
+	if _, ok, _ := reopened.GetEthereumHeader(header.Hash); ok {
+		t.Error("expected no header for an Ethereum block hash")
+	}
+}
@@ -167,6 +167,15 @@ func (s *subscriptionInformation) runLogsSubscription() {
 				kaonLogs := kaonLog.Log
 				logs := conversion.FilterKaonLogs(stringAddresses, kaonTopics, kaonLogs)
 				ethLogs := conversion.ExtractETHLogsFromTransactionReceipt(kaonLog, logs)
+				if len(ethLogs) != 0 {
+					blockHash, ok := s.servedBlockHash(kaonLog.BlockHash)
+					if !ok {
+						return
+					}
+					for i := range ethLogs {
+						ethLogs[i].BlockHash = blockHash
+					}
+				}
 				for _, ethLog := range ethLogs {
 					subscription := &eth.EthSubscription{
 						SubscriptionID: s.Subscription.id,
@@ -317,6 +326,42 @@ func (s *subscriptionInformation) runNewHeadsSubscription() {
 	}
 }
 
+// Hash the block of a log is served with, which is the hash of its Ethereum header with --eth-headers.
+// Headers that weren't computed yet are computed by serving the block.
+func (s *subscriptionInformation) servedBlockHash(kaonBlockHash string) (string, bool) {
+	if !s.kaon.EthHeadersEnabled() {
+		return utils.AddHexPrefix(kaonBlockHash), true
+	}
+	if header, ok := s.kaon.EthereumHeader(kaonBlockHash); ok {
+		return header.Hash, true
+	}
+
+	s.mutex.RLock()
+	transformer := s.agent.transformer
+	s.mutex.RUnlock()
+	if transformer == nil {
+		s.kaon.GetErrorLogger().Log("msg", "No transformer available, cannot compute Ethereum block hash of logs", "subscriptionId", s.id)
+		return "", false
+	}
+
+	params, err := json.Marshal([]interface{}{utils.AddHexPrefix(kaonBlockHash), false})
+	if err != nil {
+		s.kaon.GetErrorLogger().Log("subscriptionId", s.id, "err", err)
+		return "", false
+	}
+	result, jsonErr := transformer.Transform(&eth.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "eth_getBlockByHash",
+		Params:  params,
+	}, NewEchoWithContext(s.ctx))
+	block, ok := result.(*eth.GetBlockByHashResponse)
+	if jsonErr != nil || !ok || block == nil {
+		s.kaon.GetErrorLogger().Log("msg", "Failed to eth_getBlockByHash", "subscriptionId", s.id, "hash", kaonBlockHash, "err", jsonErr)
+		return "", false
+	}
+	return block.Hash, true
+}
+
 // Compute hash for the json serialization of the passed in argument
 func computeHash(value interface{}) string {
 	b, err := json.Marshal(value)
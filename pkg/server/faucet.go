@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/faucet"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+)
+
+type faucetRequest struct {
+	Address string `json:"address" form:"address" query:"address"`
+}
+
+type faucetResponse struct {
+	TxID   string `json:"txid,omitempty"`
+	Amount string `json:"amount,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Sends the faucet's amount to an address, each address can be funded once per rate limit period
+func (s *Server) faucetHandler(c echo.Context) error {
+	var req faucetRequest
+	if err := c.Bind(&req); err != nil || req.Address == "" {
+		return c.JSON(http.StatusBadRequest, faucetResponse{Error: "an address is required"})
+	}
+
+	txID, err := s.faucet.FundRateLimited(c.Request().Context(), req.Address)
+	switch err {
+	case nil:
+		return c.JSON(http.StatusOK, faucetResponse{
+			TxID:   utils.AddHexPrefix(txID),
+			Amount: s.faucet.Amount().String(),
+		})
+	case faucet.ErrRateLimited:
+		return c.JSON(http.StatusTooManyRequests, faucetResponse{Error: err.Error()})
+	case faucet.ErrMainnet:
+		return c.JSON(http.StatusForbidden, faucetResponse{Error: err.Error()})
+	default:
+		s.logger.Log("msg", "Faucet failed to fund address", "address", req.Address, "err", err)
+		return c.JSON(http.StatusInternalServerError, faucetResponse{Error: err.Error()})
+	}
+}
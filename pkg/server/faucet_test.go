@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/faucet"
+	"github.com/kaonone/eth-rpc-gate/pkg/internal/fakekaond"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+	"github.com/shopspring/decimal"
+)
+
+func TestFaucetHandler(t *testing.T) {
+	const minerHex = "7926223070547d2d15b2ef5e7383e541c338ffe9"
+
+	node, s := newTestServer(t)
+	miner, err := s.kaonRPCClient.FromHexAddress(minerHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.kaonRPCClient.SetFlag(kaon.FLAG_GENERATE_ADDRESS_TO, miner)
+	if _, err := node.Generate(fakekaond.DefaultCoinbaseMaturity+1, minerHex); err != nil {
+		t.Fatal(err)
+	}
+	s.faucet, err = faucet.New(s.kaonRPCClient, faucet.SetAmount(decimal.NewFromInt(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(req *http.Request) (int, faucetResponse) {
+		rec := httptest.NewRecorder()
+		if err := s.faucetHandler(echo.New().NewContext(req, rec)); err != nil {
+			t.Fatal(err)
+		}
+		var resp faucetResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, resp
+	}
+	jsonRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/faucet", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return req
+	}
+
+	status, funded := request(jsonRequest(`{"address":"0x2352be3db3177f0a07efbe6da5857615b8c9901d"}`))
+	if status != http.StatusOK || !strings.HasPrefix(funded.TxID, "0x") || funded.Amount != "3" || funded.Error != "" {
+		t.Fatalf("unexpected funding response %d %+v", status, funded)
+	}
+
+	// form requests are accepted too
+	form := url.Values{"address": {"0x2352be3db3177f0a07efbe6da5857615b8c9901d"}}
+	req := httptest.NewRequest(http.MethodPost, "/faucet", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	status, limited := request(req)
+	if status != http.StatusTooManyRequests || limited.Error != faucet.ErrRateLimited.Error() {
+		t.Fatalf("expected the second funding to be rate limited, got %d %+v", status, limited)
+	}
+
+	status, missing := request(jsonRequest(`{}`))
+	if status != http.StatusBadRequest || missing.Error == "" {
+		t.Fatalf("expected a request without address to be rejected, got %d %+v", status, missing)
+	}
+
+	status, invalid := request(jsonRequest(`{"address":"not an address"}`))
+	if status != http.StatusInternalServerError || invalid.Error == "" {
+		t.Fatalf("expected an invalid address to fail, got %d %+v", status, invalid)
+	}
+}
@@ -299,7 +299,11 @@ func websocketHandler(c echo.Context) error {
 		return err
 	}
 
+	// sends a close frame when the gateway shuts down
+	connection := cc.websockets.add(ws, &writeMutex)
+
 	defer func() {
+		cc.websockets.remove(connection)
 		stopPingPong()
 		close()
 		cc.GetDebugLogger().Log("msg", "Websocket connection closed")
@@ -322,60 +326,66 @@ func websocketHandler(c echo.Context) error {
 			cc.GetLogger().Log("msg", "Failed to read websocket message", "err", err)
 			return nil
 		}
-		var rpcReqs []eth.JSONRPCRequest
-
-		isBatchedRequest := isBatchRequests(req)
-		if !isBatchedRequest {
-			var rpcReq eth.JSONRPCRequest
-			json.Unmarshal(req, &rpcReq)
-			rpcReqs = append(rpcReqs, rpcReq)
-		} else {
-			json.Unmarshal(req, &rpcReqs)
-		}
-
-		responses, err := getRpcResponses(c, cc, rpcReqs)
-		if err != nil {
-			cc.GetErrorLogger().Log("err", err.Error())
+		if !connection.serve(func() bool { return serveWebsocketMessage(c, cc, req, send, notifier) }) {
 			return nil
 		}
+	}
+}
 
-		var response interface{}
-		if isBatchedRequest {
-			response = responses
-		} else if len(responses) > 0 {
-			response = responses[0]
-		} else {
-			response = []interface{}{}
-		}
+// answers a websocket message, returns false if the connection should be closed
+func serveWebsocketMessage(c echo.Context, cc *myCtx, req []byte, send func([]byte) error, notifier *notifier.Notifier) bool {
+	var rpcReqs []eth.JSONRPCRequest
 
-		responseBytes, err := json.Marshal(response)
+	isBatchedRequest := isBatchRequests(req)
+	if !isBatchedRequest {
+		var rpcReq eth.JSONRPCRequest
+		json.Unmarshal(req, &rpcReq)
+		rpcReqs = append(rpcReqs, rpcReq)
+	} else {
+		json.Unmarshal(req, &rpcReqs)
+	}
 
-		if err != nil {
-			cc.GetErrorLogger().Log("err", err.Error())
-			return nil
-		}
+	responses, err := getRpcResponses(c, cc, rpcReqs)
+	if err != nil {
+		cc.GetErrorLogger().Log("err", err.Error())
+		return false
+	}
 
-		cc.GetDebugLogger().Log("response", string(responseBytes))
+	var response interface{}
+	if isBatchedRequest {
+		response = responses
+	} else if len(responses) > 0 {
+		response = responses[0]
+	} else {
+		response = []interface{}{}
+	}
 
-		err = send(responseBytes)
-		if err == nil {
-			notifier.ResponseSent()
-
-			if cc.IsDebugEnabled() {
-				reqBody, err := kaon.ReformatJSON(req)
-				resBody, err := kaon.ReformatJSON(responseBytes)
-				if err == nil {
-					cc.GetDebugLogger().Log("msg", "ETH WEBSOCKET RPC")
-					fmt.Fprintf(cc.GetLogWriter(), "=> ETH request\n%s\n", reqBody)
-					fmt.Fprintf(cc.GetLogWriter(), "<= ETH response\n%s\n", resBody)
-				}
-			}
+	responseBytes, err := json.Marshal(response)
 
-		} else {
-			cc.GetErrorLogger().Log("err", err.Error())
-			return nil
+	if err != nil {
+		cc.GetErrorLogger().Log("err", err.Error())
+		return false
+	}
+
+	cc.GetDebugLogger().Log("response", string(responseBytes))
+
+	err = send(responseBytes)
+	if err != nil {
+		cc.GetErrorLogger().Log("err", err.Error())
+		return false
+	}
+	notifier.ResponseSent()
+
+	if cc.IsDebugEnabled() {
+		reqBody, err := kaon.ReformatJSON(req)
+		resBody, err := kaon.ReformatJSON(responseBytes)
+		if err == nil {
+			cc.GetDebugLogger().Log("msg", "ETH WEBSOCKET RPC")
+			fmt.Fprintf(cc.GetLogWriter(), "=> ETH request\n%s\n", reqBody)
+			fmt.Fprintf(cc.GetLogWriter(), "<= ETH response\n%s\n", resBody)
 		}
 	}
+	return true
 }
 
 func errorHandler(err error, c echo.Context) {
@@ -13,6 +13,19 @@ var ErrCannotGetConnectedChain = errors.New("Cannot detect chain kaond is connec
 var ErrBlockSyncingSeemsStalled = errors.New("Block syncing seems stalled")
 var ErrLostLotsOfBlocks = errors.New("Lost a lot of blocks, expected block height to be higher")
 var ErrLostFewBlocks = errors.New("Lost a few blocks, expected block height to be higher")
+var ErrShuttingDown = errors.New("Shutting down, not accepting new connections")
+
+const (
+	// minimum percentage of successful requests
+	DefaultHealthCheckPercent = 80
+	// blocks kaond may be behind the best header it knows of
+	DefaultHealthMaxHeaderLag = 5
+	// blocks the block hash database may be missing
+	DefaultHealthMaxBlockHashLag = 100
+)
+
+// how often the block hash database is checked for missing blocks, the query can be slow
+const blockHashCheckInterval = 30 * time.Second
 
 func (s *Server) testConnectionToKaond() error {
 	networkInfo, err := s.kaonRPCClient.GetNetworkInfo(s.kaonRPCClient.GetContext())
@@ -33,16 +46,16 @@ func (s *Server) testConnectionToKaond() error {
 				return nil
 			}
 			if networkInfo.Connections == 0 {
-				s.logger.Log("liveness", "kaond has no network connections")
+				s.logger.Log("readiness", "kaond has no network connections")
 				return ErrNoKaonConnections
 			}
 			break
 		case <-getChainTimeout.C:
-			s.logger.Log("liveness", "kaond getnetworkinfo request timed out")
+			s.logger.Log("readiness", "kaond getnetworkinfo request timed out")
 			return ErrCannotGetConnectedChain
 		}
 	} else {
-		s.logger.Log("liveness", "kaond getnetworkinfo errored", "err", err)
+		s.logger.Log("readiness", "kaond getnetworkinfo errored", "err", err)
 	}
 	return err
 }
@@ -50,7 +63,7 @@ func (s *Server) testConnectionToKaond() error {
 func (s *Server) testLogEvents() error {
 	_, err := s.kaonRPCClient.GetTransactionReceipt(s.kaonRPCClient.GetContext(), "9d37c33f92231cfc1a099029543f54e5996baaf7235e79dfd2e72c7bbeb96683")
 	if err == kaon.ErrInternalError {
-		s.logger.Log("liveness", "-logevents might not be enabled")
+		s.logger.Log("readiness", "-logevents might not be enabled")
 		return errors.Wrap(err, "-logevents might not be enabled")
 	}
 	return nil
@@ -68,7 +81,7 @@ func (s *Server) testBlocksSyncing() error {
 	}
 	if nextBlockCheck.After(now) {
 		if lastBlockStatus != nil {
-			s.logger.Log("liveness", "blocks syncing", "err", lastBlockStatus)
+			s.logger.Log("readiness", "blocks syncing", "err", lastBlockStatus)
 		}
 		return lastBlockStatus
 	}
@@ -82,7 +95,7 @@ func (s *Server) testBlocksSyncing() error {
 
 	blockChainInfo, err := s.kaonRPCClient.GetBlockChainInfo(s.kaonRPCClient.GetContext())
 	if err != nil {
-		s.logger.Log("liveness", "getblockchainfo request failed", "err", err)
+		s.logger.Log("readiness", "getblockchainfo request failed", "err", err)
 		return err
 	}
 
@@ -102,14 +115,14 @@ func (s *Server) testBlocksSyncing() error {
 			s.lastBlock = 0
 			nextBlockCheckTime = time.Now().Add(60 * time.Second)
 			s.nextBlockCheck = &nextBlockCheckTime
-			s.logger.Log("liveness", "Lost lots of blocks")
+			s.logger.Log("readiness", "Lost lots of blocks")
 			s.lastBlockStatus = ErrLostLotsOfBlocks
 		} else {
 			// lost a few blocks
 			// could be kaond nodes out of sync behind a load balancer
 			nextBlockCheckTime = time.Now().Add(10 * time.Second)
 			s.nextBlockCheck = &nextBlockCheckTime
-			s.logger.Log("liveness", "Lost a few blocks")
+			s.logger.Log("readiness", "Lost a few blocks")
 			s.lastBlockStatus = ErrLostFewBlocks
 		}
 	} else {
@@ -123,12 +136,61 @@ func (s *Server) testBlocksSyncing() error {
 	return s.lastBlockStatus
 }
 
+// kaond is in sync with the best header it knows of
+func (s *Server) testKaondSynced() error {
+	blockChainInfo, err := s.kaonRPCClient.GetBlockChainInfo(s.kaonRPCClient.GetContext())
+	if err != nil {
+		s.logger.Log("readiness", "getblockchainfo request failed", "err", err)
+		return err
+	}
+	if lag := blockChainInfo.Headers - blockChainInfo.Blocks; lag > s.healthMaxHeaderLag {
+		s.logger.Log("readiness", "kaond is behind the best header", "lag", lag)
+		return errors.New(fmt.Sprintf("kaond is %d blocks behind the best header, more than %d", lag, s.healthMaxHeaderLag))
+	}
+	return nil
+}
+
+// the block hash database has the Ethereum block hashes of the blocks served
+func (s *Server) testBlockHashLag() error {
+	blockCount, err := s.kaonRPCClient.GetBlockCount(s.kaonRPCClient.GetContext())
+	if err != nil {
+		s.logger.Log("readiness", "getblockcount request failed", "err", err)
+		return err
+	}
+	missing, err := s.blockHash.MissingBlocks(s.kaonRPCClient.GetContext(), blockCount.Int.Int64())
+	if err != nil {
+		s.logger.Log("readiness", "block hash database lag unknown", "err", err)
+		return err
+	}
+
+	s.blocksMutex.Lock()
+	s.blockHashLag = &missing
+	s.blocksMutex.Unlock()
+
+	if missing > s.healthMaxBlockHashLag {
+		s.logger.Log("readiness", "block hash database is behind", "missing", missing)
+		return errors.New(fmt.Sprintf("block hash database is missing %d blocks, more than %d", missing, s.healthMaxBlockHashLag))
+	}
+	return nil
+}
+
+func (s *Server) minimumSuccessRate() float32 {
+	percent := DefaultHealthCheckPercent
+	if s.healthCheckPercent != nil {
+		percent = *s.healthCheckPercent
+	}
+	return float32(percent) / 100
+}
+
 func (s *Server) testKaondErrorRate() error {
-	minimumSuccessRate := float32(*s.healthCheckPercent / 100)
+	if s.kaonRequestAnalytics == nil {
+		return nil
+	}
+	minimumSuccessRate := s.minimumSuccessRate()
 	kaonSuccessRate := s.kaonRequestAnalytics.GetSuccessRate()
 
 	if kaonSuccessRate < minimumSuccessRate {
-		s.logger.Log("liveness", "kaond request success rate is low", "rate", kaonSuccessRate)
+		s.logger.Log("readiness", "kaond request success rate is low", "rate", kaonSuccessRate)
 		return errors.New(fmt.Sprintf("kaond request success rate is %f<%f", kaonSuccessRate, minimumSuccessRate))
 	} else {
 		return nil
@@ -136,13 +198,20 @@ func (s *Server) testKaondErrorRate() error {
 }
 
 func (s *Server) testEthRPCGateErrorRate() error {
-	minimumSuccessRate := float32(*s.healthCheckPercent / 100)
+	minimumSuccessRate := s.minimumSuccessRate()
 	ethSuccessRate := s.ethRequestAnalytics.GetSuccessRate()
 
 	if ethSuccessRate < minimumSuccessRate {
-		s.logger.Log("liveness", "client eth success rate is low", "rate", ethSuccessRate)
+		s.logger.Log("readiness", "client eth success rate is low", "rate", ethSuccessRate)
 		return errors.New(fmt.Sprintf("client eth request success rate is %f<%f", ethSuccessRate, minimumSuccessRate))
 	} else {
 		return nil
 	}
 }
+
+func (s *Server) testShuttingDown() error {
+	if s.isShuttingDown() {
+		return ErrShuttingDown
+	}
+	return nil
+}
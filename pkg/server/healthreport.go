@@ -0,0 +1,140 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/heptiolabs/healthcheck"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+type healthCheck struct {
+	name string
+	// failing readiness checks take the gateway out of rotation, failing liveness checks get it restarted
+	readiness bool
+	check     healthcheck.Check
+}
+
+// Liveness only depends on the process, everything depending on kaond or the database is a readiness check
+type healthReport struct {
+	Live   bool                         `json:"live"`
+	Ready  bool                         `json:"ready"`
+	Checks map[string]healthCheckReport `json:"checks"`
+	Blocks *healthBlocksReport          `json:"blocks,omitempty"`
+	Cache  kaon.CacheStats              `json:"cache"`
+}
+
+type healthCheckReport struct {
+	// liveness or readiness
+	Type      string  `json:"type"`
+	OK        bool    `json:"ok"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+type healthBlocksReport struct {
+	Height  int64 `json:"height"`
+	Headers int64 `json:"headers"`
+	// blocks kaond is behind the best header
+	Lag int64 `json:"lag"`
+	// blocks missing from the block hash database when it was last checked
+	BlockHashLag *int `json:"blockHashLag,omitempty"`
+}
+
+func (s *Server) healthChecks() []healthCheck {
+	checks := []healthCheck{
+		{name: "ethrpcgate-shutting-down", readiness: true, check: s.testShuttingDown},
+		{name: "kaond-connection", readiness: true, check: s.testConnectionToKaond},
+		{name: "kaond-logevents-enabled", readiness: true, check: s.testLogEvents},
+		{name: "kaond-synced", readiness: true, check: s.testKaondSynced},
+		{name: "kaond-blocks-syncing", readiness: true, check: s.testBlocksSyncing},
+		{name: "kaond-error-rate", readiness: true, check: s.testKaondErrorRate},
+		{name: "ethrpcgate-error-rate", readiness: true, check: s.testEthRPCGateErrorRate},
+	}
+	// DbConfig.String() is never empty
+	if dbConfig := s.kaonRPCClient.DbConfig; dbConfig.ConnectionString != "" || dbConfig.Host != "" {
+		checks = append(checks, healthCheck{
+			name:      "blockhash-indexer-lag",
+			readiness: true,
+			check:     healthcheck.AsyncWithContext(s.ctx, s.testBlockHashLag, blockHashCheckInterval),
+		})
+	}
+	return checks
+}
+
+func newHealthHandler(checks []healthCheck) healthcheck.Handler {
+	health := healthcheck.NewHandler()
+	for _, check := range checks {
+		if check.readiness {
+			health.AddReadinessCheck(check.name, check.check)
+		} else {
+			health.AddLivenessCheck(check.name, check.check)
+		}
+	}
+	return health
+}
+
+// Runs every check and reports their status along with the block heights, 503 if the gateway isn't ready
+func (s *Server) healthReportHandler(checks []healthCheck) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		report := healthReport{
+			Live:   true,
+			Ready:  true,
+			Checks: make(map[string]healthCheckReport, len(checks)),
+			Cache:  s.kaonRPCClient.CacheStats(),
+		}
+
+		var mutex sync.Mutex
+		var wg sync.WaitGroup
+		for _, check := range checks {
+			wg.Add(1)
+			go func(check healthCheck) {
+				defer wg.Done()
+				start := time.Now()
+				err := check.check()
+				result := healthCheckReport{
+					Type:      "liveness",
+					OK:        err == nil,
+					LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+				}
+				if check.readiness {
+					result.Type = "readiness"
+				}
+				if err != nil {
+					result.Error = err.Error()
+				}
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				report.Checks[check.name] = result
+				if err != nil {
+					report.Ready = false
+					if !check.readiness {
+						report.Live = false
+					}
+				}
+			}(check)
+		}
+
+		if blockChainInfo, err := s.kaonRPCClient.GetBlockChainInfo(c.Request().Context()); err == nil {
+			s.blocksMutex.RLock()
+			blockHashLag := s.blockHashLag
+			s.blocksMutex.RUnlock()
+			report.Blocks = &healthBlocksReport{
+				Height:       blockChainInfo.Blocks,
+				Headers:      blockChainInfo.Headers,
+				Lag:          blockChainInfo.Headers - blockChainInfo.Blocks,
+				BlockHashLag: blockHashLag,
+			}
+		}
+		wg.Wait()
+
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		return c.JSONPretty(status, report, "  ")
+	}
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/kaonone/eth-rpc-gate/pkg/analytics"
 	"github.com/kaonone/eth-rpc-gate/pkg/blockhash"
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/faucet"
+	"github.com/kaonone/eth-rpc-gate/pkg/logindex"
+	"github.com/kaonone/eth-rpc-gate/pkg/tokenindex"
 	"github.com/kaonone/eth-rpc-gate/pkg/transformer"
 	"github.com/labstack/echo"
 )
@@ -21,6 +24,10 @@ type myCtx struct {
 	logger      log.Logger
 	transformer *transformer.Transformer
 	blockHash   *blockhash.BlockHash
+	logIndex    *logindex.Index
+	tokenIndex  *tokenindex.Index
+	faucet      *faucet.Faucet
+	websockets  *websocketConnections
 
 	healthCheckPercent *int
 	kaonAnalytics      *analytics.Analytics
@@ -14,11 +14,13 @@ import (
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
-	"github.com/heptiolabs/healthcheck"
 	"github.com/kaonone/eth-rpc-gate/pkg/analytics"
 	"github.com/kaonone/eth-rpc-gate/pkg/blockhash"
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/faucet"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/logindex"
+	"github.com/kaonone/eth-rpc-gate/pkg/tokenindex"
 	"github.com/kaonone/eth-rpc-gate/pkg/transformer"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
@@ -37,15 +39,34 @@ type Server struct {
 	mutex         *sync.Mutex
 	echo          *echo.Echo
 	blockHash     *blockhash.BlockHash
-
-	healthCheckPercent   *int
-	kaonRequestAnalytics *analytics.Analytics
-	ethRequestAnalytics  *analytics.Analytics
+	logIndex      *logindex.Index
+	tokenIndex    *tokenindex.Index
+	faucet        *faucet.Faucet
+	faucetHTTP    bool
+	websockets    *websocketConnections
+	// the Kaon client's context, background if it has none
+	ctx           context.Context
+	stopBlockHash context.CancelFunc
+
+	healthCheckPercent    *int
+	healthMaxHeaderLag    int64
+	healthMaxBlockHashLag int
+	kaonRequestAnalytics  *analytics.Analytics
+	ethRequestAnalytics   *analytics.Analytics
 
 	blocksMutex     sync.RWMutex
 	lastBlock       int64
 	nextBlockCheck  *time.Time
 	lastBlockStatus error
+	// blocks missing from the block hash database, nil until it has been checked
+	blockHashLag *int
+
+	shutdownMutex sync.RWMutex
+	shuttingDown  bool
+	// how long /ready fails before connections are closed
+	shutdownDelay time.Duration
+	// how long requests in flight and websocket clients are waited for once connections are refused
+	shutdownTimeout time.Duration
 }
 
 func New(
@@ -57,21 +78,33 @@ func New(
 	requests := 50
 
 	p := &Server{
-		logger:              log.NewNopLogger(),
-		echo:                echo.New(),
-		address:             addr,
-		kaonRPCClient:       kaonRPCClient,
-		transformer:         transformer,
-		ethRequestAnalytics: analytics.NewAnalytics(requests),
+		logger:                log.NewNopLogger(),
+		echo:                  echo.New(),
+		address:               addr,
+		kaonRPCClient:         kaonRPCClient,
+		transformer:           transformer,
+		ethRequestAnalytics:   analytics.NewAnalytics(requests),
+		websockets:            newWebsocketConnections(),
+		healthMaxHeaderLag:    DefaultHealthMaxHeaderLag,
+		healthMaxBlockHashLag: DefaultHealthMaxBlockHashLag,
 	}
 
+	// the block hash indexer is stopped by Close, after the requests in flight are done
+	ctx := kaonRPCClient.GetContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	blockHashCtx, stopBlockHash := context.WithCancel(ctx)
+	p.ctx = ctx
+	p.stopBlockHash = stopBlockHash
 	blockHashProcessor, err := blockhash.NewBlockHash(
-		kaonRPCClient.GetContext(),
+		blockHashCtx,
 		func() log.Logger {
 			return p.kaonRPCClient.GetLogger()
 		},
 	)
 	if err != nil {
+		stopBlockHash()
 		return nil, err
 	}
 
@@ -90,12 +123,8 @@ func (s *Server) Start() error {
 	logWriter := s.logWriter
 	e := s.echo
 
-	health := healthcheck.NewHandler()
-	health.AddLivenessCheck("kaond-connection", func() error { return s.testConnectionToKaond() })
-	health.AddLivenessCheck("kaond-logevents-enabled", func() error { return s.testLogEvents() })
-	health.AddLivenessCheck("kaond-blocks-syncing", func() error { return s.testBlocksSyncing() })
-	health.AddLivenessCheck("kaond-error-rate", func() error { return s.testKaondErrorRate() })
-	health.AddLivenessCheck("ethrpcgate-error-rate", func() error { return s.testEthRPCGateErrorRate() })
+	checks := s.healthChecks()
+	health := newHealthHandler(checks)
 
 	e.Use(middleware.CORS())
 	e.Use(middleware.BodyDump(func(c echo.Context, req []byte, res []byte) {
@@ -128,12 +157,19 @@ func (s *Server) Start() error {
 				logger:        s.logger,
 				transformer:   s.transformer,
 				blockHash:     s.blockHash,
+				logIndex:      s.logIndex,
+				tokenIndex:    s.tokenIndex,
+				faucet:        s.faucet,
+				websockets:    s.websockets,
 				kaonAnalytics: s.kaonRequestAnalytics,
 				ethAnalytics:  s.ethRequestAnalytics,
 			}
 
 			c.Set("myctx", cc)
 			c.Set("blockHash", cc.blockHash)
+			c.Set("logIndex", cc.logIndex)
+			c.Set("tokenIndex", cc.tokenIndex)
+			c.Set("faucet", cc.faucet)
 
 			return h(c)
 		}
@@ -153,8 +189,18 @@ func (s *Server) Start() error {
 			health.ReadyEndpoint(c.Response(), c.Request())
 			return nil
 		})
+		e.GET("/health", s.healthReportHandler(checks))
+	}
+
+	if s.faucet != nil && s.faucetHTTP {
+		e.POST("/faucet", s.faucetHandler)
 	}
 
+	// the same service description as rpc_discover
+	e.GET("/openrpc.json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, s.transformer.OpenRPCDocument())
+	})
+
 	if s.mutex == nil {
 		e.POST("/*", httpHandler)
 		e.GET("/*", websocketHandler)
@@ -174,11 +220,15 @@ func (s *Server) Start() error {
 
 	var err error
 
-	// shutdown echo server when context ends
+	// hijacked websockets aren't closed by Shutdown, they get a close frame once the listener is closed
+	e.Server.RegisterOnShutdown(s.websockets.closeAll)
+	e.TLSServer.RegisterOnShutdown(s.websockets.closeAll)
+
+	// shutdown echo server when context ends, a graceful shutdown is done with Shutdown before
 	go func(ctx context.Context, e *echo.Echo) {
 		<-ctx.Done()
 		e.Close()
-	}(s.kaonRPCClient.GetContext(), e)
+	}(s.ctx, e)
 
 	if s.kaonRPCClient.DbConfig.String() == "" {
 		level.Warn(s.logger).Log("msg", "Database not configured - won't be able to respond to Ethereum block hash requests")
@@ -194,6 +244,16 @@ func (s *Server) Start() error {
 		}()
 	}
 
+	if s.logIndex == nil {
+		level.Info(s.logger).Log("msg", "Log index not configured - eth_getLogs and filters will query kaond directly")
+	} else {
+		s.logIndex.Start()
+	}
+
+	if s.tokenIndex != nil {
+		s.tokenIndex.Start()
+	}
+
 	if https {
 		level.Info(s.logger).Log("msg", "SSL enabled")
 		err = e.StartTLS(s.address, s.httpsCert, s.httpsKey)
@@ -206,6 +266,22 @@ func (s *Server) Start() error {
 
 type Option func(*Server) error
 
+// Sets how long /ready fails on shutdown before connections are closed, giving load balancers time to notice
+func SetShutdownDelay(delay time.Duration) Option {
+	return func(p *Server) error {
+		p.shutdownDelay = delay
+		return nil
+	}
+}
+
+// Sets how long requests in flight and websocket clients are waited for on shutdown, after the shutdown delay
+func SetShutdownTimeout(timeout time.Duration) Option {
+	return func(p *Server) error {
+		p.shutdownTimeout = timeout
+		return nil
+	}
+}
+
 func SetLogWriter(logWriter io.Writer) Option {
 	return func(p *Server) error {
 		p.logWriter = logWriter
@@ -260,6 +336,46 @@ func SetHealthCheckPercent(percent *int) Option {
 	}
 }
 
+// Sets how many blocks kaond may be behind the best header before the gateway isn't ready
+func SetHealthMaxHeaderLag(blocks int64) Option {
+	return func(p *Server) error {
+		p.healthMaxHeaderLag = blocks
+		return nil
+	}
+}
+
+// Sets how many blocks the block hash database may be missing before the gateway isn't ready
+func SetHealthMaxBlockHashLag(blocks int) Option {
+	return func(p *Server) error {
+		p.healthMaxBlockHashLag = blocks
+		return nil
+	}
+}
+
+func SetLogIndex(index *logindex.Index) Option {
+	return func(p *Server) error {
+		p.logIndex = index
+		return nil
+	}
+}
+
+// The token index serves kaon_getTokenBalances and kaon_getTokenTransfers
+func SetTokenIndex(index *tokenindex.Index) Option {
+	return func(p *Server) error {
+		p.tokenIndex = index
+		return nil
+	}
+}
+
+// The faucet serves dev_faucet, its HTTP endpoint is only served when http is true
+func SetFaucet(f *faucet.Faucet, http bool) Option {
+	return func(p *Server) error {
+		p.faucet = f
+		p.faucetHTTP = http
+		return nil
+	}
+}
+
 func batchRequestsMiddleware(h echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		myctx := c.Get("myctx")
@@ -324,10 +440,18 @@ func callHttpHandler(cc *myCtx, req *eth.JSONRPCRequest) (*eth.JSONRPCResult, er
 		logger:        cc.logger,
 		transformer:   cc.transformer,
 		blockHash:     cc.blockHash,
+		logIndex:      cc.logIndex,
+		tokenIndex:    cc.tokenIndex,
+		faucet:        cc.faucet,
+		websockets:    cc.websockets,
 		kaonAnalytics: cc.kaonAnalytics,
 		ethAnalytics:  cc.ethAnalytics,
 	}
 	newCtx.Set("myctx", myCtx)
+	newCtx.Set("blockHash", myCtx.blockHash)
+	newCtx.Set("logIndex", myCtx.logIndex)
+	newCtx.Set("tokenIndex", myCtx.tokenIndex)
+	newCtx.Set("faucet", myCtx.faucet)
 	if err = httpHandler(myCtx); err != nil {
 		errorHandler(err, myCtx)
 	}
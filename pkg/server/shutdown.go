@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// how long requests in flight and websocket clients are waited for on shutdown
+	DefaultShutdownTimeout = 30 * time.Second
+	// how long /ready fails before new connections are refused
+	DefaultShutdownDelay = 5 * time.Second
+)
+
+// reason sent with the close frame of websockets still open when the gateway shuts down
+const websocketShutdownReason = "eth-rpc-gate is shutting down, reconnect to resubscribe"
+
+// Websocket connections that have to be closed when the gateway shuts down
+type websocketConnections struct {
+	mutex       sync.Mutex
+	wg          sync.WaitGroup
+	closing     bool
+	connections map[*websocketConnection]struct{}
+}
+
+type websocketConnection struct {
+	// held while a request is being answered
+	busy    sync.Mutex
+	closing bool
+	ws      *websocket.Conn
+	// serializes writes with the responses, notifications and pings
+	writeMutex *sync.Mutex
+}
+
+func newWebsocketConnections() *websocketConnections {
+	return &websocketConnections{
+		connections: make(map[*websocketConnection]struct{}),
+	}
+}
+
+// tracks a websocket connection until remove is called, it is closed right away if the gateway is shutting down
+func (w *websocketConnections) add(ws *websocket.Conn, writeMutex *sync.Mutex) *websocketConnection {
+	connection := &websocketConnection{ws: ws, writeMutex: writeMutex}
+	if w == nil {
+		return connection
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closing {
+		connection.close()
+		return connection
+	}
+	w.connections[connection] = struct{}{}
+	w.wg.Add(1)
+	return connection
+}
+
+func (w *websocketConnections) remove(connection *websocketConnection) {
+	if w == nil {
+		return
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if _, ok := w.connections[connection]; ok {
+		delete(w.connections, connection)
+		w.wg.Done()
+	}
+}
+
+// sends a close frame to every connection once the requests they are answering are done
+func (w *websocketConnections) closeAll() {
+	w.mutex.Lock()
+	if w.closing {
+		w.mutex.Unlock()
+		return
+	}
+	w.closing = true
+	connections := make([]*websocketConnection, 0, len(w.connections))
+	for connection := range w.connections {
+		connections = append(connections, connection)
+	}
+	w.mutex.Unlock()
+
+	for _, connection := range connections {
+		go connection.close()
+	}
+}
+
+// waits for the clients to close their connections, the remaining ones are dropped when ctx is done
+func (w *websocketConnections) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for connection := range w.connections {
+		connection.ws.Close()
+	}
+	return ctx.Err()
+}
+
+// answers a request unless a close frame has already been sent, returns false if the connection should be closed
+func (c *websocketConnection) serve(handle func() bool) bool {
+	c.busy.Lock()
+	defer c.busy.Unlock()
+	if c.closing {
+		// the client will answer the close frame, which ends the connection
+		return true
+	}
+	return handle()
+}
+
+func (c *websocketConnection) close() {
+	c.busy.Lock()
+	defer c.busy.Unlock()
+	if c.closing {
+		return
+	}
+	c.closing = true
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	message := websocket.FormatCloseMessage(websocket.CloseServiceRestart, websocketShutdownReason)
+	if err := c.ws.WriteControl(websocket.CloseMessage, message, time.Now().Add(writeWait)); err != nil {
+		c.ws.Close()
+	}
+}
+
+func (s *Server) isShuttingDown() bool {
+	s.shutdownMutex.RLock()
+	defer s.shutdownMutex.RUnlock()
+	return s.shuttingDown
+}
+
+// Shutdown fails /ready and waits for the shutdown delay so load balancers stop sending traffic,
+// then stops accepting connections, asks websocket subscribers to reconnect elsewhere
+// and waits for the requests in flight for the shutdown timeout. The delay doesn't count towards
+// the timeout, ctx cuts both short.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownMutex.Lock()
+	s.shuttingDown = true
+	s.shutdownMutex.Unlock()
+
+	if s.shutdownDelay > 0 {
+		s.logger.Log("msg", "Not ready anymore, waiting before closing connections", "delay", s.shutdownDelay)
+		select {
+		case <-time.After(s.shutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	if s.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.shutdownTimeout)
+		defer cancel()
+	}
+
+	// the websockets are closed once the listeners are, see Start
+	err := s.echo.Shutdown(ctx)
+	if websocketsErr := s.websockets.wait(ctx); err == nil {
+		err = websocketsErr
+	}
+	return err
+}
+
+// Stops the block hash indexer, called after Shutdown
+func (s *Server) Close() {
+	s.stopBlockHash()
+}
@@ -0,0 +1,764 @@
+package tokenindex
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+var ErrNotIndexed = errors.New("block range not indexed")
+var ErrTooManyTransfers = errors.New("too many token transfers")
+
+// Topic of Transfer(address,address,uint256), shared by QRC20 and ERC20 tokens. ERC721 transfers have the
+// same topic but index the token id as well, they're told apart by their number of topics.
+const TransferTopic = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+var (
+	DefaultPollInterval   = 10 * time.Second
+	DefaultBatchSize      = uint64(1000)
+	DefaultMaxReorgDepth  = uint64(500)
+	defaultLevelDBCache   = 16
+	defaultLevelDBHandles = 16
+)
+
+// Database layout, all numbers are big endian so that keys sort by block height
+var (
+	headKey  = []byte("LastBlock")  // headKey -> highest indexed block
+	startKey = []byte("FirstBlock") // startKey -> lowest indexed block
+
+	blockHashPrefix = []byte("b") // blockHashPrefix + num -> block hash
+	transferPrefix  = []byte("t") // transferPrefix + num + tx index + output index + log index -> transfer json
+	addressPrefix   = []byte("a") // addressPrefix + address + num + tx index + output index + log index -> nil
+	balancePrefix   = []byte("h") // balancePrefix + holder + token -> balance
+	metadataPrefix  = []byte("m") // metadataPrefix + token -> metadata json
+)
+
+// A token transfer, addresses are hex encoded without 0x prefix
+type Transfer struct {
+	Token string   `json:"token"`
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Value *big.Int `json:"value"`
+	// Ethereum transaction hash of the execution that emitted the transfer, see kaon.Execution
+	TransactionHash string `json:"transactionHash"`
+	BlockHash       string `json:"blockHash"`
+	BlockNumber     uint64 `json:"blockNumber"`
+}
+
+// Balance of a token, summed from the indexed transfers
+type Balance struct {
+	Token   string
+	Balance *big.Int
+}
+
+// Token metadata, fields the token doesn't implement are empty
+type Metadata struct {
+	Name     string `json:"name,omitempty"`
+	Symbol   string `json:"symbol,omitempty"`
+	Decimals *uint8 `json:"decimals,omitempty"`
+}
+
+// Index keeps the token transfers found by kaond's searchlogs in an embedded key-value store, indexed by
+// sender and recipient along with the resulting balances. It follows the chain tip and rolls back blocks
+// that got reorganised. Balances are only complete if the index starts below the deployment of the tokens.
+type Index struct {
+	ctx   context.Context
+	kaon  *kaon.Kaon
+	db    ethdb.KeyValueStore
+	mutex sync.RWMutex
+
+	startHeight   uint64
+	pollInterval  time.Duration
+	batchSize     uint64
+	maxReorgDepth uint64
+}
+
+type Option func(*Index) error
+
+// Only blocks from this height onwards are indexed, ignored when reopening an existing index
+func SetStartHeight(height uint64) Option {
+	return func(idx *Index) error {
+		idx.startHeight = height
+		return nil
+	}
+}
+
+func SetPollInterval(interval time.Duration) Option {
+	return func(idx *Index) error {
+		if interval <= 0 {
+			return errors.New("poll interval must be positive")
+		}
+		idx.pollInterval = interval
+		return nil
+	}
+}
+
+// Number of blocks requested from kaond per searchlogs call while catching up
+func SetBatchSize(blocks uint64) Option {
+	return func(idx *Index) error {
+		if blocks == 0 {
+			return errors.New("batch size must be positive")
+		}
+		idx.batchSize = blocks
+		return nil
+	}
+}
+
+// Block hashes are only kept for this many blocks below the tip, deeper blocks are treated as final
+func SetMaxReorgDepth(blocks uint64) Option {
+	return func(idx *Index) error {
+		idx.maxReorgDepth = blocks
+		return nil
+	}
+}
+
+// Opens (or creates) a leveldb backed index at path
+func Open(ctx context.Context, kaonClient *kaon.Kaon, path string, opts ...Option) (*Index, error) {
+	db, err := leveldb.New(path, defaultLevelDBCache, defaultLevelDBHandles, "", false)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open token index database")
+	}
+
+	idx, err := New(ctx, kaonClient, db, opts...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+func New(ctx context.Context, kaonClient *kaon.Kaon, db ethdb.KeyValueStore, opts ...Option) (*Index, error) {
+	if ctx == nil {
+		panic("ctx cannot be nil")
+	}
+	if kaonClient == nil {
+		panic("kaon cannot be nil")
+	}
+
+	idx := &Index{
+		ctx:           ctx,
+		kaon:          kaonClient,
+		db:            db,
+		pollInterval:  DefaultPollInterval,
+		batchSize:     DefaultBatchSize,
+		maxReorgDepth: DefaultMaxReorgDepth,
+	}
+
+	for _, opt := range opts {
+		if err := opt(idx); err != nil {
+			return nil, err
+		}
+	}
+
+	// an existing index keeps the range it was built with
+	start, ok, err := readNumber(db, startKey)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		idx.startHeight = start
+	} else if err := db.Put(startKey, encodeNumber(idx.startHeight)); err != nil {
+		return nil, errors.Wrap(err, "couldn't initialize token index")
+	}
+
+	return idx, nil
+}
+
+// Follows the chain tip in the background until the context is cancelled, then closes the database
+func (idx *Index) Start() {
+	go idx.run()
+}
+
+func (idx *Index) run() {
+	defer idx.Close()
+
+	ticker := time.NewTicker(idx.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := idx.Sync(idx.ctx); err != nil && idx.ctx.Err() == nil {
+			idx.kaon.GetErrorLogger().Log("msg", "Failed to sync token index", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-idx.ctx.Done():
+			return
+		}
+	}
+}
+
+func (idx *Index) Close() error {
+	return idx.db.Close()
+}
+
+// Lowest indexed block
+func (idx *Index) StartHeight() uint64 {
+	return idx.startHeight
+}
+
+// Returns the highest indexed block, ok is false if nothing has been indexed yet
+func (idx *Index) Head() (head uint64, ok bool) {
+	if idx == nil {
+		return 0, false
+	}
+	head, ok, _ = readNumber(idx.db, headKey)
+	return
+}
+
+// Reports whether every block in [from, to] has been indexed
+func (idx *Index) Covers(from, to uint64) bool {
+	if idx == nil || from > to || from < idx.startHeight {
+		return false
+	}
+	head, ok := idx.Head()
+	return ok && to <= head
+}
+
+// Rolls back blocks that are no longer part of the main chain and indexes everything up to the current tip.
+// Safe to call again after a failure or a restart, indexing resumes after the last stored block.
+func (idx *Index) Sync(ctx context.Context) error {
+	blockCount, err := idx.kaon.GetBlockCount(ctx)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get block count")
+	}
+	tip := blockCount.Uint64()
+
+	if err := idx.rollbackReorganised(ctx, tip); err != nil {
+		return err
+	}
+
+	next := idx.startHeight
+	if head, ok := idx.Head(); ok {
+		next = head + 1
+	}
+
+	for next <= tip {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		to := next + idx.batchSize - 1
+		if to > tip {
+			to = tip
+		}
+
+		if err := idx.ingest(ctx, next, to, tip); err != nil {
+			return err
+		}
+
+		next = to + 1
+	}
+
+	return nil
+}
+
+func (idx *Index) rollbackReorganised(ctx context.Context, tip uint64) error {
+	for {
+		head, ok := idx.Head()
+		if !ok {
+			return nil
+		}
+
+		if head <= tip {
+			stored, err := idx.db.Get(blockHashKey(head))
+			if err != nil {
+				// no hash kept for this block, it is deeper than any reorg we track
+				return nil
+			}
+
+			actual, err := idx.kaon.GetBlockHash(ctx, new(big.Int).SetUint64(head))
+			if err != nil {
+				return errors.Wrapf(err, "couldn't get block hash for block %d", head)
+			}
+
+			if strings.EqualFold(string(stored), string(actual)) {
+				return nil
+			}
+		}
+
+		idx.kaon.GetDebugLogger().Log("msg", "Rolling back reorganised block from token index", "block", head)
+		if err := idx.rollback(head); err != nil {
+			return err
+		}
+	}
+}
+
+func (idx *Index) ingest(ctx context.Context, from, to, tip uint64) error {
+	// hashes are kept for the last indexed block and anything that can still be reorganised
+	hashes := make(map[uint64]string)
+	for number := from; number <= to; number++ {
+		if number != to && number+idx.maxReorgDepth < tip {
+			continue
+		}
+
+		hash, err := idx.kaon.GetBlockHash(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get block hash for block %d", number)
+		}
+		hashes[number] = string(hash)
+	}
+
+	receipts, err := idx.kaon.SearchLogs(ctx, &kaon.SearchLogsRequest{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Topics:    []kaon.SearchLogsTopic{{TransferTopic}},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "couldn't search transfers in blocks [%d, %d]", from, to)
+	}
+
+	type positionedTransfer struct {
+		transfer *Transfer
+		position []byte
+	}
+	var transfers []positionedTransfer
+	executions := make(map[string][]kaon.Execution)
+	for i := range receipts {
+		receipt := &receipts[i]
+		if receipt.BlockNumber < from || receipt.BlockNumber > to {
+			continue
+		}
+		if hash, ok := hashes[receipt.BlockNumber]; ok && !strings.EqualFold(hash, receipt.BlockHash) {
+			// the chain moved between the two calls, try again on the next sync
+			return errors.Errorf("block %d was reorganised while indexing", receipt.BlockNumber)
+		}
+
+		for logIndex, log := range receipt.Log {
+			transfer, ok := decodeTransfer(receipt, &log)
+			if !ok {
+				continue
+			}
+			if transfer.TransactionHash, err = idx.transactionHash(ctx, receipt, executions); err != nil {
+				return err
+			}
+			transfers = append(transfers, positionedTransfer{transfer, transferPosition(receipt, logIndex)})
+		}
+	}
+
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	batch := idx.db.NewBatch()
+	balances := newBalanceChanges(idx.db)
+	for _, t := range transfers {
+		if err := putTransfer(batch, t.transfer, t.position); err != nil {
+			return err
+		}
+		if err := balances.apply(t.transfer, false); err != nil {
+			return err
+		}
+	}
+	if err := balances.write(batch); err != nil {
+		return err
+	}
+
+	if tip > idx.maxReorgDepth {
+		if err := idx.pruneBlockHashes(batch, tip-idx.maxReorgDepth, to); err != nil {
+			return err
+		}
+	}
+	for number, hash := range hashes {
+		if err := batch.Put(blockHashKey(number), []byte(hash)); err != nil {
+			return err
+		}
+	}
+
+	if err := batch.Put(headKey, encodeNumber(to)); err != nil {
+		return err
+	}
+
+	return batch.Write()
+}
+
+// Deletes the hashes of the blocks below height, which got deeper than any reorg we track, except the one of keep
+func (idx *Index) pruneBlockHashes(batch ethdb.Batch, height uint64, keep uint64) error {
+	it := idx.db.NewIterator(blockHashPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		number := binary.BigEndian.Uint64(it.Key()[len(blockHashPrefix):])
+		if number >= height {
+			break
+		}
+		if number == keep {
+			continue
+		}
+		if err := batch.Delete(copyBytes(it.Key())); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// Ethereum transaction hash of the execution that emitted the logs of receipt, see kaon.Execution. The contract
+// outputs of a transaction are looked up once per batch in executions, and the derived hashes are registered so
+// that the transactions of the hashes handed out can be looked up.
+func (idx *Index) transactionHash(ctx context.Context, receipt *kaon.TransactionReceipt, executions map[string][]kaon.Execution) (string, error) {
+	txID := strings.ToLower(utils.RemoveHexPrefix(receipt.TransactionHash))
+	// an EVM execution in the first output is the first one of its transaction, which keeps the transaction id
+	if receipt.OutputIndex == 0 {
+		return txID, nil
+	}
+
+	txExecutions, ok := executions[txID]
+	if !ok {
+		rawTx, err := idx.kaon.GetRawTransaction(ctx, txID, false)
+		if err != nil {
+			return "", errors.WithMessagef(err, "couldn't get raw transaction %s", txID)
+		}
+		decodedRawTx, err := idx.kaon.DecodeRawTransaction(ctx, rawTx.Hex)
+		if err != nil {
+			return "", errors.WithMessagef(err, "couldn't decode raw transaction %s", txID)
+		}
+		contracts, err := decodedRawTx.ExtractContractInfos()
+		if err != nil {
+			return "", errors.WithMessagef(err, "couldn't parse contract outputs of transaction %s", txID)
+		}
+		txExecutions = idx.kaon.RegisterExecutions(txID, contracts)
+		executions[txID] = txExecutions
+	}
+
+	for _, execution := range txExecutions {
+		if execution.OutputIndex == receipt.OutputIndex {
+			return execution.Hash, nil
+		}
+	}
+	// receipts of outputs that aren't known as executions are attributed to the first one, like eth_getLogs does
+	return txID, nil
+}
+
+// Removes the transfers of a single block, which must be the current head, and reverts their balance changes
+func (idx *Index) rollback(number uint64) error {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	batch := idx.db.NewBatch()
+	balances := newBalanceChanges(idx.db)
+
+	prefix := append(append([]byte{}, transferPrefix...), encodeNumber(number)...)
+	it := idx.db.NewIterator(prefix, nil)
+	for it.Next() {
+		var transfer Transfer
+		if err := json.Unmarshal(it.Value(), &transfer); err != nil {
+			it.Release()
+			return errors.Wrap(err, "couldn't decode indexed transfer")
+		}
+
+		position := copyBytes(it.Key()[len(transferPrefix):])
+		for _, address := range transferParties(&transfer) {
+			batch.Delete(addressKey(address, position))
+		}
+		if err := balances.apply(&transfer, true); err != nil {
+			it.Release()
+			return err
+		}
+		batch.Delete(copyBytes(it.Key()))
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := balances.write(batch); err != nil {
+		return err
+	}
+
+	batch.Delete(blockHashKey(number))
+	if number > idx.startHeight {
+		batch.Put(headKey, encodeNumber(number-1))
+	} else {
+		batch.Delete(headKey)
+	}
+
+	return batch.Write()
+}
+
+// Returns the positive token balances of an address
+func (idx *Index) Balances(address string) ([]Balance, error) {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	prefix := balanceKey(address, "")
+	it := idx.db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var balances []Balance
+	for it.Next() {
+		balance, ok := new(big.Int).SetString(string(it.Value()), 10)
+		if !ok {
+			return nil, errors.Errorf("corrupted token balance %q", it.Value())
+		}
+		if balance.Sign() <= 0 {
+			continue
+		}
+		balances = append(balances, Balance{
+			Token:   hex.EncodeToString(it.Key()[len(prefix):]),
+			Balance: balance,
+		})
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// Returns the transfers from or to an address in [from, to], in block order, optionally of a single token.
+// Fails with ErrNotIndexed if the range hasn't been indexed yet and with ErrTooManyTransfers when there
+// are more than limit transfers, a limit of 0 disables it.
+func (idx *Index) Transfers(ctx context.Context, address string, token string, from, to uint64, limit int) ([]Transfer, error) {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	if !idx.Covers(from, to) {
+		return nil, ErrNotIndexed
+	}
+
+	prefix := addressKey(address, nil)
+	it := idx.db.NewIterator(prefix, encodeNumber(from))
+	defer it.Release()
+
+	transfers := []Transfer{}
+	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		position := it.Key()[len(prefix):]
+		if binary.BigEndian.Uint64(position[:8]) > to {
+			break
+		}
+
+		data, err := idx.db.Get(append(append([]byte{}, transferPrefix...), position...))
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't read indexed transfer")
+		}
+		var transfer Transfer
+		if err := json.Unmarshal(data, &transfer); err != nil {
+			return nil, errors.Wrap(err, "couldn't decode indexed transfer")
+		}
+		if token != "" && common.HexToAddress(token) != common.HexToAddress(transfer.Token) {
+			continue
+		}
+
+		if limit > 0 && len(transfers) == limit {
+			return nil, ErrTooManyTransfers
+		}
+		transfers = append(transfers, transfer)
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+// Returns the cached metadata of a token, ok is false if it hasn't been looked up yet
+func (idx *Index) Metadata(token string) (metadata Metadata, ok bool) {
+	data, err := idx.db.Get(metadataKey(token))
+	if err != nil {
+		return Metadata{}, false
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return Metadata{}, false
+	}
+	return metadata, true
+}
+
+// Caches the metadata of a token, it isn't rolled back with reorganised blocks
+func (idx *Index) SetMetadata(token string, metadata Metadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return errors.Wrap(err, "couldn't encode token metadata")
+	}
+	return idx.db.Put(metadataKey(token), data)
+}
+
+// Decodes a QRC20/ERC20 Transfer log, ERC721 transfers are skipped. The transaction hash is the Kaon transaction id.
+func decodeTransfer(receipt *kaon.TransactionReceipt, log *kaon.Log) (*Transfer, bool) {
+	if len(log.Topics) != 3 || !strings.EqualFold(utils.RemoveHexPrefix(log.Topics[0]), TransferTopic) {
+		return nil, false
+	}
+	data := common.FromHex(log.Data)
+	if len(data) != 32 {
+		return nil, false
+	}
+
+	return &Transfer{
+		Token:           hexAddress(log.Address),
+		From:            hex.EncodeToString(common.HexToHash(log.Topics[1]).Bytes()[12:]),
+		To:              hex.EncodeToString(common.HexToHash(log.Topics[2]).Bytes()[12:]),
+		Value:           new(big.Int).SetBytes(data),
+		TransactionHash: strings.ToLower(utils.RemoveHexPrefix(receipt.TransactionHash)),
+		BlockHash:       strings.ToLower(utils.RemoveHexPrefix(receipt.BlockHash)),
+		BlockNumber:     receipt.BlockNumber,
+	}, true
+}
+
+// Addresses a transfer is indexed under, mints and burns aren't indexed under the zero address
+func transferParties(transfer *Transfer) []string {
+	var parties []string
+	for _, address := range []string{transfer.From, transfer.To} {
+		if common.HexToAddress(address) == (common.Address{}) {
+			continue
+		}
+		if len(parties) == 1 && strings.EqualFold(parties[0], address) {
+			continue
+		}
+		parties = append(parties, address)
+	}
+	return parties
+}
+
+func putTransfer(batch ethdb.Batch, transfer *Transfer, position []byte) error {
+	data, err := json.Marshal(transfer)
+	if err != nil {
+		return errors.Wrap(err, "couldn't encode transfer")
+	}
+
+	if err := batch.Put(append(append([]byte{}, transferPrefix...), position...), data); err != nil {
+		return err
+	}
+	for _, address := range transferParties(transfer) {
+		if err := batch.Put(addressKey(address, position), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Balance changes of a batch, so that several transfers of a holder in the same batch add up
+type balanceChanges struct {
+	db       ethdb.KeyValueReader
+	balances map[string]*big.Int
+}
+
+func newBalanceChanges(db ethdb.KeyValueReader) *balanceChanges {
+	return &balanceChanges{
+		db:       db,
+		balances: make(map[string]*big.Int),
+	}
+}
+
+// Moves the value of a transfer from its sender to its recipient, or back if revert is set
+func (b *balanceChanges) apply(transfer *Transfer, revert bool) error {
+	from, to := transfer.From, transfer.To
+	if revert {
+		from, to = to, from
+	}
+	if err := b.add(from, transfer.Token, new(big.Int).Neg(transfer.Value)); err != nil {
+		return err
+	}
+	return b.add(to, transfer.Token, transfer.Value)
+}
+
+func (b *balanceChanges) add(holder string, token string, value *big.Int) error {
+	if common.HexToAddress(holder) == (common.Address{}) {
+		return nil
+	}
+
+	key := string(balanceKey(holder, token))
+	balance, ok := b.balances[key]
+	if !ok {
+		balance = new(big.Int)
+		if data, err := b.db.Get([]byte(key)); err == nil {
+			if _, ok := balance.SetString(string(data), 10); !ok {
+				return errors.Errorf("corrupted token balance %q", data)
+			}
+		}
+		b.balances[key] = balance
+	}
+	balance.Add(balance, value)
+	return nil
+}
+
+func (b *balanceChanges) write(batch ethdb.Batch) error {
+	for key, balance := range b.balances {
+		var err error
+		if balance.Sign() == 0 {
+			err = batch.Delete([]byte(key))
+		} else {
+			err = batch.Put([]byte(key), []byte(balance.String()))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func transferPosition(receipt *kaon.TransactionReceipt, logIndex int) []byte {
+	position := make([]byte, 32)
+	binary.BigEndian.PutUint64(position[0:], receipt.BlockNumber)
+	binary.BigEndian.PutUint64(position[8:], receipt.TransactionIndex)
+	binary.BigEndian.PutUint64(position[16:], uint64(receipt.OutputIndex))
+	binary.BigEndian.PutUint64(position[24:], uint64(logIndex))
+	return position
+}
+
+func blockHashKey(number uint64) []byte {
+	return append(append([]byte{}, blockHashPrefix...), encodeNumber(number)...)
+}
+
+func addressKey(address string, position []byte) []byte {
+	key := append(append([]byte{}, addressPrefix...), common.HexToAddress(address).Bytes()...)
+	return append(key, position...)
+}
+
+// The key prefix of every balance of holder if token is empty
+func balanceKey(holder string, token string) []byte {
+	key := append(append([]byte{}, balancePrefix...), common.HexToAddress(holder).Bytes()...)
+	if token == "" {
+		return key
+	}
+	return append(key, common.HexToAddress(token).Bytes()...)
+}
+
+func metadataKey(token string) []byte {
+	return append(append([]byte{}, metadataPrefix...), common.HexToAddress(token).Bytes()...)
+}
+
+// Lowercase hex encoding of an address without 0x prefix
+func hexAddress(address string) string {
+	return hex.EncodeToString(common.HexToAddress(address).Bytes())
+}
+
+func encodeNumber(number uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	return enc
+}
+
+func readNumber(db ethdb.KeyValueReader, key []byte) (uint64, bool, error) {
+	has, err := db.Has(key)
+	if err != nil || !has {
+		return 0, false, err
+	}
+
+	data, err := db.Get(key)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(data) != 8 {
+		return 0, false, errors.Errorf("corrupted token index entry %q", key)
+	}
+
+	return binary.BigEndian.Uint64(data), true, nil
+}
+
+func copyBytes(b []byte) []byte {
+	return append([]byte{}, b...)
+}
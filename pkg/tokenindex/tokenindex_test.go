@@ -0,0 +1,262 @@
+package tokenindex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+)
+
+var (
+	testToken   = "db46f738bf32cdafb9a4a70eb8b44c76646bcaf0"
+	testAlice   = "7926223070547d2d15b2ef5e7383e541c338ffe9"
+	testBob     = "6b22910b1e302cf74803ffd1691c2ecb858d3712"
+	zeroAddress = "0000000000000000000000000000000000000000"
+)
+
+func addressTopic(address string) string {
+	return "000000000000000000000000" + address
+}
+
+func testTransfer(blockHash string, blockNumber uint64, txHash string, from string, to string, value int64) kaon.TransactionReceipt {
+	receipt := internal.KaonTransactionReceipt([]kaon.Log{{
+		Address: testToken,
+		Topics:  []string{TransferTopic, addressTopic(from), addressTopic(to)},
+		Data:    fmt.Sprintf("%064x", value),
+	}})
+	receipt.BlockHash = blockHash
+	receipt.BlockNumber = blockNumber
+	receipt.TransactionHash = txHash
+	return receipt
+}
+
+func newSyncedIndex(t *testing.T, db ethdb.KeyValueStore, blockCount int64, blockHash string, receipts kaon.SearchLogsResponse) *Index {
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = clientDoerMock.AddResponse(kaon.MethodGetBlockCount, big.NewInt(blockCount)); err != nil {
+		t.Fatal(err)
+	}
+	if err = clientDoerMock.AddResponse(kaon.MethodGetBlockHash, blockHash); err != nil {
+		t.Fatal(err)
+	}
+	if err = clientDoerMock.AddResponse(kaon.MethodSearchLogs, receipts); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := New(context.Background(), kaonClient, db, SetStartHeight(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = idx.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return idx
+}
+
+func balanceOf(t *testing.T, idx *Index, holder string) int64 {
+	balances, err := idx.Balances(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) == 0 {
+		return 0
+	}
+	if len(balances) != 1 || balances[0].Token != testToken {
+		t.Fatalf("unexpected balances of %s: %+v", holder, balances)
+	}
+	return balances[0].Balance.Int64()
+}
+
+func TestSyncTransfersAndBalances(t *testing.T) {
+	blockHash := "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	erc721 := testTransfer(blockHash, 3, "44", testAlice, testBob, 0)
+	erc721.Log[0].Topics = append(erc721.Log[0].Topics, addressTopic(testToken))
+	idx := newSyncedIndex(t, memorydb.New(), 3, blockHash, kaon.SearchLogsResponse{
+		testTransfer(blockHash, 1, "11", zeroAddress, testAlice, 100),
+		testTransfer(blockHash, 2, "22", testAlice, testBob, 30),
+		testTransfer(blockHash, 3, "33", testBob, testAlice, 5),
+		erc721,
+	})
+
+	if alice := balanceOf(t, idx, testAlice); alice != 75 {
+		t.Fatalf("expected a balance of 75, got %d", alice)
+	}
+	if bob := balanceOf(t, idx, "0x"+testBob); bob != 25 {
+		t.Fatalf("expected a balance of 25, got %d", bob)
+	}
+
+	transfers, err := idx.Transfers(context.Background(), testAlice, "", 1, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 3 || transfers[0].From != zeroAddress || transfers[1].Value.Int64() != 30 || transfers[2].TransactionHash != "33" {
+		t.Fatalf("unexpected transfers: %+v", transfers)
+	}
+
+	transfers, err = idx.Transfers(context.Background(), testBob, testToken, 3, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 1 || transfers[0].BlockNumber != 3 || transfers[0].To != testAlice {
+		t.Fatalf("unexpected transfers in block 3: %+v", transfers)
+	}
+
+	if _, err = idx.Transfers(context.Background(), testAlice, "", 1, 3, 2); err != ErrTooManyTransfers {
+		t.Fatalf("expected ErrTooManyTransfers, got %v", err)
+	}
+	if _, err = idx.Transfers(context.Background(), testAlice, "", 1, 10, 0); err != ErrNotIndexed {
+		t.Fatalf("expected ErrNotIndexed, got %v", err)
+	}
+}
+
+func TestSyncRollsBackReorganisedTransfers(t *testing.T) {
+	oldHash := "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	newHash := "8fcd819194cce6a8454b2bec334d3448df4f097e9cdc36707bfd569900268950"
+	db := memorydb.New()
+
+	newSyncedIndex(t, db, 2, oldHash, kaon.SearchLogsResponse{
+		testTransfer(oldHash, 1, "11", zeroAddress, testAlice, 100),
+		testTransfer(oldHash, 2, "22", testAlice, testBob, 30),
+	})
+
+	// the mocked node reports a new hash for both blocks, block 2 now sends less
+	idx := newSyncedIndex(t, db, 2, newHash, kaon.SearchLogsResponse{
+		testTransfer(newHash, 1, "11", zeroAddress, testAlice, 100),
+		testTransfer(newHash, 2, "23", testAlice, testBob, 10),
+	})
+
+	if alice := balanceOf(t, idx, testAlice); alice != 90 {
+		t.Fatalf("expected the reorganised transfer to be reverted, got a balance of %d", alice)
+	}
+	if bob := balanceOf(t, idx, testBob); bob != 10 {
+		t.Fatalf("expected a balance of 10, got %d", bob)
+	}
+
+	transfers, err := idx.Transfers(context.Background(), testBob, "", 1, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 1 || transfers[0].TransactionHash != "23" || transfers[0].BlockHash != newHash {
+		t.Fatalf("unexpected transfers: %+v", transfers)
+	}
+}
+
+func TestSyncPrunesFinalBlockHashes(t *testing.T) {
+	blockHash := "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = clientDoerMock.AddResponse(kaon.MethodGetBlockHash, blockHash); err != nil {
+		t.Fatal(err)
+	}
+	if err = clientDoerMock.AddResponse(kaon.MethodSearchLogs, kaon.SearchLogsResponse{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tip of each sync, the hashes kept by the first one are pruned by the second
+	tips := []int64{5, 10}
+	for _, tip := range tips {
+		if err = clientDoerMock.AddResponse(kaon.MethodGetBlockCount, big.NewInt(tip)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db := memorydb.New()
+	idx, err := New(context.Background(), kaonClient, db, SetStartHeight(1), SetBatchSize(3), SetMaxReorgDepth(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range tips {
+		if err = idx.Sync(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for number := uint64(1); number <= 10; number++ {
+		has, err := db.Has(blockHashKey(number))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kept := number >= 8; has != kept {
+			t.Fatalf("block %d: expected its hash to be kept %t, got %t", number, kept, has)
+		}
+	}
+}
+
+func TestTransferOfLaterExecution(t *testing.T) {
+	blockHash := "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	txID := "3208dc44733cbfa11654ad5651305428de473ef1e61a1ec07b0c1a5f4843be91"
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a transaction calling two contracts, in outputs 1 and 3, the second of which transfers tokens
+	transfer := testTransfer(blockHash, 1, txID, testAlice, testBob, 30)
+	transfer.OutputIndex = 3
+	decodedRawTransaction := &kaon.DecodedRawTransactionResponse{ID: txID, Vouts: []*kaon.DecodedRawTransactionOutV{
+		{N: 1, ScriptPubKey: kaon.DecodedRawTransactionScriptPubKey{ASM: "4 55000 40 70a08231 " + testBob + " OP_CALL"}},
+		{N: 3, ScriptPubKey: kaon.DecodedRawTransactionScriptPubKey{ASM: "4 60000 40 a9059cbb " + testToken + " OP_CALL"}},
+	}}
+	for method, response := range map[string]interface{}{
+		kaon.MethodGetBlockCount:        big.NewInt(1),
+		kaon.MethodGetBlockHash:         blockHash,
+		kaon.MethodSearchLogs:           kaon.SearchLogsResponse{transfer},
+		kaon.MethodGetRawTransaction:    &kaon.GetRawTransactionResponse{Hex: "00"},
+		kaon.MethodDecodeRawTransaction: decodedRawTransaction,
+	} {
+		if err := clientDoerMock.AddResponse(method, response); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx, err := New(context.Background(), kaonClient, memorydb.New(), SetStartHeight(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	transfers, err := idx.Transfers(context.Background(), testBob, "", 1, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := kaon.ExecutionHash(txID, 3)
+	if len(transfers) != 1 || transfers[0].TransactionHash != hash {
+		t.Fatalf("expected the transfer to have the derived hash %s, got %+v", hash, transfers)
+	}
+	if execution, ok := kaonClient.ResolveExecutionHash(hash); !ok || execution.TransactionHash != txID || execution.OutputIndex != 3 {
+		t.Fatalf("expected the derived hash to be resolvable, got %+v", execution)
+	}
+}
+
+func TestMetadataCache(t *testing.T) {
+	idx := newSyncedIndex(t, memorydb.New(), 0, "", kaon.SearchLogsResponse{})
+	if _, ok := idx.Metadata(testToken); ok {
+		t.Fatal("expected no metadata before it's looked up")
+	}
+
+	decimals := uint8(8)
+	if err := idx.SetMetadata("0x"+testToken, Metadata{Name: "Token", Symbol: "TKN", Decimals: &decimals}); err != nil {
+		t.Fatal(err)
+	}
+	metadata, ok := idx.Metadata(testToken)
+	if !ok || metadata.Symbol != "TKN" || metadata.Decimals == nil || *metadata.Decimals != 8 {
+		t.Fatalf("unexpected metadata %+v", metadata)
+	}
+}
@@ -0,0 +1,59 @@
+package transformer
+
+import (
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+	"github.com/shopspring/decimal"
+)
+
+// ProxyDevFaucet implements ETHProxy
+type ProxyDevFaucet struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyDevFaucet)(nil)
+
+func (p *ProxyDevFaucet) Method() string {
+	return "dev_faucet"
+}
+
+func (p *ProxyDevFaucet) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only use the faucet on regtest")
+	}
+	f := getFaucet(c)
+	if f == nil {
+		return nil, eth.NewInvalidRequestError("faucet not configured")
+	}
+
+	var params []interface{}
+	if err := unmarshalRequest(req.Params, &params); err != nil || len(params) < 1 || len(params) > 2 {
+		return nil, eth.NewInvalidParamsError("require 1 or 2 arguments: the hex/base58 address to fund, the amount in wei (optional)")
+	}
+	address, ok := params[0].(string)
+	if !ok || address == "" {
+		return nil, eth.NewInvalidParamsError("first parameter must be an address")
+	}
+
+	// like balances, amounts are in units of 10^-18 KAON
+	amount := decimal.Zero
+	if len(params) == 2 && params[1] != nil {
+		wei, err := parseDevQuantity(params[1])
+		if err != nil {
+			return nil, eth.NewInvalidParamsError(err.Error())
+		}
+		if wei.Sign() <= 0 {
+			return nil, eth.NewInvalidParamsError("amount must be positive")
+		}
+		amount = decimal.NewFromBigInt(wei, -18)
+	}
+
+	txID, err := f.Fund(c.Request().Context(), address, amount)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "address", address, "msg", "couldn't fund address", "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return utils.AddHexPrefix(txID), nil
+}
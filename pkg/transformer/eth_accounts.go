@@ -16,6 +16,12 @@ func (p *ProxyETHAccounts) Method() string {
 	return "eth_accounts"
 }
 
+func (p *ProxyETHAccounts) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("accounts", arrayOf(addressSchema())),
+	}
+}
+
 func (p *ProxyETHAccounts) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	return p.request()
 }
@@ -19,6 +19,12 @@ func (p *ProxyETHBlockNumber) Method() string {
 	return "eth_blockNumber"
 }
 
+func (p *ProxyETHBlockNumber) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("block number", quantitySchema()),
+	}
+}
+
 func (p *ProxyETHBlockNumber) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	return p.request(c, 5)
 }
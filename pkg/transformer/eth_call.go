@@ -23,6 +23,16 @@ func (p *ProxyETHCall) Method() string {
 	return "eth_call"
 }
 
+func (p *ProxyETHCall) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("transaction", objectSchema()),
+			describeOptionalParam("block", blockParameterSchema()),
+		},
+		Result: describeResult("return data", dataSchema()),
+	}
+}
+
 func (p *ProxyETHCall) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.CallRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
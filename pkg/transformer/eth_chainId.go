@@ -17,6 +17,12 @@ func (p *ProxyETHChainId) Method() string {
 	return "eth_chainId"
 }
 
+func (p *ProxyETHChainId) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("chain id", quantitySchema()),
+	}
+}
+
 func (p *ProxyETHChainId) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	chainId, err := getChainId(p.Kaon)
 	if err != nil {
@@ -0,0 +1,78 @@
+package transformer
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+)
+
+// ProxyETHCreateAccessList implements ETHProxy
+type ProxyETHCreateAccessList struct {
+	*ProxyETHCall
+}
+
+func (p *ProxyETHCreateAccessList) Method() string {
+	return "eth_createAccessList"
+}
+
+func (p *ProxyETHCreateAccessList) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("transaction", objectSchema()),
+			describeOptionalParam("block", blockParameterSchema()),
+		},
+		Result: describeResult("access list with gas used", objectSchema()),
+	}
+}
+
+func (p *ProxyETHCreateAccessList) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.CreateAccessListRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	ctx := c.Request().Context()
+	block, jsonErr := getStateBlockByRawParam(ctx, p.Kaon, getBlockHash(c), req.Block)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if !block.Latest {
+		return nil, historicalStateUnavailable("callcontract only runs against the current state")
+	}
+
+	simulation, jsonErr := p.simulate(ctx, &req.Call)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	resp := &eth.CreateAccessListResponse{
+		AccessList: accessList(simulation, req.Call.From, req.Call.To),
+		GasUsed:    hexutil.EncodeBig(simulation.GasUsed),
+	}
+	if simulation.Failed() {
+		resp.Error = simulation.Error().Message
+	}
+	return resp, nil
+}
+
+// callcontract doesn't report the storage slots a call reads or writes, nor the contracts it calls, only the
+// contracts emitting logs are known to be accessed. Like geth, the sender and recipient are left out.
+func accessList(simulation *callSimulation, from, to string) []eth.AccessTuple {
+	excluded := map[string]bool{
+		strings.ToLower(utils.RemoveHexPrefix(from)): true,
+		strings.ToLower(utils.RemoveHexPrefix(to)):   true,
+	}
+	list := []eth.AccessTuple{}
+	for _, log := range simulation.Logs {
+		address := strings.ToLower(utils.RemoveHexPrefix(log.Address))
+		if excluded[address] {
+			continue
+		}
+		excluded[address] = true
+		list = append(list, eth.AccessTuple{Address: utils.AddHexPrefix(address), StorageKeys: []string{}})
+	}
+	return list
+}
@@ -0,0 +1,51 @@
+package transformer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+)
+
+func TestCreateAccessList(t *testing.T) {
+	kaonClient, setter, reverter := deploySimulationContracts(t)
+	proxy := &ProxyETHCreateAccessList{&ProxyETHCall{kaonClient}}
+
+	request := func(to string) *eth.CreateAccessListResponse {
+		requestRPC, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{
+			json.RawMessage(`{"from": "` + simulationMiner + `", "to": "` + to + `", "data": "0x01"}`),
+			json.RawMessage(`"latest"`),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, jsonErr := proxy.Request(requestRPC, internal.NewEchoContext())
+		if jsonErr != nil {
+			t.Fatal(jsonErr.Message())
+		}
+		return got.(*eth.CreateAccessListResponse)
+	}
+
+	set := request(setter)
+	if len(set.AccessList) != 0 || set.Error != "" || set.GasUsed == "0x0" {
+		t.Fatalf("unexpected access list %+v", set)
+	}
+	reverted := request(reverter)
+	if reverted.Error != "execution reverted" {
+		t.Fatalf("expected the call to revert, got %+v", reverted)
+	}
+}
+
+func TestAccessListOfLoggingContracts(t *testing.T) {
+	simulation := &callSimulation{Logs: []kaon.Log{
+		{Address: "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"},
+		{Address: "2352be3db3177f0a07efbe6da5857615b8c9901d"},
+		{Address: "1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"},
+		{Address: "7926223070547d2d15b2ef5e7383e541c338ffe9"},
+	}}
+	got := accessList(simulation, simulationMiner, "0x2352BE3DB3177F0A07EFBE6DA5857615B8C9901D")
+	want := []eth.AccessTuple{{Address: "0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960", StorageKeys: []string{}}}
+	internal.CheckTestResultDefault(want, got, t, false)
+}
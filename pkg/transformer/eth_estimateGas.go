@@ -25,6 +25,16 @@ func (p *ProxyETHEstimateGas) Method() string {
 	return "eth_estimateGas"
 }
 
+func (p *ProxyETHEstimateGas) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("transaction", objectSchema()),
+			describeOptionalParam("block", blockParameterSchema()),
+		},
+		Result: describeResult("gas used", quantitySchema()),
+	}
+}
+
 func (p *ProxyETHEstimateGas) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var ethreq eth.CallRequest
 	if jsonErr := unmarshalRequest(rawreq.Params, &ethreq); jsonErr != nil {
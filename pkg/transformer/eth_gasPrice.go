@@ -18,6 +18,12 @@ func (p *ProxyETHGasPrice) Method() string {
 	return "eth_gasPrice"
 }
 
+func (p *ProxyETHGasPrice) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("gas price", quantitySchema()),
+	}
+}
+
 func (p *ProxyETHGasPrice) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	kaonresp, err := p.Kaon.GetGasPrice(c.Request().Context())
 	if err != nil {
@@ -1,6 +1,9 @@
 package transformer
 
 import (
+	"context"
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
@@ -17,6 +20,16 @@ func (p *ProxyETHGetBalance) Method() string {
 	return "eth_getBalance"
 }
 
+func (p *ProxyETHGetBalance) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("address", addressSchema()),
+			describeOptionalParam("block", blockParameterSchema()),
+		},
+		Result: describeResult("balance", quantitySchema()),
+	}
+}
+
 func (p *ProxyETHGetBalance) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.GetBalanceRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
@@ -28,6 +41,11 @@ func (p *ProxyETHGetBalance) Request(rawreq *eth.JSONRPCRequest, c echo.Context)
 		return "0x0", nil
 	}
 
+	block, jsonErr := getStateBlockByRawParam(c.Request().Context(), p.Kaon, getBlockHash(c), req.Block)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
 	addr := utils.RemoveHexPrefix(req.Address)
 	{
 		// is address a contract or an account?
@@ -36,6 +54,10 @@ func (p *ProxyETHGetBalance) Request(rawreq *eth.JSONRPCRequest, c echo.Context)
 
 		// the address is a contract
 		if err == nil {
+			if !block.Latest {
+				// getaccountinfo only knows the current balance of a contract
+				return nil, historicalStateUnavailable("kaond only has the current balance of contracts")
+			}
 			// the unit of the balance Satoshi
 			p.GetDebugLogger().Log("method", p.Method(), "address", req.Address, "msg", "is a contract")
 			return hexutil.EncodeBig(&kaonresp.Balance), nil
@@ -50,6 +72,15 @@ func (p *ProxyETHGetBalance) Request(rawreq *eth.JSONRPCRequest, c echo.Context)
 			return nil, eth.NewCallbackError(err.Error())
 		}
 
+		if !block.Latest {
+			balance, err := p.balanceAt(c.Request().Context(), base58Addr, block.Height)
+			if err != nil {
+				p.GetDebugLogger().Log("method", p.Method(), "address", req.Address, "height", block.Height, "msg", "error replaying address deltas", "error", err)
+				return nil, historicalStateUnavailable(err.Error())
+			}
+			return hexutil.EncodeBig(balance), nil
+		}
+
 		kaonreq := kaon.GetAddressBalanceRequest{Address: base58Addr}
 		kaonresp, err := p.GetAddressBalance(c.Request().Context(), &kaonreq)
 		if err != nil {
@@ -67,3 +98,26 @@ func (p *ProxyETHGetBalance) Request(rawreq *eth.JSONRPCRequest, c echo.Context)
 		return hexutil.EncodeBig(&balance), nil
 	}
 }
+
+// Balance of an address after the block at height, getaddressbalance only knows the current one so it is
+// replayed from the address index deltas up to that block
+func (p *ProxyETHGetBalance) balanceAt(ctx context.Context, base58Addr string, height int64) (*big.Int, error) {
+	if height < 1 {
+		// the genesis block pays no one
+		return new(big.Int), nil
+	}
+
+	deltas, err := p.GetAddressDeltas(ctx, &kaon.GetAddressDeltasRequest{
+		Addresses: []string{base58Addr},
+		Start:     1,
+		End:       height,
+	})
+	if err != nil {
+		return nil, err
+	}
+	balance := new(big.Int)
+	for i := range deltas {
+		balance.Add(balance, &deltas[i].Satoshis)
+	}
+	return balance, nil
+}
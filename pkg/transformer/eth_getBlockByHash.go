@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/kaonone/eth-rpc-gate/pkg/blockhash"
+	"github.com/kaonone/eth-rpc-gate/pkg/conversion"
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
 	"github.com/kaonone/eth-rpc-gate/pkg/utils"
@@ -17,6 +19,11 @@ import (
 
 var ErrBlockHashNotConfigured = errors.New("BlockHash database not configured")
 var ErrBlockHashUnknown = errors.New("BlockHash unknown")
+var ErrEthereumHeaderNotIndexed = errors.New("the Ethereum headers of the ancestors of the block are still being computed")
+
+// Ancestors whose Ethereum headers a request computes before giving up, deeper ones are left to the
+// EthereumHeaderIndexer so that a request never walks the chain
+const maxRequestEthereumHeaders = 16
 
 // ProxyETHGetBlockByHash implements ETHProxy
 type ProxyETHGetBlockByHash struct {
@@ -27,6 +34,16 @@ func (p *ProxyETHGetBlockByHash) Method() string {
 	return "eth_getBlockByHash"
 }
 
+func (p *ProxyETHGetBlockByHash) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("block hash", hashSchema()),
+			describeParam("hydrated transactions", booleanSchema()),
+		},
+		Result: describeResult("block", nullable(objectSchema())),
+	}
+}
+
 func (p *ProxyETHGetBlockByHash) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	req := new(eth.GetBlockByHashRequest)
 	if err := unmarshalRequest(rawreq.Params, req); err != nil {
@@ -34,18 +51,14 @@ func (p *ProxyETHGetBlockByHash) Request(rawreq *eth.JSONRPCRequest, c echo.Cont
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	blockHash := c.Get("blockHash")
-	bh, ok := blockHash.(*blockhash.BlockHash)
-	if !ok {
-		// ok, do nothing
-	}
+	bh := getBlockHash(c)
 
 	req.BlockHash = utils.RemoveHexPrefix(req.BlockHash)
 
 	resultChan := make(chan *eth.GetBlockByHashResponse, 2)
 	errorChan := make(chan *eth.JSONRPCError, 1)
 	kaonBlockErrorChan := make(chan error, 1)
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	ctx, cancelFunc := context.WithCancel(c.Request().Context())
 	defer cancelFunc()
 
 	go func() {
@@ -58,35 +71,26 @@ func (p *ProxyETHGetBlockByHash) Request(rawreq *eth.JSONRPCRequest, c echo.Cont
 		resultChan <- result
 	}()
 
-	if bh == nil {
-		kaonBlockErrorChan <- ErrBlockHashNotConfigured
-	} else {
-		go func() {
-			kaonBlockHash, err := bh.GetKaonBlockHashContext(ctx, req.BlockHash)
-			if err != nil {
-				kaonBlockErrorChan <- err
-				return
-			}
-
-			if kaonBlockHash == nil {
-				kaonBlockErrorChan <- ErrBlockHashUnknown
-				return
-			}
+	go func() {
+		kaonBlockHash, err := p.resolveEthereumBlockHash(ctx, bh, req.BlockHash)
+		if err != nil {
+			kaonBlockErrorChan <- err
+			return
+		}
 
-			request := &eth.GetBlockByHashRequest{
-				BlockHash:       utils.RemoveHexPrefix(*kaonBlockHash),
-				FullTransaction: req.FullTransaction,
-			}
+		request := &eth.GetBlockByHashRequest{
+			BlockHash:       utils.RemoveHexPrefix(kaonBlockHash),
+			FullTransaction: req.FullTransaction,
+		}
 
-			result, jsonErr := p.request(ctx, request)
-			if jsonErr != nil {
-				kaonBlockErrorChan <- jsonErr.Error()
-				return
-			}
+		result, jsonErr := p.request(ctx, request)
+		if jsonErr != nil {
+			kaonBlockErrorChan <- jsonErr.Error()
+			return
+		}
 
-			resultChan <- result
-		}()
-	}
+		resultChan <- result
+	}()
 
 	select {
 	case result := <-resultChan:
@@ -116,7 +120,218 @@ func (p *ProxyETHGetBlockByHash) Request(rawreq *eth.JSONRPCRequest, c echo.Cont
 	}
 }
 
+// Kaon block an Ethereum block hash stands for, either served before with --eth-headers or known to the blockhash database
+func (p *ProxyETHGetBlockByHash) resolveEthereumBlockHash(ctx context.Context, bh *blockhash.BlockHash, ethereumBlockHash string) (string, error) {
+	if kaonBlockHash, ok := p.ResolveEthereumBlockHash(ethereumBlockHash); ok {
+		return kaonBlockHash, nil
+	}
+	if bh == nil {
+		return "", ErrBlockHashNotConfigured
+	}
+	kaonBlockHash, err := bh.GetKaonBlockHashContext(ctx, ethereumBlockHash)
+	if err != nil {
+		return "", err
+	}
+	if kaonBlockHash == nil {
+		return "", ErrBlockHashUnknown
+	}
+	return *kaonBlockHash, nil
+}
+
+// Serves the block, with its Ethereum header when enabled
 func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockByHashRequest) (*eth.GetBlockByHashResponse, *eth.JSONRPCError) {
+	if !p.EthHeadersEnabled() {
+		return p.requestBlock(ctx, req)
+	}
+
+	var (
+		resp    *eth.GetBlockByHashResponse
+		jsonErr *eth.JSONRPCError
+		// the block was requested with full transactions for its header only
+		fullForHeader = false
+	)
+	header, known := p.EthereumHeader(req.BlockHash)
+	if !known && !req.FullTransaction {
+		// the header needs the full transactions, the hashes are taken from them instead of requesting the block twice
+		resp, jsonErr = p.requestBlock(ctx, &eth.GetBlockByHashRequest{BlockHash: req.BlockHash, FullTransaction: true})
+		fullForHeader = true
+	} else {
+		resp, jsonErr = p.requestBlock(ctx, req)
+	}
+	if jsonErr != nil || resp == nil {
+		return resp, jsonErr
+	}
+
+	if !known {
+		ethereumHeader, err := getEthereumHeader(ctx, p.Kaon, req.BlockHash, resp)
+		if err != nil {
+			p.GetDebugLogger().Log("msg", "couldn't compute Ethereum block header", "blockHash", req.BlockHash, "err", err)
+			if errors.Cause(err) == ErrEthereumHeaderNotIndexed {
+				return nil, eth.NewJSONRPCError(eth.CallbackErrorCode, err.Error(), err)
+			}
+			return nil, eth.NewCallbackError("couldn't compute Ethereum block header")
+		}
+		if ethereumHeader == nil {
+			// below --eth-headers-start-height
+			return withTransactionHashes(resp, fullForHeader), nil
+		}
+		header = *ethereumHeader
+	}
+	setEthereumHeader(resp, &header)
+
+	return withTransactionHashes(resp, fullForHeader), nil
+}
+
+// Replaces the full transactions of a block requested for its Ethereum header by their hashes
+func withTransactionHashes(resp *eth.GetBlockByHashResponse, replace bool) *eth.GetBlockByHashResponse {
+	if !replace {
+		return resp
+	}
+	hashes := make([]interface{}, 0, len(resp.Transactions))
+	for _, tx := range resp.Transactions {
+		if tx, ok := tx.(eth.GetTransactionByHashResponse); ok {
+			hashes = append(hashes, tx.Hash)
+		}
+	}
+	resp.Transactions = hashes
+	return resp
+}
+
+// Replaces the Kaon hashes and roots of a block by those of its Ethereum header
+func setEthereumHeader(resp *eth.GetBlockByHashResponse, header *kaon.EthereumHeader) {
+	resp.Hash = header.Hash
+	resp.ParentHash = header.ParentHash
+	resp.TransactionsRoot = header.TransactionsRoot
+	resp.ReceiptsRoot = header.ReceiptsRoot
+	resp.Sha3Uncles = conversion.EmptyUnclesHash
+	resp.MixHash = "0x0000000000000000000000000000000000000000000000000000000000000000"
+
+	for i, tx := range resp.Transactions {
+		if tx, ok := tx.(eth.GetTransactionByHashResponse); ok {
+			tx.BlockHash = header.Hash
+			resp.Transactions[i] = tx
+		}
+	}
+}
+
+// Ethereum header of a block, nil if the block keeps its Kaon hash because it's below --eth-headers-start-height
+// or --eth-headers is disabled. The headers of up to maxRequestEthereumHeaders ancestors that weren't computed
+// yet are computed first, walking back to the first known one, ErrEthereumHeaderNotIndexed is returned if more
+// are missing. block is the block with full transactions if it was already requested.
+func getEthereumHeader(ctx context.Context, p *kaon.Kaon, kaonBlockHash string, block *eth.GetBlockByHashResponse) (*kaon.EthereumHeader, error) {
+	return computeEthereumHeaders(ctx, p, kaonBlockHash, block, maxRequestEthereumHeaders)
+}
+
+// Like getEthereumHeader, computing up to maxHeaders missing headers, any number of them if maxHeaders is zero
+func computeEthereumHeaders(ctx context.Context, p *kaon.Kaon, kaonBlockHash string, block *eth.GetBlockByHashResponse, maxHeaders int) (*kaon.EthereumHeader, error) {
+	if !p.EthHeadersEnabled() {
+		return nil, nil
+	}
+	kaonBlockHash = strings.ToLower(utils.RemoveHexPrefix(kaonBlockHash))
+	if header, ok := p.EthereumHeader(kaonBlockHash); ok {
+		return &header, nil
+	}
+
+	var (
+		startHeight = p.EthHeadersStartHeight()
+		// newest first
+		pending    []string
+		parentHash string
+	)
+	for hash := kaonBlockHash; ; {
+		blockHeader, err := p.GetBlockHeader(ctx, hash)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "couldn't get header of block %s", hash)
+		}
+		if uint64(blockHeader.Height) < startHeight {
+			if len(pending) == 0 {
+				return nil, nil
+			}
+			parentHash = utils.AddHexPrefix(hash)
+			break
+		}
+		if maxHeaders > 0 && len(pending) == maxHeaders {
+			return nil, errors.Wrapf(ErrEthereumHeaderNotIndexed, "block %s", kaonBlockHash)
+		}
+		pending = append(pending, hash)
+		if blockHeader.IsGenesisBlock() {
+			parentHash = "0x0000000000000000000000000000000000000000000000000000000000000000"
+			break
+		}
+		if parent, ok := p.EthereumHeader(blockHeader.Previousblockhash); ok {
+			parentHash = parent.Hash
+			break
+		}
+		hash = blockHeader.Previousblockhash
+	}
+	if len(pending) > 1 {
+		p.GetDebugLogger().Log("msg", "Computing Ethereum headers of ancestors", "blockHash", kaonBlockHash, "blocks", len(pending))
+	}
+
+	var header kaon.EthereumHeader
+	for i := len(pending) - 1; i >= 0; i-- {
+		fullBlock := block
+		if i != 0 || fullBlock == nil {
+			var jsonErr *eth.JSONRPCError
+			fullBlock, jsonErr = (&ProxyETHGetBlockByHash{p}).requestBlock(ctx, &eth.GetBlockByHashRequest{BlockHash: pending[i], FullTransaction: true})
+			if jsonErr != nil {
+				return nil, jsonErr.Error()
+			}
+			if fullBlock == nil {
+				return nil, errors.Errorf("unknown block %s", pending[i])
+			}
+		}
+
+		var err error
+		if header, err = computeEthereumHeader(ctx, p, pending[i], fullBlock, parentHash); err != nil {
+			return nil, errors.WithMessagef(err, "couldn't compute Ethereum header of block %s", pending[i])
+		}
+		p.RegisterEthereumHeader(pending[i], header)
+		parentHash = header.Hash
+	}
+	return &header, nil
+}
+
+// Computes the Ethereum transactions and receipts tries of a block served with full transactions and the hash of
+// the resulting Ethereum header, parentHash being the hash the parent block is served with
+func computeEthereumHeader(ctx context.Context, p *kaon.Kaon, kaonBlockHash string, fullBlock *eth.GetBlockByHashResponse, parentHash string) (kaon.EthereumHeader, error) {
+	txs, err := blockResponseTransactions(fullBlock)
+	if err != nil {
+		return kaon.EthereumHeader{}, err
+	}
+
+	block, err := p.GetBlock(ctx, kaonBlockHash, true)
+	if err != nil {
+		return kaon.EthereumHeader{}, errors.WithMessage(err, "couldn't get block")
+	}
+	receipts, err := getEthereumReceipts(ctx, p, block)
+	if err != nil {
+		return kaon.EthereumHeader{}, err
+	}
+	if len(receipts) != len(txs) {
+		return kaon.EthereumHeader{}, errors.Errorf("block has %d transactions but %d receipts", len(txs), len(receipts))
+	}
+
+	header := kaon.EthereumHeader{
+		ParentHash:   parentHash,
+		ReceiptsRoot: conversion.ReceiptsRoot(receipts),
+	}
+	if header.TransactionsRoot, err = conversion.TransactionsRoot(txs); err != nil {
+		return kaon.EthereumHeader{}, err
+	}
+
+	ethBlock := *fullBlock
+	ethBlock.Transactions = nil
+	setEthereumHeader(&ethBlock, &header)
+	ethHeader, err := conversion.EthereumHeader(&ethBlock)
+	if err != nil {
+		return kaon.EthereumHeader{}, err
+	}
+	header.Hash = ethHeader.Hash().Hex()
+	return header, nil
+}
+
+func (p *ProxyETHGetBlockByHash) requestBlock(ctx context.Context, req *eth.GetBlockByHashRequest) (*eth.GetBlockByHashResponse, *eth.JSONRPCError) {
 	blockHeader, err := p.GetBlockHeader(ctx, req.BlockHash)
 	if err != nil {
 		if err == kaon.ErrInvalidAddress {
@@ -160,9 +375,7 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 		// TODO: check value correctness
 		Sha3Uncles: eth.DefaultSha3Uncles,
 
-		// TODO: backlog
-		// ! Not found
-		// - Temporary expect this value to be always zero, as Etherium logs are usually zeros
+		// Overwritten below once the block's logs are known
 		LogsBloom: eth.EmptyLogsBloom,
 
 		// TODO: researching
@@ -197,9 +410,19 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 	resp.GasLimit = utils.AddHexPrefix(kaon.DefaultBlockGasLimit)
 	resp.GasUsed = utils.AddHexPrefix(blockHeader.GasUsed.String())
 
+	// kaond only serves searchlogs with -logevents, blocks are still returned without a bloom otherwise
+	if positions, err := getBlockLogPositions(ctx, p.Kaon, nil, block); err == nil {
+		resp.LogsBloom = positions.LogsBloom()
+	} else {
+		p.GetDebugLogger().Log("msg", "couldn't get block logs, returning an empty logsBloom", "blockHash", req.BlockHash, "err", err)
+	}
+
 	var cumulativeGas *big.Int = big.NewInt(0)
+	// A transaction with several EVM executions takes one position per execution
+	var ethIndex uint64
 
 	if req.FullTransaction {
+		prefetchBlockRawTransactions(ctx, p.Kaon, block)
 		for i, txHash := range block.Txs {
 			switch txData := txHash.(type) {
 			case string:
@@ -221,6 +444,8 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 						}
 					}
 				} else {
+					tx.TransactionIndex = hexutil.EncodeUint64(ethIndex)
+					ethIndex++
 					resp.Transactions = append(resp.Transactions, *tx)
 				}
 			case *kaon.BlockTransactionDetails:
@@ -231,13 +456,18 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 					return nil, eth.NewCallbackError("couldn't get transaction by hash")
 				}
 
-				tx.GasUsed = tx.Gas // TODO
-				tx.CumulativeGas = utils.AddHexPrefix(hexutil.EncodeBig(cumulativeGas))
-				var newValue *big.Int
-				newValue, _ = hexutil.DecodeBig(tx.Gas)
-				cumulativeGas = new(big.Int).Add(cumulativeGas, newValue)
+				for _, tx := range blockTransactionExecutions(p.Kaon, txData, tx) {
+					tx.TransactionIndex = hexutil.EncodeUint64(ethIndex)
+					ethIndex++
 
-				resp.Transactions = append(resp.Transactions, *tx)
+					tx.GasUsed = tx.Gas // TODO
+					tx.CumulativeGas = utils.AddHexPrefix(hexutil.EncodeBig(cumulativeGas))
+					var newValue *big.Int
+					newValue, _ = hexutil.DecodeBig(tx.Gas)
+					cumulativeGas = new(big.Int).Add(cumulativeGas, newValue)
+
+					resp.Transactions = append(resp.Transactions, tx)
+				}
 
 			default:
 
@@ -260,13 +490,18 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 					// return nil, eth.NewCallbackError("couldn't get transaction by hash")
 				}
 
-				tx.GasUsed = tx.Gas // TODO
-				tx.CumulativeGas = utils.AddHexPrefix(hexutil.EncodeBig(cumulativeGas))
-				var newValue *big.Int
-				newValue, _ = hexutil.DecodeBig(tx.Gas)
-				cumulativeGas = new(big.Int).Add(cumulativeGas, newValue)
+				for _, tx := range blockTransactionExecutions(p.Kaon, &ethTxData, tx) {
+					tx.TransactionIndex = hexutil.EncodeUint64(ethIndex)
+					ethIndex++
+
+					tx.GasUsed = tx.Gas // TODO
+					tx.CumulativeGas = utils.AddHexPrefix(hexutil.EncodeBig(cumulativeGas))
+					var newValue *big.Int
+					newValue, _ = hexutil.DecodeBig(tx.Gas)
+					cumulativeGas = new(big.Int).Add(cumulativeGas, newValue)
 
-				resp.Transactions = append(resp.Transactions, *tx)
+					resp.Transactions = append(resp.Transactions, tx)
+				}
 
 			}
 			resp.GasLimit = utils.AddHexPrefix(kaon.DefaultBlockGasLimit) // TODO: replace by dynamic
@@ -293,7 +528,14 @@ func (p *ProxyETHGetBlockByHash) request(ctx context.Context, req *eth.GetBlockB
 					continue
 					// return nil, eth.NewCallbackError("couldn't get transaction by hash")
 				}
-				resp.Transactions = append(resp.Transactions, utils.AddHexPrefix(ethTxData.ID))
+				contracts, err := ethTxData.ExtractContractInfos()
+				if err != nil {
+					p.GetDebugLogger().Log("msg", "Couldn't parse contract outputs", "hash", ethTxData.ID, "err", err)
+					contracts = nil
+				}
+				for _, execution := range p.RegisterExecutions(ethTxData.ID, contracts) {
+					resp.Transactions = append(resp.Transactions, utils.AddHexPrefix(execution.Hash))
+				}
 			}
 		}
 	}
@@ -7,6 +7,7 @@ import (
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
 	"github.com/labstack/echo"
+	"github.com/pkg/errors"
 )
 
 // ProxyETHGetBlockByNumber implements ETHProxy
@@ -18,6 +19,16 @@ func (p *ProxyETHGetBlockByNumber) Method() string {
 	return "eth_getBlockByNumber"
 }
 
+func (p *ProxyETHGetBlockByNumber) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("block", blockParameterSchema()),
+			describeParam("hydrated transactions", booleanSchema()),
+		},
+		Result: describeResult("block", nullable(objectSchema())),
+	}
+}
+
 func (p *ProxyETHGetBlockByNumber) Request(rpcReq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	req := new(eth.GetBlockByNumberRequest)
 	if err := unmarshalRequest(rpcReq.Params, req); err != nil {
@@ -51,6 +62,9 @@ func (p *ProxyETHGetBlockByNumber) request(ctx context.Context, req *eth.GetBloc
 	block, jsonErr := proxy.request(ctx, getBlockByHashReq)
 	if jsonErr != nil {
 		p.GetDebugLogger().Log("function", p.Method(), "msg", "couldn't get block by hash", "jsonErr", jsonErr.Message())
+		if errors.Cause(jsonErr.Error()) == ErrEthereumHeaderNotIndexed {
+			return nil, jsonErr
+		}
 		return nil, eth.NewCallbackError("couldn't get block by hash")
 	}
 	if blockNum != nil {
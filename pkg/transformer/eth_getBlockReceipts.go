@@ -0,0 +1,269 @@
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/blockhash"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/logindex"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+)
+
+// ProxyETHGetBlockReceipts implements ETHProxy
+type ProxyETHGetBlockReceipts struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyETHGetBlockReceipts) Method() string {
+	return "eth_getBlockReceipts"
+}
+
+func (p *ProxyETHGetBlockReceipts) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("block", blockParameterSchema()),
+		},
+		Result: describeResult("receipts", nullable(arrayOf(objectSchema()))),
+	}
+}
+
+func (p *ProxyETHGetBlockReceipts) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.GetBlockReceiptsRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		// TODO: Correct error code?
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return p.request(c.Request().Context(), &req, getLogIndex(c), getBlockHash(c))
+}
+
+func (p *ProxyETHGetBlockReceipts) request(ctx context.Context, req *eth.GetBlockReceiptsRequest, index *logindex.Index, bh *blockhash.BlockHash) (eth.GetBlockReceiptsResponse, *eth.JSONRPCError) {
+	blockHash, requireCanonical, jsonErr := p.resolveBlock(ctx, req, bh)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if blockHash == "" {
+		return nil, nil
+	}
+
+	block, err := p.GetBlock(ctx, blockHash, true)
+	if err == kaon.ErrInvalidAddress {
+		// unknown block
+		return nil, nil
+	}
+	if err != nil {
+		p.GetDebugLogger().Log("function", p.Method(), "msg", "couldn't get block", "blockHash", blockHash, "err", err)
+		return nil, eth.NewCallbackError("couldn't get block")
+	}
+	if requireCanonical {
+		canonicalHash, err := p.GetBlockHash(ctx, big.NewInt(int64(block.Height)))
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		if !strings.EqualFold(utils.RemoveHexPrefix(string(canonicalHash)), utils.RemoveHexPrefix(block.Hash)) {
+			return nil, eth.NewCallbackError(fmt.Sprintf("hash %s is not currently canonical", blockHash))
+		}
+	}
+
+	receipts, err := p.getBlockReceipts(ctx, block, index)
+	if err != nil {
+		p.GetDebugLogger().Log("function", p.Method(), "msg", "couldn't get block receipts", "blockHash", blockHash, "err", err)
+		return nil, eth.NewCallbackError("couldn't get block receipts")
+	}
+	return receipts, nil
+}
+
+// Kaon hash of the requested block, empty for unknown blocks. requireCanonical is set for EIP-1898 block
+// hash objects that only accept blocks of the active chain
+func (p *ProxyETHGetBlockReceipts) resolveBlock(ctx context.Context, req *eth.GetBlockReceiptsRequest, bh *blockhash.BlockHash) (blockHash string, requireCanonical bool, jsonErr *eth.JSONRPCError) {
+	rawParam := bytes.TrimSpace(req.Block)
+	var param string
+	switch {
+	case len(rawParam) != 0 && rawParam[0] == '{':
+		object, jsonErr := parseBlockObject(rawParam)
+		if jsonErr != nil {
+			return "", false, jsonErr
+		}
+		if object.BlockHash != nil {
+			return resolveKaonBlockHash(ctx, p.Kaon, bh, *object.BlockHash), object.RequireCanonical, nil
+		}
+		param = *object.BlockNumber
+	case isBytesOfString(rawParam):
+		param = string(rawParam[1 : len(rawParam)-1])
+		if len(utils.RemoveHexPrefix(param)) == 64 {
+			return resolveKaonBlockHash(ctx, p.Kaon, bh, param), false, nil
+		}
+	default:
+		height, err := strconv.ParseUint(string(rawParam), 10, 64)
+		if err != nil {
+			return "", false, eth.NewInvalidParamsError("invalid parameter format - string, integer or block object is expected")
+		}
+		param = hexutil.EncodeUint64(height)
+	}
+
+	blockNum, jsonErr := getBlockNumberByParam(ctx, p.Kaon, param, false)
+	if jsonErr != nil {
+		return "", false, jsonErr
+	}
+	kaonBlockHash, jsonErr := proxyETHGetBlockByHash(ctx, p, p.Kaon, blockNum)
+	if jsonErr != nil || kaonBlockHash == nil {
+		return "", false, jsonErr
+	}
+	return string(*kaonBlockHash), false, nil
+}
+
+// Receipts of every EVM execution of a block in block order, built from the verbose block and a single
+// searchlogs call for the whole block. Only receipts of contract executions that emitted no logs are
+// fetched, in a batch; transactions that don't run the EVM are described by the block itself
+func (p *ProxyETHGetBlockReceipts) getBlockReceipts(ctx context.Context, block *kaon.GetBlockResponse, index *logindex.Index) (eth.GetBlockReceiptsResponse, error) {
+	var (
+		executions = make([]kaon.Execution, 0, len(block.Txs))
+		isCreation = make(map[string]bool)
+		txs        = make(map[string]*kaon.BlockTransactionDetails, len(block.Txs))
+	)
+	for _, tx := range block.Txs {
+		details, err := blockTransactionDetails(tx)
+		if err != nil {
+			return nil, errors.WithMessage(err, "couldn't parse block transaction")
+		}
+		txs[details.ID] = details
+		// parsing err is discarded, the transaction is then reported as a single execution
+		contracts, _ := details.ExtractContractInfos()
+		txExecutions := p.RegisterExecutions(details.ID, contracts)
+		for i, contract := range contracts {
+			isCreation[txExecutions[i].Hash] = contract.IsCreate
+		}
+		executions = append(executions, txExecutions...)
+	}
+
+	searchedReceipts, err := searchBlockLogs(ctx, p.Kaon, index, block)
+	if err != nil {
+		return nil, err
+	}
+	positions, ok := getKnownBlockLogPositions(ctx, p.Kaon, block.Hash)
+	if !ok {
+		positions = rememberBlockLogPositions(ctx, p.Kaon, block, executions, searchedReceipts)
+	}
+
+	txReceipts := make(map[string]kaon.GetTransactionReceiptsResponse)
+	for _, receipt := range searchedReceipts {
+		txReceipts[receipt.TransactionHash] = append(txReceipts[receipt.TransactionHash], receipt)
+	}
+	var (
+		missing = make(map[string]bool)
+		fetch   []string
+	)
+	for _, execution := range executions {
+		if execution.OutputIndex == -1 || missing[execution.TransactionHash] {
+			continue
+		}
+		if _, ok := findExecutionReceipt(txReceipts[execution.TransactionHash], execution); !ok {
+			missing[execution.TransactionHash] = true
+			fetch = append(fetch, execution.TransactionHash)
+		}
+	}
+	if len(fetch) != 0 {
+		fetched, err := p.GetTransactionsReceipts(ctx, fetch)
+		if err != nil {
+			return nil, errors.WithMessage(err, "couldn't get receipts of the block transactions")
+		}
+		for txHash, receipts := range fetched {
+			txReceipts[txHash] = receipts
+		}
+	}
+
+	result := make(eth.GetBlockReceiptsResponse, 0, len(executions))
+	for i, execution := range executions {
+		var ethReceipt *eth.GetTransactionReceiptResponse
+		if execution.OutputIndex == -1 {
+			details, ok := txs[execution.TransactionHash]
+			if !ok {
+				return nil, errors.Errorf("transaction %s has no receipt", execution.TransactionHash)
+			}
+			// transactions that don't run the EVM use no gas
+			cumulativeGasUsed := "0x0"
+			if i > 0 {
+				cumulativeGasUsed = result[i-1].CumulativeGasUsed
+			}
+			ethReceipt = p.newNonContractReceipt(block, details, cumulativeGasUsed)
+		} else {
+			receipt, ok := findExecutionReceipt(txReceipts[execution.TransactionHash], execution)
+			if !ok {
+				return nil, errors.Errorf("transaction %s has no receipt for output %d", execution.TransactionHash, execution.OutputIndex)
+			}
+			ethReceipt = newETHReceipt(p.Kaon, receipt, execution, isCreation[execution.Hash], positions)
+		}
+		ethReceipt.TransactionIndex = hexutil.EncodeUint64(uint64(i))
+		result = append(result, ethReceipt)
+	}
+
+	if p.EthHeadersEnabled() {
+		blockHash, err := getServedBlockHash(ctx, p.Kaon, block.Hash)
+		if err != nil {
+			return nil, err
+		}
+		for _, receipt := range result {
+			receipt.BlockHash = blockHash
+			for i := range receipt.Logs {
+				receipt.Logs[i].BlockHash = blockHash
+			}
+		}
+	}
+	return result, nil
+}
+
+// Receipt of a transaction that doesn't run the EVM, with the sender and recipient eth_getTransactionReceipt
+// reports for it
+func (p *ProxyETHGetBlockReceipts) newNonContractReceipt(block *kaon.GetBlockResponse, tx *kaon.BlockTransactionDetails, cumulativeGasUsed string) *eth.GetTransactionReceiptResponse {
+	receipt := &eth.GetTransactionReceiptResponse{
+		TransactionHash:   utils.AddHexPrefix(tx.ID),
+		BlockHash:         utils.AddHexPrefix(block.Hash),
+		BlockNumber:       hexutil.EncodeUint64(uint64(block.Height)),
+		CumulativeGasUsed: cumulativeGasUsed,
+		EffectiveGasPrice: "0x0",
+		GasUsed:           "0x0",
+		From:              utils.AddHexPrefix(kaon.ZeroAddress),
+		To:                utils.AddHexPrefix(kaon.ZeroAddress),
+		Logs:              []eth.Log{},
+		LogsBloom:         eth.EmptyLogsBloom,
+		Status:            STATUS_SUCCESS,
+	}
+
+	var from string
+	if len(tx.Vins) > 0 {
+		from = tx.Vins[0].Address
+	}
+	sender := tx.OP_SENDER
+	if sender == "" {
+		sender = from
+	}
+	if sender != "" {
+		if addr, err := p.Base58AddressToHex(sender); err == nil {
+			receipt.From = utils.AddHexPrefix(addr)
+		}
+	}
+
+	// when sending KAON, the first vout will be the target
+	// the second will be change from the vin, it will be returned to the same account
+	if len(tx.Vouts) >= 2 {
+		for _, vout := range tx.Vouts {
+			addresses := vout.ScriptPubKey.GetAddresses()
+			if len(addresses) == 0 || addresses[0] == "" || addresses[0] == from {
+				continue
+			}
+			if addr, err := p.Base58AddressToHex(addresses[0]); err == nil {
+				receipt.To = utils.AddHexPrefix(addr)
+			}
+			break
+		}
+	}
+	return receipt
+}
@@ -0,0 +1,59 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/blockhash"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyETHGetBlockTransactionCountByHash implements ETHProxy
+type ProxyETHGetBlockTransactionCountByHash struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyETHGetBlockTransactionCountByHash) Method() string {
+	return "eth_getBlockTransactionCountByHash"
+}
+
+func (p *ProxyETHGetBlockTransactionCountByHash) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("block hash", hashSchema()),
+		},
+		Result: describeResult("transaction count", nullable(quantitySchema())),
+	}
+}
+
+func (p *ProxyETHGetBlockTransactionCountByHash) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.GetBlockTransactionCountByHashRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		// TODO: Correct error code?
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	if req == "" {
+		return nil, eth.NewInvalidParamsError("empty block hash")
+	}
+	return p.request(c.Request().Context(), &req, getBlockHash(c))
+}
+
+func (p *ProxyETHGetBlockTransactionCountByHash) request(ctx context.Context, req *eth.GetBlockTransactionCountByHashRequest, bh *blockhash.BlockHash) (interface{}, *eth.JSONRPCError) {
+	return getBlockTransactionCount(ctx, p.Kaon, resolveKaonBlockHash(ctx, p.Kaon, bh, string(*req)))
+}
+
+// Number of Ethereum transactions of a block, which counts every EVM execution of a Kaon transaction
+func getBlockTransactionCount(ctx context.Context, p *kaon.Kaon, blockHash string) (interface{}, *eth.JSONRPCError) {
+	block, err := p.GetBlock(ctx, blockHash, true)
+	if err == kaon.ErrInvalidAddress {
+		// unknown block
+		return nil, nil
+	}
+	if err != nil {
+		p.GetDebugLogger().Log("function", "getBlockTransactionCount", "msg", "couldn't get block", "blockHash", blockHash, "err", err)
+		return nil, eth.NewCallbackError("couldn't get block")
+	}
+	return hexutil.EncodeUint64(uint64(len(blockExecutions(p, block)))), nil
+}
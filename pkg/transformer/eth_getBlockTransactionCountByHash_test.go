@@ -0,0 +1,54 @@
+package transformer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+)
+
+// Block with a transaction calling two contracts, in outputs 1 and 3, followed by a transaction without contract outputs
+func blockWithSeveralExecutions(txID string) kaon.GetBlockResponse {
+	block := internal.GetBlockResponse
+	block.Txs = []interface{}{
+		json.RawMessage(`{
+			"txid": "` + txID + `",
+			"vout": [
+				{"n": 1, "scriptPubKey": {"asm": "4 55000 40 a9059cbb af1ae4e29253ba755c723bca25e883b8deb777b8 OP_CALL"}},
+				{"n": 3, "scriptPubKey": {"asm": "4 60000 40 70a08231 6b22910b1e302cf74803ffd1691c2ecb858d3712 OP_CALL"}}
+			]
+		}`),
+		json.RawMessage(`{"txid": "8fcd819194cce6a8454b2bec334d3448df4f097e9cdc36707bfd569900268950"}`),
+	}
+	return block
+}
+
+func TestGetBlockTransactionCountByHash(t *testing.T) {
+	requestParams := []json.RawMessage{[]byte(`"` + internal.GetTransactionByHashBlockHexHash + `"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mockedClientDoer.AddResponse(kaon.MethodGetBlock, blockWithSeveralExecutions("3208dc44733cbfa11654ad5651305428de473ef1e61a1ec07b0c1a5f4843be91"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetBlockTransactionCountByHash{kaonClient}
+	got, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	// every EVM execution counts as a transaction
+	want := "0x3"
+
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+}
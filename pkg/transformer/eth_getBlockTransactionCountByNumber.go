@@ -0,0 +1,52 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyETHGetBlockTransactionCountByNumber implements ETHProxy
+type ProxyETHGetBlockTransactionCountByNumber struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyETHGetBlockTransactionCountByNumber) Method() string {
+	return "eth_getBlockTransactionCountByNumber"
+}
+
+func (p *ProxyETHGetBlockTransactionCountByNumber) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("block", blockParameterSchema()),
+		},
+		Result: describeResult("transaction count", nullable(quantitySchema())),
+	}
+}
+
+func (p *ProxyETHGetBlockTransactionCountByNumber) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.GetBlockTransactionCountByNumberRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		// TODO: Correct error code?
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return p.request(c.Request().Context(), &req)
+}
+
+func (p *ProxyETHGetBlockTransactionCountByNumber) request(ctx context.Context, req *eth.GetBlockTransactionCountByNumberRequest) (interface{}, *eth.JSONRPCError) {
+	blockNum, jsonErr := getBlockNumberByParam(ctx, p.Kaon, string(*req), false)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	blockHash, jsonErr := proxyETHGetBlockByHash(ctx, p, p.Kaon, blockNum)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if blockHash == nil {
+		return nil, nil
+	}
+	return getBlockTransactionCount(ctx, p.Kaon, string(*blockHash))
+}
@@ -18,6 +18,16 @@ func (p *ProxyETHGetCode) Method() string {
 	return "eth_getCode"
 }
 
+func (p *ProxyETHGetCode) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("address", addressSchema()),
+			describeOptionalParam("block", blockParameterSchema()),
+		},
+		Result: describeResult("bytecode", dataSchema()),
+	}
+}
+
 func (p *ProxyETHGetCode) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.GetCodeRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
@@ -25,10 +35,20 @@ func (p *ProxyETHGetCode) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (i
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	return p.request(c.Request().Context(), &req)
+	block, jsonErr := getStateBlockByRawParam(c.Request().Context(), p.Kaon, getBlockHash(c), req.Block)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	return p.request(c.Request().Context(), &req, block)
 }
 
-func (p *ProxyETHGetCode) request(ctx context.Context, ethreq *eth.GetCodeRequest) (eth.GetCodeResponse, *eth.JSONRPCError) {
+func (p *ProxyETHGetCode) request(ctx context.Context, ethreq *eth.GetCodeRequest, block *stateBlock) (eth.GetCodeResponse, *eth.JSONRPCError) {
+	if !block.Latest {
+		// getaccountinfo only reads the current state, which doesn't tell whether the contract existed back then
+		return "", historicalStateUnavailable("kaond only has the current code of contracts")
+	}
+
 	kaonreq := kaon.GetAccountInfoRequest(utils.RemoveHexPrefix(ethreq.Address))
 
 	kaonresp, err := p.GetAccountInfo(ctx, &kaonreq)
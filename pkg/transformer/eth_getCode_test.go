@@ -13,7 +13,7 @@ import (
 
 func TestGetAccountInfoRequest(t *testing.T) {
 	//prepare request
-	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"123"`)}
+	requestParams := []json.RawMessage{[]byte(`"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960"`), []byte(`"latest"`)}
 	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
 	if err != nil {
 		t.Fatal(err)
@@ -58,7 +58,7 @@ func TestGetAccountInfoRequest(t *testing.T) {
 
 func TestGetCodeInvalidAddressRequest(t *testing.T) {
 	//prepare request
-	requestParams := []json.RawMessage{[]byte(`"0x0000000000000000000000000000000000000000"`), []byte(`"123"`)}
+	requestParams := []json.RawMessage{[]byte(`"0x0000000000000000000000000000000000000000"`), []byte(`"latest"`)}
 	requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
 	if err != nil {
 		t.Fatal(err)
@@ -91,3 +91,40 @@ func TestGetCodeInvalidAddressRequest(t *testing.T) {
 
 	internal.CheckTestResultEthRequestRPC(*requestRPC, want, got, t, false)
 }
+
+func TestGetCodeReplayRequest(t *testing.T) {
+	//prepare client
+	mockedClientDoer, err := internal.NewDoerReplayMock("testdata/eth_getCode.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kaonClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		address string
+		want    eth.GetCodeResponse
+	}{
+		{"0x1e6f89d7399081b4f8f8aa1ae2805a5efff2f960", "0x606060405236156100ad576000357c0100000000000000000"},
+		{"0x0000000000000000000000000000000000000000", "0x"},
+	}
+	for _, test := range tests {
+		//prepare request
+		requestParams := []json.RawMessage{[]byte(`"` + test.address + `"`), []byte(`"latest"`)}
+		requestRPC, err := internal.PrepareEthRPCRequest(1, requestParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//preparing proxy & executing request
+		proxyEth := ProxyETHGetCode{kaonClient}
+		got, jsonErr := proxyEth.Request(requestRPC, internal.NewEchoContext())
+		if jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+
+		internal.CheckTestResultEthRequestRPC(*requestRPC, test.want, got, t, false)
+	}
+}
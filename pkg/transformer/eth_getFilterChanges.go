@@ -10,6 +10,7 @@ import (
 	"github.com/kaonone/eth-rpc-gate/pkg/conversion"
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/logindex"
 	"github.com/kaonone/eth-rpc-gate/pkg/utils"
 )
 
@@ -23,6 +24,15 @@ func (p *ProxyETHGetFilterChanges) Method() string {
 	return "eth_getFilterChanges"
 }
 
+func (p *ProxyETHGetFilterChanges) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("filter id", quantitySchema()),
+		},
+		Result: describeResult("block hashes or logs", arrayOf(eth.OpenRPCSchema{})),
+	}
+}
+
 func (p *ProxyETHGetFilterChanges) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 
 	filter, err := processFilter(p, rawreq)
@@ -32,7 +42,7 @@ func (p *ProxyETHGetFilterChanges) Request(rawreq *eth.JSONRPCRequest, c echo.Co
 
 	switch filter.Type {
 	case eth.NewFilterTy:
-		return p.requestFilter(c.Request().Context(), filter)
+		return p.requestFilter(c.Request().Context(), filter, getLogIndex(c))
 	case eth.NewBlockFilterTy:
 		return p.requestBlockFilter(c.Request().Context(), filter)
 	case eth.NewPendingTransactionFilterTy:
@@ -59,24 +69,37 @@ func (p *ProxyETHGetFilterChanges) requestBlockFilter(ctx context.Context, filte
 
 	differ := blockCount - lastBlockNumber
 
+	blockNumbers := make([]*big.Int, differ)
+	for i := range blockNumbers {
+		blockNumbers[i] = new(big.Int).SetUint64(lastBlockNumber + uint64(i) + 1)
+	}
+	resp, blockHashesErr := p.GetBlockHashes(ctx, blockNumbers)
+	if blockHashesErr != nil {
+		return kaonresp, eth.NewCallbackError(blockHashesErr.Error())
+	}
+
 	hashes := make(eth.GetFilterChangesResponse, differ)
 	for i := range hashes {
-		blockNumber := new(big.Int).SetUint64(lastBlockNumber + uint64(i) + 1)
-
-		resp, err := p.GetBlockHash(ctx, blockNumber)
+		hash, err := getServedBlockHash(ctx, p.Kaon, string(resp[i]))
 		if err != nil {
 			return kaonresp, eth.NewCallbackError(err.Error())
 		}
-
-		hashes[i] = utils.AddHexPrefix(string(resp))
+		hashes[i] = hash
 	}
 
+	advanced, advanceErr := p.filter.Advance(filter, lastBlockNumber, blockCount)
+	if advanceErr != nil {
+		return kaonresp, eth.NewCallbackError(advanceErr.Error())
+	}
+	if !advanced {
+		// a concurrent poll of the filter returned these blocks
+		return kaonresp, nil
+	}
 	kaonresp = hashes
-	filter.Data.Store("lastBlockNumber", blockCount)
 	return
 }
 
-func (p *ProxyETHGetFilterChanges) requestFilter(ctx context.Context, filter *eth.Filter) (kaonresp eth.GetFilterChangesResponse, err *eth.JSONRPCError) {
+func (p *ProxyETHGetFilterChanges) requestFilter(ctx context.Context, filter *eth.Filter, index *logindex.Index) (kaonresp eth.GetFilterChangesResponse, err *eth.JSONRPCError) {
 	kaonresp = make(eth.GetFilterChangesResponse, 0)
 
 	_lastBlockNumber, ok := filter.Data.Load("lastBlockNumber")
@@ -102,29 +125,29 @@ func (p *ProxyETHGetFilterChanges) requestFilter(ctx context.Context, filter *et
 		return nil, err
 	}
 
-	return p.doSearchLogs(ctx, searchLogsReq)
+	return p.doSearchLogs(ctx, searchLogsReq, index)
 }
 
-func (p *ProxyETHGetFilterChanges) doSearchLogs(ctx context.Context, req *kaon.SearchLogsRequest) (eth.GetFilterChangesResponse, *eth.JSONRPCError) {
-	resp, err := conversion.SearchLogsAndFilterExtraTopics(ctx, p.Kaon, req)
+func (p *ProxyETHGetFilterChanges) doSearchLogs(ctx context.Context, req *kaon.SearchLogsRequest, index *logindex.Index) (eth.GetFilterChangesResponse, *eth.JSONRPCError) {
+	resp, err := conversion.SearchLogsAndFilterExtraTopics(ctx, getLogSearcher(p.Kaon, index, req), req)
 	if err != nil {
 		return nil, err
 	}
 
-	receiptToResult := func(receipt *kaon.TransactionReceipt) []interface{} {
-		logs := conversion.ExtractETHLogsFromTransactionReceipt(receipt, receipt.Log)
-		res := make([]interface{}, len(logs))
-		for i := range res {
-			res[i] = logs[i]
-		}
-		return res
-	}
-	results := make(eth.GetFilterChangesResponse, 0)
+	positions := newBlockLogPositionsCache(ctx, p.Kaon, index)
+	logs := make([]eth.Log, 0)
 	for _, receipt := range resp {
 		r := kaon.TransactionReceipt(receipt)
-		results = append(results, receiptToResult(&r)...)
+		logs = append(logs, conversion.ExtractETHLogsFromTransactionReceiptInBlock(&r, r.Log, positions.get(r.BlockHash))...)
+	}
+	if err := setServedLogsBlockHash(ctx, p.Kaon, logs); err != nil {
+		return nil, eth.NewCallbackError(err.Error())
 	}
 
+	results := make(eth.GetFilterChangesResponse, len(logs))
+	for i := range logs {
+		results[i] = logs[i]
+	}
 	return results, nil
 }
 
@@ -157,9 +180,12 @@ func (p *ProxyETHGetFilterChanges) toSearchLogsReq(filter *eth.Filter, from, to
 		ToBlock:   to,
 	}
 
-	topics, ok := filter.Data.Load("topics")
-	if ok {
-		kaonreq.Topics = topics.([]kaon.SearchLogsTopic)
+	if len(ethreq.Topics) > 0 {
+		topics, err := eth.TranslateTopics(ethreq.Topics)
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		kaonreq.Topics = kaon.NewSearchLogsTopics(topics)
 	}
 
 	return kaonreq, nil
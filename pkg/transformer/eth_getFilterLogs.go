@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/logindex"
 	"github.com/labstack/echo"
 )
 
@@ -17,6 +18,15 @@ func (p *ProxyETHGetFilterLogs) Method() string {
 	return "eth_getFilterLogs"
 }
 
+func (p *ProxyETHGetFilterLogs) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("filter id", quantitySchema()),
+		},
+		Result: describeResult("logs", arrayOf(objectSchema())),
+	}
+}
+
 func (p *ProxyETHGetFilterLogs) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 
 	filter, err := processFilter(p.ProxyETHGetFilterChanges, rawreq)
@@ -26,13 +36,13 @@ func (p *ProxyETHGetFilterLogs) Request(rawreq *eth.JSONRPCRequest, c echo.Conte
 
 	switch filter.Type {
 	case eth.NewFilterTy:
-		return p.request(c.Request().Context(), filter)
+		return p.request(c.Request().Context(), filter, getLogIndex(c))
 	default:
 		return nil, eth.NewInvalidParamsError("filter not found")
 	}
 }
 
-func (p *ProxyETHGetFilterLogs) request(ctx context.Context, filter *eth.Filter) (kaonresp eth.GetFilterChangesResponse, err *eth.JSONRPCError) {
+func (p *ProxyETHGetFilterLogs) request(ctx context.Context, filter *eth.Filter, index *logindex.Index) (kaonresp eth.GetFilterChangesResponse, err *eth.JSONRPCError) {
 	kaonresp = make(eth.GetFilterChangesResponse, 0)
 
 	_lastBlockNumber, ok := filter.Data.Load("lastBlockNumber")
@@ -52,6 +62,6 @@ func (p *ProxyETHGetFilterLogs) request(ctx context.Context, filter *eth.Filter)
 		return nil, err
 	}
 
-	return p.ProxyETHGetFilterChanges.doSearchLogs(ctx, searchLogsReq)
+	return p.ProxyETHGetFilterChanges.doSearchLogs(ctx, searchLogsReq, index)
 
 }
@@ -3,10 +3,15 @@ package transformer
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
 
 	"github.com/kaonone/eth-rpc-gate/pkg/conversion"
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/logindex"
 	"github.com/kaonone/eth-rpc-gate/pkg/utils"
 	"github.com/labstack/echo"
 )
@@ -20,6 +25,15 @@ func (p *ProxyETHGetLogs) Method() string {
 	return "eth_getLogs"
 }
 
+func (p *ProxyETHGetLogs) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("filter", objectSchema()),
+		},
+		Result: describeResult("logs", arrayOf(objectSchema())),
+	}
+}
+
 func (p *ProxyETHGetLogs) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.GetLogsRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
@@ -38,19 +52,75 @@ func (p *ProxyETHGetLogs) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (i
 		return nil, err
 	}
 
-	return p.request(c.Request().Context(), kaonreq)
+	return p.request(c.Request().Context(), kaonreq, getLogIndex(c))
 }
 
-func (p *ProxyETHGetLogs) request(ctx context.Context, req *kaon.SearchLogsRequest) (*eth.GetLogsResponse, *eth.JSONRPCError) {
-	receipts, err := conversion.SearchLogsAndFilterExtraTopics(ctx, p.Kaon, req)
-	if err != nil {
-		return nil, err
+func (p *ProxyETHGetLogs) request(ctx context.Context, req *kaon.SearchLogsRequest, index *logindex.Index) (*eth.GetLogsResponse, *eth.JSONRPCError) {
+	// Cancelling the context aborts any searchlogs call still in flight
+	// once we return early, e.g. when the result limit has been hit
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		maxResults = p.GetLogsMaxResults()
+		chunkSize  = p.GetLogsChunkSize()
+		positions  = newBlockLogPositionsCache(ctx, p.Kaon, index)
+		logs       = make([]eth.Log, 0)
+	)
+
+	for from := req.FromBlock; from.Cmp(req.ToBlock) <= 0; {
+		if err := ctx.Err(); err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+
+		to := req.ToBlock
+		if chunkSize > 0 {
+			chunkEnd := new(big.Int).Add(from, big.NewInt(chunkSize-1))
+			if chunkEnd.Cmp(to) < 0 {
+				to = chunkEnd
+			}
+		}
+
+		chunkReq := *req
+		chunkReq.FromBlock = from
+		chunkReq.ToBlock = to
+
+		// chunks that have already been indexed locally are served without asking kaond
+		receipts, err := conversion.SearchLogsAndFilterExtraTopics(ctx, getLogSearcher(p.Kaon, index, &chunkReq), &chunkReq)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, receipt := range receipts {
+			r := kaon.TransactionReceipt(receipt)
+			receiptLogs := conversion.ExtractETHLogsFromTransactionReceiptInBlock(&r, r.Log, positions.get(r.BlockHash))
+			if maxResults > 0 && len(logs)+len(receiptLogs) > maxResults {
+				overflowed := new(big.Int).SetUint64(receipt.BlockNumber)
+				if overflowed.Cmp(req.FromBlock) <= 0 {
+					// no smaller range helps when the first block alone doesn't fit
+					return nil, eth.NewLimitExceededError(fmt.Sprintf(
+						"query returned more than %d results. Block %s alone exceeds the limit, narrow the filter or raise --getlogs-max-results.",
+						maxResults,
+						hexutil.EncodeBig(overflowed),
+					))
+				}
+				// everything before the block that overflowed fits into the limit
+				suggestedTo := overflowed.Sub(overflowed, big.NewInt(1))
+				return nil, eth.NewLimitExceededError(fmt.Sprintf(
+					"query returned more than %d results. Try with this block range [%s, %s].",
+					maxResults,
+					hexutil.EncodeBig(req.FromBlock),
+					hexutil.EncodeBig(suggestedTo),
+				))
+			}
+			logs = append(logs, receiptLogs...)
+		}
+
+		from = new(big.Int).Add(to, big.NewInt(1))
 	}
 
-	logs := make([]eth.Log, 0)
-	for _, receipt := range receipts {
-		r := kaon.TransactionReceipt(receipt)
-		logs = append(logs, conversion.ExtractETHLogsFromTransactionReceipt(r, r.Log)...)
+	if err := setServedLogsBlockHash(ctx, p.Kaon, logs); err != nil {
+		return nil, eth.NewCallbackError(err.Error())
 	}
 
 	resp := eth.GetLogsResponse(logs)
@@ -58,16 +128,49 @@ func (p *ProxyETHGetLogs) request(ctx context.Context, req *kaon.SearchLogsReque
 }
 
 func (p *ProxyETHGetLogs) ToRequest(ctx context.Context, ethreq *eth.GetLogsRequest) (*kaon.SearchLogsRequest, *eth.JSONRPCError) {
-	//transform EthRequest fromBlock to KaonReq fromBlock:
-	from, err := getBlockNumberByRawParam(ctx, p.Kaon, ethreq.FromBlock, true)
-	if err != nil {
-		return nil, err
+	var from, to *big.Int
+	if ethreq.Blockhash != "" {
+		// EIP-234: blockHash is mutually exclusive with fromBlock/toBlock
+		if isBlockParamSet(ethreq.FromBlock) || isBlockParamSet(ethreq.ToBlock) {
+			return nil, eth.NewInvalidParamsError("cannot specify both blockHash and fromBlock/toBlock, choose one or the other")
+		}
+
+		blockNumber, err := getBlockNumberByHash(ctx, p.Kaon, utils.RemoveHexPrefix(ethreq.Blockhash))
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		from = new(big.Int).SetUint64(blockNumber)
+		to = new(big.Int).SetUint64(blockNumber)
+	} else {
+		var err *eth.JSONRPCError
+		//transform EthRequest fromBlock to KaonReq fromBlock:
+		from, err = getBlockNumberByRawParam(ctx, p.Kaon, ethreq.FromBlock, true)
+		if err != nil {
+			return nil, err
+		}
+
+		//transform EthRequest toBlock to KaonReq toBlock:
+		to, err = getBlockNumberByRawParam(ctx, p.Kaon, ethreq.ToBlock, true)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	//transform EthRequest toBlock to KaonReq toBlock:
-	to, err := getBlockNumberByRawParam(ctx, p.Kaon, ethreq.ToBlock, true)
-	if err != nil {
-		return nil, err
+	if from.Cmp(to) > 0 {
+		return nil, eth.NewInvalidParamsError("invalid block range params")
+	}
+
+	if maxBlockRange := p.GetLogsMaxBlockRange(); maxBlockRange > 0 {
+		blockRange := new(big.Int).Sub(to, from)
+		if blockRange.Cmp(big.NewInt(maxBlockRange)) >= 0 {
+			suggestedTo := new(big.Int).Add(from, big.NewInt(maxBlockRange-1))
+			return nil, eth.NewLimitExceededError(fmt.Sprintf(
+				"exceed maximum block range: %d. Try with this block range [%s, %s].",
+				maxBlockRange,
+				hexutil.EncodeBig(from),
+				hexutil.EncodeBig(suggestedTo),
+			))
+		}
 	}
 
 	//transform EthReq address to KaonReq address:
@@ -102,3 +205,8 @@ func (p *ProxyETHGetLogs) ToRequest(ctx context.Context, ethreq *eth.GetLogsRequ
 		Topics:    kaon.NewSearchLogsTopics(topics),
 	}, nil
 }
+
+// Reports whether an optional block parameter was actually provided
+func isBlockParamSet(rawParam json.RawMessage) bool {
+	return len(rawParam) != 0 && string(rawParam) != "null"
+}
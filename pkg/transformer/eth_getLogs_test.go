@@ -452,3 +452,249 @@ func TestGetLogsTranslateTopicWorksWithNil(t *testing.T) {
 
 	internal.CheckTestResultEthRequestLog(request, expectedRawRequest, string(kaonRawRequest), t, false)
 }
+
+func TestGetLogsBlockRangeTooLarge(t *testing.T) {
+	fromBlock, _ := json.Marshal("0x1")
+	toBlock, _ := json.Marshal("0x2710")
+
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetLogs{kaonClient}
+	_, jsonErr := proxyEth.ToRequest(context.Background(), &eth.GetLogsRequest{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+	})
+	if jsonErr == nil {
+		t.Fatal("expected an error for a block range above the configured maximum")
+	}
+
+	if jsonErr.Code() != eth.LimitExceededErrorCode {
+		t.Fatalf("unexpected error code: %d", jsonErr.Code())
+	}
+	want := "exceed maximum block range: 5000. Try with this block range [0x1, 0x1388]."
+	if jsonErr.Message() != want {
+		t.Fatalf("unexpected error message, got: %q want: %q", jsonErr.Message(), want)
+	}
+}
+
+func TestGetLogsBlockHashExcludesBlockRange(t *testing.T) {
+	fromBlock, _ := json.Marshal("0xfde")
+
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetLogs{kaonClient}
+	_, jsonErr := proxyEth.ToRequest(context.Background(), &eth.GetLogsRequest{
+		FromBlock: fromBlock,
+		Blockhash: internal.GetTransactionByHashBlockHexHash,
+	})
+	if jsonErr == nil {
+		t.Fatal("expected an error when both blockHash and fromBlock are set")
+	}
+	if jsonErr.Code() != eth.InvalidParamsErrorCode {
+		t.Fatalf("unexpected error code: %d", jsonErr.Code())
+	}
+}
+
+func TestGetLogsByBlockHash(t *testing.T) {
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = clientDoerMock.AddResponse(kaon.MethodGetBlock, internal.GetBlockResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetLogs{kaonClient}
+	kaonRequest, jsonErr := proxyEth.ToRequest(context.Background(), &eth.GetLogsRequest{
+		Blockhash: internal.GetTransactionByHashBlockHexHash,
+	})
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	kaonRawRequest, err := json.Marshal(kaonRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	internal.CheckTestResultDefault(`[3983,3983,null,null]`, string(kaonRawRequest), t, false)
+}
+
+func TestGetLogsSplitsRangeIntoChunks(t *testing.T) {
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kaonClient.SetFlag(kaon.FLAG_GETLOGS_CHUNK_SIZE, 1)
+
+	// the mock keeps replaying the last response, so each chunk sees one receipt
+	err = clientDoerMock.AddResponse(kaon.MethodSearchLogs, kaon.SearchLogsResponse{
+		internal.KaonTransactionReceipt([]kaon.Log{
+			{
+				Address: "db46f738bf32cdafb9a4a70eb8b44c76646bcaf0",
+				Topics:  []string{"0f6798a560793a54c3bcfe86a93cde1e73087d944c0ea20544137d4121396885"},
+				Data:    "0000000000000000000000000000000000000000000000000000000000000001",
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetLogs{kaonClient}
+	got, jsonErr := proxyEth.request(context.Background(), &kaon.SearchLogsRequest{
+		FromBlock: big.NewInt(4062),
+		ToBlock:   big.NewInt(4064),
+	}, nil)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	if len(*got) != 3 {
+		t.Fatalf("expected one log per chunk (3), got %d", len(*got))
+	}
+}
+
+func TestGetLogsTooManyResults(t *testing.T) {
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kaonClient.SetFlag(kaon.FLAG_GETLOGS_MAX_RESULTS, 1)
+
+	log := kaon.Log{
+		Address: "db46f738bf32cdafb9a4a70eb8b44c76646bcaf0",
+		Topics:  []string{"0f6798a560793a54c3bcfe86a93cde1e73087d944c0ea20544137d4121396885"},
+		Data:    "0000000000000000000000000000000000000000000000000000000000000001",
+	}
+	first := internal.KaonTransactionReceipt([]kaon.Log{log})
+	first.BlockNumber = 4062
+	second := internal.KaonTransactionReceipt([]kaon.Log{log})
+	second.BlockNumber = 4063
+
+	err = clientDoerMock.AddResponse(kaon.MethodSearchLogs, kaon.SearchLogsResponse{first, second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetLogs{kaonClient}
+	_, jsonErr := proxyEth.request(context.Background(), &kaon.SearchLogsRequest{
+		FromBlock: big.NewInt(4062),
+		ToBlock:   big.NewInt(4070),
+	}, nil)
+	if jsonErr == nil {
+		t.Fatal("expected an error when the result limit is exceeded")
+	}
+
+	if jsonErr.Code() != eth.LimitExceededErrorCode {
+		t.Fatalf("unexpected error code: %d", jsonErr.Code())
+	}
+	want := "query returned more than 1 results. Try with this block range [0xfde, 0xfde]."
+	if jsonErr.Message() != want {
+		t.Fatalf("unexpected error message, got: %q want: %q", jsonErr.Message(), want)
+	}
+}
+
+func TestGetLogsTooManyResultsInFirstBlock(t *testing.T) {
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kaonClient.SetFlag(kaon.FLAG_GETLOGS_MAX_RESULTS, 1)
+
+	log := kaon.Log{
+		Address: "db46f738bf32cdafb9a4a70eb8b44c76646bcaf0",
+		Topics:  []string{"0f6798a560793a54c3bcfe86a93cde1e73087d944c0ea20544137d4121396885"},
+		Data:    "0000000000000000000000000000000000000000000000000000000000000001",
+	}
+	first := internal.KaonTransactionReceipt([]kaon.Log{log, log})
+	first.BlockNumber = 4062
+
+	err = clientDoerMock.AddResponse(kaon.MethodSearchLogs, kaon.SearchLogsResponse{first})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetLogs{kaonClient}
+	_, jsonErr := proxyEth.request(context.Background(), &kaon.SearchLogsRequest{
+		FromBlock: big.NewInt(4062),
+		ToBlock:   big.NewInt(4070),
+	}, nil)
+	if jsonErr == nil {
+		t.Fatal("expected an error when the result limit is exceeded")
+	}
+
+	if jsonErr.Code() != eth.LimitExceededErrorCode {
+		t.Fatalf("unexpected error code: %d", jsonErr.Code())
+	}
+	// [fromBlock, fromBlock] would fail the same way, so no range is suggested
+	want := "query returned more than 1 results. Block 0xfde alone exceeds the limit, narrow the filter or raise --getlogs-max-results."
+	if jsonErr.Message() != want {
+		t.Fatalf("unexpected error message, got: %q want: %q", jsonErr.Message(), want)
+	}
+}
+
+func TestGetLogsBlockWideLogIndex(t *testing.T) {
+	clientDoerMock := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(clientDoerMock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logA := kaon.Log{
+		Address: "db46f738bf32cdafb9a4a70eb8b44c76646bcaf0",
+		Topics:  []string{"0f6798a560793a54c3bcfe86a93cde1e73087d944c0ea20544137d4121396885"},
+		Data:    "0000000000000000000000000000000000000000000000000000000000000001",
+	}
+	logB := kaon.Log{
+		Address: "6b22910b1e302cf74803ffd1691c2ecb858d3712",
+		Topics:  []string{"0f6798a560793a54c3bcfe86a93cde1e73087d944c0ea20544137d4121396885"},
+		Data:    "0000000000000000000000000000000000000000000000000000000000000002",
+	}
+	first := internal.KaonTransactionReceipt([]kaon.Log{logA, logA})
+	first.TransactionHash = "3208dc44733cbfa11654ad5651305428de473ef1e61a1ec07b0c1a5f4843be91"
+	second := internal.KaonTransactionReceipt([]kaon.Log{logB})
+	second.TransactionHash = "8fcd819194cce6a8454b2bec334d3448df4f097e9cdc36707bfd569900268950"
+
+	// the same receipts are returned for the filtered query and when looking up the whole block
+	err = clientDoerMock.AddResponse(kaon.MethodSearchLogs, kaon.SearchLogsResponse{second, first})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = clientDoerMock.AddResponse(kaon.MethodGetBlock, internal.GetBlockResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetLogs{kaonClient}
+	got, jsonErr := proxyEth.request(context.Background(), &kaon.SearchLogsRequest{
+		FromBlock: big.NewInt(3983),
+		ToBlock:   big.NewInt(3983),
+		Addresses: []string{logB.Address},
+	}, nil)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	if len(*got) != 1 {
+		t.Fatalf("expected a single log, got %d", len(*got))
+	}
+	log := (*got)[0]
+	if log.LogIndex != "0x2" || log.TransactionIndex != "0x1" {
+		t.Fatalf("expected block-wide logIndex 0x2 and transactionIndex 0x1, got %s and %s", log.LogIndex, log.TransactionIndex)
+	}
+}
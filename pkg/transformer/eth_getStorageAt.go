@@ -3,6 +3,7 @@ package transformer
 import (
 	"context"
 	"fmt"
+	"math/big"
 
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
@@ -19,6 +20,17 @@ func (p *ProxyETHGetStorageAt) Method() string {
 	return "eth_getStorageAt"
 }
 
+func (p *ProxyETHGetStorageAt) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("address", addressSchema()),
+			describeParam("storage slot", quantitySchema()),
+			describeOptionalParam("block", blockParameterSchema()),
+		},
+		Result: describeResult("value", hashSchema()),
+	}
+}
+
 func (p *ProxyETHGetStorageAt) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.GetStorageRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
@@ -26,21 +38,21 @@ func (p *ProxyETHGetStorageAt) Request(rawreq *eth.JSONRPCRequest, c echo.Contex
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	kaonAddress := utils.RemoveHexPrefix(req.Address)
-	blockNumber, err := getBlockNumberByParam(c.Request().Context(), p.Kaon, req.BlockNumber, false)
+	block, err := getStateBlockByRawParam(c.Request().Context(), p.Kaon, getBlockHash(c), req.Block)
 	if err != nil {
-		p.GetDebugLogger().Log("msg", fmt.Sprintf("Failed to get block number by param for '%s'", req.BlockNumber), "err", err)
+		p.GetDebugLogger().Log("msg", fmt.Sprintf("Failed to get block number by param for '%s'", string(req.Block)), "err", err)
 		return nil, err
 	}
 
-	return p.request(
-		c.Request().Context(),
-		&kaon.GetStorageRequest{
-			Address:     kaonAddress,
-			BlockNumber: blockNumber,
-		},
-		utils.RemoveHexPrefix(req.Index),
-	)
+	kaonreq := &kaon.GetStorageRequest{
+		Address: utils.RemoveHexPrefix(req.Address),
+	}
+	if !block.Latest {
+		// getstorage defaults to the tip
+		kaonreq.BlockNumber = big.NewInt(block.Height)
+	}
+
+	return p.request(c.Request().Context(), kaonreq, utils.RemoveHexPrefix(req.Index))
 }
 
 func (p *ProxyETHGetStorageAt) request(ctx context.Context, ethreq *kaon.GetStorageRequest, index string) (*eth.GetStorageResponse, *eth.JSONRPCError) {
@@ -26,7 +26,12 @@ func TestGetStorageAtRequestWithNoLeadingZeros(t *testing.T) {
 	getStorageResponse := kaon.GetStorageResponse{}
 	getStorageResponse[leftPadStringWithZerosTo64Bytes("12345")] = make(map[string]string)
 	getStorageResponse[leftPadStringWithZerosTo64Bytes("12345")][leftPadStringWithZerosTo64Bytes(index)] = value
-	err = mockedClientDoer.AddResponseWithRequestID(2, kaon.MethodGetStorage, getStorageResponse)
+	// the requested block is below the tip
+	err = mockedClientDoer.AddResponseWithRequestID(2, kaon.MethodGetBlockChainInfo, kaon.GetBlockChainInfoResponse{Blocks: 0x2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mockedClientDoer.AddResponseWithRequestID(3, kaon.MethodGetStorage, getStorageResponse)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -60,7 +65,12 @@ func TestGetStorageAtRequestWithLeadingZeros(t *testing.T) {
 	getStorageResponse := kaon.GetStorageResponse{}
 	getStorageResponse[leftPadStringWithZerosTo64Bytes("12345")] = make(map[string]string)
 	getStorageResponse[leftPadStringWithZerosTo64Bytes("12345")][leftPadStringWithZerosTo64Bytes(index)] = value
-	err = mockedClientDoer.AddResponseWithRequestID(2, kaon.MethodGetStorage, getStorageResponse)
+	// the requested block is below the tip
+	err = mockedClientDoer.AddResponseWithRequestID(2, kaon.MethodGetBlockChainInfo, kaon.GetBlockChainInfoResponse{Blocks: 0x2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mockedClientDoer.AddResponseWithRequestID(3, kaon.MethodGetStorage, getStorageResponse)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -95,7 +105,12 @@ func TestGetStorageAtUnknownFieldRequest(t *testing.T) {
 	getStorageResponse := kaon.GetStorageResponse{}
 	getStorageResponse[leftPadStringWithZerosTo64Bytes("12345")] = make(map[string]string)
 	getStorageResponse[leftPadStringWithZerosTo64Bytes("12345")][leftPadStringWithZerosTo64Bytes(index)] = value
-	err = mockedClientDoer.AddResponseWithRequestID(2, kaon.MethodGetStorage, getStorageResponse)
+	// the requested block is below the tip
+	err = mockedClientDoer.AddResponseWithRequestID(2, kaon.MethodGetBlockChainInfo, kaon.GetBlockChainInfoResponse{Blocks: 0x2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mockedClientDoer.AddResponseWithRequestID(3, kaon.MethodGetStorage, getStorageResponse)
 	if err != nil {
 		t.Fatal(err)
 	}
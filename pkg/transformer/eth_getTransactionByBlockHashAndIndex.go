@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/blockhash"
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
 	"github.com/labstack/echo"
@@ -19,6 +20,16 @@ func (p *ProxyETHGetTransactionByBlockHashAndIndex) Method() string {
 	return "eth_getTransactionByBlockHashAndIndex"
 }
 
+func (p *ProxyETHGetTransactionByBlockHashAndIndex) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("block hash", hashSchema()),
+			describeParam("transaction index", quantitySchema()),
+		},
+		Result: describeResult("transaction", nullable(objectSchema())),
+	}
+}
+
 func (p *ProxyETHGetTransactionByBlockHashAndIndex) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.GetTransactionByBlockHashAndIndex
 	if err := json.Unmarshal(rawreq.Params, &req); err != nil {
@@ -30,10 +41,10 @@ func (p *ProxyETHGetTransactionByBlockHashAndIndex) Request(rawreq *eth.JSONRPCR
 		return nil, eth.NewInvalidParamsError("invalid argument 0: empty hex string")
 	}
 
-	return p.request(c.Request().Context(), &req)
+	return p.request(c.Request().Context(), &req, getBlockHash(c))
 }
 
-func (p *ProxyETHGetTransactionByBlockHashAndIndex) request(ctx context.Context, req *eth.GetTransactionByBlockHashAndIndex) (interface{}, *eth.JSONRPCError) {
+func (p *ProxyETHGetTransactionByBlockHashAndIndex) request(ctx context.Context, req *eth.GetTransactionByBlockHashAndIndex, bh *blockhash.BlockHash) (interface{}, *eth.JSONRPCError) {
 	transactionIndex, err := hexutil.DecodeUint64(req.TransactionIndex)
 	if err != nil {
 		// TODO: Correct error code?
@@ -42,7 +53,7 @@ func (p *ProxyETHGetTransactionByBlockHashAndIndex) request(ctx context.Context,
 
 	// Proxy eth_getBlockByHash and return the transaction at requested index
 	getBlockByNumber := ProxyETHGetBlockByHash{p.Kaon}
-	blockByNumber, jsonErr := getBlockByNumber.request(ctx, &eth.GetBlockByHashRequest{BlockHash: req.BlockHash, FullTransaction: true})
+	blockByNumber, jsonErr := getBlockByNumber.request(ctx, &eth.GetBlockByHashRequest{BlockHash: resolveKaonBlockHash(ctx, p.Kaon, bh, req.BlockHash), FullTransaction: true})
 
 	if jsonErr != nil {
 		return nil, jsonErr
@@ -19,6 +19,16 @@ func (p *ProxyETHGetTransactionByBlockNumberAndIndex) Method() string {
 	return "eth_getTransactionByBlockNumberAndIndex"
 }
 
+func (p *ProxyETHGetTransactionByBlockNumberAndIndex) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("block", blockParameterSchema()),
+			describeParam("transaction index", quantitySchema()),
+		},
+		Result: describeResult("transaction", nullable(objectSchema())),
+	}
+}
+
 func (p *ProxyETHGetTransactionByBlockNumberAndIndex) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.GetTransactionByBlockNumberAndIndex
 	if err := json.Unmarshal(rawreq.Params, &req); err != nil {
@@ -60,5 +70,5 @@ func (p *ProxyETHGetTransactionByBlockNumberAndIndex) request(ctx context.Contex
 		}
 		proxy = &ProxyETHGetTransactionByBlockHashAndIndex{Kaon: p.Kaon}
 	)
-	return proxy.request(ctx, getBlockByHashReq)
+	return proxy.request(ctx, getBlockByHashReq, nil)
 }
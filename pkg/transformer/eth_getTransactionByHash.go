@@ -3,27 +3,27 @@ package transformer
 import (
 	"context"
 	"encoding/json"
-	"math/big"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
-	"github.com/qtumproject/janus/pkg/eth"
-	"github.com/qtumproject/janus/pkg/qtum"
-	"github.com/qtumproject/janus/pkg/utils"
+	"github.com/shopspring/decimal"
 )
 
 // ProxyETHGetTransactionByHash implements ETHProxy
 type ProxyETHGetTransactionByHash struct {
-	*qtum.Qtum
+	*kaon.Kaon
 }
 
 func (p *ProxyETHGetTransactionByHash) Method() string {
 	return "eth_getTransactionByHash"
 }
 
-func (p *ProxyETHGetTransactionByHash) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHGetTransactionByHash) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var txHash eth.GetTransactionByHashRequest
 	if err := json.Unmarshal(req.Params, &txHash); err != nil {
 		// TODO: Correct error code?
@@ -34,50 +34,72 @@ func (p *ProxyETHGetTransactionByHash) Request(req *eth.JSONRPCRequest, c echo.C
 		return nil, eth.NewInvalidParamsError("transaction hash is empty")
 	}
 
-	qtumReq := &qtum.GetTransactionRequest{
+	kaonReq := &kaon.GetTransactionRequest{
 		TxID: utils.RemoveHexPrefix(string(txHash)),
 	}
-	return p.request(c.Request().Context(), qtumReq)
+	return p.request(c.Request().Context(), kaonReq)
 }
 
-func (p *ProxyETHGetTransactionByHash) request(ctx context.Context, req *qtum.GetTransactionRequest) (*eth.GetTransactionByHashResponse, eth.JSONRPCError) {
-	ethTx, err := getTransactionByHash(ctx, p.Qtum, req.TxID)
-	if err != nil {
-		return nil, err
+func (p *ProxyETHGetTransactionByHash) request(ctx context.Context, req *kaon.GetTransactionRequest) (*eth.GetTransactionByHashResponse, *eth.JSONRPCError) {
+	// Hashes of EVM executions following the first one of a transaction are derived from its txid
+	execution, isExecution := p.ResolveExecutionHash(req.TxID)
+	txID := req.TxID
+	if isExecution {
+		txID = execution.TransactionHash
+	}
+
+	ethTx, jsonErr := getTransactionByHash(ctx, p.Kaon, txID)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if isExecution && ethTx != nil {
+		var err error
+		if ethTx, err = getExecutionTransaction(ctx, p.Kaon, ethTx, execution); err != nil {
+			p.GetDebugLogger().Log("msg", "Couldn't convert transaction execution", "hash", req.TxID, "err", err)
+			return nil, eth.NewCallbackError("couldn't convert transaction execution")
+		}
+	}
+	if ethTx != nil && ethTx.BlockHash != "" && !isZeroBlockHash(ethTx.BlockHash) && p.EthHeadersEnabled() {
+		blockHash, err := getServedBlockHash(ctx, p.Kaon, ethTx.BlockHash)
+		if err != nil {
+			p.GetDebugLogger().Log("msg", "Couldn't get served block hash", "hash", req.TxID, "err", err)
+			return nil, eth.NewCallbackError("couldn't get block hash")
+		}
+		ethTx.BlockHash = blockHash
 	}
 	return ethTx, nil
 }
 
 // TODO: think of returning flag if it's a reward transaction for miner
 //
-// FUTURE WORK: It might be possible to simplify this (and other?) translation by using a single verbose getblock qtum RPC command,
+// FUTURE WORK: It might be possible to simplify this (and other?) translation by using a single verbose getblock kaon RPC command,
 // since it returns a lot of data including the equivalent of calling GetRawTransaction on every transaction in block.
 // The last point is of particular interest because GetRawTransaction doesn't by default work for every transaction.
 // This would mean fetching a lot of probably unnecessary data, but in this setup query response delay is reasonably the biggest bottleneck anyway
-func getTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string) (*eth.GetTransactionByHashResponse, eth.JSONRPCError) {
-	qtumTx, err := p.GetTransaction(ctx, hash)
+func getTransactionByHash(ctx context.Context, p *kaon.Kaon, hash string) (*eth.GetTransactionByHashResponse, *eth.JSONRPCError) {
+	kaonTx, err := p.GetTransaction(ctx, hash)
 	var ethTx *eth.GetTransactionByHashResponse
 	if err != nil {
-		if errors.Cause(err) != qtum.ErrInvalidAddress {
+		if errors.Cause(err) != kaon.ErrInvalidAddress {
 			p.GetDebugLogger().Log("msg", "Failed to GetTransaction", "hash", hash, "err", err)
 			return nil, eth.NewCallbackError(err.Error())
 		}
-		var rawQtumTx *qtum.GetRawTransactionResponse
-		ethTx, rawQtumTx, err = getRewardTransactionByHash(ctx, p, hash)
+		var rawKaonTx *kaon.GetRawTransactionResponse
+		ethTx, rawKaonTx, err = getRewardTransactionByHash(ctx, p, hash)
 		if err != nil {
-			if errors.Cause(err) == qtum.ErrInvalidAddress {
+			if errors.Cause(err) == kaon.ErrInvalidAddress {
 				return nil, nil
 			}
 			rawTx, err := p.GetRawTransaction(ctx, hash, false)
 			if err != nil {
-				if errors.Cause(err) == qtum.ErrInvalidAddress {
+				if errors.Cause(err) == kaon.ErrInvalidAddress {
 					return nil, nil
 				}
 				p.GetDebugLogger().Log("msg", "Failed to GetRawTransaction", "hash", hash, "err", err)
 				return nil, eth.NewCallbackError(err.Error())
 			} else {
 				p.GetDebugLogger().Log("msg", "Got raw transaction by hash")
-				qtumTx = &qtum.GetTransactionResponse{
+				kaonTx = &kaon.GetTransactionResponse{
 					BlockHash:  rawTx.BlockHash,
 					BlockIndex: 1, // TODO: Possible to get this somewhere?
 					Hex:        rawTx.Hex,
@@ -85,22 +107,22 @@ func getTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string) (*eth.
 			}
 		} else {
 			p.GetDebugLogger().Log("msg", "Got reward transaction by hash")
-			qtumTx = &qtum.GetTransactionResponse{
-				Hex:       rawQtumTx.Hex,
-				BlockHash: rawQtumTx.BlockHash,
+			kaonTx = &kaon.GetTransactionResponse{
+				Hex:       rawKaonTx.Hex,
+				BlockHash: rawKaonTx.BlockHash,
 			}
 		}
 
 	}
-	qtumDecodedRawTx, err := p.DecodeRawTransaction(ctx, qtumTx.Hex)
+	kaonDecodedRawTx, err := p.DecodeRawTransaction(ctx, kaonTx.Hex)
 	if err != nil {
-		p.GetDebugLogger().Log("msg", "Failed to DecodeRawTransaction", "hex", qtumTx.Hex, "err", err)
+		p.GetDebugLogger().Log("msg", "Failed to DecodeRawTransaction", "hex", kaonTx.Hex, "err", err)
 		return nil, eth.NewCallbackError("couldn't get raw transaction")
 	}
 
 	if ethTx == nil {
 		ethTx = &eth.GetTransactionByHashResponse{
-			Hash:  utils.AddHexPrefix(qtumDecodedRawTx.ID),
+			Hash:  utils.AddHexPrefix(kaonDecodedRawTx.ID),
 			Nonce: "0x0",
 
 			// Added for go-ethereum client and graph-node support
@@ -113,16 +135,16 @@ func getTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string) (*eth.
 		}
 	}
 
-	if !qtumTx.IsPending() { // otherwise, the following values must be nulls
-		blockNumber, err := getBlockNumberByHash(ctx, p, qtumTx.BlockHash)
+	if !kaonTx.IsPending() { // otherwise, the following values must be nulls
+		blockNumber, err := getBlockNumberByHash(ctx, p, kaonTx.BlockHash)
 		if err != nil {
-			p.GetDebugLogger().Log("msg", "Failed to get block number by hash", "hash", qtumTx.BlockHash, "err", err)
+			p.GetDebugLogger().Log("msg", "Failed to get block number by hash", "hash", kaonTx.BlockHash, "err", err)
 			return nil, eth.NewCallbackError("couldn't get block number by hash")
 		}
 		ethTx.BlockNumber = hexutil.EncodeUint64(blockNumber)
-		ethTx.BlockHash = utils.AddHexPrefix(qtumTx.BlockHash)
+		ethTx.BlockHash = utils.AddHexPrefix(kaonTx.BlockHash)
 		if ethTx.TransactionIndex == "" {
-			ethTx.TransactionIndex = hexutil.EncodeUint64(uint64(qtumTx.BlockIndex))
+			ethTx.TransactionIndex = hexutil.EncodeUint64(uint64(kaonTx.BlockIndex))
 		} else {
 			// Already set in getRewardTransactionByHash
 		}
@@ -130,61 +152,61 @@ func getTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string) (*eth.
 
 	if ethTx.Value == "" {
 		// TODO: This CalcAmount() func needs improvement
-		ethAmount, err := formatQtumAmount(qtumDecodedRawTx.CalcAmount())
+		ethAmount, err := formatKaonAmount(kaonDecodedRawTx.CalcAmount())
 		if err != nil {
 			// TODO: Correct error code?
-			p.GetDebugLogger().Log("msg", "Couldn't format qtum amount", "qtum", qtumDecodedRawTx.CalcAmount().String(), "err", err)
+			p.GetDebugLogger().Log("msg", "Couldn't format kaon amount", "kaon", kaonDecodedRawTx.CalcAmount().String(), "err", err)
 			return nil, eth.NewInvalidParamsError("couldn't format amount")
 		}
 		ethTx.Value = ethAmount
 	}
 
-	qtumTxContractInfo, isContractTx, _ := qtumDecodedRawTx.ExtractContractInfo()
+	kaonTxContractInfo, isContractTx, _ := kaonDecodedRawTx.ExtractContractInfo()
 	// parsing err is discarded because it's not an error if the transaction is not a valid contract call
-	// https://testnet.qtum.info/tx/24ed3749022ed21e53d8924764bb0303a4b6fa469f26922bfa64ba44507c4c4a
+	// https://testnet.kaon.info/tx/24ed3749022ed21e53d8924764bb0303a4b6fa469f26922bfa64ba44507c4c4a
 	// if err != nil {
 	// 	p.GetDebugLogger().Log("msg", "Couldn't extract contract info", "err", err)
-	// 	return nil, eth.NewCallbackError(qtumTx.Hex /*"couldn't extract contract info"*/)
+	// 	return nil, eth.NewCallbackError(kaonTx.Hex /*"couldn't extract contract info"*/)
 	// }
 	if isContractTx {
-		// TODO: research is this allowed? ethTx.Input = utils.AddHexPrefix(qtumTxContractInfo.UserInput)
-		if qtumTxContractInfo.UserInput == "" {
+		// TODO: research is this allowed? ethTx.Input = utils.AddHexPrefix(kaonTxContractInfo.UserInput)
+		if kaonTxContractInfo.UserInput == "" {
 			ethTx.Input = "0x"
 		} else {
-			ethTx.Input = utils.AddHexPrefix(qtumTxContractInfo.UserInput)
+			ethTx.Input = utils.AddHexPrefix(kaonTxContractInfo.UserInput)
 		}
-		if qtumTxContractInfo.From != "" {
-			ethTx.From = utils.AddHexPrefix(qtumTxContractInfo.From)
+		if kaonTxContractInfo.From != "" {
+			ethTx.From = utils.AddHexPrefix(kaonTxContractInfo.From)
 		} else {
 			// It seems that ExtractContractInfo only looks for OP_SENDER address when assigning From field, so if none is present we handle it like for a non-contract TX
-			ethTx.From, err = getNonContractTxSenderAddress(ctx, p, qtumDecodedRawTx)
+			ethTx.From, err = getNonContractTxSenderAddress(ctx, p, kaonDecodedRawTx)
 			if err != nil {
-				p.GetDebugLogger().Log("msg", "Contract tx parsing found no sender address", "tx", qtumDecodedRawTx, "err", err)
+				p.GetDebugLogger().Log("msg", "Contract tx parsing found no sender address", "tx", kaonDecodedRawTx, "err", err)
 				return nil, eth.NewCallbackError("Contract tx parsing found no sender address, and the fallback function also failed: " + err.Error())
 			}
 		}
 		//TODO: research if 'To' adress could be other than zero address when 'isContractTx == TRUE'
-		if len(qtumTxContractInfo.To) == 0 {
-			ethTx.To = utils.AddHexPrefix(qtum.ZeroAddress)
+		if len(kaonTxContractInfo.To) == 0 {
+			ethTx.To = utils.AddHexPrefix(kaon.ZeroAddress)
 		} else {
-			ethTx.To = utils.AddHexPrefix(qtumTxContractInfo.To)
+			ethTx.To = utils.AddHexPrefix(kaonTxContractInfo.To)
 		}
 
 		// gasLimit
-		if len(qtumTxContractInfo.GasLimit) == 0 {
-			qtumTxContractInfo.GasLimit = "0"
+		if len(kaonTxContractInfo.GasLimit) == 0 {
+			kaonTxContractInfo.GasLimit = "0"
 		}
-		ethTx.Gas = utils.AddHexPrefix(qtumTxContractInfo.GasLimit)
+		ethTx.Gas = utils.AddHexPrefix(kaonTxContractInfo.GasLimit)
 
 		// trim leading zeros from gasPrice
-		qtumTxContractInfo.GasPrice = strings.TrimLeft(qtumTxContractInfo.GasPrice, "0")
-		if len(qtumTxContractInfo.GasPrice) == 0 {
-			qtumTxContractInfo.GasPrice = "0"
+		kaonTxContractInfo.GasPrice = strings.TrimLeft(utils.RemoveHexPrefix(kaonTxContractInfo.GasPrice), "0")
+		if len(kaonTxContractInfo.GasPrice) == 0 {
+			kaonTxContractInfo.GasPrice = "0"
 		}
 		// Gas price is in hex satoshis, convert to wei
-		gasPriceInSatoshis, err := utils.DecodeBig(qtumTxContractInfo.GasPrice)
+		gasPriceInSatoshis, err := utils.DecodeBig(kaonTxContractInfo.GasPrice)
 		if err != nil {
-			p.GetErrorLogger().Log("msg", "Failed to parse gasPrice: "+qtumTxContractInfo.GasPrice, "error", err.Error())
+			p.GetErrorLogger().Log("msg", "Failed to parse gasPrice: "+kaonTxContractInfo.GasPrice, "error", err.Error())
 			return ethTx, eth.NewCallbackError("Failed to parse gasPrice")
 		}
 
@@ -194,27 +216,27 @@ func getTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string) (*eth.
 		return ethTx, nil
 	}
 
-	if qtumTx.Generated {
-		ethTx.From = utils.AddHexPrefix(qtum.ZeroAddress)
+	if kaonTx.Generated {
+		ethTx.From = utils.AddHexPrefix(kaon.ZeroAddress)
 	} else {
 		// TODO: Figure out if following code still cause issues in some cases, see next comment
 
 		// causes issues on coinbase txs, coinbase will not have a sender and so this should be able to fail
-		ethTx.From, _ = getNonContractTxSenderAddress(ctx, p, qtumDecodedRawTx)
+		ethTx.From, _ = getNonContractTxSenderAddress(ctx, p, kaonDecodedRawTx)
 
 		// TODO: discuss
 		// ? Does func above return incorrect address for graph-node (len is < 40)
 		// ! Temporary solution
 		if ethTx.From == "" {
-			ethTx.From = utils.AddHexPrefix(qtum.ZeroAddress)
+			ethTx.From = utils.AddHexPrefix(kaon.ZeroAddress)
 		}
 	}
 	if ethTx.To == "" {
-		ethTx.To, err = findNonContractTxReceiverAddress(qtumDecodedRawTx.Vouts)
+		ethTx.To, err = findNonContractTxReceiverAddress(p, kaonDecodedRawTx.Vouts)
 		if err != nil {
 			// TODO: discuss, research
 			// ? Some vouts doesn't have `receive` category at all
-			ethTx.To = utils.AddHexPrefix(qtum.ZeroAddress)
+			ethTx.To = utils.AddHexPrefix(kaon.ZeroAddress)
 
 			// TODO: uncomment, after todo above will be resolved
 			// return nil, errors.WithMessage(err, "couldn't get non contract transaction receiver address")
@@ -224,26 +246,26 @@ func getTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string) (*eth.
 	// ? Does func above return incorrect address for graph-node (len is < 40)
 	// ! Temporary solution
 	if ethTx.To == "" {
-		ethTx.To = utils.AddHexPrefix(qtum.ZeroAddress)
+		ethTx.To = utils.AddHexPrefix(kaon.ZeroAddress)
 	}
 
 	// TODO: researching
 	// ! Temporary solution
-	//	if len(qtumTx.Hex) == 0 {
+	//	if len(kaonTx.Hex) == 0 {
 	//		ethTx.Input = "0x0"
 	//	} else {
-	//		ethTx.Input = utils.AddHexPrefix(qtumTx.Hex)
+	//		ethTx.Input = utils.AddHexPrefix(kaonTx.Hex)
 	//	}
-	ethTx.Input = utils.AddHexPrefix(qtumTx.Hex)
+	ethTx.Input = utils.AddHexPrefix(kaonTx.Hex)
 
 	return ethTx, nil
 }
 
-// TODO: Does this need to return eth.JSONRPCError
+// TODO: Does this need to return *eth.JSONRPCError
 // TODO: discuss
 // ? There are `witness` transactions, that is not acquireable nither via `gettransaction`, nor `getrawtransaction`
-func getRewardTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string) (*eth.GetTransactionByHashResponse, *qtum.GetRawTransactionResponse, error) {
-	rawQtumTx, err := p.GetRawTransaction(ctx, hash, false)
+func getRewardTransactionByHash(ctx context.Context, p *kaon.Kaon, hash string) (*eth.GetTransactionByHashResponse, *kaon.GetRawTransactionResponse, error) {
+	rawKaonTx, err := p.GetRawTransaction(ctx, hash, false)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "couldn't get raw reward transaction")
 	}
@@ -267,29 +289,29 @@ func getRewardTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string)
 		V: "0x25",
 	}
 
-	if rawQtumTx.IsPending() {
+	if rawKaonTx.IsPending() {
 		// geth returns null if the tx is pending
-		return nil, rawQtumTx, nil
+		return nil, rawKaonTx, nil
 	} else {
-		blockIndex, err := getTransactionIndexInBlock(ctx, p, hash, rawQtumTx.BlockHash)
+		blockIndex, err := getTransactionIndexInBlock(ctx, p, hash, rawKaonTx.BlockHash)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "couldn't get transaction index in block")
 		}
 		ethTx.TransactionIndex = hexutil.EncodeUint64(uint64(blockIndex))
 
-		blockNumber, err := getBlockNumberByHash(ctx, p, rawQtumTx.BlockHash)
+		blockNumber, err := getBlockNumberByHash(ctx, p, rawKaonTx.BlockHash)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "couldn't get block number by hash")
 		}
 		ethTx.BlockNumber = hexutil.EncodeUint64(blockNumber)
 
-		ethTx.BlockHash = utils.AddHexPrefix(rawQtumTx.BlockHash)
+		ethTx.BlockHash = utils.AddHexPrefix(rawKaonTx.BlockHash)
 	}
 
-	for i := range rawQtumTx.Vouts {
+	for i := range rawKaonTx.Vouts {
 		// TODO: discuss
 		// ! The response may be null, even if txout is presented
-		_, err := p.GetTransactionOut(ctx, hash, i, rawQtumTx.IsPending())
+		_, err := p.GetTransactionOut(ctx, hash, i, rawKaonTx.IsPending())
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "couldn't get transaction out")
 		}
@@ -300,86 +322,95 @@ func getRewardTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string)
 
 	// TODO: discuss
 	// ? Do we have to set `from` == `0x00..00`
-	ethTx.From = utils.AddHexPrefix(qtum.ZeroAddress)
+	ethTx.From = utils.AddHexPrefix(kaon.ZeroAddress)
 
 	// I used Base58AddressToHex at the moment
-	// because convertQtumAddress functions causes error for
+	// because convertKaonAddress functions causes error for
 	// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-	if rawQtumTx.OP_SENDER != "" {
-		// addr, err := convertQtumAddress(rawQtumTx.OP_SENDER)
-		addr, err := p.Base58AddressToHex(rawQtumTx.OP_SENDER)
+	if rawKaonTx.OP_SENDER != "" {
+		// addr, err := convertKaonAddress(rawKaonTx.OP_SENDER)
+		addr, err := p.Base58AddressToHex(rawKaonTx.OP_SENDER)
 		if err == nil {
 			ethTx.From = utils.AddHexPrefix(addr)
 		}
-	} else if len(rawQtumTx.Vins) > 0 && rawQtumTx.Vins[0].Address != "" {
-		// addr, err := convertQtumAddress(rawQtumTx.Vins[0].Address)
-		addr, err := p.Base58AddressToHex(rawQtumTx.Vins[0].Address)
+	} else if len(rawKaonTx.Vins) > 0 && rawKaonTx.Vins[0].Address != "" {
+		// addr, err := convertKaonAddress(rawKaonTx.Vins[0].Address)
+		addr, err := p.Base58AddressToHex(rawKaonTx.Vins[0].Address)
 		if err == nil {
 			ethTx.From = utils.AddHexPrefix(addr)
 		}
 	}
 	// TODO: discuss
 	// ? Where is a `to`
-	ethTx.To = utils.AddHexPrefix(qtum.ZeroAddress)
+	ethTx.To = utils.AddHexPrefix(kaon.ZeroAddress)
 
-	// when sending QTUM, the first vout will be the target
+	// when sending KAON, the first vout will be the target
 	// the second will be change from the vin, it will be returned to the same account
-	if len(rawQtumTx.Vouts) >= 2 {
+	if len(rawKaonTx.Vouts) >= 2 {
 		from := ""
-		if len(rawQtumTx.Vins) > 0 {
-			from = rawQtumTx.Vins[0].Address
+		if len(rawKaonTx.Vins) > 0 {
+			from = rawKaonTx.Vins[0].Address
 		}
 
-		var valueIn int64
-		var valueOut int64
-		var refund int64
-		var sent int64
-		var sentTo int64
-
-		for _, vin := range rawQtumTx.Vins {
-			valueIn += vin.AmountSatoshi
+		var (
+			valueIn  decimal.Decimal
+			valueOut decimal.Decimal
+			refund   decimal.Decimal
+			sent     decimal.Decimal
+			sentTo   decimal.Decimal
+		)
+
+		// amounts are already in wei
+		for _, vin := range rawKaonTx.Vins {
+			valueIn = valueIn.Add(vin.Amount.Decimal)
 		}
 
 		var to string
 
-		for _, vout := range rawQtumTx.Vouts {
-			valueOut += vout.AmountSatoshi
+		for _, vout := range rawKaonTx.Vouts {
+			valueOut = valueOut.Add(vout.Amount.Decimal)
 			addresses := vout.Details.GetAddresses()
 			addressesCount := len(addresses)
 			if addressesCount > 0 && addresses[0] == from {
-				refund += vout.AmountSatoshi
+				refund = refund.Add(vout.Amount.Decimal)
 			} else {
 				if addressesCount > 0 && addresses[0] != "" {
 					if to == "" {
 						to = addresses[0]
 					}
 					if to == addresses[0] {
-						sentTo += vout.AmountSatoshi
+						sentTo = sentTo.Add(vout.Amount.Decimal)
 					}
 				}
-				sent += vout.AmountSatoshi
+				sent = sent.Add(vout.Amount.Decimal)
 			}
 		}
-		fee := valueIn - valueOut
-		if fee < 0 {
+		fee := valueIn.Sub(valueOut)
+		if fee.IsNegative() {
 			// coinbase/coinstake txs have no fees since they are a part of making a block
-			fee = 0
+			fee = decimal.Zero
 		}
 
-		if refund == 0 && sent == 0 {
+		if refund.IsZero() && sent.IsZero() {
 			// entire tx was burnt
-		} else if refund == 0 {
+		} else if refund.IsZero() {
 			// no refund, entire vin was consumed
 			// subtract fee from sent coins
-			sent -= fee
-			sentTo -= fee
+			sent = sent.Sub(fee)
+			sentTo = sentTo.Sub(fee)
 		} else {
 			// no coins sent to anybody
 			// subtract fee from refund
-			refund -= fee
+			refund = refund.Sub(fee)
+		}
+		if sentTo.IsNegative() {
+			sentTo = decimal.Zero
+		}
+		value, err := formatKaonAmount(sentTo.Truncate(0))
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "couldn't format sent amount")
 		}
-		sentToInWei := convertFromSatoshiToWei(big.NewInt(sentTo))
-		ethTx.Value = hexutil.EncodeUint64(sentToInWei.Uint64())
+		ethTx.Value = value
 
 		if to != "" {
 			toAddress, err := p.Base58AddressToHex(to)
@@ -392,5 +423,5 @@ func getRewardTransactionByHash(ctx context.Context, p *qtum.Qtum, hash string)
 		// gas price is set in the OP_CALL/OP_CREATE script
 	}
 
-	return ethTx, rawQtumTx, nil
+	return ethTx, rawKaonTx, nil
 }
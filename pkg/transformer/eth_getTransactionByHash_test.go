@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/internal"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
 )
@@ -125,6 +126,63 @@ func TestGetTransactionByHashRequestWithOpSender(t *testing.T) {
 	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
 }
 
+func TestGetTransactionByHashRequestForSecondExecution(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a transaction calling two contracts, in outputs 0 and 1
+	vouts := []*kaon.DecodedRawTransactionOutV{
+		{
+			Value: kaon.ZeroAmount,
+			N:     0,
+			ScriptPubKey: kaon.DecodedRawTransactionScriptPubKey{
+				ASM: "1 81e872329e767a0487de7e970992b13b644f1f4f 6b483045022100b83ef90bc808569fb00e29a0f6209d32c1795207c95a554c091401ac8fa8ab920220694b7ec801efd2facea2026d12e8eb5de7689c637f539a620f24c6da8fff235f0121021104b7672c2e08fe321f1bfaffc3768c2777adeedb857b4313ed9d2f15fc8ce4 OP_SENDER 4 25548 40 8588b2c50000000000000000000000000000000000000000000000000000000000000000 57946bb437560b13275c32a468c6fd1e0c2cdd48 OP_CALL",
+			},
+		},
+		{
+			Value: kaon.ZeroAmount,
+			N:     1,
+			ScriptPubKey: kaon.DecodedRawTransactionScriptPubKey{
+				ASM: "1 81e872329e767a0487de7e970992b13b644f1f4f 6b483045022100b83ef90bc808569fb00e29a0f6209d32c1795207c95a554c091401ac8fa8ab920220694b7ec801efd2facea2026d12e8eb5de7689c637f539a620f24c6da8fff235f0121021104b7672c2e08fe321f1bfaffc3768c2777adeedb857b4313ed9d2f15fc8ce4 OP_SENDER 4 60000 40 70a08231 6b22910b1e302cf74803ffd1691c2ecb858d3712 OP_CALL",
+			},
+		},
+	}
+	internal.SetupGetBlockByHashResponsesWithVouts(t, vouts, mockedClientDoer)
+
+	contracts, err := (&kaon.DecodedRawTransactionResponse{Vouts: vouts}).ExtractContractInfos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the second execution hash has been handed out in a block before
+	executions := kaonClient.RegisterExecutions("11e97fa5877c5df349934bafc02da6218038a427e8ed081f048626fa6eb523f5", contracts)
+
+	requestParams := []json.RawMessage{[]byte(`"0x` + executions[1].Hash + `"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetTransactionByHash{kaonClient}
+	result, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	got := result.(*eth.GetTransactionByHashResponse)
+
+	if got.Hash != "0x"+executions[1].Hash {
+		t.Errorf("expected the execution hash, got %s", got.Hash)
+	}
+	if got.From != "0x81e872329e767a0487de7e970992b13b644f1f4f" || got.To != "0x6b22910b1e302cf74803ffd1691c2ecb858d3712" || got.Input != "0x70a08231" {
+		t.Errorf("expected the call of the second execution, got %+v", got)
+	}
+	if got.BlockHash != internal.GetTransactionByHashResponseData.BlockHash {
+		t.Errorf("expected the block of the transaction, got %s", got.BlockHash)
+	}
+}
+
 /*
 // TODO: Removing this unit test as the transformer computes the "Amount" value (how much KAON was transferred out) from the MethodDecodeRawTransaction response
 // and the way that the balance is calculated cannot return a precision overflow error
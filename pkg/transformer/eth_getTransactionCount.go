@@ -19,6 +19,16 @@ func (p *ProxyETHTxCount) Method() string {
 	return "eth_getTransactionCount"
 }
 
+func (p *ProxyETHTxCount) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("address", addressSchema()),
+			describeOptionalParam("block", blockParameterSchema()),
+		},
+		Result: describeResult("transaction count", quantitySchema()),
+	}
+}
+
 func (p *ProxyETHTxCount) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.GetTransactionCountRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
@@ -26,15 +36,20 @@ func (p *ProxyETHTxCount) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (i
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	kaonAddress := utils.RemoveHexPrefix(req.Address)
+	block, jsonErr := getStateBlockByRawParam(c.Request().Context(), p.Kaon, getBlockHash(c), req.Block)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
 
-	return p.request(
-		c.Request().Context(),
-		&kaon.GetTransactionCountRequest{
-			Address:     kaonAddress,
-			BlockNumber: req.Tag,
-		},
-	)
+	kaonreq := &kaon.GetTransactionCountRequest{
+		Address: utils.RemoveHexPrefix(req.Address),
+	}
+	if !block.Latest {
+		// getaddressnonce defaults to the tip
+		kaonreq.BlockNumber = block.Height
+	}
+
+	return p.request(c.Request().Context(), kaonreq)
 }
 
 func (p *ProxyETHTxCount) request(ctx context.Context, ethreq *kaon.GetTransactionCountRequest) (*eth.GetTransactionCountResponse, *eth.JSONRPCError) {
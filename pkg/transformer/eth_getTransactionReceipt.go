@@ -7,6 +7,7 @@ import (
 	"github.com/kaonone/eth-rpc-gate/pkg/conversion"
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/logindex"
 	"github.com/kaonone/eth-rpc-gate/pkg/utils"
 	"github.com/labstack/echo"
 	"github.com/pkg/errors"
@@ -24,6 +25,15 @@ func (p *ProxyETHGetTransactionReceipt) Method() string {
 	return "eth_getTransactionReceipt"
 }
 
+func (p *ProxyETHGetTransactionReceipt) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("transaction hash", hashSchema()),
+		},
+		Result: describeResult("receipt", nullable(objectSchema())),
+	}
+}
+
 func (p *ProxyETHGetTransactionReceipt) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.GetTransactionReceiptRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
@@ -38,11 +48,36 @@ func (p *ProxyETHGetTransactionReceipt) Request(rawreq *eth.JSONRPCRequest, c ec
 		txHash  = utils.RemoveHexPrefix(string(req))
 		kaonReq = kaon.GetTransactionReceiptRequest(txHash)
 	)
-	return p.request(c.Request().Context(), &kaonReq)
+	return p.request(c.Request().Context(), &kaonReq, getLogIndex(c))
+}
+
+func (p *ProxyETHGetTransactionReceipt) request(ctx context.Context, req *kaon.GetTransactionReceiptRequest, index *logindex.Index) (*eth.GetTransactionReceiptResponse, *eth.JSONRPCError) {
+	receipt, jsonErr := p.requestReceipt(ctx, req, index)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if err := setServedReceiptBlockHash(ctx, p.Kaon, receipt); err != nil {
+		p.GetDebugLogger().Log("msg", "couldn't get served block hash", "hash", string(*req), "err", err)
+		return nil, eth.NewCallbackError("couldn't get block hash")
+	}
+	return receipt, nil
 }
 
-func (p *ProxyETHGetTransactionReceipt) request(ctx context.Context, req *kaon.GetTransactionReceiptRequest) (*eth.GetTransactionReceiptResponse, *eth.JSONRPCError) {
-	kaonReceipt, err := p.Kaon.GetTransactionReceipt(ctx, string(*req))
+// Receipt reporting the Kaon hash of its block
+func (p *ProxyETHGetTransactionReceipt) requestReceipt(ctx context.Context, req *kaon.GetTransactionReceiptRequest, index *logindex.Index) (*eth.GetTransactionReceiptResponse, *eth.JSONRPCError) {
+	// Hashes of EVM executions following the first one of a transaction are derived from its txid
+	txHash := string(*req)
+	execution, isExecution := p.ResolveExecutionHash(txHash)
+	if isExecution {
+		txHash = execution.TransactionHash
+	}
+
+	indexed := true
+	kaonReceipts, err := index.GetTransactionReceipts(txHash)
+	if err != nil {
+		indexed = false
+		kaonReceipts, err = p.Kaon.GetTransactionReceipts(ctx, txHash)
+	}
 	if err != nil {
 		kaonHash, err := p.GetTransactionHashByEthHash(ctx, string(*req))
 
@@ -83,8 +118,57 @@ func (p *ProxyETHGetTransactionReceipt) request(ctx context.Context, req *kaon.G
 		}, nil
 	}
 
+	kaonTx, err := p.Kaon.GetRawTransaction(ctx, txHash, false)
+	if err != nil {
+		p.GetDebugLogger().Log("msg", "couldn't get transaction", "err", err)
+		return nil, eth.NewCallbackError("couldn't get transaction")
+	}
+	decodedRawKaonTx, err := p.Kaon.DecodeRawTransaction(ctx, kaonTx.Hex)
+	if err != nil {
+		p.GetDebugLogger().Log("msg", "couldn't decode raw transaction", "err", err)
+		return nil, eth.NewCallbackError("couldn't decode raw transaction")
+	}
+	// parsing err is discarded, the transaction is then reported as a single execution
+	contracts, _ := decodedRawKaonTx.ExtractContractInfos()
+	executions := p.RegisterExecutions(txHash, contracts)
+	if !isExecution {
+		execution = executions[0]
+	}
+
+	kaonReceipt, ok := findExecutionReceipt(kaonReceipts, execution)
+	if !ok && indexed {
+		// only executions that emitted logs are indexed
+		if receipts, err := p.Kaon.GetTransactionReceipts(ctx, txHash); err == nil {
+			kaonReceipts = receipts
+			kaonReceipt, ok = findExecutionReceipt(kaonReceipts, execution)
+		}
+	}
+	if !ok && !isExecution && len(kaonReceipts) != 0 {
+		// outputs couldn't be matched to receipts, keep reporting the first receipt for the txid
+		kaonReceipt, ok = &kaonReceipts[0], true
+	}
+	if !ok {
+		p.GetDebugLogger().Log("msg", "Transaction has no receipt for execution", "hash", execution.Hash, "outputIndex", execution.OutputIndex)
+		return nil, nil
+	}
+
+	var positions *conversion.BlockLogPositions
+	if !isZeroBlockHash(kaonReceipt.BlockHash) {
+		positions = newBlockLogPositionsCache(ctx, p.Kaon, index).get(kaonReceipt.BlockHash)
+	}
+	isCreation := decodedRawKaonTx.IsContractCreation()
+	if contract, ok := findExecutionContract(contracts, execution); ok {
+		isCreation = contract.IsCreate
+	}
+	ethReceipt := newETHReceipt(p.Kaon, kaonReceipt, execution, isCreation, positions)
+
+	return ethReceipt, nil
+}
+
+// Converts the Kaon receipt of an execution, positions may be nil for receipts that can't be placed in their block
+func newETHReceipt(p *kaon.Kaon, kaonReceipt *kaon.TransactionReceipt, execution kaon.Execution, isCreation bool, positions *conversion.BlockLogPositions) *eth.GetTransactionReceiptResponse {
 	ethReceipt := &eth.GetTransactionReceiptResponse{
-		TransactionHash:   utils.AddHexPrefix(kaonReceipt.TransactionHash),
+		TransactionHash:   utils.AddHexPrefix(execution.Hash),
 		TransactionIndex:  hexutil.EncodeUint64(kaonReceipt.TransactionIndex),
 		BlockHash:         utils.AddHexPrefix(kaonReceipt.BlockHash),
 		BlockNumber:       hexutil.EncodeUint64(kaonReceipt.BlockNumber),
@@ -95,39 +179,34 @@ func (p *ProxyETHGetTransactionReceipt) request(ctx context.Context, req *kaon.G
 		From:              utils.AddHexPrefixIfNotEmpty(kaonReceipt.From),
 		To:                utils.AddHexPrefixIfNotEmpty(kaonReceipt.To),
 
-		// TODO: researching
-		// ! Temporary accept this value to be always zero, as it is at eth logs
-		LogsBloom: eth.EmptyLogsBloom,
+		LogsBloom: conversion.LogsBloom(kaonReceipt.Log),
 	}
 
 	status := STATUS_FAILURE
 	if kaonReceipt.Excepted == "None" {
 		status = STATUS_SUCCESS
 	} else {
-		p.Kaon.GetDebugLogger().Log("transaction", ethReceipt.TransactionHash, "msg", "transaction excepted", "message", kaonReceipt.Excepted)
+		p.GetDebugLogger().Log("transaction", ethReceipt.TransactionHash, "msg", "transaction excepted", "message", kaonReceipt.Excepted)
 	}
 	ethReceipt.Status = status
 
-	r := kaon.TransactionReceipt(*kaonReceipt)
-	ethReceipt.Logs = conversion.ExtractETHLogsFromTransactionReceipt(&r, r.Log)
-
-	kaonTx, err := p.Kaon.GetRawTransaction(ctx, kaonReceipt.TransactionHash, false)
-	if err != nil {
-		p.GetDebugLogger().Log("msg", "couldn't get transaction", "err", err)
-		return nil, eth.NewCallbackError("couldn't get transaction")
+	r := *kaonReceipt
+	for i := range r.Log {
+		r.Log[i].Index = i
 	}
-	decodedRawKaonTx, err := p.Kaon.DecodeRawTransaction(ctx, kaonTx.Hex)
-	if err != nil {
-		p.GetDebugLogger().Log("msg", "couldn't decode raw transaction", "err", err)
-		return nil, eth.NewCallbackError("couldn't decode raw transaction")
+	ethReceipt.TransactionIndex = hexutil.EncodeUint64(positions.TransactionIndex(&r))
+	ethReceipt.Logs = conversion.ExtractETHLogsFromTransactionReceiptInBlock(&r, r.Log, positions)
+	for i := range ethReceipt.Logs {
+		ethReceipt.Logs[i].TransactionHash = ethReceipt.TransactionHash
 	}
-	if decodedRawKaonTx.IsContractCreation() {
+
+	if isCreation {
 		ethReceipt.To = ""
 	} else {
 		ethReceipt.ContractAddress = ""
 	}
 
-	if ethReceipt.BlockHash == "0x0000000000000000000000000000000000000000000000000000000000000000" { // nullify pending txs
+	if isZeroBlockHash(ethReceipt.BlockHash) { // nullify pending txs
 		ethReceipt.ContractAddress = ""
 		ethReceipt.BlockNumber = ""
 		ethReceipt.BlockHash = ""
@@ -143,5 +222,19 @@ func (p *ProxyETHGetTransactionReceipt) request(ctx context.Context, req *kaon.G
 	// 	ethTxReceipt.ContractAddress = ""
 	// }
 
-	return ethReceipt, nil
+	return ethReceipt
+}
+
+func isZeroBlockHash(blockHash string) bool {
+	return utils.RemoveHexPrefix(blockHash) == "0000000000000000000000000000000000000000000000000000000000000000"
+}
+
+// Receipt of the EVM execution in the execution's output
+func findExecutionReceipt(receipts []kaon.TransactionReceipt, execution kaon.Execution) (*kaon.TransactionReceipt, bool) {
+	for i := range receipts {
+		if receipts[i].OutputIndex == execution.OutputIndex {
+			return &receipts[i], true
+		}
+	}
+	return nil, false
 }
@@ -67,3 +67,81 @@ func TestGetTransactionReceiptForNonVMTransaction(t *testing.T) {
 
 	internal.CheckTestResultEthRequestRPC(*request, &want, got, t, false)
 }
+
+func TestGetTransactionReceiptForSecondExecution(t *testing.T) {
+	txID := "3208dc44733cbfa11654ad5651305428de473ef1e61a1ec07b0c1a5f4843be91"
+
+	mockedClientDoer := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a transaction calling two contracts, in outputs 1 and 3
+	vouts := []*kaon.DecodedRawTransactionOutV{
+		{
+			N: 1,
+			ScriptPubKey: kaon.DecodedRawTransactionScriptPubKey{
+				ASM: "4 55000 40 a9059cbb af1ae4e29253ba755c723bca25e883b8deb777b8 OP_CALL",
+			},
+		},
+		{
+			N: 3,
+			ScriptPubKey: kaon.DecodedRawTransactionScriptPubKey{
+				ASM: "4 60000 40 70a08231 6b22910b1e302cf74803ffd1691c2ecb858d3712 OP_CALL",
+			},
+		},
+	}
+	decodedRawTransaction := &kaon.DecodedRawTransactionResponse{ID: txID, Vouts: vouts}
+	contracts, err := decodedRawTransaction.ExtractContractInfos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the second execution hash has been handed out in a block before
+	executions := kaonClient.RegisterExecutions(txID, contracts)
+
+	firstReceipt := internal.KaonTransactionReceipt([]kaon.Log{})
+	firstReceipt.TransactionHash = txID
+	firstReceipt.OutputIndex = 1
+	secondReceipt := internal.KaonTransactionReceipt([]kaon.Log{{
+		Address: "6b22910b1e302cf74803ffd1691c2ecb858d3712",
+		Topics:  []string{"ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+		Data:    "0000000000000000000000000000000000000000000000000000000000000001",
+	}})
+	secondReceipt.TransactionHash = txID
+	secondReceipt.OutputIndex = 3
+	secondReceipt.Excepted = "None"
+
+	if err = mockedClientDoer.AddResponse(kaon.MethodGetTransactionReceipt, []kaon.TransactionReceipt{firstReceipt, secondReceipt}); err != nil {
+		t.Fatal(err)
+	}
+	if err = mockedClientDoer.AddResponse(kaon.MethodGetRawTransaction, &kaon.GetRawTransactionResponse{Hex: "00"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = mockedClientDoer.AddResponse(kaon.MethodDecodeRawTransaction, decodedRawTransaction); err != nil {
+		t.Fatal(err)
+	}
+
+	requestParams := []json.RawMessage{[]byte(`"0x` + executions[1].Hash + `"`)}
+	request, err := internal.PrepareEthRPCRequest(1, requestParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyEth := ProxyETHGetTransactionReceipt{kaonClient}
+	result, jsonErr := proxyEth.Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	got := result.(*eth.GetTransactionReceiptResponse)
+
+	if got.TransactionHash != utils.AddHexPrefix(executions[1].Hash) {
+		t.Errorf("expected the execution hash, got %s", got.TransactionHash)
+	}
+	if got.Status != STATUS_SUCCESS {
+		t.Errorf("expected the status of the second execution, got %s", got.Status)
+	}
+	if len(got.Logs) != 1 || got.Logs[0].TransactionHash != got.TransactionHash {
+		t.Errorf("expected the logs of the second execution, got %+v", got.Logs)
+	}
+}
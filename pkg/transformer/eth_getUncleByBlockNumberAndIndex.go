@@ -0,0 +1,18 @@
+package transformer
+
+import (
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/labstack/echo"
+)
+
+type ETHGetUncleByBlockNumberAndIndex struct {
+}
+
+func (p *ETHGetUncleByBlockNumberAndIndex) Method() string {
+	return "eth_getUncleByBlockNumberAndIndex"
+}
+
+func (p *ETHGetUncleByBlockNumberAndIndex) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	// hardcoded to nil
+	return nil, nil
+}
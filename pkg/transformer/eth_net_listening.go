@@ -15,6 +15,12 @@ func (p *ProxyNetListening) Method() string {
 	return "net_listening"
 }
 
+func (p *ProxyNetListening) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("listening", booleanSchema()),
+	}
+}
+
 func (p *ProxyNetListening) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	networkInfo, err := p.GetNetworkInfo(c.Request().Context())
 	if err != nil {
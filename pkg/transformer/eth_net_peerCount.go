@@ -18,6 +18,12 @@ func (p *ProxyNetPeerCount) Method() string {
 	return "net_peerCount"
 }
 
+func (p *ProxyNetPeerCount) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("peer count", quantitySchema()),
+	}
+}
+
 func (p *ProxyNetPeerCount) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	return p.request(c.Request().Context())
 }
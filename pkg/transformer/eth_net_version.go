@@ -16,6 +16,12 @@ func (p *ProxyETHNetVersion) Method() string {
 	return "net_version"
 }
 
+func (p *ProxyETHNetVersion) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("network id", stringSchema()),
+	}
+}
+
 func (p *ProxyETHNetVersion) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	return p.request()
 }
@@ -19,6 +19,12 @@ func (p *ProxyETHNewBlockFilter) Method() string {
 	return "eth_newBlockFilter"
 }
 
+func (p *ProxyETHNewBlockFilter) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("filter id", quantitySchema()),
+	}
+}
+
 func (p *ProxyETHNewBlockFilter) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	return p.request(c.Request().Context())
 }
@@ -31,6 +37,9 @@ func (p *ProxyETHNewBlockFilter) request(ctx context.Context) (eth.NewBlockFilte
 
 	filter := p.filter.New(eth.NewBlockFilterTy)
 	filter.Data.Store("lastBlockNumber", blockCount.Uint64())
+	if err := p.filter.Save(filter); err != nil {
+		return "", eth.NewCallbackError(err.Error())
+	}
 
 	p.GenerateIfPossible()
 
@@ -20,6 +20,15 @@ func (p *ProxyETHNewFilter) Method() string {
 	return "eth_newFilter"
 }
 
+func (p *ProxyETHNewFilter) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("filter", objectSchema()),
+		},
+		Result: describeResult("filter id", quantitySchema()),
+	}
+}
+
 func (p *ProxyETHNewFilter) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.NewFilterRequest
 	if err := json.Unmarshal(rawreq.Params, &req); err != nil {
@@ -48,11 +57,13 @@ func (p *ProxyETHNewFilter) request(ctx context.Context, ethreq *eth.NewFilterRe
 	filter.Data.Store("toBlock", to.Uint64())
 
 	if len(ethreq.Topics) > 0 {
-		topics, err := eth.TranslateTopics(ethreq.Topics)
-		if err != nil {
+		// the topics are translated again from the request when the filter is polled
+		if _, err := eth.TranslateTopics(ethreq.Topics); err != nil {
 			return nil, eth.NewCallbackError(err.Error())
 		}
-		filter.Data.Store("topics", kaon.NewSearchLogsTopics(topics))
+	}
+	if err := p.filter.Save(filter); err != nil {
+		return nil, eth.NewCallbackError(err.Error())
 	}
 	resp := eth.NewFilterResponse(hexutil.EncodeUint64(filter.ID))
 	return &resp, nil
@@ -0,0 +1,42 @@
+package transformer
+
+import (
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+)
+
+// ProxyETHPersonalECRecover implements ETHProxy, recovering signatures of the Ethereum sign scheme
+type ProxyETHPersonalECRecover struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyETHPersonalECRecover) Method() string {
+	return "personal_ecRecover"
+}
+
+func (p *ProxyETHPersonalECRecover) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("message", dataSchema()),
+			describeParam("signature", dataSchema()),
+		},
+		Result: describeResult("signer", addressSchema()),
+	}
+}
+
+func (p *ProxyETHPersonalECRecover) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.ECRecoverRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	signer, err := recoverHashSigner(p.Accounts, accounts.TextHash(req.Message), req.Signature)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "Failed to recover signer", "error", err)
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return eth.ECRecoverResponse(utils.AddHexPrefix(signer)), nil
+}
@@ -0,0 +1,37 @@
+package transformer
+
+import (
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyETHPersonalSign implements ETHProxy
+type ProxyETHPersonalSign struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyETHPersonalSign) Method() string {
+	return "personal_sign"
+}
+
+func (p *ProxyETHPersonalSign) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("message", dataSchema()),
+			describeParam("address", addressSchema()),
+		},
+		Result: describeResult("signature", dataSchema()),
+	}
+}
+
+func (p *ProxyETHPersonalSign) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.PersonalSignRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "error", err)
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	signReq := eth.SignRequest(req)
+	return signAccountMessage(p.Kaon, p.Method(), &signReq)
+}
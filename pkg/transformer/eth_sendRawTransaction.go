@@ -3,15 +3,15 @@ package transformer
 import (
 	"context"
 
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
 	"github.com/labstack/echo"
-	"github.com/qtumproject/janus/pkg/eth"
-	"github.com/qtumproject/janus/pkg/qtum"
-	"github.com/qtumproject/janus/pkg/utils"
 )
 
 // ProxyETHSendRawTransaction implements ETHProxy
 type ProxyETHSendRawTransaction struct {
-	*qtum.Qtum
+	*kaon.Kaon
 }
 
 var _ ETHProxy = (*ProxyETHSendRawTransaction)(nil)
@@ -20,7 +20,16 @@ func (p *ProxyETHSendRawTransaction) Method() string {
 	return "eth_sendRawTransaction"
 }
 
-func (p *ProxyETHSendRawTransaction) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, eth.JSONRPCError) {
+func (p *ProxyETHSendRawTransaction) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("transaction", dataSchema()),
+		},
+		Result: describeResult("transaction hash", hashSchema()),
+	}
+}
+
+func (p *ProxyETHSendRawTransaction) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var params eth.SendRawTransactionRequest
 	if err := unmarshalRequest(req.Params, &params); err != nil {
 		// TODO: Correct error code?
@@ -34,23 +43,23 @@ func (p *ProxyETHSendRawTransaction) Request(req *eth.JSONRPCRequest, c echo.Con
 	return p.request(c.Request().Context(), params)
 }
 
-func (p *ProxyETHSendRawTransaction) request(ctx context.Context, params eth.SendRawTransactionRequest) (eth.SendRawTransactionResponse, eth.JSONRPCError) {
+func (p *ProxyETHSendRawTransaction) request(ctx context.Context, params eth.SendRawTransactionRequest) (eth.SendRawTransactionResponse, *eth.JSONRPCError) {
 	var (
-		qtumHexedRawTx = utils.RemoveHexPrefix(params[0])
-		req            = qtum.SendRawTransactionRequest([1]string{qtumHexedRawTx})
+		kaonHexedRawTx = utils.RemoveHexPrefix(params[0])
+		req            = kaon.SendRawTransactionRequest([1]string{kaonHexedRawTx})
 	)
 
-	qtumresp, err := p.Qtum.SendRawTransaction(ctx, &req)
+	kaonresp, err := p.Kaon.SendRawTransaction(ctx, &req)
 	if err != nil {
-		if err == qtum.ErrVerifyAlreadyInChain {
+		if err == kaon.ErrVerifyAlreadyInChain {
 			// already committed
 			// we need to send back the tx hash
-			rawTx, err := p.Qtum.DecodeRawTransaction(ctx, qtumHexedRawTx)
+			rawTx, err := p.Kaon.DecodeRawTransaction(ctx, kaonHexedRawTx)
 			if err != nil {
 				p.GetErrorLogger().Log("msg", "Error decoding raw transaction for duplicate raw transaction", "err", err)
 				return eth.SendRawTransactionResponse(""), eth.NewCallbackError(err.Error())
 			}
-			qtumresp = &qtum.SendRawTransactionResponse{Result: rawTx.Hash}
+			kaonresp = &kaon.GeneralSendRawTransactionResponse{Result: rawTx.Hash}
 		} else {
 			return eth.SendRawTransactionResponse(""), eth.NewCallbackError(err.Error())
 		}
@@ -58,7 +67,10 @@ func (p *ProxyETHSendRawTransaction) request(ctx context.Context, params eth.Sen
 		p.GenerateIfPossible()
 	}
 
-	resp := *qtumresp
+	resp := *kaonresp
+	if resp.Result != "" {
+		p.TrackTransaction(resp.Result, kaonHexedRawTx)
+	}
 	ethHexedTxHash := utils.AddHexPrefix(resp.Result)
 	return eth.SendRawTransactionResponse(ethHexedTxHash), nil
 }
@@ -0,0 +1,100 @@
+package transformer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/logindex"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+)
+
+const (
+	DefaultSendRawTransactionSyncTimeout = time.Minute
+	// longer timeouts are shortened, a client waiting longer should poll the receipt itself
+	MaxSendRawTransactionSyncTimeout = 10 * time.Minute
+
+	sendRawTransactionSyncPollInterval = 500 * time.Millisecond
+)
+
+// ProxyETHSendRawTransactionSync implements ETHProxy, sending a raw transaction and waiting for its receipt
+type ProxyETHSendRawTransactionSync struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyETHSendRawTransactionSync) Method() string {
+	return "eth_sendRawTransactionSync"
+}
+
+func (p *ProxyETHSendRawTransactionSync) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Summary: "Sends a raw transaction and waits for its receipt",
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("transaction", dataSchema()),
+			describeOptionalParam("timeout in milliseconds", quantitySchema()),
+		},
+		Result: describeResult("receipt", objectSchema()),
+	}
+}
+
+func (p *ProxyETHSendRawTransactionSync) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.SendRawTransactionSyncRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	if req.RawTx == "" {
+		return nil, eth.NewInvalidParamsError("invalid parameter: raw transaction hexed string is empty")
+	}
+
+	timeout := DefaultSendRawTransactionSyncTimeout
+	if req.Timeout != nil {
+		timeout = time.Duration(*req.Timeout) * time.Millisecond
+	}
+	if timeout > MaxSendRawTransactionSyncTimeout {
+		timeout = MaxSendRawTransactionSyncTimeout
+	}
+
+	hash, jsonErr := (&ProxyETHSendRawTransaction{p.Kaon}).request(c.Request().Context(), eth.SendRawTransactionRequest{req.RawTx})
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+	defer cancel()
+	return p.waitForReceipt(ctx, string(hash), timeout, getLogIndex(c))
+}
+
+// Polls the receipt of a sent transaction until it's mined, dropped by the tracker or the timeout expires
+func (p *ProxyETHSendRawTransactionSync) waitForReceipt(ctx context.Context, hash string, timeout time.Duration, index *logindex.Index) (*eth.GetTransactionReceiptResponse, *eth.JSONRPCError) {
+	receipts := &ProxyETHGetTransactionReceipt{p.Kaon}
+	req := kaon.GetTransactionReceiptRequest(utils.RemoveHexPrefix(hash))
+
+	// cached lookups of the pending transaction would outlive its mining
+	uncached := kaon.WithoutCache(ctx)
+	ticker := time.NewTicker(sendRawTransactionSyncPollInterval)
+	defer ticker.Stop()
+	for {
+		receipt, jsonErr := receipts.request(uncached, &req, index)
+		if jsonErr != nil {
+			p.GetDebugLogger().Log("method", p.Method(), "msg", "Failed to get receipt", "hash", hash, "error", jsonErr.Message())
+		} else if receipt != nil {
+			return receipt, nil
+		}
+		if tx, tracked := p.TrackedTransaction(hash); tracked && tx.Status == kaon.TransactionDropped {
+			return nil, eth.NewCallbackError(fmt.Sprintf("transaction %s was evicted from the mempool and dropped", hash))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, eth.NewJSONRPCError(
+				eth.TransactionTimeoutErrorCode,
+				fmt.Sprintf("transaction %s was added to the mempool but wasn't mined within %s", hash, timeout),
+				nil,
+			)
+		}
+	}
+}
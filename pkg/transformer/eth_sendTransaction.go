@@ -19,6 +19,15 @@ func (p *ProxyETHSendTransaction) Method() string {
 	return "eth_sendTransaction"
 }
 
+func (p *ProxyETHSendTransaction) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("transaction", objectSchema()),
+		},
+		Result: describeResult("transaction hash", hashSchema()),
+	}
+}
+
 func (p *ProxyETHSendTransaction) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.SendTransactionRequest
 	err := unmarshalRequest(rawreq.Params, &req)
@@ -31,7 +40,7 @@ func (p *ProxyETHSendTransaction) Request(rawreq *eth.JSONRPCRequest, c echo.Con
 		p.GetLogger().Log("msg", "Gas limit is too low", "gasLimit", req.Gas.String())
 	}
 
-	var result interface{}
+	var result *eth.SendTransactionResponse
 	var jsonErr *eth.JSONRPCError
 
 	if req.IsCreateContract() {
@@ -44,11 +53,14 @@ func (p *ProxyETHSendTransaction) Request(rawreq *eth.JSONRPCRequest, c echo.Con
 		return nil, eth.NewInvalidParamsError("Unknown operation")
 	}
 
-	if err == nil {
-		p.GenerateIfPossible()
+	if jsonErr != nil {
+		return nil, jsonErr
 	}
+	p.GenerateIfPossible()
+	// kaond's wallet built the transaction, the tracker gets it from kaond to rebroadcast it
+	p.TrackTransaction(string(*result), "")
 
-	return result, jsonErr
+	return result, nil
 }
 
 func (p *ProxyETHSendTransaction) requestSendToContract(ethtx *eth.SendTransactionRequest) (*eth.SendTransactionResponse, *eth.JSONRPCError) {
@@ -5,13 +5,18 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
 	"github.com/kaonone/eth-rpc-gate/pkg/utils"
 	"github.com/labstack/echo"
+	"github.com/pkg/errors"
 )
 
 // ProxyETHGetLogs implements ETHProxy
@@ -23,6 +28,16 @@ func (p *ProxyETHSign) Method() string {
 	return "eth_sign"
 }
 
+func (p *ProxyETHSign) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("address", addressSchema()),
+			describeParam("message", dataSchema()),
+		},
+		Result: describeResult("signature", dataSchema()),
+	}
+}
+
 func (p *ProxyETHSign) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.SignRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
@@ -31,31 +46,96 @@ func (p *ProxyETHSign) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (inte
 		return nil, eth.NewInvalidParamsError(err.Error())
 	}
 
-	addr := utils.RemoveHexPrefix(req.Account)
+	return signAccountMessage(p.Kaon, p.Method(), &req)
+}
 
-	acc := p.Kaon.Accounts.FindByHexAddress(addr)
-	if acc == nil {
-		p.GetDebugLogger().Log("method", p.Method(), "account", addr, "msg", "Unknown account")
-		return nil, eth.NewInvalidParamsError(fmt.Sprintf("No such account: %s", addr))
+// Signs a message with an account of the gateway, using its sign scheme
+func signAccountMessage(p *kaon.Kaon, method string, req *eth.SignRequest) (eth.SignResponse, *eth.JSONRPCError) {
+	acc, jsonErr := findAccount(p, method, req.Account)
+	if jsonErr != nil {
+		return "", jsonErr
 	}
 
-	sig, err := signMessage(acc.PrivKey, req.Message)
+	var sig []byte
+	var err error
+	if p.SignScheme() == kaon.SignSchemeKaon {
+		sig, err = signMessage(acc.PrivKey, acc.CompressPubKey, req.Message)
+	} else {
+		sig, err = signHash(acc.PrivKey, accounts.TextHash(req.Message))
+	}
 	if err != nil {
-		p.GetDebugLogger().Log("method", p.Method(), "msg", "Failed to sign message", "error", err)
-		return nil, eth.NewCallbackError(err.Error())
+		p.GetDebugLogger().Log("method", method, "msg", "Failed to sign message", "error", err)
+		return "", eth.NewCallbackError(err.Error())
 	}
 
-	p.GetDebugLogger().Log("method", p.Method(), "msg", "Successfully signed message")
+	p.GetDebugLogger().Log("method", method, "msg", "Successfully signed message", "scheme", p.SignScheme())
 
 	return eth.SignResponse("0x" + hex.EncodeToString(sig)), nil
 }
 
-func signMessage(key *btcec.PrivateKey, msg []byte) ([]byte, error) {
+func findAccount(p *kaon.Kaon, method string, account string) (*btcutil.WIF, *eth.JSONRPCError) {
+	addr := strings.ToLower(utils.RemoveHexPrefix(account))
+
+	acc := p.Accounts.FindByHexAddress(addr)
+	if acc == nil {
+		p.GetDebugLogger().Log("method", method, "account", addr, "msg", "Unknown account")
+		return nil, eth.NewInvalidParamsError(fmt.Sprintf("No such account: %s", addr))
+	}
+	return acc, nil
+}
+
+// compressed tells which address verifymessage recovers from the signature
+func signMessage(key *btcec.PrivateKey, compressed bool, msg []byte) ([]byte, error) {
 	msghash := chainhash.DoubleHashB(paddedMessage(msg))
 
 	secp256k1 := btcec.S256()
 
-	return btcec.SignCompact(secp256k1, key, msghash, true)
+	return btcec.SignCompact(secp256k1, key, msghash, compressed)
+}
+
+// Ethereum signature r || s || v of a hash, v is 27 or 28
+func signHash(key *btcec.PrivateKey, hash []byte) ([]byte, error) {
+	sig, err := crypto.Sign(hash, key.ToECDSA())
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+// Hex address of the key that signed hash with an Ethereum signature. Kaon addresses are the Hash160 of the
+// public key, not the Keccak256 Ethereum recovers, and Ethereum signatures don't tell whether the key is
+// compressed: the compressed one kaond's wallets use is returned unless accounts hold the uncompressed one.
+func recoverHashSigner(accounts kaon.Accounts, hash []byte, sig []byte) (string, error) {
+	if len(sig) != crypto.SignatureLength {
+		return "", errors.Errorf("signature must be %d bytes long", crypto.SignatureLength)
+	}
+	sig = append([]byte(nil), sig...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return "", err
+	}
+	uncompressed := hex.EncodeToString(btcutil.Hash160(crypto.FromECDSAPub(pub)))
+	if accounts.FindByHexAddress(uncompressed) != nil {
+		return uncompressed, nil
+	}
+	return hex.EncodeToString(btcutil.Hash160(crypto.CompressPubkey(pub))), nil
+}
+
+// Hex address of the key that signed msg with the Kaon scheme
+func recoverMessageSigner(msg []byte, sig []byte) (string, error) {
+	pub, compressed, err := btcec.RecoverCompact(btcec.S256(), sig, chainhash.DoubleHashB(paddedMessage(msg)))
+	if err != nil {
+		return "", err
+	}
+	serialized := pub.SerializeUncompressed()
+	if compressed {
+		serialized = pub.SerializeCompressed()
+	}
+	return hex.EncodeToString(btcutil.Hash160(serialized)), nil
 }
 
 var kaonSignMessagePrefix = []byte("\u0015Kaon Signed Message:\n")
@@ -0,0 +1,295 @@
+package transformer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+)
+
+// ProxyETHSignTypedData implements ETHProxy for eth_signTypedData_v3 and eth_signTypedData_v4, which both take
+// EIP-712 typed data. The legacy eth_signTypedData is served by ProxyETHSignTypedDataLegacy.
+type ProxyETHSignTypedData struct {
+	*kaon.Kaon
+	method string
+}
+
+func (p *ProxyETHSignTypedData) Method() string {
+	return p.method
+}
+
+func (p *ProxyETHSignTypedData) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("address", addressSchema()),
+			describeParam("typed data", objectSchema()),
+		},
+		Result: describeResult("signature", dataSchema()),
+	}
+}
+
+func (p *ProxyETHSignTypedData) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.SignTypedDataRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "error", err)
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	if chainId := req.TypedData.Domain.ChainId; chainId != nil && (*big.Int)(chainId).Cmp(big.NewInt(int64(p.ChainId()))) != 0 {
+		return nil, eth.NewInvalidParamsError(fmt.Sprintf("typed data is for chain %s, not %d", (*big.Int)(chainId), p.ChainId()))
+	}
+
+	acc, jsonErr := findAccount(p.Kaon, p.Method(), req.Account)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	hash, err := typedDataHash(&req.TypedData)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	// EIP-712 signatures are Ethereum signatures whatever the sign scheme of messages
+	sig, err := signHash(acc.PrivKey, hash)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "Failed to sign typed data", "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	return eth.SignResponse("0x" + hex.EncodeToString(sig)), nil
+}
+
+// keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message)) as defined by EIP-712
+func typedDataHash(typedData *apitypes.TypedData) ([]byte, error) {
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, err
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash), nil
+}
+
+// ProxyETHSignTypedDataLegacy implements ETHProxy for eth_signTypedData and its eth_signTypedData_v1 alias,
+// which take the array of typed values MetaMask signed before EIP-712
+type ProxyETHSignTypedDataLegacy struct {
+	*kaon.Kaon
+	method string
+}
+
+func (p *ProxyETHSignTypedDataLegacy) Method() string {
+	return p.method
+}
+
+func (p *ProxyETHSignTypedDataLegacy) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("typed data", arrayOf(objectSchema())),
+			describeParam("address", addressSchema()),
+		},
+		Result: describeResult("signature", dataSchema()),
+	}
+}
+
+func (p *ProxyETHSignTypedDataLegacy) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.SignTypedDataLegacyRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "error", err)
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	acc, jsonErr := findAccount(p.Kaon, p.Method(), req.Account)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	hash, err := legacyTypedDataHash(req.TypedData)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	sig, err := signHash(acc.PrivKey, hash)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "Failed to sign typed data", "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	return eth.SignResponse("0x" + hex.EncodeToString(sig)), nil
+}
+
+// keccak256(soliditySHA3(schema) ‖ soliditySHA3(values)) like typedSignatureHash of MetaMask's eth-sig-util,
+// where the schema is the "<type> <name>" strings and soliditySHA3 hashes the tightly packed values
+func legacyTypedDataHash(typedData []eth.TypedValue) ([]byte, error) {
+	var schema, values []byte
+	for _, typedValue := range typedData {
+		schema = append(schema, typedValue.Type+" "+typedValue.Name...)
+		packed, err := packLegacyTypedValue(elementaryTypeName(typedValue.Type), typedValue.Value, 0)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid value of %s", typedValue.Name)
+		}
+		values = append(values, packed...)
+	}
+	return crypto.Keccak256(crypto.Keccak256(schema), crypto.Keccak256(values)), nil
+}
+
+var legacyTypedArray = regexp.MustCompile(`\[[0-9]*\]`)
+
+// int, uint, int[] and uint[] are hashed as their 256 bit types
+func elementaryTypeName(typ string) string {
+	for _, name := range []string{"int", "uint"} {
+		if typ == name || strings.HasPrefix(typ, name+"[") {
+			return name + "256" + strings.TrimPrefix(typ, name)
+		}
+	}
+	return typ
+}
+
+// Tight packing of Solidity's abi.encodePacked, except that elements of arrays are padded to width bytes
+// like ethereumjs-abi does. A zero width packs a value in the size of its type.
+func packLegacyTypedValue(typ string, value json.RawMessage, width int) ([]byte, error) {
+	if location := legacyTypedArray.FindStringIndex(typ); location != nil {
+		if !strings.HasSuffix(typ, "]") {
+			return nil, errors.Errorf("unsupported type %s", typ)
+		}
+		var elements []json.RawMessage
+		if err := json.Unmarshal(value, &elements); err != nil {
+			return nil, errors.Errorf("%s should be an array", typ)
+		}
+		if length := typ[location[0]+1 : location[1]-1]; length != "" && strings.Count(typ, "[") == 1 {
+			if size, _ := strconv.Atoi(length); len(elements) > size {
+				return nil, errors.Errorf("%s has %d elements", typ, len(elements))
+			}
+		}
+		elementType := typ[:location[0]] + typ[location[1]:]
+		var packed []byte
+		for _, element := range elements {
+			p, err := packLegacyTypedValue(elementType, element, 32)
+			if err != nil {
+				return nil, err
+			}
+			packed = append(packed, p...)
+		}
+		return packed, nil
+	}
+
+	switch {
+	case typ == "string":
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return nil, errors.New("string should be a JSON string")
+		}
+		return []byte(s), nil
+	case typ == "bytes":
+		return legacyTypedBytes(value)
+	case typ == "bool":
+		var b bool
+		if err := json.Unmarshal(value, &b); err != nil {
+			return nil, errors.New("bool should be a JSON boolean")
+		}
+		packed := make([]byte, packedSize(width, 1))
+		if b {
+			packed[len(packed)-1] = 1
+		}
+		return packed, nil
+	case typ == "address":
+		address, err := legacyTypedBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(address) > 20 {
+			return nil, errors.New("address is longer than 20 bytes")
+		}
+		return common.LeftPadBytes(address, packedSize(width, 20)), nil
+	case strings.HasPrefix(typ, "bytes"):
+		size, err := strconv.Atoi(strings.TrimPrefix(typ, "bytes"))
+		if err != nil || size < 1 || size > 32 {
+			return nil, errors.Errorf("unsupported type %s", typ)
+		}
+		b, err := legacyTypedBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > size {
+			return nil, errors.Errorf("%s value is %d bytes long", typ, len(b))
+		}
+		return append(b, make([]byte, size-len(b))...), nil
+	case strings.HasPrefix(typ, "int"), strings.HasPrefix(typ, "uint"):
+		signed := strings.HasPrefix(typ, "int")
+		size, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int"))
+		if err != nil || size%8 != 0 || size < 8 || size > 256 {
+			return nil, errors.Errorf("unsupported type %s", typ)
+		}
+		n, err := legacyTypedNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		if n.BitLen() > size || !signed && n.Sign() < 0 {
+			return nil, errors.Errorf("%s doesn't fit in %s", n, typ)
+		}
+		// two's complement in the size of the type, which arrays zero-pad rather than sign extend
+		twos := new(big.Int).Mod(n, new(big.Int).Lsh(big.NewInt(1), uint(size)))
+		return common.LeftPadBytes(twos.Bytes(), packedSize(width, size/8)), nil
+	}
+	return nil, errors.Errorf("unsupported type %s", typ)
+}
+
+// Hex strings are decoded, other strings taken as UTF-8 like ethereumjs-util's toBuffer does
+func legacyTypedBytes(value json.RawMessage) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return nil, errors.New("bytes should be a JSON string")
+	}
+	if !strings.HasPrefix(s, "0x") {
+		return []byte(s), nil
+	}
+	s = utils.RemoveHexPrefix(s)
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid hex string")
+	}
+	return b, nil
+}
+
+// Numbers can be JSON numbers, decimal strings or 0x prefixed hex strings
+func legacyTypedNumber(value json.RawMessage) (*big.Int, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(value, &number); err != nil {
+			return nil, errors.New("number should be a JSON number or string")
+		}
+		s = number.String()
+	}
+	n, ok := new(big.Int), false
+	if strings.HasPrefix(s, "0x") {
+		n, ok = n.SetString(utils.RemoveHexPrefix(s), 16)
+	} else {
+		n, ok = n.SetString(s, 10)
+	}
+	if !ok {
+		return nil, errors.Errorf("invalid number %s", s)
+	}
+	return n, nil
+}
+
+func packedSize(width int, size int) int {
+	if width == 0 {
+		return size
+	}
+	return width
+}
@@ -0,0 +1,225 @@
+package transformer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+)
+
+// The example of EIP-712
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": CHAIN_ID,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedDataHash(t *testing.T) {
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal([]byte(strings.Replace(mailTypedData, "CHAIN_ID", `"1"`, 1)), &typedData); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := typedDataHash(&typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	internal.CheckTestResultDefault("be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", hex.EncodeToString(hash), t, false)
+
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), crypto.Keccak256([]byte("cow")))
+	sig, err := signHash(key, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d" +
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562" + "1c"
+	internal.CheckTestResultDefault(want, hex.EncodeToString(sig), t, false)
+}
+
+func TestSignTypedDataRequest(t *testing.T) {
+	kaonClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), crypto.Keccak256([]byte("cow")))
+	account, err := btcutil.NewWIF(key, &chaincfg.MainNetParams, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kaonClient.Accounts = append(kaonClient.Accounts, account)
+	address := "0x" + hex.EncodeToString(btcutil.Hash160(account.SerializePubKey()))
+
+	typedData := strings.Replace(mailTypedData, "CHAIN_ID", strconv.Itoa(kaonClient.ChainId()), 1)
+	encoded, err := json.Marshal(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// MetaMask sends the typed data as a JSON string
+	for _, params := range [][]json.RawMessage{
+		{json.RawMessage(`"` + address + `"`), json.RawMessage(typedData)},
+		{json.RawMessage(`"` + address + `"`), encoded},
+	} {
+		request, err := internal.PrepareEthRPCRequest(1, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, jsonErr := (&ProxyETHSignTypedData{kaonClient, "eth_signTypedData_v4"}).Request(request, internal.NewEchoContext())
+		if jsonErr != nil {
+			t.Fatal(jsonErr.Message())
+		}
+		sig, err := hex.DecodeString(strings.TrimPrefix(string(got.(eth.SignResponse)), "0x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var parsed eth.SignTypedDataRequest
+		if err := json.Unmarshal(request.Params, &parsed); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := typedDataHash(&parsed.TypedData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer, err := recoverHashSigner(kaonClient.Accounts, hash, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		internal.CheckTestResultDefault(address, "0x"+signer, t, false)
+	}
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{
+		json.RawMessage(`"` + address + `"`),
+		json.RawMessage(strings.Replace(mailTypedData, "CHAIN_ID", "1", 1)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := (&ProxyETHSignTypedData{kaonClient, "eth_signTypedData_v4"}).Request(request, internal.NewEchoContext()); jsonErr == nil {
+		t.Fatal("expected typed data of another chain to be rejected")
+	}
+}
+
+// The examples of MetaMask's eth-sig-util
+func TestLegacyTypedDataHash(t *testing.T) {
+	for _, test := range []struct {
+		typedData string
+		hash      string
+	}{
+		{
+			`[{"type": "string", "name": "message", "value": "Hi, Alice!"}]`,
+			"14b9f24872e28cc49e72dc104d7380d8e0ba84a3fe2e712704bcac66a5702bd5",
+		},
+		{
+			`[{"type": "string", "name": "message", "value": "Hi, Alice!"}, {"type": "uint8", "name": "value", "value": 10}]`,
+			"f7ad23226db5c1c00ca0ca1468fd49c8f8bbc1489bc1c382de5adc557a69c229",
+		},
+		{
+			`[{"type": "bytes", "name": "message", "value": "0xdeadbeaf"}]`,
+			"6c69d03412450b174def7d1e48b3bcbbbd8f51df2e76e2c5b3a5d951125be3a9",
+		},
+	} {
+		var typedData []eth.TypedValue
+		if err := json.Unmarshal([]byte(test.typedData), &typedData); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := legacyTypedDataHash(typedData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		internal.CheckTestResultDefault(test.hash, hex.EncodeToString(hash), t, false)
+	}
+}
+
+func TestSignTypedDataLegacyRequest(t *testing.T) {
+	kaonClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), crypto.Keccak256([]byte("cow")))
+	account, err := btcutil.NewWIF(key, &chaincfg.MainNetParams, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kaonClient.Accounts = append(kaonClient.Accounts, account)
+	address := json.RawMessage(`"0x` + hex.EncodeToString(btcutil.Hash160(account.SerializePubKey())) + `"`)
+
+	typedData := `[{"type": "string", "name": "message", "value": "Hi, Alice!"}, {"type": "uint8", "name": "value", "value": 10}]`
+	hash, _ := hex.DecodeString("f7ad23226db5c1c00ca0ca1468fd49c8f8bbc1489bc1c382de5adc557a69c229")
+	encoded, err := json.Marshal(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// MetaMask sends the typed data first, as a JSON string
+	for _, params := range [][]json.RawMessage{
+		{encoded, address},
+		{address, json.RawMessage(typedData)},
+	} {
+		request, err := internal.PrepareEthRPCRequest(1, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, jsonErr := (&ProxyETHSignTypedDataLegacy{kaonClient, "eth_signTypedData"}).Request(request, internal.NewEchoContext())
+		if jsonErr != nil {
+			t.Fatal(jsonErr.Message())
+		}
+		sig, err := hex.DecodeString(strings.TrimPrefix(string(got.(eth.SignResponse)), "0x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer, err := recoverHashSigner(kaonClient.Accounts, hash, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		internal.CheckTestResultDefault(string(address), `"0x`+signer+`"`, t, false)
+	}
+
+	// each method points to the other for the format it doesn't take
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{address, json.RawMessage(typedData)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := (&ProxyETHSignTypedData{kaonClient, "eth_signTypedData_v4"}).Request(request, internal.NewEchoContext()); jsonErr == nil || !strings.Contains(jsonErr.Message(), "eth_signTypedData") {
+		t.Fatalf("expected legacy typed data to be rejected by eth_signTypedData_v4, got %v", jsonErr)
+	}
+	request, err = internal.PrepareEthRPCRequest(1, []json.RawMessage{address, json.RawMessage(strings.Replace(mailTypedData, "CHAIN_ID", "1", 1))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := (&ProxyETHSignTypedDataLegacy{kaonClient, "eth_signTypedData"}).Request(request, internal.NewEchoContext()); jsonErr == nil || !strings.Contains(jsonErr.Message(), "eth_signTypedData_v4") {
+		t.Fatalf("expected EIP-712 typed data to be rejected by eth_signTypedData, got %v", jsonErr)
+	}
+}
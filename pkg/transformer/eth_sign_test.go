@@ -0,0 +1,104 @@
+package transformer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+)
+
+func TestSignAndRecover(t *testing.T) {
+	account, err := btcutil.DecodeWIF("5JK4Gu9nxCvsCxiq9Zf3KdmA9ACza6dUn5BRLVWAYEtQabdnJ89")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const address = "0x6d358cf96533189dd5a602d0937fddf0888ad3ae"
+
+	tests := []struct {
+		scheme  string
+		sign    string
+		recover ETHProxyInitializer
+		// first byte of Kaon signatures, last one of Ethereum signatures
+		recoveryByte int
+	}{
+		{kaon.SignSchemeEthereum, "eth_sign", func(k *kaon.Kaon) ETHProxy { return &ProxyETHPersonalECRecover{k} }, 64},
+		{kaon.SignSchemeEthereum, "personal_sign", func(k *kaon.Kaon) ETHProxy { return &ProxyETHPersonalECRecover{k} }, 64},
+		{kaon.SignSchemeKaon, "eth_sign", func(k *kaon.Kaon) ETHProxy { return &ProxyKAONECRecover{k} }, 0},
+		{kaon.SignSchemeKaon, "personal_sign", func(k *kaon.Kaon) ETHProxy { return &ProxyKAONECRecover{k} }, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scheme+"/"+tt.sign, func(t *testing.T) {
+			kaonClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+			if err != nil {
+				t.Fatal(err)
+			}
+			kaonClient.Accounts = append(kaonClient.Accounts, account)
+			kaonClient.SetFlag(kaon.FLAG_SIGN_SCHEME, tt.scheme)
+
+			var signer ETHProxy = &ProxyETHSign{kaonClient}
+			params := []json.RawMessage{json.RawMessage(`"` + address + `"`), json.RawMessage(`"0x68656c6c6f"`)}
+			if tt.sign == "personal_sign" {
+				signer = &ProxyETHPersonalSign{kaonClient}
+				params[0], params[1] = params[1], params[0]
+			}
+			request, err := internal.PrepareEthRPCRequest(1, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signature, jsonErr := signer.Request(request, internal.NewEchoContext())
+			if jsonErr != nil {
+				t.Fatal(jsonErr.Message())
+			}
+			sig := string(signature.(eth.SignResponse))
+			if len(sig) != 2+65*2 {
+				t.Fatalf("expected a 65 bytes signature, got %s", sig)
+			}
+			if v := sig[2+tt.recoveryByte*2 : 4+tt.recoveryByte*2]; v != "1b" && v != "1c" {
+				t.Fatalf("unexpected recovery byte %s in %s", v, sig)
+			}
+
+			request, err = internal.PrepareEthRPCRequest(1, []json.RawMessage{
+				json.RawMessage(`"0x68656c6c6f"`),
+				json.RawMessage(`"` + sig + `"`),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			recovered, jsonErr := tt.recover(kaonClient).Request(request, internal.NewEchoContext())
+			if jsonErr != nil {
+				t.Fatal(jsonErr.Message())
+			}
+			internal.CheckTestResultDefault(eth.ECRecoverResponse(address), recovered, t, false)
+		})
+	}
+}
+
+func TestSignUnknownAccount(t *testing.T) {
+	kaonClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{
+		json.RawMessage(`"hello"`),
+		json.RawMessage(`"0x6d358cf96533189dd5a602d0937fddf0888ad3ae"`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := (&ProxyETHPersonalSign{kaonClient}).Request(request, internal.NewEchoContext()); jsonErr == nil {
+		t.Fatal("expected signing with an unknown account to fail")
+	}
+}
+
+func TestSignSchemeDefaultsToKaon(t *testing.T) {
+	kaonClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// signatures of earlier releases keep verifying unless --sign-scheme=ethereum is set
+	internal.CheckTestResultDefault(kaon.SignSchemeKaon, kaonClient.SignScheme(), t, false)
+}
@@ -0,0 +1,224 @@
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/conversion"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+)
+
+// Times a simulation is run again when a block is mined while it runs
+const simulationAttempts = 3
+
+// Gas of a transfer to an account, which callcontract can't run
+const transferGas = 21000
+
+var ErrSimulationTipMoved = errors.New("a block was mined during the simulation")
+
+// ProxyETHSimulateV1 implements ETHProxy
+type ProxyETHSimulateV1 struct {
+	*ProxyETHCall
+}
+
+func (p *ProxyETHSimulateV1) Method() string {
+	return "eth_simulateV1"
+}
+
+func (p *ProxyETHSimulateV1) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("simulation", objectSchema()),
+			describeOptionalParam("block", blockParameterSchema()),
+		},
+		Result: describeResult("simulated blocks", arrayOf(objectSchema())),
+	}
+}
+
+func (p *ProxyETHSimulateV1) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.SimulateRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	if len(req.BlockStateCalls) == 0 {
+		return nil, eth.NewInvalidParamsError("empty blockStateCalls")
+	}
+	calls := 0
+	for _, blockStateCalls := range req.BlockStateCalls {
+		// callcontract runs against the current state and block, simulating anything else would be silently wrong
+		if isOverride(blockStateCalls.BlockOverrides) || isOverride(blockStateCalls.StateOverrides) {
+			return nil, eth.NewInvalidParamsError("block and state overrides are not supported")
+		}
+		calls += len(blockStateCalls.Calls)
+	}
+	// callcontract doesn't keep state between calls, later calls wouldn't see the changes of earlier ones
+	if calls > 1 {
+		return nil, eth.NewInvalidParamsError("only a single call can be simulated, callcontract doesn't chain the state of calls")
+	}
+
+	ctx := c.Request().Context()
+	block, jsonErr := getStateBlockByRawParam(ctx, p.Kaon, getBlockHash(c), req.Block)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if !block.Latest {
+		return nil, historicalStateUnavailable("callcontract only runs against the current state")
+	}
+
+	// every call has to run on the same tip, kaond mining a block in between would mix states
+	for attempt := 0; attempt < simulationAttempts; attempt++ {
+		tip, err := p.GetBlockCount(ctx)
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		result, jsonErr := p.request(ctx, &req, tip.Uint64())
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		after, err := p.GetBlockCount(ctx)
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		if after.Cmp(tip.Int) == 0 {
+			return result, nil
+		}
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "Tip moved during simulation, retrying", "tip", tip.Int, "after", after.Int)
+	}
+	return nil, eth.NewCallbackError(ErrSimulationTipMoved.Error())
+}
+
+// Simulates each block on top of tip
+func (p *ProxyETHSimulateV1) request(ctx context.Context, req *eth.SimulateRequest, tip uint64) (eth.SimulateResponse, *eth.JSONRPCError) {
+	result := make(eth.SimulateResponse, 0, len(req.BlockStateCalls))
+	for i, blockStateCalls := range req.BlockStateCalls {
+		number := tip + uint64(i) + 1
+		blockGasUsed := new(big.Int)
+		logIndex := uint64(0)
+		calls := make([]eth.SimulatedCall, 0, len(blockStateCalls.Calls))
+		for index, call := range blockStateCalls.Calls {
+			ethreq := eth.CallRequest(call)
+			simulation, jsonErr := p.simulate(ctx, &ethreq)
+			if jsonErr != nil {
+				return nil, jsonErr
+			}
+
+			logs := conversion.ExtractETHLogsFromTransactionReceipt(simulatedLogBlockData{number: number, index: uint64(index)}, simulation.Logs)
+			// logs are numbered across the whole block
+			for j := range logs {
+				logs[j].LogIndex = hexutil.EncodeUint64(logIndex)
+				logIndex++
+			}
+
+			simulated := eth.SimulatedCall{
+				ReturnData: utils.AddHexPrefix(simulation.Output),
+				Logs:       logs,
+				GasUsed:    hexutil.EncodeBig(simulation.GasUsed),
+				Status:     "0x1",
+			}
+			if simulation.Failed() {
+				simulated.Status = "0x0"
+				simulated.Error = simulation.Error()
+			}
+			blockGasUsed.Add(blockGasUsed, simulation.GasUsed)
+			calls = append(calls, simulated)
+		}
+		result = append(result, eth.SimulatedBlock{
+			Number:  hexutil.EncodeUint64(number),
+			GasUsed: hexutil.EncodeBig(blockGasUsed),
+			Calls:   calls,
+		})
+	}
+	return result, nil
+}
+
+func isOverride(override json.RawMessage) bool {
+	override = bytes.TrimSpace(override)
+	return len(override) != 0 && string(override) != "null" && string(override) != "{}"
+}
+
+// A call run by callcontract against the state of the tip
+type callSimulation struct {
+	GasUsed *big.Int
+	// return data, or revert data when the call reverted
+	Output string
+	// "None" unless the EVM raised an exception
+	Excepted string
+	Logs     []kaon.Log
+}
+
+func (s *callSimulation) Failed() bool {
+	return s.Excepted != "" && s.Excepted != "None"
+}
+
+func (s *callSimulation) Error() *eth.SimulatedCallError {
+	if s.Excepted == "Revert" {
+		return &eth.SimulatedCallError{
+			Code:    eth.ExecutionRevertedErrorCode,
+			Message: ErrExecutionReverted.Error(),
+			Data:    utils.AddHexPrefix(s.Output),
+		}
+	}
+	return &eth.SimulatedCallError{
+		Code:    eth.VMErrorCode,
+		Message: "execution failed: " + s.Excepted,
+	}
+}
+
+func (p *ProxyETHCall) simulate(ctx context.Context, ethreq *eth.CallRequest) (*callSimulation, *eth.JSONRPCError) {
+	if ethreq.To == "" {
+		return nil, eth.NewInvalidParamsError("contract creations can't be simulated, callcontract needs a contract address")
+	}
+	kaonreq, jsonErr := p.ToRequest(ethreq)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	kaonresp, err := p.CallContract(ctx, kaonreq)
+	if err == kaon.ErrInvalidAddress {
+		// not a contract, a plain transfer
+		return &callSimulation{GasUsed: big.NewInt(transferGas), Excepted: "None"}, nil
+	}
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	logs, err := kaonresp.Logs()
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return &callSimulation{
+		GasUsed:  new(big.Int).Set(&kaonresp.ExecutionResult.GasUsed),
+		Output:   kaonresp.ExecutionResult.Output,
+		Excepted: kaonresp.ExecutionResult.Excepted,
+		Logs:     logs,
+	}, nil
+}
+
+// Simulated transactions and blocks aren't hashed
+type simulatedLogBlockData struct {
+	number uint64
+	index  uint64
+}
+
+func (d simulatedLogBlockData) GetTransactionHash() string {
+	return strings.Repeat("0", 64)
+}
+
+func (d simulatedLogBlockData) GetTransactionIndex() uint64 {
+	return d.index
+}
+
+func (d simulatedLogBlockData) GetBlockHash() string {
+	return strings.Repeat("0", 64)
+}
+
+func (d simulatedLogBlockData) GetBlockNumber() uint64 {
+	return d.number
+}
@@ -17,6 +17,15 @@ func (p *ProxyETHUninstallFilter) Method() string {
 	return "eth_uninstallFilter"
 }
 
+func (p *ProxyETHUninstallFilter) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("filter id", quantitySchema()),
+		},
+		Result: describeResult("uninstalled", booleanSchema()),
+	}
+}
+
 func (p *ProxyETHUninstallFilter) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var req eth.UninstallFilterRequest
 	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
@@ -0,0 +1,108 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/pkg/errors"
+)
+
+const DefaultEthereumHeaderPollInterval = 10 * time.Second
+
+// Computes the Ethereum headers of the active chain from --eth-headers-start-height up to the tip in the
+// background, so that serving a block only computes the headers of the few blocks mined since the last sync
+type EthereumHeaderIndexer struct {
+	kaon         *kaon.Kaon
+	pollInterval time.Duration
+
+	// lowest height whose header may not have been computed yet, found again on the first sync
+	next   uint64
+	synced bool
+}
+
+func NewEthereumHeaderIndexer(kaonClient *kaon.Kaon) *EthereumHeaderIndexer {
+	return &EthereumHeaderIndexer{
+		kaon:         kaonClient,
+		pollInterval: DefaultEthereumHeaderPollInterval,
+	}
+}
+
+// Follows the chain tip in the background until the context is cancelled
+func (i *EthereumHeaderIndexer) Start(ctx context.Context) {
+	go i.run(ctx)
+}
+
+func (i *EthereumHeaderIndexer) run(ctx context.Context) {
+	ticker := time.NewTicker(i.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := i.Sync(ctx); err != nil && ctx.Err() == nil {
+			i.kaon.GetErrorLogger().Log("msg", "Failed to compute Ethereum block headers", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Computes the headers of every block up to the current tip that doesn't have one yet. Blocks reorganised
+// below the last synced height are computed when a later block is, by walking back to the first known header.
+func (i *EthereumHeaderIndexer) Sync(ctx context.Context) error {
+	if !i.kaon.EthHeadersEnabled() {
+		return nil
+	}
+	blockCount, err := i.kaon.GetBlockCount(ctx)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get block count")
+	}
+	tip := blockCount.Uint64()
+
+	if !i.synced {
+		if i.next, err = i.firstMissing(ctx, tip); err != nil {
+			return err
+		}
+		i.synced = true
+		if i.next <= tip {
+			i.kaon.GetDebugLogger().Log("msg", "Computing Ethereum block headers", "from", i.next, "to", tip)
+		}
+	}
+
+	for ; i.next <= tip; i.next++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hash, err := i.kaon.GetBlockHash(ctx, new(big.Int).SetUint64(i.next))
+		if err != nil {
+			return errors.Wrapf(err, "couldn't get block hash for block %d", i.next)
+		}
+		if _, err := computeEthereumHeaders(ctx, i.kaon, string(hash), nil, 0); err != nil {
+			return errors.WithMessagef(err, "couldn't compute Ethereum header of block %d", i.next)
+		}
+	}
+	return nil
+}
+
+// Lowest height from --eth-headers-start-height whose header isn't known, headers being computed in order
+// the known ones are found by bisection instead of looking up every block after a restart
+func (i *EthereumHeaderIndexer) firstMissing(ctx context.Context, tip uint64) (uint64, error) {
+	low, high := i.kaon.EthHeadersStartHeight(), tip+1
+	for low < high {
+		middle := low + (high-low)/2
+		hash, err := i.kaon.GetBlockHash(ctx, new(big.Int).SetUint64(middle))
+		if err != nil {
+			return 0, errors.Wrapf(err, "couldn't get block hash for block %d", middle)
+		}
+		if _, ok := i.kaon.EthereumHeader(string(hash)); ok {
+			low = middle + 1
+		} else {
+			high = middle
+		}
+	}
+	return low, nil
+}
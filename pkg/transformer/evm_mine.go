@@ -0,0 +1,30 @@
+package transformer
+
+import (
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyEVMMine implements ETHProxy
+type ProxyEVMMine struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyEVMMine)(nil)
+
+func (p *ProxyEVMMine) Method() string {
+	return "evm_mine"
+}
+
+func (p *ProxyEVMMine) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only mine on regtest")
+	}
+
+	// a timestamp parameter can't be honoured, kaond sets block times itself
+	if _, err := p.Generate(c.Request().Context(), 1, nil); err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return "0x0", nil
+}
@@ -0,0 +1,40 @@
+package transformer
+
+import (
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyEVMRevert implements ETHProxy
+type ProxyEVMRevert struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyEVMRevert)(nil)
+
+func (p *ProxyEVMRevert) Method() string {
+	return "evm_revert"
+}
+
+func (p *ProxyEVMRevert) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only revert snapshots on regtest")
+	}
+
+	var params []interface{}
+	if err := unmarshalRequest(req.Params, &params); err != nil || len(params) != 1 {
+		return nil, eth.NewInvalidParamsError("require 1 argument: the snapshot id")
+	}
+	id, err := parseDevQuantity(params[0])
+	if err != nil || !id.IsUint64() {
+		return nil, eth.NewInvalidParamsError("invalid snapshot id")
+	}
+
+	reverted, err := p.RevertToSnapshot(c.Request().Context(), id.Uint64())
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "couldn't revert snapshot", "id", id, "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return reverted, nil
+}
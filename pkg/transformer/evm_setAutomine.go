@@ -0,0 +1,32 @@
+package transformer
+
+import (
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyEVMSetAutomine implements ETHProxy
+type ProxyEVMSetAutomine struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyEVMSetAutomine)(nil)
+
+func (p *ProxyEVMSetAutomine) Method() string {
+	return "evm_setAutomine"
+}
+
+func (p *ProxyEVMSetAutomine) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only configure mining on regtest")
+	}
+
+	var params []bool
+	if err := unmarshalRequest(req.Params, &params); err != nil || len(params) != 1 {
+		return nil, eth.NewInvalidParamsError("require 1 argument: whether to mine a block after each transaction")
+	}
+
+	p.SetAutomine(params[0])
+	return true, nil
+}
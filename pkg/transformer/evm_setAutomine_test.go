@@ -0,0 +1,39 @@
+package transformer
+
+import (
+	"testing"
+)
+
+func TestSetAutomine(t *testing.T) {
+	_, kaonClient := newDevNode(t)
+	proxy := &ProxyEVMSetAutomine{kaonClient}
+	if !kaonClient.Automine() {
+		t.Fatal("expected automine to be enabled by default")
+	}
+	height := blockCount(t, kaonClient)
+
+	if result, jsonErr := devRequest(t, proxy, `false`); jsonErr != nil || result != true {
+		t.Fatalf("expected automine to be disabled, got %v %v", result, jsonErr)
+	}
+	if kaonClient.Automine() {
+		t.Fatal("expected automine to be disabled")
+	}
+	kaonClient.GenerateIfPossible()
+	if got := blockCount(t, kaonClient); got != height {
+		t.Fatalf("expected no block without automine, height %d -> %d", height, got)
+	}
+
+	if _, jsonErr := devRequest(t, proxy, `true`); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	kaonClient.GenerateIfPossible()
+	if got := blockCount(t, kaonClient); got != height+1 {
+		t.Fatalf("expected a block with automine, height %d -> %d", height, got)
+	}
+
+	for _, params := range [][]string{{}, {`"yes"`}, {`true`, `true`}} {
+		if _, jsonErr := devRequest(t, proxy, params...); jsonErr == nil {
+			t.Errorf("expected %v to be rejected", params)
+		}
+	}
+}
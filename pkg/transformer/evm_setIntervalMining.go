@@ -0,0 +1,49 @@
+package transformer
+
+import (
+	"time"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyEVMSetIntervalMining implements ETHProxy
+type ProxyEVMSetIntervalMining struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyEVMSetIntervalMining)(nil)
+
+func (p *ProxyEVMSetIntervalMining) Method() string {
+	return "evm_setIntervalMining"
+}
+
+func (p *ProxyEVMSetIntervalMining) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only configure mining on regtest")
+	}
+
+	var params []interface{}
+	if err := unmarshalRequest(req.Params, &params); err != nil || len(params) != 1 {
+		return nil, eth.NewInvalidParamsError("require 1 argument: the mining interval in milliseconds, 0 disables interval mining")
+	}
+	// a [min, max] range is accepted like Hardhat does, blocks are then mined at the minimum interval
+	interval := params[0]
+	if intervalRange, ok := interval.([]interface{}); ok {
+		if len(intervalRange) != 2 {
+			return nil, eth.NewInvalidParamsError("the mining interval range must have 2 values")
+		}
+		interval = intervalRange[0]
+	}
+	milliseconds, err := parseDevQuantity(interval)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	if milliseconds.Sign() < 0 {
+		return nil, eth.NewInvalidParamsError("the mining interval must be >= 0")
+	}
+
+	p.SetIntervalMining(time.Duration(milliseconds.Int64()) * time.Millisecond)
+	return true, nil
+}
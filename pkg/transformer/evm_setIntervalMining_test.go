@@ -0,0 +1,42 @@
+package transformer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetIntervalMining(t *testing.T) {
+	_, kaonClient := newDevNode(t)
+	proxy := &ProxyEVMSetIntervalMining{kaonClient}
+	defer kaonClient.SetIntervalMining(0)
+	height := blockCount(t, kaonClient)
+
+	if result, jsonErr := devRequest(t, proxy, `50`); jsonErr != nil || result != true {
+		t.Fatalf("expected interval mining to be enabled, got %v %v", result, jsonErr)
+	}
+	waitForBlockCount(t, kaonClient, height+2)
+
+	if _, jsonErr := devRequest(t, proxy, `0`); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	// a block may have been in the making when interval mining stopped
+	time.Sleep(100 * time.Millisecond)
+	stopped := blockCount(t, kaonClient)
+	time.Sleep(200 * time.Millisecond)
+	if got := blockCount(t, kaonClient); got != stopped {
+		t.Fatalf("expected no more blocks once interval mining is disabled, height %d -> %d", stopped, got)
+	}
+
+	// a range is mined at its minimum interval
+	if _, jsonErr := devRequest(t, proxy, `[50, 100000]`); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	waitForBlockCount(t, kaonClient, stopped+2)
+	kaonClient.SetIntervalMining(0)
+
+	for _, interval := range []string{`-1`, `[50]`, `"soon"`} {
+		if _, jsonErr := devRequest(t, proxy, interval); jsonErr == nil {
+			t.Errorf("expected interval %s to be rejected", interval)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package transformer
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyEVMSnapshot implements ETHProxy
+type ProxyEVMSnapshot struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyEVMSnapshot)(nil)
+
+func (p *ProxyEVMSnapshot) Method() string {
+	return "evm_snapshot"
+}
+
+func (p *ProxyEVMSnapshot) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only take snapshots on regtest")
+	}
+
+	id, err := p.Snapshot(c.Request().Context())
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "couldn't take snapshot", "error", err)
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return hexutil.EncodeUint64(id), nil
+}
@@ -0,0 +1,218 @@
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+)
+
+// Records the kaond methods called and their parameters
+type recordingDoer struct {
+	internal.Doer
+	requests []kaon.JSONRPCRequest
+}
+
+func (d *recordingDoer) Do(request *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	var rpcRequest kaon.JSONRPCRequest
+	if err := json.Unmarshal(body, &rpcRequest); err != nil {
+		return nil, err
+	}
+	d.requests = append(d.requests, rpcRequest)
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return d.Doer.Do(request)
+}
+
+func (d *recordingDoer) params(method string) []string {
+	var params []string
+	for _, request := range d.requests {
+		if request.Method == method {
+			var compacted bytes.Buffer
+			if err := json.Compact(&compacted, request.Params); err != nil {
+				compacted.Write(request.Params)
+			}
+			params = append(params, compacted.String())
+		}
+	}
+	return params
+}
+
+func TestSnapshotAndRevert(t *testing.T) {
+	mockedClientDoer := &recordingDoer{Doer: internal.NewDoerMappedMock()}
+	kaonClient, err := internal.CreateMockedClientForNetwork(mockedClientDoer, kaon.ChainRegTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the snapshot is taken at height 10, two blocks are mined before reverting
+	for _, blockCount := range []int{10, 12} {
+		if err = mockedClientDoer.AddResponse(kaon.MethodGetBlockCount, blockCount); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = mockedClientDoer.AddResponse(kaon.MethodGetBlockHash, "snapshotblock"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mockedClientDoer.AddResponse(kaon.MethodGetBlockHash, "firstblockaftersnapshot"); err != nil {
+		t.Fatal(err)
+	}
+	// both blocks after the snapshot only have their coinbase
+	if err = mockedClientDoer.AddResponse(kaon.MethodGetBlock, kaon.GetBlockResponse{Txs: []interface{}{"coinbase"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []string{kaon.MethodReconsiderBlock, kaon.MethodInvalidateBlock} {
+		if err = mockedClientDoer.AddResponse(method, []byte("null")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := ProxyEVMSnapshot{kaonClient}
+	id, jsonErr := snapshot.Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	internal.CheckTestResultEthRequestRPC(*request, "0x1", id, t, false)
+
+	request, err = internal.PrepareEthRPCRequest(1, []json.RawMessage{[]byte(`"0x1"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revert := ProxyEVMRevert{kaonClient}
+	reverted, jsonErr := revert.Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	internal.CheckTestResultEthRequestRPC(*request, true, reverted, t, false)
+
+	if got := mockedClientDoer.params(kaon.MethodReconsiderBlock); len(got) != 1 || got[0] != `["snapshotblock"]` {
+		t.Errorf("expected the snapshot block to be reconsidered, got %v", got)
+	}
+	if got := mockedClientDoer.params(kaon.MethodGetBlockHash); len(got) != 3 || got[1] != `[11]` || got[2] != `[12]` {
+		t.Errorf("expected the blocks after the snapshot to be looked up, got %v", got)
+	}
+	if got := mockedClientDoer.params(kaon.MethodInvalidateBlock); len(got) != 1 || got[0] != `["firstblockaftersnapshot"]` {
+		t.Errorf("expected the block after the snapshot to be invalidated, got %v", got)
+	}
+
+	// a snapshot can only be reverted to once
+	reverted, jsonErr = revert.Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	internal.CheckTestResultEthRequestRPC(*request, false, reverted, t, false)
+}
+
+func TestRevertThenMine(t *testing.T) {
+	_, kaonClient := newDevNode(t)
+	var (
+		snapshot = &ProxyEVMSnapshot{kaonClient}
+		revert   = &ProxyEVMRevert{kaonClient}
+		mine     = &ProxyEVMMine{kaonClient}
+		height   = blockCount(t, kaonClient)
+	)
+	mempoolSize := func() int {
+		mempool, err := kaonClient.GetRawMempool(kaon.WithoutCache(context.Background()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(mempool)
+	}
+
+	// empty blocks are reverted and mined again from the snapshot
+	id, jsonErr := devRequest(t, snapshot)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	for i := 0; i < 2; i++ {
+		if _, jsonErr := devRequest(t, mine); jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+	}
+	if reverted, jsonErr := devRequest(t, revert, `"`+id.(string)+`"`); jsonErr != nil || reverted != true {
+		t.Fatalf("expected the empty blocks to be reverted, got %v %v", reverted, jsonErr)
+	}
+	if got := blockCount(t, kaonClient); got != height {
+		t.Fatalf("expected the chain back at height %d, got %d", height, got)
+	}
+	if _, jsonErr := devRequest(t, mine); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if got := blockCount(t, kaonClient); got != height+1 {
+		t.Fatalf("expected a block mined on the snapshot, got height %d", got)
+	}
+
+	// reverting a transaction would only put it back into the mempool for the next block
+	id, jsonErr = devRequest(t, snapshot)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	sender, err := kaonClient.FromHexAddress(simulationMiner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = kaonClient.Request(kaon.MethodCreateContract, &kaon.CreateContractRequest{
+		ByteCode:      "6001600c60003960016000f300",
+		GasLimit:      big.NewInt(100000),
+		GasPrice:      "0.0000004",
+		SenderAddress: sender,
+	}, &kaon.CreateContractResponse{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := devRequest(t, mine); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	_, jsonErr = devRequest(t, revert, `"`+id.(string)+`"`)
+	if jsonErr == nil || !strings.Contains(jsonErr.Message(), kaon.ErrRevertOverTransactions.Error()) {
+		t.Fatalf("expected reverting a block with a transaction to be refused, got %v", jsonErr)
+	}
+	if got := blockCount(t, kaonClient); got != height+2 {
+		t.Fatalf("expected the chain to be left at height %d, got %d", height+2, got)
+	}
+	if _, jsonErr := devRequest(t, mine); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if size := mempoolSize(); size != 0 {
+		t.Fatalf("expected no transaction to be put back into the mempool, got %d", size)
+	}
+}
+
+func TestDevMethodsRequireRegtest(t *testing.T) {
+	kaonClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, proxy := range []ETHProxy{
+		&ProxyEVMMine{kaonClient},
+		&ProxyHardhatMine{kaonClient},
+		&ProxyEVMSetAutomine{kaonClient},
+		&ProxyEVMSetIntervalMining{kaonClient},
+		&ProxyEVMSnapshot{kaonClient},
+		&ProxyEVMRevert{kaonClient},
+		&ProxyHardhatSetBalance{kaonClient},
+	} {
+		if _, jsonErr := proxy.Request(request, internal.NewEchoContext()); jsonErr == nil {
+			t.Errorf("expected %s to be rejected outside of regtest", proxy.Method())
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package transformer
+
+import (
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyHardhatGetAutomine implements ETHProxy
+type ProxyHardhatGetAutomine struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyHardhatGetAutomine)(nil)
+
+func (p *ProxyHardhatGetAutomine) Method() string {
+	return "hardhat_getAutomine"
+}
+
+func (p *ProxyHardhatGetAutomine) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	return p.CanGenerate() && p.Automine(), nil
+}
@@ -0,0 +1,50 @@
+package transformer
+
+import (
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyHardhatMine implements ETHProxy
+type ProxyHardhatMine struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyHardhatMine)(nil)
+
+func (p *ProxyHardhatMine) Method() string {
+	return "hardhat_mine"
+}
+
+func (p *ProxyHardhatMine) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only mine on regtest")
+	}
+
+	var params []interface{}
+	if len(req.Params) != 0 {
+		if err := unmarshalRequest(req.Params, &params); err != nil {
+			// TODO: Correct error code?
+			return nil, eth.NewInvalidParamsError("couldn't unmarshal request parameters")
+		}
+	}
+
+	// the interval between block timestamps can't be honoured, kaond sets block times itself
+	blocks := int64(1)
+	if len(params) > 0 && params[0] != nil {
+		n, err := parseDevQuantity(params[0])
+		if err != nil {
+			return nil, eth.NewInvalidParamsError(err.Error())
+		}
+		blocks = n.Int64()
+	}
+	if blocks <= 0 {
+		return nil, eth.NewInvalidParamsError("Blocks to mine must be > 0")
+	}
+
+	if _, err := p.Generate(c.Request().Context(), int(blocks), nil); err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return true, nil
+}
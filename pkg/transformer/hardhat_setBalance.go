@@ -0,0 +1,96 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+	"github.com/shopspring/decimal"
+)
+
+// ProxyHardhatSetBalance implements ETHProxy
+type ProxyHardhatSetBalance struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyHardhatSetBalance)(nil)
+
+func (p *ProxyHardhatSetBalance) Method() string {
+	return "hardhat_setBalance"
+}
+
+func (p *ProxyHardhatSetBalance) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	if !p.CanGenerate() {
+		return nil, eth.NewInvalidRequestError("Can only set balances on regtest")
+	}
+
+	var params []interface{}
+	if err := unmarshalRequest(req.Params, &params); err != nil || len(params) != 2 {
+		return nil, eth.NewInvalidParamsError("require 2 arguments: the hex address, the balance")
+	}
+	address, ok := params[0].(string)
+	if !ok || !utils.IsEthHexAddress(address) {
+		return nil, eth.NewInvalidParamsError("first parameter must be a hex address")
+	}
+	balance, err := parseDevQuantity(params[1])
+	if err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	return p.request(c.Request().Context(), utils.RemoveHexPrefix(address), balance)
+}
+
+// Kaon balances can't be written, the difference is sent from the address blocks are generated to
+// and confirmed in a new block. Balances can thus only be increased.
+func (p *ProxyHardhatSetBalance) request(ctx context.Context, address string, balance *big.Int) (bool, *eth.JSONRPCError) {
+	accountInfoReq := kaon.GetAccountInfoRequest(address)
+	if _, err := p.GetAccountInfo(ctx, &accountInfoReq); err == nil {
+		return false, eth.NewInvalidParamsError("can't set the balance of a contract")
+	}
+
+	base58Address, err := p.FromHexAddress(address)
+	if err != nil {
+		return false, eth.NewCallbackError(err.Error())
+	}
+	current := big.NewInt(0)
+	addressBalance, err := p.GetAddressBalance(ctx, &kaon.GetAddressBalanceRequest{Address: base58Address})
+	if err == nil {
+		current = &addressBalance.Balance
+	} else if err != kaon.ErrInvalidAddress {
+		p.GetDebugLogger().Log("method", p.Method(), "address", address, "msg", "error getting address balance", "error", err)
+		return false, eth.NewCallbackError(err.Error())
+	}
+
+	difference := new(big.Int).Sub(balance, current)
+	switch difference.Sign() {
+	case 0:
+		return true, nil
+	case -1:
+		return false, eth.NewInvalidParamsError("balances can only be increased on kaond")
+	}
+
+	generateTo, err := p.GenerateToAddress()
+	if err != nil {
+		return false, eth.NewCallbackError(err.Error())
+	}
+	// sendtoaddress takes amounts in KAON, balances are in units of 10^-18 KAON
+	sendReq := kaon.SendToAddressRequest{
+		Address:       base58Address,
+		Amount:        kaon.TransformAmount(decimal.NewFromBigInt(difference, -18)),
+		SenderAddress: generateTo,
+	}
+	var txID kaon.SendToAddressResponse
+	if err := p.Kaon.RequestWithContext(ctx, kaon.MethodSendToAddress, &sendReq, &txID); err != nil {
+		return false, eth.NewCallbackError(err.Error())
+	}
+	p.GetDebugLogger().Log("method", p.Method(), "address", address, "msg", "funded address", "txid", string(txID), "amount", difference)
+
+	// getaddressbalance only counts confirmed outputs
+	if _, err := p.Generate(ctx, 1, nil); err != nil {
+		return false, eth.NewCallbackError(err.Error())
+	}
+	return true, nil
+}
@@ -0,0 +1,90 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+)
+
+func TestHardhatSetBalance(t *testing.T) {
+	const receiver = "0x2352be3db3177f0a07efbe6da5857615b8c9901d"
+
+	_, kaonClient := newDevNode(t)
+	proxy := &ProxyHardhatSetBalance{kaonClient}
+	balanceOf := func(address string) *big.Int {
+		base58Address, err := kaonClient.FromHexAddress(utils.RemoveHexPrefix(address))
+		if err != nil {
+			t.Fatal(err)
+		}
+		balance, err := kaonClient.GetAddressBalance(kaon.WithoutCache(context.Background()), &kaon.GetAddressBalanceRequest{Address: base58Address})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &balance.Balance
+	}
+
+	// 5 KAON
+	five := new(big.Int).Mul(big.NewInt(5), big.NewInt(1e18))
+	height := blockCount(t, kaonClient)
+	if result, jsonErr := devRequest(t, proxy, `"`+receiver+`"`, `"`+hexutil.EncodeBig(five)+`"`); jsonErr != nil || result != true {
+		t.Fatalf("expected the balance to be set, got %v %v", result, jsonErr)
+	}
+	if got := balanceOf(receiver); got.Cmp(five) != 0 {
+		t.Fatalf("expected a balance of %s, got %s", five, got)
+	}
+	// the transfer from the miner is confirmed in a new block
+	if got := blockCount(t, kaonClient); got != height+1 {
+		t.Fatalf("expected a block confirming the funding, height %d -> %d", height, got)
+	}
+
+	// setting the current balance again is a no-op
+	if _, jsonErr := devRequest(t, proxy, `"`+receiver+`"`, `"`+hexutil.EncodeBig(five)+`"`); jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if got := blockCount(t, kaonClient); got != height+1 {
+		t.Fatalf("expected no block for an unchanged balance, height %d -> %d", height+1, got)
+	}
+
+	// kaond balances can only be increased
+	_, jsonErr := devRequest(t, proxy, `"`+receiver+`"`, `"0x1"`)
+	if jsonErr == nil || jsonErr.Code() != eth.NewInvalidParamsError("").Code() {
+		t.Fatalf("expected decreasing a balance to be rejected, got %v", jsonErr)
+	}
+	if got := balanceOf(receiver); got.Cmp(five) != 0 {
+		t.Fatalf("expected the balance to stay %s, got %s", five, got)
+	}
+
+	// deploys the single byte runtime code 0x00
+	var created kaon.CreateContractResponse
+	sender, err := kaonClient.FromHexAddress(utils.RemoveHexPrefix(simulationMiner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = kaonClient.Request(kaon.MethodCreateContract, &kaon.CreateContractRequest{
+		ByteCode:      "6001600c60003960016000f300",
+		GasLimit:      big.NewInt(100000),
+		GasPrice:      "0.0000004",
+		SenderAddress: sender,
+	}, &created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kaonClient.Generate(context.Background(), 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, jsonErr = devRequest(t, proxy, `"0x`+created.Address+`"`, `"`+hexutil.EncodeBig(five)+`"`)
+	if jsonErr == nil || jsonErr.Message() != "can't set the balance of a contract" {
+		t.Fatalf("expected setting the balance of a contract to be rejected, got %v", jsonErr)
+	}
+
+	for _, params := range [][]string{{`"` + receiver + `"`}, {`"not an address"`, `"0x1"`}, {`"` + receiver + `"`, `"lots"`}} {
+		if _, jsonErr := devRequest(t, proxy, params...); jsonErr == nil {
+			t.Errorf("expected %v to be rejected", params)
+		}
+	}
+}
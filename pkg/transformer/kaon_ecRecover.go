@@ -0,0 +1,31 @@
+package transformer
+
+import (
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+)
+
+// ProxyKAONECRecover implements ETHProxy, recovering signatures of the Kaon sign scheme
+type ProxyKAONECRecover struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyKAONECRecover) Method() string {
+	return "kaon_ecRecover"
+}
+
+func (p *ProxyKAONECRecover) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.ECRecoverRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	signer, err := recoverMessageSigner(req.Message, req.Signature)
+	if err != nil {
+		p.GetDebugLogger().Log("method", p.Method(), "msg", "Failed to recover signer", "error", err)
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+	return eth.ECRecoverResponse(utils.AddHexPrefix(signer)), nil
+}
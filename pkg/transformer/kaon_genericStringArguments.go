@@ -18,6 +18,16 @@ func (p *ProxyKAONGenericStringArguments) Method() string {
 	return p.prefix + "_" + p.method
 }
 
+func (p *ProxyKAONGenericStringArguments) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Summary: "Passes its argument to kaond's " + p.method,
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("argument", stringSchema()),
+		},
+		Result: describeResult("result", stringSchema()),
+	}
+}
+
 func (p *ProxyKAONGenericStringArguments) Request(req *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var params eth.StringsArguments
 	if err := unmarshalRequest(req.Params, &params); err != nil {
@@ -0,0 +1,142 @@
+package transformer
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/tokenindex"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+)
+
+// QRC20/ERC20 metadata getters
+const (
+	tokenNameSelector     = "0x06fdde03"
+	tokenSymbolSelector   = "0x95d89b41"
+	tokenDecimalsSelector = "0x313ce567"
+)
+
+// ProxyKAONGetTokenBalances implements ETHProxy, returning the token balances of an address from the token index
+type ProxyKAONGetTokenBalances struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyKAONGetTokenBalances)(nil)
+
+func (p *ProxyKAONGetTokenBalances) Method() string {
+	return "kaon_getTokenBalances"
+}
+
+func (p *ProxyKAONGetTokenBalances) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Summary: "Returns the QRC20/ERC20 balances of an address, summed from the transfers in the token index",
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("address", addressSchema()),
+		},
+		Result: describeResult("balances", arrayOf(objectSchema())),
+	}
+}
+
+func (p *ProxyKAONGetTokenBalances) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.GetTokenBalancesRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	index := getTokenIndex(c)
+	if index == nil {
+		return nil, eth.NewInvalidRequestError("token index not configured")
+	}
+
+	balances, err := index.Balances(string(req))
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	ctx := c.Request().Context()
+	resp := make(eth.GetTokenBalancesResponse, 0, len(balances))
+	for _, balance := range balances {
+		metadata := getTokenMetadata(ctx, p.Kaon, index, balance.Token)
+		tokenBalance := eth.TokenBalance{
+			Token:   utils.AddHexPrefix(balance.Token),
+			Name:    metadata.Name,
+			Symbol:  metadata.Symbol,
+			Balance: hexutil.EncodeBig(balance.Balance),
+		}
+		if metadata.Decimals != nil {
+			tokenBalance.Decimals = hexutil.EncodeUint64(uint64(*metadata.Decimals))
+		}
+		resp = append(resp, tokenBalance)
+	}
+	return resp, nil
+}
+
+// Metadata of a token, looked up with eth_call the first time and cached in the token index. Metadata
+// isn't cached when kaond couldn't be queried, getters the token doesn't implement are left empty.
+func getTokenMetadata(ctx context.Context, p *kaon.Kaon, index *tokenindex.Index, token string) tokenindex.Metadata {
+	if metadata, ok := index.Metadata(token); ok {
+		return metadata
+	}
+
+	var metadata tokenindex.Metadata
+	complete := true
+	call := func(selector string) []byte {
+		result, jsonErr := (&ProxyETHCall{p}).request(ctx, &eth.CallRequest{
+			To:   utils.AddHexPrefix(token),
+			Data: selector,
+		})
+		if jsonErr != nil {
+			p.GetDebugLogger().Log("function", "getTokenMetadata", "msg", "Couldn't call token", "token", token, "selector", selector, "error", jsonErr.Message())
+			complete = false
+			return nil
+		}
+		switch output := result.(type) {
+		case *eth.CallResponse:
+			return common.FromHex(string(*output))
+		case eth.CallResponse:
+			return common.FromHex(string(output))
+		}
+		return nil
+	}
+
+	metadata.Name = decodeTokenString(call(tokenNameSelector))
+	metadata.Symbol = decodeTokenString(call(tokenSymbolSelector))
+	if output := call(tokenDecimalsSelector); len(output) == 32 {
+		if decimals := new(big.Int).SetBytes(output); decimals.IsUint64() && decimals.Uint64() <= 255 {
+			value := uint8(decimals.Uint64())
+			metadata.Decimals = &value
+		}
+	}
+
+	if complete {
+		if err := index.SetMetadata(token, metadata); err != nil {
+			p.GetErrorLogger().Log("function", "getTokenMetadata", "msg", "Couldn't cache token metadata", "token", token, "error", err)
+		}
+	}
+	return metadata
+}
+
+// Decodes the ABI encoded string returned by name() and symbol(), or the bytes32 returned by older tokens
+func decodeTokenString(output []byte) string {
+	if len(output) == 32 {
+		return strings.TrimRight(string(output), "\x00")
+	}
+	if len(output) < 64 || len(output)%32 != 0 {
+		return ""
+	}
+	offset := new(big.Int).SetBytes(output[:32])
+	if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(output)) {
+		return ""
+	}
+	start := offset.Uint64() + 32
+	length := new(big.Int).SetBytes(output[offset.Uint64():start])
+	if !length.IsUint64() || start+length.Uint64() > uint64(len(output)) {
+		return ""
+	}
+	return string(output[start : start+length.Uint64()])
+}
@@ -0,0 +1,142 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/tokenindex"
+	"github.com/labstack/echo"
+)
+
+const (
+	testTokenAddress  = "db46f738bf32cdafb9a4a70eb8b44c76646bcaf0"
+	testTokenHolder   = "7926223070547d2d15b2ef5e7383e541c338ffe9"
+	testTokenReceiver = "6b22910b1e302cf74803ffd1691c2ecb858d3712"
+)
+
+func testTokenTransferReceipt(blockNumber uint64, txHash string, from string, to string, value int64) kaon.TransactionReceipt {
+	receipt := internal.KaonTransactionReceipt([]kaon.Log{{
+		Address: testTokenAddress,
+		Topics:  []string{tokenindex.TransferTopic, "000000000000000000000000" + from, "000000000000000000000000" + to},
+		Data:    fmt.Sprintf("%064x", value),
+	}})
+	receipt.BlockHash = "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"
+	receipt.BlockNumber = blockNumber
+	receipt.TransactionHash = txHash
+	return receipt
+}
+
+func addCallContractOutput(t *testing.T, mockedClientDoer internal.Doer, output string) {
+	response := json.RawMessage(`{"executionResult": {"gasUsed": 21000, "excepted": "None", "output": "` + output + `"}}`)
+	if err := mockedClientDoer.AddResponse(kaon.MethodCallContract, response); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// An echo context serving a token index synced with two transfers of a token
+func prepareTokenIndex(t *testing.T) (*kaon.Kaon, internal.Doer, echo.Context) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mockedClientDoer.AddResponse(kaon.MethodGetBlockCount, big.NewInt(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err = mockedClientDoer.AddResponse(kaon.MethodGetBlockHash, "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mockedClientDoer.AddResponse(kaon.MethodSearchLogs, kaon.SearchLogsResponse{
+		testTokenTransferReceipt(1, "11", "0000000000000000000000000000000000000000", testTokenHolder, 1000),
+		testTokenTransferReceipt(2, "22", testTokenHolder, testTokenReceiver, 250),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := tokenindex.New(context.Background(), kaonClient, memorydb.New(), tokenindex.SetStartHeight(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = index.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c := internal.NewEchoContext()
+	c.Set("tokenIndex", index)
+	return kaonClient, mockedClientDoer, c
+}
+
+func TestGetTokenBalances(t *testing.T) {
+	kaonClient, mockedClientDoer, c := prepareTokenIndex(t)
+	// name(), symbol() and decimals()
+	addCallContractOutput(t, mockedClientDoer, "000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000055465737420000000000000000000000000000000000000000000000000000000")
+	addCallContractOutput(t, mockedClientDoer, "5453540000000000000000000000000000000000000000000000000000000000")
+	addCallContractOutput(t, mockedClientDoer, "0000000000000000000000000000000000000000000000000000000000000008")
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{json.RawMessage(`"0x` + testTokenHolder + `"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr := (&ProxyKAONGetTokenBalances{kaonClient}).Request(request, c)
+	if jsonErr != nil {
+		t.Fatal(jsonErr.Message())
+	}
+
+	want := eth.GetTokenBalancesResponse{{
+		Token:    "0x" + testTokenAddress,
+		Name:     "Test ",
+		Symbol:   "TST",
+		Decimals: "0x8",
+		Balance:  "0x2ee",
+	}}
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+
+	if metadata, ok := getTokenIndex(c).Metadata(testTokenAddress); !ok || metadata.Symbol != "TST" {
+		t.Fatalf("expected the token metadata to be cached, got %+v", metadata)
+	}
+}
+
+func TestGetTokenTransfers(t *testing.T) {
+	kaonClient, _, c := prepareTokenIndex(t)
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{
+		json.RawMessage(`"0x` + testTokenReceiver + `"`),
+		json.RawMessage(`{"fromBlock": "0x1", "token": "0x` + testTokenAddress + `"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr := (&ProxyKAONGetTokenTransfers{kaonClient}).Request(request, c)
+	if jsonErr != nil {
+		t.Fatal(jsonErr.Message())
+	}
+
+	want := eth.GetTokenTransfersResponse{{
+		Token:           "0x" + testTokenAddress,
+		From:            "0x" + testTokenHolder,
+		To:              "0x" + testTokenReceiver,
+		Value:           "0xfa",
+		TransactionHash: "0x22",
+		BlockHash:       "0xbba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5",
+		BlockNumber:     "0x2",
+	}}
+	internal.CheckTestResultEthRequestRPC(*request, want, got, t, false)
+
+	request, err = internal.PrepareEthRPCRequest(1, []json.RawMessage{
+		json.RawMessage(`"0x` + testTokenReceiver + `"`),
+		json.RawMessage(`{"fromBlock": "0x5"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, jsonErr := (&ProxyKAONGetTokenTransfers{kaonClient}).Request(request, c); jsonErr == nil {
+		t.Fatal("expected an error for blocks that aren't indexed")
+	}
+}
@@ -0,0 +1,111 @@
+package transformer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/tokenindex"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+)
+
+// ProxyKAONGetTokenTransfers implements ETHProxy, returning the token transfers of an address from the token index
+type ProxyKAONGetTokenTransfers struct {
+	*kaon.Kaon
+}
+
+var _ ETHProxy = (*ProxyKAONGetTokenTransfers)(nil)
+
+func (p *ProxyKAONGetTokenTransfers) Method() string {
+	return "kaon_getTokenTransfers"
+}
+
+func (p *ProxyKAONGetTokenTransfers) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Summary: "Returns the QRC20/ERC20 transfers from or to an address in a block range of the token index",
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("address", addressSchema()),
+			describeOptionalParam("range", eth.OpenRPCSchema{
+				"type": "object",
+				"properties": eth.OpenRPCSchema{
+					"fromBlock": blockParameterSchema(),
+					"toBlock":   blockParameterSchema(),
+					"token":     addressSchema(),
+				},
+			}),
+		},
+		Result: describeResult("transfers", arrayOf(objectSchema())),
+	}
+}
+
+func (p *ProxyKAONGetTokenTransfers) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.GetTokenTransfersRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	index := getTokenIndex(c)
+	if index == nil {
+		return nil, eth.NewInvalidRequestError("token index not configured")
+	}
+	head, ok := index.Head()
+	if !ok {
+		return nil, eth.NewCallbackError("token index is still empty")
+	}
+
+	// the whole index by default
+	ctx := c.Request().Context()
+	from, to := new(big.Int).SetUint64(index.StartHeight()), new(big.Int).SetUint64(head)
+	if len(req.Range.FromBlock) != 0 {
+		var jsonErr *eth.JSONRPCError
+		if from, jsonErr = getBlockNumberByRawParam(ctx, p.Kaon, req.Range.FromBlock, false); jsonErr != nil {
+			return nil, jsonErr
+		}
+	}
+	if len(req.Range.ToBlock) != 0 {
+		var jsonErr *eth.JSONRPCError
+		if to, jsonErr = getBlockNumberByRawParam(ctx, p.Kaon, req.Range.ToBlock, false); jsonErr != nil {
+			return nil, jsonErr
+		}
+	}
+	if from.Cmp(to) > 0 || !to.IsUint64() {
+		return nil, eth.NewInvalidParamsError("invalid block range params")
+	}
+	// the blocks mined since the last sync of the index are left out, rather than failing "latest"
+	if to.Uint64() > head && from.Uint64() <= head {
+		to.SetUint64(head)
+	}
+
+	maxResults := p.GetLogsMaxResults()
+	transfers, err := index.Transfers(ctx, req.Address, req.Range.Token, from.Uint64(), to.Uint64(), maxResults)
+	switch err {
+	case nil:
+	case tokenindex.ErrNotIndexed:
+		return nil, eth.NewInvalidParamsError(fmt.Sprintf(
+			"block range not indexed, the token index covers [%s, %s]",
+			hexutil.EncodeUint64(index.StartHeight()),
+			hexutil.EncodeUint64(head),
+		))
+	case tokenindex.ErrTooManyTransfers:
+		return nil, eth.NewLimitExceededError(fmt.Sprintf("query returned more than %d results, narrow the block range", maxResults))
+	default:
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	resp := make(eth.GetTokenTransfersResponse, 0, len(transfers))
+	for _, transfer := range transfers {
+		resp = append(resp, eth.TokenTransfer{
+			Token:           utils.AddHexPrefix(transfer.Token),
+			From:            utils.AddHexPrefix(transfer.From),
+			To:              utils.AddHexPrefix(transfer.To),
+			Value:           hexutil.EncodeBig(transfer.Value),
+			TransactionHash: utils.AddHexPrefix(transfer.TransactionHash),
+			BlockHash:       utils.AddHexPrefix(transfer.BlockHash),
+			BlockNumber:     hexutil.EncodeUint64(transfer.BlockNumber),
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,89 @@
+package transformer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+)
+
+// ProxyKAONGetTransactionStatus implements ETHProxy, reporting where a transaction is in its lifecycle
+type ProxyKAONGetTransactionStatus struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyKAONGetTransactionStatus) Method() string {
+	return "kaon_getTransactionStatus"
+}
+
+func (p *ProxyKAONGetTransactionStatus) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Summary: "Returns where a transaction is in its lifecycle, rebroadcasts included",
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("transaction hash", hashSchema()),
+		},
+		Result: describeResult("status", objectSchema()),
+	}
+}
+
+func (p *ProxyKAONGetTransactionStatus) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.GetTransactionStatusRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	status, err := getTransactionStatus(c.Request().Context(), p.Kaon, string(req))
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return status, nil
+}
+
+// Status of a tracked transaction, or as kaond sees it for transactions submitted elsewhere
+func getTransactionStatus(ctx context.Context, p *kaon.Kaon, hash string) (*eth.GetTransactionStatusResponse, error) {
+	txID := strings.ToLower(utils.RemoveHexPrefix(hash))
+	// Hashes of EVM executions following the first one of a transaction are derived from its txid
+	if execution, isExecution := p.ResolveExecutionHash(txID); isExecution {
+		txID = execution.TransactionHash
+	}
+
+	if tx, tracked := p.TrackedTransaction(txID); tracked {
+		return &eth.GetTransactionStatusResponse{
+			Hash:          utils.AddHexPrefix(strings.ToLower(utils.RemoveHexPrefix(hash))),
+			Status:        string(tx.Status),
+			Tracked:       true,
+			BlockHash:     utils.AddHexPrefixIfNotEmpty(tx.BlockHash),
+			Confirmations: hexutil.EncodeUint64(uint64(tx.Confirmations)),
+			Rebroadcasts:  hexutil.EncodeUint64(uint64(tx.Rebroadcasts)),
+			SubmittedAt:   hexutil.EncodeUint64(uint64(tx.SubmittedAt.Unix())),
+			Error:         tx.LastError,
+		}, nil
+	}
+
+	status := &eth.GetTransactionStatusResponse{
+		Hash:          utils.AddHexPrefix(strings.ToLower(utils.RemoveHexPrefix(hash))),
+		Status:        string(kaon.TransactionUnknown),
+		Confirmations: "0x0",
+		Rebroadcasts:  "0x0",
+	}
+	rawTx, err := p.GetRawTransaction(kaon.WithoutCache(ctx), txID, false)
+	if errors.Cause(err) == kaon.ErrInvalidAddress {
+		return status, nil
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't get raw transaction")
+	}
+	if rawTx.BlockHash == "" {
+		status.Status = string(kaon.TransactionPending)
+		return status, nil
+	}
+	status.Status = string(kaon.TransactionMined)
+	status.BlockHash = utils.AddHexPrefix(rawTx.BlockHash)
+	status.Confirmations = hexutil.EncodeUint64(uint64(rawTx.Confirmations))
+	return status, nil
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
-	"github.com/kaonone/eth-rpc-gate/pkg/utils"
 	"github.com/labstack/echo"
 	"github.com/shopspring/decimal"
 )
@@ -39,7 +38,7 @@ func (p *ProxyKAONGetUTXOs) Request(req *eth.JSONRPCRequest, c echo.Context) (in
 }
 
 func (p *ProxyKAONGetUTXOs) request(ctx context.Context, params eth.GetUTXOsRequest) (*eth.GetUTXOsResponse, *eth.JSONRPCError) {
-	address, err := convertETHAddress(utils.RemoveHexPrefix(params.Address), p.Chain())
+	address, err := p.FromHexAddress(params.Address)
 	if err != nil {
 		return nil, eth.NewInvalidParamsError("couldn't convert Ethereum address to Kaon address")
 	}
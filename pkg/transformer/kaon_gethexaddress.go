@@ -45,18 +45,11 @@ func (p *ProxyKAONGetHexAddress) Request(req *eth.JSONRPCRequest, c echo.Context
 }
 
 func (p *ProxyKAONGetHexAddress) request(ctx context.Context, req *string) (interface{}, *eth.JSONRPCError) {
-	addr, err := utils.ConvertKaonAddress(*req)
-	if err == nil {
-		return p.ToResponse(addr), nil
-	} else {
-		// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-		// will cause ConvertKaonAddress to fall
-		addr, rerr := p.Kaon.GetHexAddress(ctx, *req)
-		if rerr == nil {
-			return p.ToResponse(addr), nil
-		}
-		return nil, eth.NewCallbackError(rerr.Error())
+	addr, err := p.Kaon.GetHexAddress(ctx, *req)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
 	}
+	return p.ToResponse(addr), nil
 }
 
 func (p *ProxyKAONGetHexAddress) ToResponse(hexaddr string) interface{} {
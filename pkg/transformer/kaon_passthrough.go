@@ -0,0 +1,246 @@
+package transformer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+)
+
+// Kaon RPC methods exposed as kaon_<method>, declared in a JSON file passed with --kaon-passthrough, e.g.
+//
+//	[{"method": "getblockheader", "params": [{"name": "blockhash", "type": "string"}, {"name": "verbose", "type": "bool", "optional": true}]}]
+
+// Argument types of passthrough methods
+const (
+	PassthroughString = "string"
+	PassthroughNumber = "number"
+	PassthroughBool   = "bool"
+	PassthroughObject = "object"
+)
+
+// Who passthrough methods are served to
+const (
+	// always served
+	PassthroughPublic = "public"
+	// only served when the gateway runs with --dev
+	PassthroughDev = "dev"
+	// only served when connected to a regtest node
+	PassthroughRegtest = "regtest"
+)
+
+type KaonPassthroughParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// optional arguments can only be followed by optional arguments
+	Optional bool `json:"optional,omitempty"`
+}
+
+// KaonPassthroughMethod declares a Kaon RPC method exposed as kaon_<method>
+type KaonPassthroughMethod struct {
+	Method string                 `json:"method"`
+	Params []KaonPassthroughParam `json:"params"`
+	// changes kaond's state or wallet, write methods can't be public
+	Write bool `json:"write,omitempty"`
+	// public by default
+	Access string `json:"access,omitempty"`
+}
+
+// DefaultKaonPassthroughMethods are exposed unless --kaon-passthrough declares others. The address index
+// methods require kaond to run with -addressindex.
+var DefaultKaonPassthroughMethods = []KaonPassthroughMethod{
+	{
+		Method: "getstakinginfo",
+	},
+	{
+		Method: "getaddressbalance",
+		Params: []KaonPassthroughParam{{Name: "addresses", Type: PassthroughObject}},
+	},
+	{
+		Method: "getaddressdeltas",
+		Params: []KaonPassthroughParam{{Name: "addresses", Type: PassthroughObject}},
+	},
+	{
+		Method: "getblockheader",
+		Params: []KaonPassthroughParam{
+			{Name: "blockhash", Type: PassthroughString},
+			{Name: "verbose", Type: PassthroughBool, Optional: true},
+		},
+	},
+}
+
+var kaonMethodName = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// LoadKaonPassthroughMethods reads and validates a JSON list of passthrough methods
+func LoadKaonPassthroughMethods(r io.Reader) ([]KaonPassthroughMethod, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var methods []KaonPassthroughMethod
+	if err := decoder.Decode(&methods); err != nil {
+		return nil, errors.Wrap(err, "couldn't parse passthrough methods")
+	}
+	for i := range methods {
+		if err := methods[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return methods, nil
+}
+
+func (m *KaonPassthroughMethod) validate() error {
+	if !kaonMethodName.MatchString(m.Method) {
+		return errors.Errorf("invalid passthrough method %q, expected a lowercase Kaon RPC method", m.Method)
+	}
+
+	switch m.Access {
+	case "":
+		m.Access = PassthroughPublic
+	case PassthroughPublic, PassthroughDev, PassthroughRegtest:
+	default:
+		return errors.Errorf("invalid access %q of passthrough method %s, expected %s, %s or %s", m.Access, m.Method, PassthroughPublic, PassthroughDev, PassthroughRegtest)
+	}
+	if m.Write && m.Access == PassthroughPublic {
+		return errors.Errorf("passthrough method %s writes, its access must be %s or %s", m.Method, PassthroughDev, PassthroughRegtest)
+	}
+
+	optional := false
+	for i, param := range m.Params {
+		switch param.Type {
+		case PassthroughString, PassthroughNumber, PassthroughBool, PassthroughObject:
+		default:
+			return errors.Errorf("invalid type %q of argument %d of passthrough method %s", param.Type, i+1, m.Method)
+		}
+		if optional && !param.Optional {
+			return errors.Errorf("argument %d of passthrough method %s is required but follows an optional argument", i+1, m.Method)
+		}
+		optional = param.Optional
+	}
+	return nil
+}
+
+// KaonPassthroughProxies returns the proxies of the methods whose access policy allows serving them
+func KaonPassthroughProxies(kaonClient *kaon.Kaon, methods []KaonPassthroughMethod) ([]ETHProxy, error) {
+	proxies := make([]ETHProxy, 0, len(methods))
+	for _, method := range methods {
+		if err := method.validate(); err != nil {
+			return nil, err
+		}
+		if method.Access == PassthroughDev && !kaonClient.IsDebugEnabled() {
+			continue
+		}
+		if method.Access == PassthroughRegtest && kaonClient.Chain() != kaon.ChainRegTest {
+			continue
+		}
+		proxies = append(proxies, &ProxyKAONPassthrough{Kaon: kaonClient, method: method})
+	}
+	return proxies, nil
+}
+
+// ProxyKAONPassthrough implements ETHProxy, forwarding validated arguments to a Kaon RPC method
+type ProxyKAONPassthrough struct {
+	*kaon.Kaon
+	method KaonPassthroughMethod
+}
+
+var _ ETHProxy = (*ProxyKAONPassthrough)(nil)
+
+func (p *ProxyKAONPassthrough) Method() string {
+	return "kaon_" + p.method.Method
+}
+
+func (p *ProxyKAONPassthrough) Describe() eth.OpenRPCMethod {
+	summary := "Passes its arguments to kaond's " + p.method.Method
+	if p.method.Write {
+		summary += ", changing kaond's state"
+	}
+	params := make([]eth.OpenRPCContentDescriptor, 0, len(p.method.Params))
+	for _, param := range p.method.Params {
+		schema := passthroughSchema(param.Type)
+		if param.Optional {
+			params = append(params, describeOptionalParam(param.Name, schema))
+		} else {
+			params = append(params, describeParam(param.Name, schema))
+		}
+	}
+	return eth.OpenRPCMethod{
+		Summary: summary,
+		Params:  params,
+		Result:  describeResult("result", eth.OpenRPCSchema{}),
+	}
+}
+
+func (p *ProxyKAONPassthrough) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var params []json.RawMessage
+	if len(rawreq.Params) != 0 {
+		if err := unmarshalRequest(rawreq.Params, &params); err != nil {
+			return nil, eth.NewInvalidParamsError(err.Error())
+		}
+	}
+	if err := p.validateParams(params); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	ctx := c.Request().Context()
+	if p.method.Write {
+		// a cached response would hide the write
+		ctx = kaon.WithoutCache(ctx)
+	}
+	var result json.RawMessage
+	if err := p.Client.RequestWithContext(ctx, p.method.Method, params, &result); err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return result, nil
+}
+
+func (p *ProxyKAONPassthrough) validateParams(params []json.RawMessage) error {
+	if len(params) > len(p.method.Params) {
+		return fmt.Errorf("too many arguments, want at most %d", len(p.method.Params))
+	}
+	for i, param := range p.method.Params {
+		if i >= len(params) {
+			if !param.Optional {
+				return fmt.Errorf("missing value for required argument %d (%s)", i+1, param.Name)
+			}
+			break
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(params[i], &value); err != nil {
+			return fmt.Errorf("invalid argument %d (%s): %s", i+1, param.Name, err)
+		}
+		valid := false
+		switch value.(type) {
+		case string:
+			valid = param.Type == PassthroughString
+		case float64:
+			valid = param.Type == PassthroughNumber
+		case bool:
+			valid = param.Type == PassthroughBool
+		case map[string]interface{}:
+			valid = param.Type == PassthroughObject
+		}
+		if !valid {
+			return fmt.Errorf("invalid argument %d (%s): expected a %s", i+1, param.Name, param.Type)
+		}
+	}
+	return nil
+}
+
+func passthroughSchema(paramType string) eth.OpenRPCSchema {
+	switch paramType {
+	case PassthroughString:
+		return stringSchema()
+	case PassthroughNumber:
+		return eth.OpenRPCSchema{"type": "number"}
+	case PassthroughBool:
+		return booleanSchema()
+	default:
+		return objectSchema()
+	}
+}
@@ -0,0 +1,129 @@
+package transformer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+)
+
+func TestLoadKaonPassthroughMethods(t *testing.T) {
+	methods, err := LoadKaonPassthroughMethods(strings.NewReader(`[
+		{"method": "getblockheader", "params": [{"name": "blockhash", "type": "string"}, {"name": "verbose", "type": "bool", "optional": true}]},
+		{"method": "generate", "params": [{"name": "blocks", "type": "number"}], "write": true, "access": "regtest"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(methods) != 2 || methods[0].Access != PassthroughPublic || methods[1].Access != PassthroughRegtest {
+		t.Fatalf("unexpected methods %+v", methods)
+	}
+
+	invalid := map[string]string{
+		"unknown field":               `[{"method": "getstakinginfo", "cached": true}]`,
+		"uppercase method":            `[{"method": "getStakingInfo"}]`,
+		"unknown access":              `[{"method": "getstakinginfo", "access": "private"}]`,
+		"public write":                `[{"method": "sendtoaddress", "write": true}]`,
+		"unknown type":                `[{"method": "getblock", "params": [{"name": "blockhash", "type": "hash"}]}]`,
+		"required following optional": `[{"method": "getblock", "params": [{"name": "blockhash", "type": "string", "optional": true}, {"name": "verbosity", "type": "number"}]}]`,
+	}
+	for name, config := range invalid {
+		if _, err := LoadKaonPassthroughMethods(strings.NewReader(config)); err == nil {
+			t.Errorf("expected an error for a %s", name)
+		}
+	}
+}
+
+func TestKaonPassthroughAccess(t *testing.T) {
+	methods := []KaonPassthroughMethod{
+		{Method: "getstakinginfo"},
+		{Method: "generate", Write: true, Access: PassthroughRegtest},
+	}
+
+	kaonClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxies, err := KaonPassthroughProxies(kaonClient, methods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proxies) != 1 || proxies[0].Method() != "kaon_getstakinginfo" {
+		t.Fatalf("expected only the public method to be served, got %d proxies", len(proxies))
+	}
+
+	regtestClient, err := internal.CreateMockedClientForNetwork(internal.NewDoerMappedMock(), kaon.ChainRegTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxies, err = KaonPassthroughProxies(regtestClient, methods); err != nil || len(proxies) != 2 {
+		t.Fatalf("expected both methods to be served on regtest, got %d proxies, err %v", len(proxies), err)
+	}
+}
+
+func TestKaonPassthroughRequest(t *testing.T) {
+	mockedClientDoer := internal.NewDoerMappedMock()
+	kaonClient, err := internal.CreateMockedClient(mockedClientDoer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := map[string]interface{}{"hash": "bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5", "height": 5}
+	if err := mockedClientDoer.AddResponse("getblockheader", header); err != nil {
+		t.Fatal(err)
+	}
+
+	proxies, err := KaonPassthroughProxies(kaonClient, DefaultKaonPassthroughMethods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var getBlockHeader ETHProxy
+	for _, proxy := range proxies {
+		if proxy.Method() == "kaon_getblockheader" {
+			getBlockHeader = proxy
+		}
+	}
+	if getBlockHeader == nil {
+		t.Fatal("expected kaon_getblockheader to be served by default")
+	}
+
+	// the optional verbose argument is left out
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{json.RawMessage(`"bba11e1bacc69ba535d478cf1f2e542da3735a517b0b8eebaf7e6bb25eeb48c5"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr := getBlockHeader.Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr.Message())
+	}
+	var gotHeader map[string]interface{}
+	if err := json.Unmarshal(got.(json.RawMessage), &gotHeader); err != nil {
+		t.Fatal(err)
+	}
+	if gotHeader["hash"] != header["hash"] {
+		t.Fatalf("expected kaond's block header, got %s", got)
+	}
+
+	// arguments are numbered from 1, like in the errors of the registry
+	invalid := map[string]struct {
+		params  []json.RawMessage
+		message string
+	}{
+		"missing argument":    {[]json.RawMessage{}, "argument 1 (blockhash)"},
+		"number as blockhash": {[]json.RawMessage{json.RawMessage(`5`)}, "argument 1 (blockhash)"},
+		"string as verbose":   {[]json.RawMessage{json.RawMessage(`"bba11e"`), json.RawMessage(`"true"`)}, "argument 2 (verbose)"},
+		"too many arguments":  {[]json.RawMessage{json.RawMessage(`"bba11e"`), json.RawMessage(`true`), json.RawMessage(`1`)}, "at most 2"},
+	}
+	for name, test := range invalid {
+		request, err := internal.PrepareEthRPCRequest(1, test.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, jsonErr := getBlockHeader.Request(request, internal.NewEchoContext())
+		if jsonErr == nil || jsonErr.Code() != eth.NewInvalidParamsError("").Code() || !strings.Contains(jsonErr.Message(), test.message) {
+			t.Errorf("expected an invalid params error about %s for a %s, got %v", test.message, name, jsonErr)
+		}
+	}
+}
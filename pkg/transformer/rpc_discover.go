@@ -0,0 +1,136 @@
+package transformer
+
+import (
+	"sort"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/params"
+	"github.com/labstack/echo"
+)
+
+// ProxyRPCDiscover implements ETHProxy, returning the OpenRPC description of the registered methods
+type ProxyRPCDiscover struct {
+	transformer *Transformer
+}
+
+var _ ETHProxy = (*ProxyRPCDiscover)(nil)
+
+func (p *ProxyRPCDiscover) Method() string {
+	return "rpc_discover"
+}
+
+func (p *ProxyRPCDiscover) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	return p.transformer.OpenRPCDocument(), nil
+}
+
+func (p *ProxyRPCDiscover) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Summary: "Returns the OpenRPC description of the methods served by the gateway",
+		Result:  describeResult("service description", objectSchema()),
+	}
+}
+
+// OpenRPCDocument describes every registered method, sorted by name. Methods whose proxy doesn't implement
+// Describer are listed without params and with an unconstrained result.
+func (t *Transformer) OpenRPCDocument() *eth.OpenRPCDocument {
+	methods := make([]eth.OpenRPCMethod, 0, len(t.transformers))
+	for name, proxy := range t.transformers {
+		var method eth.OpenRPCMethod
+		if describer, ok := proxy.(Describer); ok {
+			method = describer.Describe()
+		}
+		method.Name = name
+		if method.Params == nil {
+			method.Params = []eth.OpenRPCContentDescriptor{}
+		}
+		if method.Result == nil {
+			method.Result = describeResult("result", eth.OpenRPCSchema{})
+		}
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+
+	return &eth.OpenRPCDocument{
+		OpenRPC: eth.OpenRPCVersion,
+		Info: eth.OpenRPCInfo{
+			Title:   "eth-rpc-gate",
+			Version: params.Version,
+		},
+		Methods: methods,
+	}
+}
+
+func describeParam(name string, schema eth.OpenRPCSchema) eth.OpenRPCContentDescriptor {
+	return eth.OpenRPCContentDescriptor{Name: name, Required: true, Schema: schema}
+}
+
+func describeOptionalParam(name string, schema eth.OpenRPCSchema) eth.OpenRPCContentDescriptor {
+	return eth.OpenRPCContentDescriptor{Name: name, Schema: schema}
+}
+
+func describeResult(name string, schema eth.OpenRPCSchema) *eth.OpenRPCContentDescriptor {
+	return &eth.OpenRPCContentDescriptor{Name: name, Schema: schema}
+}
+
+// JSON schemas of the values exchanged with Ethereum clients
+
+func quantitySchema() eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{"title": "hex encoded unsigned integer", "type": "string", "pattern": "^0x(0|[1-9a-f][0-9a-f]*)$"}
+}
+
+func dataSchema() eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{"title": "hex encoded bytes", "type": "string", "pattern": "^0x([0-9a-fA-F]{2})*$"}
+}
+
+func addressSchema() eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{"title": "hex encoded address", "type": "string", "pattern": "^0x[0-9a-fA-F]{40}$"}
+}
+
+func hashSchema() eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{"title": "32 byte hex value", "type": "string", "pattern": "^0x[0-9a-fA-F]{64}$"}
+}
+
+func blockTagSchema() eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{"title": "block tag", "type": "string", "enum": []string{"earliest", "latest", "pending", "safe", "finalized"}}
+}
+
+// A block number or tag, or an EIP-1898 block hash or number object
+func blockParameterSchema() eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{
+		"title": "block number, tag or hash",
+		"oneOf": []eth.OpenRPCSchema{
+			quantitySchema(),
+			blockTagSchema(),
+			{
+				"type": "object",
+				"properties": eth.OpenRPCSchema{
+					"blockNumber":      quantitySchema(),
+					"blockHash":        hashSchema(),
+					"requireCanonical": booleanSchema(),
+				},
+			},
+		},
+	}
+}
+
+func objectSchema() eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{"type": "object"}
+}
+
+func booleanSchema() eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{"type": "boolean"}
+}
+
+func stringSchema() eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{"type": "string"}
+}
+
+func arrayOf(items eth.OpenRPCSchema) eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{"type": "array", "items": items}
+}
+
+func nullable(schema eth.OpenRPCSchema) eth.OpenRPCSchema {
+	return eth.OpenRPCSchema{"oneOf": []eth.OpenRPCSchema{schema, {"type": "null"}}}
+}
@@ -0,0 +1,70 @@
+package transformer
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+)
+
+func TestRPCDiscoverListsRegisteredMethods(t *testing.T) {
+	kaonClient, err := internal.CreateMockedClient(internal.NewDoerMappedMock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxies := DefaultProxies(kaonClient, nil)
+	transformer, err := New(kaonClient, proxies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := internal.PrepareEthRPCRequest(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Method = "rpc_discover"
+	got, jsonErr := transformer.Transform(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr.Message())
+	}
+	document := got.(*eth.OpenRPCDocument)
+
+	if document.OpenRPC != eth.OpenRPCVersion {
+		t.Errorf("expected OpenRPC %s, got %s", eth.OpenRPCVersion, document.OpenRPC)
+	}
+	// every default proxy along with rpc_discover itself
+	if len(document.Methods) != len(proxies)+1 {
+		t.Fatalf("expected %d methods, got %d", len(proxies)+1, len(document.Methods))
+	}
+	if !sort.SliceIsSorted(document.Methods, func(i, j int) bool {
+		return document.Methods[i].Name < document.Methods[j].Name
+	}) {
+		t.Error("expected methods sorted by name")
+	}
+
+	methods := make(map[string]eth.OpenRPCMethod, len(document.Methods))
+	for _, method := range document.Methods {
+		if method.Params == nil || method.Result == nil {
+			t.Errorf("expected %s to have params and a result", method.Name)
+		}
+		methods[method.Name] = method
+	}
+	for _, name := range []string{"rpc_discover", "eth_getTransactionByBlockHashAndIndex", "dev_gethexaddress", "dev_fromhexaddress"} {
+		if _, ok := methods[name]; !ok {
+			t.Errorf("expected %s to be described", name)
+		}
+	}
+
+	getBalance := methods["eth_getBalance"]
+	if len(getBalance.Params) != 2 || getBalance.Params[0].Name != "address" || !getBalance.Params[0].Required || getBalance.Params[1].Required {
+		t.Errorf("unexpected eth_getBalance params %+v", getBalance.Params)
+	}
+	if getBalance.Result.Schema["type"] != "string" {
+		t.Errorf("expected eth_getBalance to return a quantity, got %+v", getBalance.Result.Schema)
+	}
+	// not described by its proxy
+	if hashrate := methods["eth_hashrate"]; len(hashrate.Params) != 0 || len(hashrate.Result.Schema) != 0 {
+		t.Errorf("expected eth_hashrate to be listed without schemas, got %+v", hashrate)
+	}
+}
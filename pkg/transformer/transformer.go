@@ -33,6 +33,12 @@ func New(kaonClient *kaon.Kaon, proxies []ETHProxy, opts ...Option) (*Transforme
 			return nil, err
 		}
 	}
+	// rpc_discover describes whatever ends up registered
+	if _, ok := t.transformers["rpc_discover"]; !ok {
+		if err = t.Register(&ProxyRPCDiscover{transformer: t}); err != nil {
+			return nil, err
+		}
+	}
 
 	for _, opt := range opts {
 		if err := opt(t); err != nil {
@@ -87,6 +93,11 @@ func (t *Transformer) IsDebugEnabled() bool {
 // DefaultProxies are the default proxy methods made available
 func DefaultProxies(kaonRPCClient *kaon.Kaon, agent *notifier.Agent) []ETHProxy {
 	filter := eth.NewFilterSimulator()
+	if store := kaonRPCClient.SharedCacheStore(); store != nil {
+		filter.SetStore(store, func() string {
+			return kaon.CacheKeyPrefix + kaonRPCClient.Chain() + ":"
+		})
+	}
 	getFilterChanges := &ProxyETHGetFilterChanges{Kaon: kaonRPCClient, filter: filter}
 	ethCall := &ProxyETHCall{Kaon: kaonRPCClient}
 
@@ -101,6 +112,7 @@ func DefaultProxies(kaonRPCClient *kaon.Kaon, agent *notifier.Agent) []ETHProxy
 		&ProxyETHNetVersion{Kaon: kaonRPCClient},
 		&ProxyETHGetTransactionByHash{Kaon: kaonRPCClient},
 		&ProxyETHGetTransactionByBlockNumberAndIndex{Kaon: kaonRPCClient},
+		&ProxyETHGetTransactionByBlockHashAndIndex{Kaon: kaonRPCClient},
 		&ProxyETHGetLogs{Kaon: kaonRPCClient},
 		&ProxyETHGetTransactionReceipt{Kaon: kaonRPCClient},
 		&ProxyETHSendTransaction{Kaon: kaonRPCClient},
@@ -117,22 +129,35 @@ func DefaultProxies(kaonRPCClient *kaon.Kaon, agent *notifier.Agent) []ETHProxy
 		&ProxyETHUninstallFilter{Kaon: kaonRPCClient, filter: filter},
 
 		&ProxyETHEstimateGas{ProxyETHCall: ethCall},
+		&ProxyETHCreateAccessList{ProxyETHCall: ethCall},
+		&ProxyETHSimulateV1{ProxyETHCall: ethCall},
 		&ProxyETHGetBlockByNumber{Kaon: kaonRPCClient},
 		&ProxyETHGetBlockByHash{Kaon: kaonRPCClient},
+		&ProxyETHGetBlockTransactionCountByHash{Kaon: kaonRPCClient},
+		&ProxyETHGetBlockTransactionCountByNumber{Kaon: kaonRPCClient},
+		&ProxyETHGetBlockReceipts{Kaon: kaonRPCClient},
 		&ProxyETHGetBalance{Kaon: kaonRPCClient},
 		&ProxyETHGetStorageAt{Kaon: kaonRPCClient},
 		&ETHGetCompilers{},
 		&ETHProtocolVersion{},
 		&ETHGetUncleByBlockHashAndIndex{},
+		&ETHGetUncleByBlockNumberAndIndex{},
 		&ETHGetUncleCountByBlockHash{},
 		&ETHGetUncleCountByBlockNumber{},
 		&Web3ClientVersion{},
 		&Web3Sha3{},
 		&ProxyETHSign{Kaon: kaonRPCClient},
+		&ProxyETHPersonalSign{Kaon: kaonRPCClient},
+		&ProxyETHPersonalECRecover{Kaon: kaonRPCClient},
+		&ProxyETHSignTypedDataLegacy{Kaon: kaonRPCClient, method: "eth_signTypedData"},
+		&ProxyETHSignTypedDataLegacy{Kaon: kaonRPCClient, method: "eth_signTypedData_v1"},
+		&ProxyETHSignTypedData{Kaon: kaonRPCClient, method: "eth_signTypedData_v3"},
+		&ProxyETHSignTypedData{Kaon: kaonRPCClient, method: "eth_signTypedData_v4"},
 		&ProxyETHGasPrice{Kaon: kaonRPCClient},
 		&ProxyETHTxCount{Kaon: kaonRPCClient},
 		&ProxyETHSignTransaction{Kaon: kaonRPCClient},
 		&ProxyETHSendRawTransaction{Kaon: kaonRPCClient},
+		&ProxyETHSendRawTransactionSync{Kaon: kaonRPCClient},
 
 		&ETHSubscribe{Kaon: kaonRPCClient, Agent: agent},
 		&ETHUnsubscribe{Kaon: kaonRPCClient, Agent: agent},
@@ -140,8 +165,27 @@ func DefaultProxies(kaonRPCClient *kaon.Kaon, agent *notifier.Agent) []ETHProxy
 		&ProxyKAONGetUTXOs{Kaon: kaonRPCClient},
 		&ProxyKAONGenerateToAddress{Kaon: kaonRPCClient},
 
+		&ProxyEVMMine{Kaon: kaonRPCClient},
+		&ProxyHardhatMine{Kaon: kaonRPCClient},
+		&ProxyEVMSetAutomine{Kaon: kaonRPCClient},
+		&ProxyHardhatGetAutomine{Kaon: kaonRPCClient},
+		&ProxyEVMSetIntervalMining{Kaon: kaonRPCClient},
+		&ProxyEVMSnapshot{Kaon: kaonRPCClient},
+		&ProxyEVMRevert{Kaon: kaonRPCClient},
+		&ProxyHardhatSetBalance{Kaon: kaonRPCClient},
+		&ProxyDevFaucet{Kaon: kaonRPCClient},
+
 		&ProxyKAONFromHexAddress{Kaon: kaonRPCClient},
 		&ProxyKAONGetHexAddress{Kaon: kaonRPCClient},
+		&ProxyKAONECRecover{Kaon: kaonRPCClient},
+		&ProxyKAONGetTransactionStatus{Kaon: kaonRPCClient},
+		&ProxyKAONGetTokenBalances{Kaon: kaonRPCClient},
+		&ProxyKAONGetTokenTransfers{Kaon: kaonRPCClient},
+
+		&ProxyTxPoolStatus{Kaon: kaonRPCClient},
+		&ProxyTxPoolContent{Kaon: kaonRPCClient},
+		&ProxyTxPoolContentFrom{Kaon: kaonRPCClient},
+		&ProxyTxPoolInspect{Kaon: kaonRPCClient},
 
 		&ProxyNetPeerCount{Kaon: kaonRPCClient},
 	}
@@ -0,0 +1,187 @@
+package transformer
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/utils"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+)
+
+// ProxyTxPoolContent implements ETHProxy
+type ProxyTxPoolContent struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyTxPoolContent) Method() string {
+	return "txpool_content"
+}
+
+func (p *ProxyTxPoolContent) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("pending and queued transactions", objectSchema()),
+	}
+}
+
+func (p *ProxyTxPoolContent) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	pool, err := getTxPool(c.Request().Context(), p.Kaon)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	resp := &eth.TxPoolContentResponse{
+		Pending: make(map[string]eth.TxPoolTransactions, len(pool)),
+		Queued:  map[string]eth.TxPoolTransactions{},
+	}
+	for sender, txs := range pool {
+		resp.Pending[sender] = txs.byNonce()
+	}
+	return resp, nil
+}
+
+// Pending Ethereum transactions of the mempool by sender, in the order they'd be nonced. kaond has no nonce
+// gaps to queue transactions behind, every transaction it accepted is pending.
+type txPool map[string]txPoolTransactions
+
+type txPoolTransactions []*eth.GetTransactionByHashResponse
+
+func (txs txPoolTransactions) byNonce() eth.TxPoolTransactions {
+	result := make(eth.TxPoolTransactions, len(txs))
+	for _, tx := range txs {
+		nonce, _ := hexutil.DecodeUint64(tx.Nonce)
+		result[strconv.FormatUint(nonce, 10)] = tx
+	}
+	return result
+}
+
+// Decodes the mempool into Ethereum transactions grouped by sender. Kaon transactions have no nonce, so the
+// transactions of a sender are numbered from its getaddressnonce in the order they entered the mempool.
+func getTxPool(ctx context.Context, p *kaon.Kaon) (txPool, error) {
+	mempool, err := p.GetRawMempool(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't get mempool")
+	}
+	ids := make([]string, 0, len(mempool))
+	for id := range mempool {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if mempool[ids[i]].Time != mempool[ids[j]].Time {
+			return mempool[ids[i]].Time < mempool[ids[j]].Time
+		}
+		return ids[i] < ids[j]
+	})
+
+	pool := make(txPool)
+	for _, id := range ids {
+		txs, err := getMempoolTransactions(ctx, p, id)
+		if errors.Cause(err) == kaon.ErrInvalidAddress {
+			// mined or evicted since getrawmempool
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range txs {
+			pool[tx.From] = append(pool[tx.From], tx)
+		}
+	}
+
+	for sender, txs := range pool {
+		nonce := uint64(0)
+		count, err := p.GetTransactionCount(ctx, &kaon.GetTransactionCountRequest{Address: utils.RemoveHexPrefix(sender)})
+		if err == nil {
+			nonce = count.Uint64()
+		} else {
+			p.GetDebugLogger().Log("function", "getTxPool", "msg", "Couldn't get sender nonce", "sender", sender, "err", err)
+		}
+		for _, tx := range txs {
+			tx.Nonce = hexutil.EncodeUint64(nonce)
+			nonce++
+		}
+	}
+	return pool, nil
+}
+
+// Ethereum transactions of a mempool transaction, one per EVM execution
+func getMempoolTransactions(ctx context.Context, p *kaon.Kaon, id string) ([]*eth.GetTransactionByHashResponse, error) {
+	rawTx, err := p.GetRawTransaction(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	decodedRawTx, err := p.DecodeRawTransaction(ctx, rawTx.Hex)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode raw transaction")
+	}
+	value, err := formatKaonAmount(decodedRawTx.CalcAmount())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "couldn't format amount of %s", id)
+	}
+
+	tx := &eth.GetTransactionByHashResponse{
+		Hash:     utils.AddHexPrefix(strings.ToLower(id)),
+		Nonce:    "0x0",
+		Value:    value,
+		From:     mempoolTransactionSender(p, rawTx),
+		Gas:      "0x0",
+		GasPrice: "0x0",
+
+		// Added for go-ethereum client and graph-node support
+		R: "0xf000000000000000000000000000000000000000000000000000000000000000",
+		S: "0xf000000000000000000000000000000000000000000000000000000000000000",
+		V: "0x25",
+	}
+
+	contracts, err := decodedRawTx.ExtractContractInfos()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "couldn't parse contract outputs of %s", id)
+	}
+	if len(contracts) == 0 {
+		tx.To, err = findNonContractTxReceiverAddress(p, decodedRawTx.Vouts)
+		if err != nil {
+			tx.To = utils.AddHexPrefix(kaon.ZeroAddress)
+		}
+		tx.Input = utils.AddHexPrefix(rawTx.Hex)
+		return []*eth.GetTransactionByHashResponse{tx}, nil
+	}
+
+	executions := p.RegisterExecutions(id, contracts)
+	txs := make([]*eth.GetTransactionByHashResponse, 0, len(executions))
+	for i, execution := range executions {
+		executionTx, err := applyExecution(tx, execution, contracts[i])
+		if err != nil {
+			return nil, err
+		}
+		// the value of the whole transaction includes the change, an execution only gets its output's
+		for _, out := range decodedRawTx.Vouts {
+			if out.N == contracts[i].OutputIndex {
+				if executionTx.Value, err = formatKaonAmount(out.Value.Decimal); err != nil {
+					return nil, errors.WithMessagef(err, "couldn't format amount of %s", execution.Hash)
+				}
+			}
+		}
+		txs = append(txs, executionTx)
+	}
+	return txs, nil
+}
+
+// The address of the first input, the sender of OP_CALL and OP_CREATE outputs without OP_SENDER
+func mempoolTransactionSender(p *kaon.Kaon, rawTx *kaon.GetRawTransactionResponse) string {
+	for _, in := range rawTx.Vins {
+		if in.Address == "" {
+			continue
+		}
+		hexAddress, err := p.Base58AddressToHex(in.Address)
+		if err != nil {
+			continue
+		}
+		return utils.AddHexPrefix(strings.ToLower(hexAddress))
+	}
+	return utils.AddHexPrefix(kaon.ZeroAddress)
+}
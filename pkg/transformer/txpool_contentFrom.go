@@ -0,0 +1,42 @@
+package transformer
+
+import (
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyTxPoolContentFrom implements ETHProxy
+type ProxyTxPoolContentFrom struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyTxPoolContentFrom) Method() string {
+	return "txpool_contentFrom"
+}
+
+func (p *ProxyTxPoolContentFrom) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("address", addressSchema()),
+		},
+		Result: describeResult("pending and queued transactions", objectSchema()),
+	}
+}
+
+func (p *ProxyTxPoolContentFrom) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	var req eth.TxPoolContentFromRequest
+	if err := unmarshalRequest(rawreq.Params, &req); err != nil {
+		return nil, eth.NewInvalidParamsError(err.Error())
+	}
+
+	pool, err := getTxPool(c.Request().Context(), p.Kaon)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	return &eth.TxPoolContentFromResponse{
+		Pending: pool[string(req)].byNonce(),
+		Queued:  eth.TxPoolTransactions{},
+	}, nil
+}
@@ -0,0 +1,104 @@
+package transformer
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/internal"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+)
+
+func TestTxPoolContent(t *testing.T) {
+	kaonClient, setter, _ := deploySimulationContracts(t)
+	sender, err := kaonClient.FromHexAddress(simulationMiner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var txids []string
+	for i := 0; i < 2; i++ {
+		var sent kaon.SendToContractResponse
+		err := kaonClient.Request(kaon.MethodSendToContract, &kaon.SendToContractRequest{
+			ContractAddress: strings.TrimPrefix(setter, "0x"),
+			Datahex:         "000000000000000000000000000000000000000000000000000000000000002a",
+			Amount:          kaon.NewAmount(0, 0),
+			GasLimit:        big.NewInt(100000),
+			GasPrice:        "0.0000004",
+			SenderAddress:   sender,
+		}, &sent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txids = append(txids, "0x"+sent.Txid)
+	}
+
+	request, err := internal.PrepareEthRPCRequest(1, []json.RawMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr := (&ProxyTxPoolContent{kaonClient}).Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr.Message())
+	}
+	content := got.(*eth.TxPoolContentResponse)
+	if len(content.Pending) != 1 || len(content.Queued) != 0 {
+		t.Fatalf("expected the transactions of a single sender, got %+v", content)
+	}
+	pending := content.Pending[simulationMiner]
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending transactions, got %+v", pending)
+	}
+	nonce, err := kaonClient.GetTransactionCount(internal.NewEchoContext().Request().Context(), &kaon.GetTransactionCountRequest{Address: strings.TrimPrefix(simulationMiner, "0x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := map[string]bool{}
+	for i := int64(0); i < 2; i++ {
+		key := new(big.Int).Add(nonce.Int, big.NewInt(i)).String()
+		tx, ok := pending[key]
+		if !ok {
+			t.Fatalf("missing nonce %s in %+v", key, pending)
+		}
+		if tx.To != setter || tx.From != simulationMiner {
+			t.Fatalf("unexpected transaction %+v", tx)
+		}
+		hashes[tx.Hash] = true
+	}
+	for _, txid := range txids {
+		if !hashes[txid] {
+			t.Fatalf("missing transaction %s", txid)
+		}
+	}
+
+	got, jsonErr = (&ProxyTxPoolStatus{kaonClient}).Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr.Message())
+	}
+	if status := got.(*eth.TxPoolStatusResponse); status.Pending != "0x2" || status.Queued != "0x0" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	request, err = internal.PrepareEthRPCRequest(1, []json.RawMessage{json.RawMessage(`"` + strings.ToUpper(simulationMiner[2:]) + `"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, jsonErr = (&ProxyTxPoolContentFrom{kaonClient}).Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr.Message())
+	}
+	if from := got.(*eth.TxPoolContentFromResponse); len(from.Pending) != 2 {
+		t.Fatalf("expected 2 pending transactions from the sender, got %+v", from)
+	}
+
+	got, jsonErr = (&ProxyTxPoolInspect{kaonClient}).Request(request, internal.NewEchoContext())
+	if jsonErr != nil {
+		t.Fatal(jsonErr.Message())
+	}
+	for _, summary := range got.(*eth.TxPoolInspectResponse).Pending[simulationMiner] {
+		if !strings.HasPrefix(summary, setter+": 0 wei + ") {
+			t.Fatalf("unexpected summary %q", summary)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package transformer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+)
+
+// ProxyTxPoolInspect implements ETHProxy
+type ProxyTxPoolInspect struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyTxPoolInspect) Method() string {
+	return "txpool_inspect"
+}
+
+func (p *ProxyTxPoolInspect) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("pending and queued transaction summaries", objectSchema()),
+	}
+}
+
+func (p *ProxyTxPoolInspect) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	pool, err := getTxPool(c.Request().Context(), p.Kaon)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	resp := &eth.TxPoolInspectResponse{
+		Pending: make(map[string]map[string]string, len(pool)),
+		Queued:  map[string]map[string]string{},
+	}
+	for sender, txs := range pool {
+		summaries := make(map[string]string, len(txs))
+		for nonce, tx := range txs.byNonce() {
+			summaries[nonce] = inspectTransaction(tx)
+		}
+		resp.Pending[sender] = summaries
+	}
+	return resp, nil
+}
+
+// Summarizes a transaction like geth, "to: value wei + gas gas × gasPrice wei"
+func inspectTransaction(tx *eth.GetTransactionByHashResponse) string {
+	to := tx.To
+	if strings.TrimLeft(strings.TrimPrefix(to, "0x"), "0") == "" {
+		to = "contract creation"
+	}
+	return fmt.Sprintf("%s: %s wei + %s gas × %s wei", to, decimalQuantity(tx.Value), decimalQuantity(tx.Gas), decimalQuantity(tx.GasPrice))
+}
+
+func decimalQuantity(quantity string) string {
+	value, err := hexutil.DecodeBig(quantity)
+	if err != nil {
+		return "0"
+	}
+	return value.String()
+}
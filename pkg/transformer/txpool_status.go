@@ -0,0 +1,66 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+)
+
+// ProxyTxPoolStatus implements ETHProxy
+type ProxyTxPoolStatus struct {
+	*kaon.Kaon
+}
+
+func (p *ProxyTxPoolStatus) Method() string {
+	return "txpool_status"
+}
+
+func (p *ProxyTxPoolStatus) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("pending and queued counts", objectSchema()),
+	}
+}
+
+func (p *ProxyTxPoolStatus) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
+	pending, err := countTxPool(c.Request().Context(), p.Kaon)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+
+	return &eth.TxPoolStatusResponse{
+		Pending: hexutil.EncodeUint64(uint64(pending)),
+		Queued:  "0x0",
+	}, nil
+}
+
+// Number of Ethereum transactions getTxPool would return, one per EVM execution. Only the outputs of the
+// mempool transactions are needed to count them, so they aren't decoded, attributed to senders nor nonced.
+func countTxPool(ctx context.Context, p *kaon.Kaon) (int, error) {
+	mempool, err := p.GetRawMempool(ctx)
+	if err != nil {
+		return 0, errors.WithMessage(err, "couldn't get mempool")
+	}
+	ids := make([]string, 0, len(mempool))
+	for id := range mempool {
+		ids = append(ids, id)
+	}
+
+	// transactions mined or evicted since getrawmempool are missing
+	size := 0
+	for id, rawTx := range p.GetRawTransactions(ctx, ids, false) {
+		contracts, err := rawTx.ExtractContractInfos()
+		if err != nil {
+			return 0, errors.WithMessagef(err, "couldn't parse contract outputs of %s", id)
+		}
+		if len(contracts) == 0 {
+			size++
+		} else {
+			size += len(contracts)
+		}
+	}
+	return size, nil
+}
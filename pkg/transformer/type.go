@@ -15,3 +15,9 @@ type ETHProxy interface {
 	Request(*eth.JSONRPCRequest, echo.Context) (interface{}, *eth.JSONRPCError)
 	Method() string
 }
+
+// Describer is optionally implemented by an ETHProxy to document its params and result in the service
+// description returned by rpc_discover. The method name is filled in from Method().
+type Describer interface {
+	Describe() eth.OpenRPCMethod
+}
@@ -3,16 +3,21 @@ package transformer
 import (
 	"bytes"
 	"context"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
 	"strconv"
 	"strings"
 
-	"github.com/btcsuite/btcutil/base58"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/kaonone/eth-rpc-gate/pkg/blockhash"
+	"github.com/kaonone/eth-rpc-gate/pkg/conversion"
 	"github.com/kaonone/eth-rpc-gate/pkg/eth"
+	"github.com/kaonone/eth-rpc-gate/pkg/faucet"
 	"github.com/kaonone/eth-rpc-gate/pkg/kaon"
+	"github.com/kaonone/eth-rpc-gate/pkg/logindex"
+	"github.com/kaonone/eth-rpc-gate/pkg/tokenindex"
+	"github.com/labstack/echo"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/kaonone/eth-rpc-gate/pkg/utils"
@@ -24,6 +29,8 @@ var ZeroSatoshi = decimal.NewFromInt(0)
 var OneSatoshi = decimal.NewFromFloat(1e-18)
 var MinimumGas = decimal.NewFromFloat(1e-9)
 
+var ErrHistoricalStateUnavailable = errors.New("historical state unavailable")
+
 type EthGas interface {
 	GasHex() string
 	GasPriceHex() string
@@ -156,19 +163,11 @@ func getNonContractTxSenderAddress(ctx context.Context, p *kaon.Kaon, tx *kaon.D
 		if len(in.Address) == 0 {
 			continue
 		}
-		hexAddress, err := utils.ConvertKaonAddress(in.Address)
-		if err == nil {
-			return utils.AddHexPrefix(hexAddress), nil
-		} else {
-			// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-			// will cause ConvertKaonAddress to fall
-			hexAddress, err := p.Base58AddressToHex(in.Address)
-			if err == nil {
-				return utils.AddHexPrefix(hexAddress), nil
-			} else {
-				return "", err
-			}
+		hexAddress, err := p.Base58AddressToHex(in.Address)
+		if err != nil {
+			return "", err
 		}
+		return utils.AddHexPrefix(hexAddress), nil
 	}
 
 	return "", errors.New("Couldn't find sender address the transaction")
@@ -203,19 +202,11 @@ func getNonContractTxSenderAddressFromBlockTx(ctx context.Context, p *kaon.Kaon,
 		if len(in.Address) == 0 {
 			continue
 		}
-		hexAddress, err := utils.ConvertKaonAddress(in.Address)
-		if err == nil {
-			return utils.AddHexPrefix(hexAddress), nil
-		} else {
-			// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-			// will cause ConvertKaonAddress to fall
-			hexAddress, err := p.Base58AddressToHex(in.Address)
-			if err == nil {
-				return utils.AddHexPrefix(hexAddress), nil
-			} else {
-				return "", err
-			}
+		hexAddress, err := p.Base58AddressToHex(in.Address)
+		if err != nil {
+			return "", err
 		}
+		return utils.AddHexPrefix(hexAddress), nil
 	}
 
 	return "", errors.New("Couldn't find sender address the transaction")
@@ -291,19 +282,11 @@ func findNonContractTxReceiverAddress(p *kaon.Kaon, vouts []*kaon.DecodedRawTran
 	for _, vout := range vouts {
 		for _, address := range vout.ScriptPubKey.Addresses {
 			if address != "" {
-				hex, err := utils.ConvertKaonAddress(address)
-				if err == nil {
-					return utils.AddHexPrefix(hex), nil
-				} else {
-					// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-					// will cause ConvertKaonAddress to fall
-					hex, err := p.Base58AddressToHex(address)
-					if err == nil {
-						return utils.AddHexPrefix(hex), nil
-					} else {
-						return "", err
-					}
+				hex, err := p.Base58AddressToHex(address)
+				if err != nil {
+					return "", err
 				}
+				return utils.AddHexPrefix(hex), nil
 			}
 		}
 	}
@@ -317,25 +300,76 @@ func findNonContractTxReceiverAddressFromBlockTx(p *kaon.Kaon, vouts []kaon.Bloc
 	for _, vout := range vouts {
 		for _, address := range vout.ScriptPubKey.Addresses {
 			if address != "" {
-				hex, err := utils.ConvertKaonAddress(address)
-				if err == nil {
-					return utils.AddHexPrefix(hex), nil
-				} else {
-					// P2Sh address(such as MUrenj2sPqEVTiNbHQ2RARiZYyTAAeKiDX) and BECH32 address (such as qc1qkt33x6hkrrlwlr6v59wptwy6zskyrjfe40y0lx)
-					// will cause ConvertKaonAddress to fall
-					hex, err := p.Base58AddressToHex(address)
-					if err == nil {
-						return utils.AddHexPrefix(hex), nil
-					} else {
-						return "", err
-					}
+				hex, err := p.Base58AddressToHex(address)
+				if err != nil {
+					return "", err
 				}
+				return utils.AddHexPrefix(hex), nil
 			}
 		}
 	}
 	return "", errors.New("not found")
 }
 
+// Returns the local log index, nil if the server hasn't been configured with one
+func getLogIndex(c echo.Context) *logindex.Index {
+	if c == nil {
+		return nil
+	}
+	index, _ := c.Get("logIndex").(*logindex.Index)
+	return index
+}
+
+// Returns the token index, nil if the server hasn't been configured with one
+func getTokenIndex(c echo.Context) *tokenindex.Index {
+	if c == nil {
+		return nil
+	}
+	index, _ := c.Get("tokenIndex").(*tokenindex.Index)
+	return index
+}
+
+// Returns the block hash mapping, nil if it isn't available
+func getBlockHash(c echo.Context) *blockhash.BlockHash {
+	if c == nil {
+		return nil
+	}
+	bh, _ := c.Get("blockHash").(*blockhash.BlockHash)
+	return bh
+}
+
+// Returns the faucet, nil if the server hasn't been configured with one
+func getFaucet(c echo.Context) *faucet.Faucet {
+	if c == nil {
+		return nil
+	}
+	f, _ := c.Get("faucet").(*faucet.Faucet)
+	return f
+}
+
+// Kaon hash of a block hash that is either a Kaon block hash or an Ethereum block hash served with
+// --eth-headers or known to bh, which may be nil. Other hashes are taken as Kaon block hashes.
+func resolveKaonBlockHash(ctx context.Context, p *kaon.Kaon, bh *blockhash.BlockHash, blockHash string) string {
+	if kaonBlockHash, ok := p.ResolveEthereumBlockHash(blockHash); ok {
+		return kaonBlockHash
+	}
+	if bh != nil {
+		kaonBlockHash, err := bh.GetKaonBlockHashContext(ctx, blockHash)
+		if err == nil && kaonBlockHash != nil && *kaonBlockHash != "" {
+			return utils.RemoveHexPrefix(*kaonBlockHash)
+		}
+	}
+	return utils.RemoveHexPrefix(blockHash)
+}
+
+// Prefers the local log index when it has already indexed the whole requested range, otherwise asks kaond
+func getLogSearcher(p *kaon.Kaon, index *logindex.Index, req *kaon.SearchLogsRequest) conversion.LogSearcher {
+	if index != nil && req.FromBlock != nil && req.ToBlock != nil && index.Covers(req.FromBlock.Uint64(), req.ToBlock.Uint64()) {
+		return index
+	}
+	return p
+}
+
 func getBlockNumberByHash(ctx context.Context, p *kaon.Kaon, hash string) (uint64, error) {
 	block, err := p.GetBlock(ctx, hash, true)
 	if err != nil {
@@ -350,8 +384,9 @@ func getTransactionIndexInBlock(ctx context.Context, p *kaon.Kaon, txHash string
 	if err != nil {
 		return -1, errors.WithMessage(err, "couldn't get block")
 	}
-	for i, blockTx := range block.Txs {
-		if txHash == blockTx {
+	txHash = strings.ToLower(utils.RemoveHexPrefix(txHash))
+	for i, execution := range blockExecutions(p, block) {
+		if txHash == execution.Hash {
 			p.GetDebugLogger().Log("function", "getTransactionIndexInBlock", "msg", "Found transaction index in block", "txHash", txHash, "blockHash", blockHash, "index", i)
 			return int64(i), nil
 		}
@@ -360,6 +395,380 @@ func getTransactionIndexInBlock(ctx context.Context, p *kaon.Kaon, txHash string
 	return -1, errors.New("not found")
 }
 
+// Details of a transaction of a block, getblock returns either hashes or transaction objects
+func blockTransactionDetails(tx interface{}) (*kaon.BlockTransactionDetails, error) {
+	switch txData := tx.(type) {
+	case string:
+		return &kaon.BlockTransactionDetails{ID: txData}, nil
+	case *kaon.BlockTransactionDetails:
+		return txData, nil
+	default:
+		var details kaon.BlockTransactionDetails
+		if err := json.Unmarshal([]byte(marshalToString(txData)), &details); err != nil {
+			return nil, err
+		}
+		return &details, nil
+	}
+}
+
+// Fetches the raw transactions of a block in batches, so that formatting its transactions one by one finds them
+// in the client cache instead of querying kaond for each of them. Nothing is fetched if they wouldn't be cached.
+func prefetchBlockRawTransactions(ctx context.Context, p *kaon.Kaon, block *kaon.GetBlockResponse) {
+	if !p.CachesResponses(ctx, kaon.MethodGetRawTransaction) {
+		return
+	}
+	txIDs := make([]string, 0, len(block.Txs))
+	for _, tx := range block.Txs {
+		if details, err := blockTransactionDetails(tx); err == nil && details.ID != "" {
+			txIDs = append(txIDs, details.ID)
+		}
+	}
+	p.GetRawTransactions(ctx, txIDs, false)
+}
+
+// Ethereum transactions of a block in block order, a transaction with several contract outputs
+// is expanded into one transaction per EVM execution
+func blockExecutions(p *kaon.Kaon, block *kaon.GetBlockResponse) []kaon.Execution {
+	executions := make([]kaon.Execution, 0, len(block.Txs))
+	for _, tx := range block.Txs {
+		details, err := blockTransactionDetails(tx)
+		if err != nil {
+			// keep positions of the following transactions intact
+			executions = append(executions, kaon.Execution{OutputIndex: -1})
+			continue
+		}
+		contracts, err := details.ExtractContractInfos()
+		if err != nil {
+			p.GetDebugLogger().Log("function", "blockExecutions", "msg", "Couldn't parse contract outputs", "txHash", details.ID, "err", err)
+			contracts = nil
+		}
+		executions = append(executions, p.RegisterExecutions(details.ID, contracts)...)
+	}
+	return executions
+}
+
+// Converts tx, built from the first EVM execution of its Kaon transaction, into the Ethereum
+// transaction of another execution of that transaction
+func applyExecution(tx *eth.GetTransactionByHashResponse, execution kaon.Execution, contract kaon.ContractInfo) (*eth.GetTransactionByHashResponse, error) {
+	result := *tx
+	result.Hash = utils.AddHexPrefix(execution.Hash)
+
+	if contract.UserInput == "" {
+		result.Input = "0x"
+	} else {
+		result.Input = utils.AddHexPrefix(contract.UserInput)
+	}
+	if contract.From != "" {
+		result.From = utils.AddHexPrefix(contract.From)
+	}
+	if len(contract.To) == 0 {
+		result.To = utils.AddHexPrefix(kaon.ZeroAddress)
+	} else {
+		result.To = utils.AddHexPrefix(contract.To)
+	}
+
+	gasLimit := contract.GasLimit
+	if len(gasLimit) == 0 {
+		gasLimit = "0"
+	}
+	result.Gas = utils.AddHexPrefix(gasLimit)
+
+	// Gas price is in hex satoshis, convert to wei
+	gasPrice := strings.TrimLeft(utils.RemoveHexPrefix(contract.GasPrice), "0")
+	if len(gasPrice) == 0 {
+		gasPrice = "0"
+	}
+	gasPriceInSatoshis, err := utils.DecodeBig(gasPrice)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse gasPrice %q", contract.GasPrice)
+	}
+	result.GasPrice = hexutil.EncodeBig(convertFromSatoshiToWei(gasPriceInSatoshis))
+
+	return &result, nil
+}
+
+// Contract output of a transaction's EVM execution
+func findExecutionContract(contracts []kaon.ContractInfo, execution kaon.Execution) (kaon.ContractInfo, bool) {
+	for _, contract := range contracts {
+		if contract.OutputIndex == execution.OutputIndex {
+			return contract, true
+		}
+	}
+	return kaon.ContractInfo{}, false
+}
+
+// Converts tx, the Ethereum transaction of the first EVM execution of a Kaon transaction, into the one of execution
+func getExecutionTransaction(ctx context.Context, p *kaon.Kaon, tx *eth.GetTransactionByHashResponse, execution kaon.Execution) (*eth.GetTransactionByHashResponse, error) {
+	rawTx, err := p.GetRawTransaction(ctx, execution.TransactionHash, false)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't get raw transaction")
+	}
+	decodedRawTx, err := p.DecodeRawTransaction(ctx, rawTx.Hex)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't decode raw transaction")
+	}
+	contracts, err := decodedRawTx.ExtractContractInfos()
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't parse contract outputs")
+	}
+	contract, ok := findExecutionContract(contracts, execution)
+	if !ok {
+		return nil, errors.Errorf("transaction has no contract output %d", execution.OutputIndex)
+	}
+
+	result, err := applyExecution(tx, execution, contract)
+	if err != nil {
+		return nil, err
+	}
+	if result.BlockHash != "" {
+		if index, err := getTransactionIndexInBlock(ctx, p, execution.Hash, result.BlockHash); err == nil {
+			result.TransactionIndex = hexutil.EncodeUint64(uint64(index))
+		}
+	}
+	return result, nil
+}
+
+// Ethereum transactions of a block transaction, tx is the formatted transaction of its first EVM execution
+func blockTransactionExecutions(p *kaon.Kaon, details *kaon.BlockTransactionDetails, tx *eth.GetTransactionByHashResponse) []eth.GetTransactionByHashResponse {
+	result := []eth.GetTransactionByHashResponse{*tx}
+
+	contracts, err := details.ExtractContractInfos()
+	if err != nil {
+		p.GetDebugLogger().Log("function", "blockTransactionExecutions", "msg", "Couldn't parse contract outputs", "txHash", details.ID, "err", err)
+		return result
+	}
+
+	executions := p.RegisterExecutions(details.ID, contracts)
+	for i := 1; i < len(executions); i++ {
+		executionTx, err := applyExecution(tx, executions[i], contracts[i])
+		if err != nil {
+			p.GetDebugLogger().Log("function", "blockTransactionExecutions", "msg", "Couldn't convert transaction execution", "hash", executions[i].Hash, "err", err)
+			continue
+		}
+		result = append(result, *executionTx)
+	}
+	return result
+}
+
+// Transactions of a block served with full transactions
+func blockResponseTransactions(block *eth.GetBlockByHashResponse) ([]eth.GetTransactionByHashResponse, error) {
+	txs := make([]eth.GetTransactionByHashResponse, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		switch txData := tx.(type) {
+		case eth.GetTransactionByHashResponse:
+			txs = append(txs, txData)
+		case *eth.GetTransactionByHashResponse:
+			txs = append(txs, *txData)
+		default:
+			return nil, errors.Errorf("unexpected block transaction %T", tx)
+		}
+	}
+	return txs, nil
+}
+
+// Hash a block is served with, the hash of its Ethereum header with --eth-headers and its Kaon hash otherwise
+func getServedBlockHash(ctx context.Context, p *kaon.Kaon, kaonBlockHash string) (string, error) {
+	header, err := getEthereumHeader(ctx, p, kaonBlockHash, nil)
+	if err != nil {
+		return "", err
+	}
+	if header == nil {
+		return utils.AddHexPrefix(kaonBlockHash), nil
+	}
+	return header.Hash, nil
+}
+
+// Replaces the Kaon block hashes of logs by the hashes their blocks are served with
+func setServedLogsBlockHash(ctx context.Context, p *kaon.Kaon, logs []eth.Log) error {
+	if !p.EthHeadersEnabled() {
+		return nil
+	}
+	hashes := make(map[string]string)
+	for i := range logs {
+		hash, ok := hashes[logs[i].BlockHash]
+		if !ok {
+			var err error
+			if hash, err = getServedBlockHash(ctx, p, logs[i].BlockHash); err != nil {
+				return err
+			}
+			hashes[logs[i].BlockHash] = hash
+		}
+		logs[i].BlockHash = hash
+	}
+	return nil
+}
+
+// Replaces the Kaon block hash of a mined receipt and its logs by the hash its block is served with
+func setServedReceiptBlockHash(ctx context.Context, p *kaon.Kaon, receipt *eth.GetTransactionReceiptResponse) error {
+	if !p.EthHeadersEnabled() || receipt == nil || receipt.BlockHash == "" {
+		return nil
+	}
+	hash, err := getServedBlockHash(ctx, p, receipt.BlockHash)
+	if err != nil {
+		return err
+	}
+	receipt.BlockHash = hash
+	for i := range receipt.Logs {
+		receipt.Logs[i].BlockHash = hash
+	}
+	return nil
+}
+
+// Consensus fields of the Ethereum receipts of a block's transactions in block order, one per EVM execution
+func getEthereumReceipts(ctx context.Context, p *kaon.Kaon, block *kaon.GetBlockResponse) (types.Receipts, error) {
+	var (
+		receipts          types.Receipts
+		cumulativeGasUsed uint64
+		executions        = blockExecutions(p, block)
+		txHashes          []string
+	)
+	for _, execution := range executions {
+		if execution.OutputIndex != -1 && (len(txHashes) == 0 || txHashes[len(txHashes)-1] != execution.TransactionHash) {
+			txHashes = append(txHashes, execution.TransactionHash)
+		}
+	}
+	txReceipts, err := p.GetTransactionsReceipts(ctx, txHashes)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't get receipts of the block transactions")
+	}
+
+	for _, execution := range executions {
+		if execution.OutputIndex == -1 {
+			receipts = append(receipts, conversion.SuccessfulReceipt(cumulativeGasUsed))
+			continue
+		}
+
+		receipt, ok := findExecutionReceipt(txReceipts[execution.TransactionHash], execution)
+		if !ok {
+			return nil, errors.Errorf("transaction %s has no receipt for output %d", execution.TransactionHash, execution.OutputIndex)
+		}
+		cumulativeGasUsed += receipt.GasUsed.Uint64()
+		receipts = append(receipts, conversion.EthereumReceipt(receipt, cumulativeGasUsed))
+	}
+	return receipts, nil
+}
+
+// Block-wide transaction and log positions of a block, derived once per block hash and then
+// served from the response cache or the log index
+func getBlockLogPositions(ctx context.Context, p *kaon.Kaon, index *logindex.Index, block *kaon.GetBlockResponse) (*conversion.BlockLogPositions, error) {
+	if positions, ok := getKnownBlockLogPositions(ctx, p, block.Hash); ok {
+		return positions, nil
+	}
+	return deriveBlockLogPositions(ctx, p, index, block)
+}
+
+// Derives the positions of a block from its logs and remembers them by block hash
+func deriveBlockLogPositions(ctx context.Context, p *kaon.Kaon, index *logindex.Index, block *kaon.GetBlockResponse) (*conversion.BlockLogPositions, error) {
+	receipts, err := searchBlockLogs(ctx, p, index, block)
+	if err != nil {
+		return nil, err
+	}
+	return rememberBlockLogPositions(ctx, p, block, blockExecutions(p, block), receipts), nil
+}
+
+// Receipts of the executions of a block that emitted logs
+func searchBlockLogs(ctx context.Context, p *kaon.Kaon, index *logindex.Index, block *kaon.GetBlockResponse) ([]kaon.TransactionReceipt, error) {
+	height := big.NewInt(int64(block.Height))
+	req := &kaon.SearchLogsRequest{
+		FromBlock: height,
+		ToBlock:   height,
+	}
+	receipts, err := getLogSearcher(p, index, req).SearchLogs(ctx, req)
+	if err != nil {
+		return nil, errors.WithMessage(err, "couldn't search block logs")
+	}
+	for _, receipt := range receipts {
+		if !strings.EqualFold(utils.RemoveHexPrefix(receipt.BlockHash), utils.RemoveHexPrefix(block.Hash)) {
+			return nil, errors.Errorf("block %d was reorganized while searching its logs", block.Height)
+		}
+	}
+	return receipts, nil
+}
+
+// Builds the positions of a block from its executions and the receipts searchBlockLogs found for it
+func rememberBlockLogPositions(ctx context.Context, p *kaon.Kaon, block *kaon.GetBlockResponse, executions []kaon.Execution, receipts []kaon.TransactionReceipt) *conversion.BlockLogPositions {
+	positions := conversion.NewBlockLogPositions(executions, receipts)
+	if data, err := json.Marshal(positions); err == nil {
+		p.StoreBlockLogPositions(ctx, block.Hash, data)
+	}
+	return positions
+}
+
+// Positions derived before for a block, ok is false if they have to be derived
+func getKnownBlockLogPositions(ctx context.Context, p *kaon.Kaon, blockHash string) (*conversion.BlockLogPositions, bool) {
+	data, ok := p.BlockLogPositions(ctx, blockHash)
+	if !ok {
+		return nil, false
+	}
+	var positions conversion.BlockLogPositions
+	if err := json.Unmarshal(data, &positions); err != nil {
+		p.GetDebugLogger().Log("msg", "Couldn't decode block log positions", "blockHash", blockHash, "err", err)
+		return nil, false
+	}
+	return &positions, true
+}
+
+// Memoizes block positions while converting receipts of a request, blocks that can't be resolved
+// (e.g. kaond running without -logevents) fall back to the per receipt numbering kaond uses
+type blockLogPositionsCache struct {
+	ctx       context.Context
+	kaon      *kaon.Kaon
+	index     *logindex.Index
+	positions map[string]*conversion.BlockLogPositions
+}
+
+func newBlockLogPositionsCache(ctx context.Context, p *kaon.Kaon, index *logindex.Index) *blockLogPositionsCache {
+	return &blockLogPositionsCache{
+		ctx:       ctx,
+		kaon:      p,
+		index:     index,
+		positions: make(map[string]*conversion.BlockLogPositions),
+	}
+}
+
+func (c *blockLogPositionsCache) get(blockHash string) *conversion.BlockLogPositions {
+	blockHash = utils.RemoveHexPrefix(blockHash)
+	if positions, ok := c.positions[blockHash]; ok {
+		return positions
+	}
+
+	positions, ok := getKnownBlockLogPositions(c.ctx, c.kaon, blockHash)
+	if !ok {
+		block, err := c.kaon.GetBlock(c.ctx, blockHash, false)
+		if err == nil {
+			positions, err = deriveBlockLogPositions(c.ctx, c.kaon, c.index, block)
+		}
+		if err != nil {
+			c.kaon.GetDebugLogger().Log("msg", "Couldn't get block-wide log positions, numbering logs per receipt", "blockHash", blockHash, "err", err)
+		}
+	}
+
+	c.positions[blockHash] = positions
+	return positions
+}
+
+// Parses a quantity given to a developer method, clients send them as hex strings, decimal strings or numbers
+func parseDevQuantity(v interface{}) (*big.Int, error) {
+	switch value := v.(type) {
+	case float64:
+		n, _ := big.NewFloat(value).Int(nil)
+		return n, nil
+	case string:
+		n, ok := new(big.Int), false
+		if strings.HasPrefix(value, "0x") {
+			_, ok = n.SetString(utils.RemoveHexPrefix(value), 16)
+		} else {
+			_, ok = n.SetString(value, 10)
+		}
+		if !ok {
+			return nil, errors.Errorf("invalid quantity %q", value)
+		}
+		return n, nil
+	default:
+		return nil, errors.Errorf("invalid quantity type %T", v)
+	}
+}
+
 func formatKaonNonce(nonce int) string {
 	var (
 		hexedNonce     = strconv.FormatInt(int64(nonce), 16)
@@ -378,6 +787,7 @@ func formatKaonNonce(nonce int) string {
 //   - string "latest" - for the latest mined block
 //   - string "earliest" for the genesis block
 //   - string "pending" - for the pending state/transactions
+//   - string "safe" and "finalized" - for the blocks the configured number of confirmations below the tip
 //
 // Uses defaultVal to differntiate from a eth_getBlockByNumber req and eth_getLogs/eth_newFilter
 func getBlockNumberByRawParam(ctx context.Context, p *kaon.Kaon, rawParam json.RawMessage, defaultVal bool) (*big.Int, *eth.JSONRPCError) {
@@ -424,6 +834,18 @@ func getBlockNumberByParam(ctx context.Context, p *kaon.Kaon, param string, defa
 		// ! Genesis block cannot be retreived
 		return big.NewInt(0), nil
 
+	case "safe", "finalized":
+		res, err := p.GetBlockChainInfo(ctx)
+		if err != nil {
+			return nil, eth.NewCallbackError(err.Error())
+		}
+		height := p.SafeBlockHeight(res.Blocks)
+		if param == "finalized" {
+			height = p.FinalizedBlockHeight(res.Blocks)
+		}
+		p.GetDebugLogger().Log(param, height, "latest", res.Blocks, "msg", "Got "+param+" block")
+		return big.NewInt(height), nil
+
 	case "pending":
 		// TODO: discuss
 		// 	! Researching
@@ -442,54 +864,132 @@ func getBlockNumberByParam(ctx context.Context, p *kaon.Kaon, param string, defa
 	}
 }
 
-func isBytesOfString(v json.RawMessage) bool {
-	dQuote := []byte{'"'}
-	if !bytes.HasPrefix(v, dQuote) && !bytes.HasSuffix(v, dQuote) {
-		return false
+// An EIP-1898 block parameter, either BlockHash or BlockNumber is set
+type blockObject struct {
+	BlockHash        *string `json:"blockHash"`
+	BlockNumber      *string `json:"blockNumber"`
+	RequireCanonical bool    `json:"requireCanonical"`
+}
+
+func parseBlockObject(rawParam json.RawMessage) (*blockObject, *eth.JSONRPCError) {
+	var param blockObject
+	if err := json.Unmarshal(rawParam, &param); err != nil {
+		return nil, eth.NewInvalidParamsError("invalid block object: " + err.Error())
 	}
-	if bytes.Count(v, dQuote) != 2 {
-		return false
+	switch {
+	case param.BlockHash != nil && param.BlockNumber != nil:
+		return nil, eth.NewInvalidParamsError("cannot specify both blockHash and blockNumber, choose one or the other")
+	case param.BlockHash == nil && param.BlockNumber == nil:
+		return nil, eth.NewInvalidParamsError("block object needs a blockHash or a blockNumber")
 	}
-	// TODO: decide
-	// ? Should we iterate over v to check if v[1:len(v)-2] is in a range of a-A, z-Z, 0-9
-	return true
+	return &param, nil
 }
 
-// Converts Ethereum address to a Kaon address, where `address` represents
-// Ethereum address without `0x` prefix and `chain` represents target Kaon
-// chain
-func convertETHAddress(address string, chain string) (kaonAddress string, _ error) {
-	addrBytes, err := hex.DecodeString(address)
-	if err != nil {
-		return "", errors.Wrapf(err, "couldn't decode hexed address - %q", address)
+// Block a state query is answered at, kaond answers for the tip from its current state
+type stateBlock struct {
+	Height int64
+	Latest bool
+}
+
+// Resolves the block parameter of a state query, a number, a tag or an EIP-1898 {blockHash, requireCanonical}
+// or {blockNumber} object, a missing parameter is latest. Blocks above the tip are an error.
+func getStateBlockByRawParam(ctx context.Context, p *kaon.Kaon, bh *blockhash.BlockHash, rawParam json.RawMessage) (*stateBlock, *eth.JSONRPCError) {
+	rawParam = bytes.TrimSpace(rawParam)
+	if len(rawParam) == 0 || string(rawParam) == "null" {
+		return &stateBlock{Latest: true}, nil
 	}
 
-	var prefix []byte
-	switch chain {
-	case kaon.ChainMain:
-		chainPrefix, err := kaon.PrefixMainChainAddress.AsBytes()
-		if err != nil {
-			return "", errors.WithMessagef(err, "couldn't convert %q Kaon chain prefix to slice of bytes", chain)
+	if rawParam[0] == '{' {
+		param, jsonErr := parseBlockObject(rawParam)
+		if jsonErr != nil {
+			return nil, jsonErr
+		}
+		if param.BlockHash != nil {
+			return getStateBlockByHash(ctx, p, bh, *param.BlockHash, param.RequireCanonical)
 		}
-		prefix = chainPrefix
+		return getStateBlockByParam(ctx, p, *param.BlockNumber)
+	}
 
-	case kaon.ChainTest, kaon.ChainRegTest:
-		chainPrefix, err := kaon.PrefixTestChainAddress.AsBytes()
-		if err != nil {
-			return "", errors.WithMessagef(err, "couldn't convert %q Kaon chain prefix to slice of bytes", chain)
+	if isBytesOfString(rawParam) {
+		return getStateBlockByParam(ctx, p, string(rawParam[1:len(rawParam)-1]))
+	}
+	height, err := strconv.ParseInt(string(rawParam), 10, 64)
+	if err != nil {
+		return nil, eth.NewInvalidParamsError("invalid parameter format - string, integer or block object is expected")
+	}
+	return getStateBlockAt(ctx, p, height)
+}
+
+func getStateBlockByParam(ctx context.Context, p *kaon.Kaon, param string) (*stateBlock, *eth.JSONRPCError) {
+	switch param {
+	case "", "latest", "pending":
+		// kaond has no pending state, its current state is the closest
+		return &stateBlock{Latest: true}, nil
+	}
+
+	height, jsonErr := getBlockNumberByParam(ctx, p, param, false)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	if !height.IsInt64() {
+		return nil, eth.NewCallbackError("header not found")
+	}
+	return getStateBlockAt(ctx, p, height.Int64())
+}
+
+func getStateBlockAt(ctx context.Context, p *kaon.Kaon, height int64) (*stateBlock, *eth.JSONRPCError) {
+	tip, err := p.GetBlockChainInfo(ctx)
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	return newStateBlock(height, tip.Blocks)
+}
+
+// Resolves an EIP-1898 block hash, which can be an Ethereum block hash known to bh. kaond only keeps the state
+// of the active chain so a block that isn't part of it is an error even if requireCanonical is false.
+func getStateBlockByHash(ctx context.Context, p *kaon.Kaon, bh *blockhash.BlockHash, blockHash string, requireCanonical bool) (*stateBlock, *eth.JSONRPCError) {
+	kaonBlockHash := resolveKaonBlockHash(ctx, p, bh, blockHash)
+	block, err := p.GetBlock(ctx, kaonBlockHash, false)
+	if err != nil {
+		p.GetDebugLogger().Log("function", "getStateBlockByHash", "hash", blockHash, "error", err)
+		return nil, eth.NewCallbackError(fmt.Sprintf("header for hash %s not found", blockHash))
+	}
+	canonicalHash, err := p.GetBlockHash(ctx, big.NewInt(int64(block.Height)))
+	if err != nil {
+		return nil, eth.NewCallbackError(err.Error())
+	}
+	if !strings.EqualFold(utils.RemoveHexPrefix(string(canonicalHash)), kaonBlockHash) {
+		if requireCanonical {
+			return nil, eth.NewCallbackError(fmt.Sprintf("hash %s is not currently canonical", blockHash))
 		}
-		prefix = chainPrefix
+		return nil, historicalStateUnavailable(fmt.Sprintf("block %s isn't part of the active chain", blockHash))
+	}
+	return getStateBlockAt(ctx, p, int64(block.Height))
+}
 
-	default:
-		return "", errors.Errorf("unsupported %q Kaon chain", chain)
+func newStateBlock(height, tip int64) (*stateBlock, *eth.JSONRPCError) {
+	if height < 0 || height > tip {
+		return nil, eth.NewCallbackError("header not found")
 	}
+	return &stateBlock{Height: height, Latest: height == tip}, nil
+}
 
-	var (
-		prefixedAddrBytes = append(prefix, addrBytes...)
-		checksum          = kaon.CalcAddressChecksum(prefixedAddrBytes)
-		kaonAddressBytes  = append(prefixedAddrBytes, checksum...)
-	)
-	return base58.Encode(kaonAddressBytes), nil
+// Error for state queries kaond can only answer at the tip
+func historicalStateUnavailable(reason string) *eth.JSONRPCError {
+	return eth.NewCallbackError(errors.WithMessage(ErrHistoricalStateUnavailable, reason).Error())
+}
+
+func isBytesOfString(v json.RawMessage) bool {
+	dQuote := []byte{'"'}
+	if !bytes.HasPrefix(v, dQuote) && !bytes.HasSuffix(v, dQuote) {
+		return false
+	}
+	if bytes.Count(v, dQuote) != 2 {
+		return false
+	}
+	// TODO: decide
+	// ? Should we iterate over v to check if v[1:len(v)-2] is in a range of a-A, z-Z, 0-9
+	return true
 }
 
 func processFilter(p *ProxyETHGetFilterChanges, rawreq *eth.JSONRPCRequest) (*eth.Filter, *eth.JSONRPCError) {
@@ -17,6 +17,12 @@ func (p *Web3ClientVersion) Method() string {
 	return "web3_clientVersion"
 }
 
+func (p *Web3ClientVersion) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Result: describeResult("client version", stringSchema()),
+	}
+}
+
 func (p *Web3ClientVersion) Request(_ *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	return "eth-rpc-gate/" + params.VersionWithGitSha + "/" + runtime.GOOS + "-" + runtime.GOARCH + "/" + runtime.Version(), nil
 }
@@ -15,6 +15,15 @@ func (p *Web3Sha3) Method() string {
 	return "web3_sha3"
 }
 
+func (p *Web3Sha3) Describe() eth.OpenRPCMethod {
+	return eth.OpenRPCMethod{
+		Params: []eth.OpenRPCContentDescriptor{
+			describeParam("data", dataSchema()),
+		},
+		Result: describeResult("keccak-256 hash", hashSchema()),
+	}
+}
+
 func (p *Web3Sha3) Request(rawreq *eth.JSONRPCRequest, c echo.Context) (interface{}, *eth.JSONRPCError) {
 	var err error
 	var req eth.Web3Sha3Request
@@ -77,6 +77,8 @@ func DecodeBig(input string) (*big.Int, error) {
 }
 
 // Converts Kaon address to an Ethereum address
+//
+// Deprecated: only checks the base58 checksum, use address.ToHex or a chain's address.Codec instead
 func ConvertKaonAddress(address string) (ethAddress string, _ error) {
 	if n := len(address); n < 22 {
 		return "", errors.Errorf("invalid address: length is less than 22 bytes - %d", n)